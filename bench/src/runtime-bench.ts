@@ -52,9 +52,13 @@ import {
   validateComplexConfig,
   noValidateComplexConfig,
   zodValidateComplexConfig,
+  validateStringOrBigObject,
+  noValidateStringOrBigObject,
+  zodValidateStringOrBigObject,
   testTaskWithUnion,
   testUserWithTemplates,
   testComplexConfig,
+  testStringOrBigObject,
 } from "./scenarios/complex-types.js";
 
 // JSON types - direct validators for inline benchmarks
@@ -311,6 +315,15 @@ async function main() {
     ),
   );
 
+  results.push(
+    await runBenchmark(
+      "string | big object (string)",
+      () => noValidateStringOrBigObject(testStringOrBigObject),
+      () => validateStringOrBigObject(testStringOrBigObject),
+      () => zodValidateStringOrBigObject(testStringOrBigObject),
+    ),
+  );
+
   results.push(
     await runBenchmark(
       "template literals",
@@ -53,6 +53,22 @@ export interface ComplexConfig {
   metadata: Record<string, string>;
 }
 
+// Union of a primitive and a large object - checks should try the string first
+export interface BigObject {
+  id: string;
+  name: string;
+  description: string;
+  createdAt: string;
+  updatedAt: string;
+  owner: string;
+  tags: string[];
+  score: number;
+  active: boolean;
+  metadata: Record<string, string>;
+}
+
+export type StringOrBigObject = string | BigObject;
+
 // Zod schemas
 const zodTaskStatus = z.enum(["pending", "in_progress", "completed", "cancelled"]);
 const zodTaskWithUnion = z.object({
@@ -98,6 +114,21 @@ const zodComplexConfig = z.object({
   metadata: z.record(z.string(), z.string()),
 });
 
+const zodBigObject = z.object({
+  id: z.string(),
+  name: z.string(),
+  description: z.string(),
+  createdAt: z.string(),
+  updatedAt: z.string(),
+  owner: z.string(),
+  tags: z.array(z.string()),
+  score: z.number(),
+  active: z.boolean(),
+  metadata: z.record(z.string(), z.string()),
+});
+
+const zodStringOrBigObject = z.union([z.string(), zodBigObject]);
+
 // Typical validation
 export function validateTaskWithUnion(task: TaskWithUnion): TaskWithUnion {
   return task;
@@ -111,6 +142,10 @@ export function validateComplexConfig(config: ComplexConfig): ComplexConfig {
   return config;
 }
 
+export function validateStringOrBigObject(value: StringOrBigObject): StringOrBigObject {
+  return value;
+}
+
 // No-validation baseline
 export function noValidateTaskWithUnion(task: any): any {
   return task;
@@ -124,6 +159,10 @@ export function noValidateComplexConfig(config: any): any {
   return config;
 }
 
+export function noValidateStringOrBigObject(value: any): any {
+  return value;
+}
+
 // Zod validation
 export function zodValidateTaskWithUnion(task: any): any {
   return zodTaskWithUnion.parse(task);
@@ -137,6 +176,10 @@ export function zodValidateComplexConfig(config: any): any {
   return zodComplexConfig.parse(config);
 }
 
+export function zodValidateStringOrBigObject(value: any): any {
+  return zodStringOrBigObject.parse(value);
+}
+
 // Test data
 export const testTaskWithUnion: TaskWithUnion = {
   id: "task-001",
@@ -177,3 +220,5 @@ export const testComplexConfig: ComplexConfig = {
     environment: "production",
   },
 };
+
+export const testStringOrBigObject: StringOrBigObject = "just-a-string";
@@ -24,6 +24,22 @@ export interface TransformResult {
   sourceMap?: RawSourceMap;
 }
 
+export interface ValidateResult {
+  valid: boolean;
+  error?: string;
+}
+
+export interface TypeChange {
+  path: string;
+  kind: "added-required" | "made-required" | "narrowed" | "changed";
+  message: string;
+}
+
+export interface CompareResult {
+  compatible: boolean;
+  changes: TypeChange[];
+}
+
 export interface TransformOptions {
   ignoreTypes?: string[];
   maxGeneratedFunctions?: number;
@@ -595,4 +611,176 @@ export class WasmTypicalCompiler {
       sourceMap: result.sourceMap,
     };
   }
+
+  /**
+   * Check a value against a type declared in a standalone TypeScript source string.
+   *
+   * @param fileName - Virtual filename for error messages
+   * @param source - TypeScript source code declaring the type
+   * @param typeName - Name of the type, interface, class or enum to check against
+   * @param value - Value to check (must be JSON-serialisable)
+   * @returns Whether the value is valid, with the validation error if not
+   */
+  async validateValue(
+    fileName: string,
+    source: string,
+    typeName: string,
+    value: unknown,
+  ): Promise<ValidateResult> {
+    if (!this.ready) {
+      throw new Error("Compiler not started");
+    }
+
+    const validateFn = (globalThis as any).typicalValidateValue;
+    if (typeof validateFn !== "function") {
+      throw new Error("typicalValidateValue function not available");
+    }
+
+    const resultJson = validateFn(fileName, source, typeName, JSON.stringify(value));
+    const result = JSON.parse(resultJson);
+
+    // Failed validation also has an error, but only problems with the inputs lack `valid`
+    if (result.valid === undefined) {
+      throw new Error(result.error);
+    }
+
+    return {
+      valid: result.valid,
+      error: result.error,
+    };
+  }
+
+  /**
+   * Compare an old and new version of a type declared in standalone TypeScript source
+   * strings, reporting whether every value valid under the old version is still valid
+   * under the new one.
+   *
+   * @param fileName - Virtual filename for error messages
+   * @param oldSource - TypeScript source code declaring the old version of the type
+   * @param newSource - TypeScript source code declaring the new version of the type
+   * @param typeName - Name of the type, interface, class or enum to compare
+   * @returns Whether the change is backward compatible, with the breaking changes if not
+   */
+  async compareTypes(
+    fileName: string,
+    oldSource: string,
+    newSource: string,
+    typeName: string,
+  ): Promise<CompareResult> {
+    if (!this.ready) {
+      throw new Error("Compiler not started");
+    }
+
+    const compareFn = (globalThis as any).typicalCompareTypes;
+    if (typeof compareFn !== "function") {
+      throw new Error("typicalCompareTypes function not available");
+    }
+
+    const resultJson = compareFn(fileName, oldSource, newSource, typeName);
+    const result = JSON.parse(resultJson);
+
+    if (result.compatible === undefined) {
+      throw new Error(result.error);
+    }
+
+    return {
+      compatible: result.compatible,
+      changes: result.changes,
+    };
+  }
+
+  /**
+   * Describe a type declared in a standalone TypeScript source string as a JSON Schema
+   * (draft 2020-12) document, e.g. for API docs.
+   *
+   * @param fileName - Virtual filename for error messages
+   * @param source - TypeScript source code declaring the type
+   * @param typeName - Name of the type, interface, class or enum to describe
+   * @returns The JSON Schema document
+   */
+  async typeToJSONSchema(fileName: string, source: string, typeName: string): Promise<object> {
+    if (!this.ready) {
+      throw new Error("Compiler not started");
+    }
+
+    const schemaFn = (globalThis as any).typicalTypeToJSONSchema;
+    if (typeof schemaFn !== "function") {
+      throw new Error("typicalTypeToJSONSchema function not available");
+    }
+
+    const resultJson = schemaFn(fileName, source, typeName);
+    const result = JSON.parse(resultJson);
+
+    if (result.error) {
+      throw new Error(result.error);
+    }
+
+    return JSON.parse(result.schema);
+  }
+
+  /**
+   * Generate a standalone module of validators for the exported types in a
+   * TypeScript source string, without transforming the source itself.
+   *
+   * @param fileName - Virtual filename, also used as the module the types are imported from
+   * @param source - TypeScript source code declaring the types
+   * @param options - Transform options
+   * @returns Module source exporting a check function, filter function and type guard per type
+   */
+  async emitValidators(
+    fileName: string,
+    source: string,
+    options?: TransformOptions,
+  ): Promise<string> {
+    if (!this.ready) {
+      throw new Error("Compiler not started");
+    }
+
+    const emitFn = (globalThis as any).typicalEmitValidators;
+    if (typeof emitFn !== "function") {
+      throw new Error("typicalEmitValidators function not available");
+    }
+
+    const resultJson = emitFn(fileName, source, JSON.stringify(options ?? {}));
+    const result = JSON.parse(resultJson);
+
+    if (result.error) {
+      throw new Error(result.error);
+    }
+
+    return result.code;
+  }
+
+  /**
+   * Compute the typical-hash that emitValidators records in the header of its module,
+   * for checking committed validators are up to date (e.g. in CI).
+   *
+   * @param fileName - Virtual filename, also used as the module the types are imported from
+   * @param source - TypeScript source code declaring the types
+   * @param options - Transform options
+   * @returns Hash of the compiler version and the validators that would be generated
+   */
+  async validatorsHash(
+    fileName: string,
+    source: string,
+    options?: TransformOptions,
+  ): Promise<string> {
+    if (!this.ready) {
+      throw new Error("Compiler not started");
+    }
+
+    const hashFn = (globalThis as any).typicalValidatorsHash;
+    if (typeof hashFn !== "function") {
+      throw new Error("typicalValidatorsHash function not available");
+    }
+
+    const resultJson = hashFn(fileName, source, JSON.stringify(options ?? {}));
+    const result = JSON.parse(resultJson);
+
+    if (result.error) {
+      throw new Error(result.error);
+    }
+
+    return result.hash;
+  }
 }
@@ -8,6 +8,9 @@ export { WasmTypicalCompiler, wrapSyncFSForGo } from "./client.js";
 export type {
   TransformResult,
   TransformOptions,
+  ValidateResult,
+  CompareResult,
+  TypeChange,
   WasmTypicalCompilerOptions,
   RawSourceMap,
 } from "./client.js";
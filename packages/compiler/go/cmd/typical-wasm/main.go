@@ -48,6 +48,131 @@ func main() {
 		return successResult(transformResult)
 	}))
 
+	js.Global().Set("typicalValidateValue", js.FuncOf(func(this js.Value, args []js.Value) (result any) {
+		// Recover from panics and return error
+		defer func() {
+			if r := recover(); r != nil {
+				result = errorResult(fmt.Sprintf("panic: %v", r))
+			}
+		}()
+
+		if len(args) < 4 {
+			return errorResult("typicalValidateValue requires 4 arguments: fileName, source, typeName, jsonValue")
+		}
+
+		validateResult, err := api.ValidateValue(args[0].String(), args[1].String(), args[2].String(), args[3].String())
+		if err != nil {
+			return errorResult(err.Error())
+		}
+
+		data, _ := json.Marshal(validateResult)
+		return string(data)
+	}))
+
+	js.Global().Set("typicalCompareTypes", js.FuncOf(func(this js.Value, args []js.Value) (result any) {
+		// Recover from panics and return error
+		defer func() {
+			if r := recover(); r != nil {
+				result = errorResult(fmt.Sprintf("panic: %v", r))
+			}
+		}()
+
+		if len(args) < 4 {
+			return errorResult("typicalCompareTypes requires 4 arguments: fileName, oldSource, newSource, typeName")
+		}
+
+		compareResult, err := api.CompareTypes(args[0].String(), args[1].String(), args[2].String(), args[3].String())
+		if err != nil {
+			return errorResult(err.Error())
+		}
+
+		data, _ := json.Marshal(compareResult)
+		return string(data)
+	}))
+
+	js.Global().Set("typicalTypeToJSONSchema", js.FuncOf(func(this js.Value, args []js.Value) (result any) {
+		// Recover from panics and return error
+		defer func() {
+			if r := recover(); r != nil {
+				result = errorResult(fmt.Sprintf("panic: %v", r))
+			}
+		}()
+
+		if len(args) < 3 {
+			return errorResult("typicalTypeToJSONSchema requires 3 arguments: fileName, source, typeName")
+		}
+
+		schema, err := api.TypeToJSONSchema(args[0].String(), args[1].String(), args[2].String())
+		if err != nil {
+			return errorResult(err.Error())
+		}
+
+		data, _ := json.Marshal(map[string]any{"schema": schema})
+		return string(data)
+	}))
+
+	js.Global().Set("typicalEmitValidators", js.FuncOf(func(this js.Value, args []js.Value) (result any) {
+		// Recover from panics and return error
+		defer func() {
+			if r := recover(); r != nil {
+				result = errorResult(fmt.Sprintf("panic: %v", r))
+			}
+		}()
+
+		if len(args) < 2 {
+			return errorResult("typicalEmitValidators requires at least 2 arguments: fileName, source")
+		}
+
+		var options wasmapi.TransformOptions
+		if len(args) >= 3 && args[2].Type() == js.TypeString {
+			optionsStr := args[2].String()
+			if optionsStr != "" && optionsStr != "{}" {
+				if err := json.Unmarshal([]byte(optionsStr), &options); err != nil {
+					return errorResult("failed to parse options: " + err.Error())
+				}
+			}
+		}
+
+		code, err := api.EmitValidators(args[0].String(), args[1].String(), &options)
+		if err != nil {
+			return errorResult(err.Error())
+		}
+
+		data, _ := json.Marshal(map[string]any{"code": code})
+		return string(data)
+	}))
+
+	js.Global().Set("typicalValidatorsHash", js.FuncOf(func(this js.Value, args []js.Value) (result any) {
+		// Recover from panics and return error
+		defer func() {
+			if r := recover(); r != nil {
+				result = errorResult(fmt.Sprintf("panic: %v", r))
+			}
+		}()
+
+		if len(args) < 2 {
+			return errorResult("typicalValidatorsHash requires at least 2 arguments: fileName, source")
+		}
+
+		var options wasmapi.TransformOptions
+		if len(args) >= 3 && args[2].Type() == js.TypeString {
+			optionsStr := args[2].String()
+			if optionsStr != "" && optionsStr != "{}" {
+				if err := json.Unmarshal([]byte(optionsStr), &options); err != nil {
+					return errorResult("failed to parse options: " + err.Error())
+				}
+			}
+		}
+
+		hash, err := api.ValidatorsHash(args[0].String(), args[1].String(), &options)
+		if err != nil {
+			return errorResult(err.Error())
+		}
+
+		data, _ := json.Marshal(map[string]any{"hash": hash})
+		return string(data)
+	}))
+
 	// Keep the Go runtime alive
 	<-make(chan struct{})
 }
@@ -4,8 +4,11 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/elliots/typical/packages/compiler/internal/server"
+	"github.com/elliots/typical/packages/compiler/internal/transform"
 )
 
 func main() {
@@ -15,18 +18,82 @@ func main() {
 func run() int {
 	fs := flag.NewFlagSet("typical", flag.ContinueOnError)
 	cwd := fs.String("cwd", mustGetwd(), "current working directory")
+	configFile := fs.String("config", "", "config file to load, instead of typical.config.json in --cwd")
+	checkOnly := fs.Bool("check-only", false, "analyse the project and fail if any validation is skipped because its type is generic or too complex")
+	project := fs.String("project", "tsconfig.json", "tsconfig.json to load (with --check-only, --emit-validators-only, --validators-hash or --watch)")
+	allowSkips := fs.String("allow-skips", "", "comma-separated type patterns that --check-only accepts as unvalidated, e.g. \"T,Foo<*>\"")
+	noAnyBoundaries := fs.Bool("no-any-boundaries", false, "with --check-only, also fail on parameters and return types of exported functions typed any")
+	emitValidators := fs.String("emit-validators-only", "", "print a standalone module of validators for the exported types of this file, instead of transforming it")
+	validatorsHash := fs.String("validators-hash", "", "print the typical-hash --emit-validators-only records for this file, to check committed validators are up to date")
+	watch := fs.Bool("watch", false, "transform the project's files, then again whenever they change, streaming results on stdout until stdin closes")
+	watchInterval := fs.Duration("watch-interval", 500*time.Millisecond, "how often --watch checks files for changes")
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return 2
 	}
 
-	s := server.New(&server.Options{
-		In:  os.Stdin,
-		Out: os.Stdout,
-		Err: os.Stderr,
-		Cwd: *cwd,
+	s, err := server.New(&server.Options{
+		In:         os.Stdin,
+		Out:        os.Stdout,
+		Err:        os.Stderr,
+		Cwd:        *cwd,
+		ConfigFile: *configFile,
 	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *checkOnly {
+		config := s.Config()
+		if *allowSkips != "" {
+			config.AllowedSkips = transform.CompileIgnorePatterns(strings.Split(*allowSkips, ","))
+		}
+		config.NoAnyBoundaries = config.NoAnyBoundaries || *noAnyBoundaries
+
+		failures, err := s.Check(*project, config)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, f := range failures {
+			fmt.Fprintln(os.Stderr, f)
+		}
+		if len(failures) > 0 {
+			fmt.Fprintf(os.Stderr, "typical: %d validation(s) skipped\n", len(failures))
+			return 1
+		}
+		return 0
+	}
+
+	if *emitValidators != "" {
+		code, err := s.EmitValidators(*project, *emitValidators, s.Config())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Fprint(os.Stdout, code)
+		return 0
+	}
+
+	if *validatorsHash != "" {
+		hash, err := s.ValidatorsHash(*project, *validatorsHash, s.Config())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Fprintln(os.Stdout, hash)
+		return 0
+	}
+
+	if *watch {
+		if err := s.Watch(*project, *watchInterval); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
 
 	if err := s.Run(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -22,11 +22,47 @@ type ValidationItem struct {
 	StartColumn int    // 0-based column
 	EndLine     int    // 1-based line number
 	EndColumn   int    // 0-based column
-	Kind        string // "parameter", "return", "cast", "json-parse", "json-stringify"
+	Kind        string // "parameter", "return", "cast", "json-parse", "json-stringify", "parameter-reassignment"
 	Name        string // param name, "return value", or expression text
 	Status      string // "validated" or "skipped"
 	TypeString  string // e.g. "User", "string | null"
 	SkipReason  string // reason for skipping (when status is "skipped")
+	Exported    bool   // A parameter or return type of an exported function
+}
+
+// Skip reasons for types that should be validated but are too generic or too complex
+// to check at runtime. Other skips (any, unknown, ignore patterns, already validated)
+// are intentional and don't count as validation gaps.
+const (
+	SkipReasonGeneric       = "type contains generic parameter (cannot validate at runtime)"
+	SkipReasonConditional   = "type is conditional"
+	SkipReasonIndexedAccess = "type uses indexed access"
+)
+
+// SkipReasonAny marks a type that is any, which accepts every value so there's nothing to check.
+const SkipReasonAny = "type is 'any'"
+
+// SkipReasonParameterReassigned marks a validated parameter that is overwritten with an
+// any-typed value, so its entry validation no longer says anything about it.
+const SkipReasonParameterReassigned = "parameter reassigned from an unvalidated value after entry validation"
+
+// IsGap reports whether the item was skipped because its type couldn't be validated,
+// rather than because validation was deliberately turned off for it.
+func (item ValidationItem) IsGap() bool {
+	if item.Status != "skipped" {
+		return false
+	}
+	switch item.SkipReason {
+	case SkipReasonGeneric, SkipReasonConditional, SkipReasonIndexedAccess:
+		return true
+	}
+	return false
+}
+
+// IsAnyBoundary reports whether the item is a parameter or return type of an exported
+// function typed any - a hole in a public API that Config.NoAnyBoundaries reports.
+func (item ValidationItem) IsAnyBoundary() bool {
+	return item.Exported && item.SkipReason == SkipReasonAny && (item.Kind == "parameter" || item.Kind == "return-type")
 }
 
 // TypeInfo holds type information for code generation.
@@ -78,7 +114,9 @@ type DirtyExternalArg struct {
 	VarName string
 }
 
-// Config specifies which validations to analyse.
+// Config specifies which validations to analyse. Options that only change the checks
+// generated, such as StrictNumbers, aren't needed: a value is only treated as valid after a
+// check generated with the same options has passed, and reassigning it makes it unchecked.
 type Config struct {
 	ValidateParameters     bool
 	ValidateReturns        bool
@@ -88,6 +126,7 @@ type Config struct {
 	IgnoreTypes            []*regexp.Regexp
 	PureFunctions          []*regexp.Regexp // Functions that don't mutate their arguments
 	TrustedFunctions       []*regexp.Regexp // Functions whose return values are trusted as valid
+	Concurrency            int              // Files AnalyseProject analyses at once (0 = one per CPU, 1 = sequential)
 }
 
 // AnalyseFile performs a single AST pass over the source file.
@@ -174,7 +213,7 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 		}
 		flags := checker.Type_flags(t)
 		if flags&checker.TypeFlagsAny != 0 {
-			return "type is 'any'"
+			return SkipReasonAny
 		}
 		if flags&checker.TypeFlagsUnknown != 0 {
 			return "type is 'unknown'"
@@ -186,13 +225,13 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 			return "type is 'void'"
 		}
 		if flags&checker.TypeFlagsTypeParameter != 0 {
-			return "type contains generic parameter (cannot validate at runtime)"
+			return SkipReasonGeneric
 		}
 		if flags&checker.TypeFlagsConditional != 0 {
-			return "type is conditional"
+			return SkipReasonConditional
 		}
 		if flags&checker.TypeFlagsIndexedAccess != 0 {
-			return "type uses indexed access"
+			return SkipReasonIndexedAccess
 		}
 		// Check ignore patterns
 		if sym := checker.Type_symbol(t); sym != nil && sym.Name != "" {
@@ -582,7 +621,7 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 					for j := i + 1; j < len(text) && text[j] != '\n'; j++ {
 						lineEnd = j + 1
 					}
-					if lineEnd > lineStart && strings.Contains(text[lineStart:lineEnd], "@typical-ignore") {
+					if lineEnd > lineStart && isIgnoreComment(text[lineStart:lineEnd]) {
 						return true
 					}
 					break
@@ -591,7 +630,7 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 					// Block comment end - search for start
 					for j := i - 2; j >= 0; j-- {
 						if j > 0 && text[j] == '*' && text[j-1] == '/' {
-							if strings.Contains(text[j-1:i+1], "@typical-ignore") {
+							if isIgnoreComment(text[j-1 : i+1]) {
 								return true
 							}
 							break
@@ -608,13 +647,18 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 
 	// Track function context for return type analysis and validated variables
 	type funcContext struct {
-		returnType         *ast.Node
-		isAsync            bool
-		validated          map[string][]*checker.Type // variables validated in this function
-		bodyStart          int                        // position where function body starts
-		bodyNode           *ast.Node                  // function body for dirty checking
-		funcKey            string                     // unique key for cross-file analysis
-		escapedToExternal  map[string]bool            // variables that have escaped to external code
+		returnType        *ast.Node
+		isAsync           bool
+		validated         map[string][]*checker.Type // variables validated in this function
+		bodyStart         int                        // position where function body starts
+		bodyNode          *ast.Node                  // function body for dirty checking
+		funcKey           string                     // unique key for cross-file analysis
+		escapedToExternal map[string]bool            // variables that have escaped to external code
+		params            map[string]*checker.Type   // validated parameters and their types
+		isCallback        bool                       // passed as a call argument, e.g. items.map(x => ...)
+		ignoreParams      bool                       // @typical-ignore-params
+		ignoreReturns     bool                       // @typical-ignore-returns
+		ignoreCasts       bool                       // @typical-ignore-casts, here or on an enclosing function
 	}
 	var funcStack []*funcContext
 
@@ -635,8 +679,8 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 					if targetType == nil {
 						return t, true
 					}
-					if checker.Checker_isTypeAssignableTo(c, t, targetType) {
-						return t, true
+					if narrowed := NarrowedValidatedType(c, expr, t); checker.Checker_isTypeAssignableTo(c, narrowed, targetType) {
+						return narrowed, true
 					}
 				}
 			}
@@ -968,16 +1012,23 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 				bodyNode:          bodyNode,
 				funcKey:           getFunctionKey(fn),
 				escapedToExternal: make(map[string]bool),
+				params:            make(map[string]*checker.Type),
+				isCallback:        node.Parent != nil && node.Parent.Kind == ast.KindCallExpression && node.Parent.AsCallExpression().Expression != node,
+			}
+			ctx.ignoreParams, ctx.ignoreReturns, ctx.ignoreCasts = IgnoreDirectives(node, text)
+			if len(funcStack) > 0 && funcStack[len(funcStack)-1].ignoreCasts {
+				ctx.ignoreCasts = true
 			}
 			funcStack = append(funcStack, ctx)
 			defer func() { funcStack = funcStack[:len(funcStack)-1] }()
 
+			boundaryItems := len(result.Items)
+
 			// Analyse parameters and mark them as validated
-			if config.ValidateParameters {
+			if config.ValidateParameters && !ctx.ignoreParams {
 				params := getFunctionParameters(fn)
 				for _, param := range params {
-					if param.Type != nil {
-						paramType := checker.Checker_getTypeFromTypeNode(c, param.Type)
+					if paramType, _ := GetParamType(c, node, param); paramType != nil {
 						paramName := GetParamName(param)
 						if paramName == "" {
 							paramName = "(destructured)"
@@ -989,6 +1040,7 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 						skipReason := getSkipReason(paramType)
 						if skipReason == "" && paramName != "(destructured)" {
 							ctx.validated[paramName] = append(ctx.validated[paramName], paramType)
+							ctx.params[paramName] = paramType
 						}
 					}
 				}
@@ -1008,7 +1060,7 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 			}
 
 			// Analyse return type annotation (if present)
-			if config.ValidateReturns && ctx.returnType != nil {
+			if config.ValidateReturns && !ctx.ignoreReturns && ctx.returnType != nil {
 				returnType := checker.Checker_getTypeFromTypeNode(c, ctx.returnType)
 				if returnType != nil {
 					actualType := unwrapPromiseType(returnType, ctx.isAsync, c)
@@ -1017,6 +1069,13 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 				}
 			}
 
+			// The parameter and return type items record whether they're on the module's public surface
+			if IsExportedFunction(projectAnalysis, ctx.funcKey, node) {
+				for i := boundaryItems; i < len(result.Items); i++ {
+					result.Items[i].Exported = true
+				}
+			}
+
 		case ast.KindReturnStatement:
 			if len(funcStack) == 0 {
 				break
@@ -1062,7 +1121,7 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 			}
 
 			// Regular return validation - highlight just the return expression
-			if config.ValidateReturns && returnType != nil {
+			if config.ValidateReturns && !ctx.ignoreReturns && returnType != nil && !HasTrustComment(returnStmt.Expression, text) {
 				actualType := unwrapPromiseType(returnType, ctx.isAsync, c)
 
 				// Check if the return expression is already validated and not dirty
@@ -1109,6 +1168,9 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 			}
 
 			castType := checker.Checker_getTypeFromTypeNode(c, asExpr.Type)
+			if resolved := ResolveIndexedAccessType(c, castType, asExpr.Type); resolved != nil {
+				castType = resolved
+			}
 			exprText := text[asExpr.Expression.Pos():asExpr.Expression.End()]
 			if len(exprText) > 30 {
 				exprText = exprText[:27] + "..."
@@ -1148,7 +1210,7 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 			}
 
 			// Regular cast
-			if config.ValidateCasts {
+			if config.ValidateCasts && (len(funcStack) == 0 || !funcStack[len(funcStack)-1].ignoreCasts) {
 				// Check if this cast is the initializer of a variable declaration
 				// If so, highlight the variable name instead of the whole cast
 				highlightNode := node
@@ -1164,6 +1226,14 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 				}
 				countCheck(castType, highlightNode, highlightNode, "cast", exprText+" as "+typeText)
 
+				// A cast in a callback (items.map(x => x as Item)) runs once per element, so
+				// its check function is worth hoisting even if the type is only cast once
+				if len(funcStack) > 0 && funcStack[len(funcStack)-1].isCallback {
+					if key := getTypeKey(castType, asExpr.Type); result.CheckTypeUsage[key] == 1 {
+						result.CheckTypeUsage[key]++
+					}
+				}
+
 				// Mark variable as validated (if it's a variable declaration with a cast)
 				if varName != "" && len(funcStack) > 0 {
 					ctx := funcStack[len(funcStack)-1]
@@ -1220,7 +1290,7 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 				if isExternal && callExpr.Arguments != nil {
 					for argIdx, arg := range callExpr.Arguments.Nodes {
 						rootVar := GetRootIdentifierName(arg)
-						if rootVar == "" {
+						if rootVar == "" || HasTrustComment(arg, text) {
 							continue
 						}
 
@@ -1254,12 +1324,12 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 						// Include arg.Pos() in the key to handle chained calls like Object.keys(x).map(y)
 						// where multiple calls can share the same node.Pos() but have different argument positions
 						result.DirtyExternalArgs = append(result.DirtyExternalArgs, DirtyExternalArg{
-							CallPos:   node.Pos(),
-							ArgIndex:  argIdx,
-							ArgPos:    arg.Pos(),
-							ArgEnd:    arg.End(),
-							Type:      argType,
-							VarName:   rootVar,
+							CallPos:  node.Pos(),
+							ArgIndex: argIdx,
+							ArgPos:   arg.Pos(),
+							ArgEnd:   arg.End(),
+							Type:     argType,
+							VarName:  rootVar,
 						})
 					}
 				}
@@ -1445,6 +1515,21 @@ func AnalyseFileWithProjectAnalysis(sourceFile *ast.SourceFile, c *checker.Check
 					}
 				}
 			}
+
+			// Reassigning a validated parameter from an any-typed value (user = req.body) undoes
+			// its entry validation, so flag it and stop treating the parameter as validated
+			if config.ValidateParameters && len(funcStack) > 0 && bin.Left.Kind == ast.KindIdentifier {
+				ctx := funcStack[len(funcStack)-1]
+				paramName := bin.Left.AsIdentifier().Text
+				if paramType, ok := ctx.params[paramName]; ok {
+					rightType := checker.Checker_GetTypeAtLocation(c, bin.Right)
+					if rightType != nil && checker.Type_flags(rightType)&checker.TypeFlagsAny != 0 {
+						addValidationItem(node, nil, "parameter-reassignment", paramName, paramType, true, SkipReasonParameterReassigned)
+						delete(ctx.validated, paramName)
+						delete(ctx.params, paramName)
+					}
+				}
+			}
 		}
 
 		node.ForEachChild(visit)
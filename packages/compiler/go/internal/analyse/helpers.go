@@ -2,10 +2,113 @@
 package analyse
 
 import (
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
 	"github.com/microsoft/typescript-go/shim/ast"
 	"github.com/microsoft/typescript-go/shim/checker"
 )
 
+// ignoreCommentRegex matches a bare @typical-ignore, not the suffixed per-function directives.
+var ignoreCommentRegex = regexp.MustCompile(`@typical-ignore(?:[^-\w]|$)`)
+
+// isIgnoreComment reports whether comment skips all validation with @typical-ignore.
+func isIgnoreComment(comment string) bool {
+	return ignoreCommentRegex.MatchString(comment)
+}
+
+// ignoreDirectiveRegex matches the per-function @typical-ignore-params, @typical-ignore-returns
+// and @typical-ignore-casts directives.
+var ignoreDirectiveRegex = regexp.MustCompile(`@typical-ignore-(params|returns|casts)\b`)
+
+// trustCommentRegex matches a /* @typical-trust */ marker on an argument or return expression.
+var trustCommentRegex = regexp.MustCompile(`@typical-trust\b`)
+
+// IgnoreDirectives reports which per-function @typical-ignore-* directives apply to fn.
+// Comments are read from the start of the statement fn is declared in (so a directive above
+// `const f = () => ...` counts) up to its body, leaving nested functions' directives to them.
+func IgnoreDirectives(fn *ast.Node, text string) (params, returns, casts bool) {
+	start := fn
+	for start.Parent != nil && isFunctionDeclarationWrapper(start.Parent) {
+		start = start.Parent
+	}
+	end := fn.End()
+	if body := fn.Body(); body != nil {
+		end = body.Pos()
+	}
+	for _, m := range ignoreDirectiveRegex.FindAllStringSubmatch(text[start.Pos():end], -1) {
+		switch m[1] {
+		case "params":
+			params = true
+		case "returns":
+			returns = true
+		case "casts":
+			casts = true
+		}
+	}
+	return params, returns, casts
+}
+
+// IgnoresCasts reports whether casts in fn are skipped by @typical-ignore-casts on fn or a
+// function it's nested in.
+func IgnoresCasts(fn *ast.Node, text string) bool {
+	for n := fn; n != nil; n = n.Parent {
+		switch n.Kind {
+		case ast.KindFunctionDeclaration, ast.KindFunctionExpression, ast.KindArrowFunction, ast.KindMethodDeclaration:
+			if _, _, casts := IgnoreDirectives(n, text); casts {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasTrustComment reports whether a /* @typical-trust */ comment is among the comments leading
+// up to node's first token, e.g. f(/* @typical-trust */ x).
+func HasTrustComment(node *ast.Node, text string) bool {
+	i := node.Pos()
+	for i < len(text) {
+		switch {
+		case text[i] == ' ' || text[i] == '\t' || text[i] == '\n' || text[i] == '\r':
+			i++
+		case strings.HasPrefix(text[i:], "//"):
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			if trustCommentRegex.MatchString(text[i : i+end]) {
+				return true
+			}
+			i += end
+		case strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				return false
+			}
+			if trustCommentRegex.MatchString(text[i : i+2+end]) {
+				return true
+			}
+			i += end + 4
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// isFunctionDeclarationWrapper reports whether node is part of the statement declaring a
+// function expression, so comments before it document the function.
+func isFunctionDeclarationWrapper(node *ast.Node) bool {
+	switch node.Kind {
+	case ast.KindVariableDeclaration, ast.KindVariableDeclarationList, ast.KindVariableStatement,
+		ast.KindPropertyAssignment, ast.KindPropertyDeclaration, ast.KindExportAssignment:
+		return true
+	}
+	return false
+}
+
 // ShouldSkipType checks if a type should be skipped for validation.
 func ShouldSkipType(t *checker.Type) bool {
 	if t == nil {
@@ -36,6 +139,55 @@ func IsPrimitiveType(t *checker.Type) bool {
 		checker.TypeFlagsBigIntLiteral) != 0
 }
 
+// NarrowedValidatedType returns what a variable validated as validatedType is known to be at
+// ref, taking control-flow narrowing into account: inside `if ("kind" in x)` a value validated
+// as A | B is an A. Returns validatedType when ref isn't an identifier or narrowing doesn't refine it.
+func NarrowedValidatedType(c *checker.Checker, ref *ast.Node, validatedType *checker.Type) *checker.Type {
+	if ref == nil || ref.Kind != ast.KindIdentifier || validatedType == nil {
+		return validatedType
+	}
+	narrowed := checker.Checker_GetTypeAtLocation(c, ref)
+	if narrowed == nil || narrowed == validatedType || !checker.Checker_isTypeAssignableTo(c, narrowed, validatedType) {
+		return validatedType
+	}
+	return narrowed
+}
+
+// ResolveIndexedAccessType resolves an indexed access type such as Config["database"] to
+// the property type it refers to, so it can be validated like any other type. The checker
+// leaves an indexed access unresolved when its object type is generic; then the object's
+// constraint is used. typeNode is the written type, if any. Returns t unchanged if it isn't
+// an indexed access, or nil if it can't be resolved to something concrete.
+func ResolveIndexedAccessType(c *checker.Checker, t *checker.Type, typeNode *ast.Node) *checker.Type {
+	if t == nil || checker.Type_flags(t)&checker.TypeFlagsIndexedAccess == 0 {
+		return t
+	}
+
+	var resolved *checker.Type
+	// Config["database"] as written: look the property up on the object type
+	if typeNode != nil && typeNode.Kind == ast.KindIndexedAccessType {
+		indexed := typeNode.AsIndexedAccessTypeNode()
+		objectType := checker.Checker_getTypeFromTypeNode(c, indexed.ObjectType)
+		indexType := checker.Checker_getTypeFromTypeNode(c, indexed.IndexType)
+		if objectType != nil && indexType != nil && checker.Type_flags(indexType)&checker.TypeFlagsStringLiteral != 0 {
+			if name, ok := indexType.AsLiteralType().Value().(string); ok {
+				if prop := checker.Checker_getPropertyOfType(c, objectType, name); prop != nil {
+					resolved = checker.Checker_getTypeOfSymbol(c, prop)
+				}
+			}
+		}
+	}
+	// T["database"] with T extends Config: its values are at least Config["database"]
+	if resolved == nil {
+		resolved = checker.Checker_getBaseConstraintOfType(c, t)
+	}
+
+	if resolved == nil || resolved == t || ShouldSkipType(resolved) {
+		return nil
+	}
+	return resolved
+}
+
 // GetRootIdentifierName extracts the root identifier name from an expression.
 // For example: user.name.first -> "user", arr[0] -> "arr"
 func GetRootIdentifierName(node *ast.Node) string {
@@ -106,6 +258,37 @@ func GetParamName(param *ast.ParameterDeclaration) string {
 	return ""
 }
 
+// GetParamType returns the type to validate a parameter against, and its type annotation.
+// A parameter without an annotation falls back to its contextual type when the function
+// initialises a typed variable, e.g. const handler: Handler = (req) => {...}; the returned
+// annotation is nil then. Returns nil if there's no type to validate against.
+func GetParamType(c *checker.Checker, fn *ast.Node, param *ast.ParameterDeclaration) (*checker.Type, *ast.Node) {
+	if param.Type != nil {
+		return checker.Checker_getTypeFromTypeNode(c, param.Type), param.Type
+	}
+	// A default value's type is inferred from the value, not the context
+	if param.Initializer != nil || param.Name() == nil || !isTypedVariableInitializer(fn) {
+		return nil, nil
+	}
+	return checker.Checker_GetTypeAtLocation(c, param.Name()), nil
+}
+
+// isTypedVariableInitializer reports whether fn is an arrow or function expression that
+// initialises a variable declared with a type annotation.
+func isTypedVariableInitializer(fn *ast.Node) bool {
+	if fn.Kind != ast.KindArrowFunction && fn.Kind != ast.KindFunctionExpression {
+		return false
+	}
+	parent := fn.Parent
+	for parent != nil && parent.Kind == ast.KindParenthesizedExpression {
+		parent = parent.Parent
+	}
+	if parent == nil || parent.Kind != ast.KindVariableDeclaration {
+		return false
+	}
+	return parent.AsVariableDeclaration().Type != nil
+}
+
 // GetJSONMethodName checks if a call expression is JSON.parse or JSON.stringify.
 // Returns the method name ("parse" or "stringify") and true if it's a JSON method,
 // or empty string and false otherwise.
@@ -138,6 +321,55 @@ func GetJSONMethodName(callExpr *ast.CallExpression) (string, bool) {
 	return "", false
 }
 
+// ArrayFindAssertion returns the call in `list.find(...)!`, whose non-null assertion narrows
+// the `T | undefined` result of an array's find to T without checking anything. Returns nil
+// for any other expression.
+func ArrayFindAssertion(c *checker.Checker, expr *ast.Node) *ast.Node {
+	if expr == nil || expr.Kind != ast.KindNonNullExpression {
+		return nil
+	}
+	call := expr.AsNonNullExpression().Expression
+	for call.Kind == ast.KindParenthesizedExpression {
+		call = call.AsParenthesizedExpression().Expression
+	}
+	if call.Kind != ast.KindCallExpression || call.AsCallExpression().Expression.Kind != ast.KindPropertyAccessExpression {
+		return nil
+	}
+	access := call.AsCallExpression().Expression.AsPropertyAccessExpression()
+	if nameNode := access.Name(); nameNode == nil || nameNode.Text() != "find" {
+		return nil
+	}
+	listType := checker.Checker_GetTypeAtLocation(c, access.Expression)
+	if listType == nil || !checker.Checker_isArrayOrTupleType(c, listType) {
+		return nil
+	}
+	return call
+}
+
+// IsExportedFunction reports whether a function is part of its module's public surface.
+// Uses project analysis when available, otherwise the export modifier on the function,
+// its variable declaration (export const f = () => {}), or its class for methods.
+func IsExportedFunction(pa *ProjectAnalysis, funcKey string, node *ast.Node) bool {
+	if pa != nil && pa.IsExported(funcKey) {
+		return true
+	}
+
+	switch node.Kind {
+	case ast.KindFunctionDeclaration:
+		return ast.GetCombinedModifierFlags(node)&ast.ModifierFlagsExport != 0
+	case ast.KindMethodDeclaration:
+		if ast.GetCombinedModifierFlags(node)&ast.ModifierFlagsPrivate != 0 || node.Name().Kind == ast.KindPrivateIdentifier {
+			return false
+		}
+		class := node.Parent
+		return class != nil && class.Kind == ast.KindClassDeclaration && ast.GetCombinedModifierFlags(class)&ast.ModifierFlagsExport != 0
+	case ast.KindArrowFunction, ast.KindFunctionExpression:
+		decl := node.Parent
+		return decl != nil && decl.Kind == ast.KindVariableDeclaration && ast.GetCombinedModifierFlags(decl)&ast.ModifierFlagsExport != 0
+	}
+	return false
+}
+
 // FunctionLike provides a common interface for function-like nodes.
 type FunctionLike struct {
 	Node *ast.Node
@@ -231,6 +463,22 @@ func (f *FunctionLike) IsAsync() bool {
 	return false
 }
 
+// IsGenerator returns true if the function is a generator (function* or *method()).
+func (f *FunctionLike) IsGenerator() bool {
+	if f == nil || f.Node == nil {
+		return false
+	}
+	switch f.Node.Kind {
+	case ast.KindFunctionDeclaration:
+		return f.Node.AsFunctionDeclaration().AsteriskToken != nil
+	case ast.KindFunctionExpression:
+		return f.Node.AsFunctionExpression().AsteriskToken != nil
+	case ast.KindMethodDeclaration:
+		return f.Node.AsMethodDeclaration().AsteriskToken != nil
+	}
+	return false
+}
+
 // Name returns the function name (empty string for anonymous functions).
 func (f *FunctionLike) Name() string {
 	if f == nil || f.Node == nil {
@@ -373,3 +621,32 @@ func GetEntityName(node *ast.Node) string {
 
 	return ""
 }
+
+// forEachConcurrently calls fn for every index below n, on up to workers goroutines at
+// once, and returns once all calls have. workers below 1 means one per CPU.
+func forEachConcurrently(n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers = min(workers, n); workers <= 1 {
+		for i := range n {
+			fn(i)
+		}
+		return
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for i := range next {
+				fn(i)
+			}
+		})
+	}
+	for i := range n {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
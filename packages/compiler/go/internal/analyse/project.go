@@ -3,8 +3,11 @@ package analyse
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/microsoft/typescript-go/shim/ast"
 	"github.com/microsoft/typescript-go/shim/checker"
@@ -53,6 +56,14 @@ type ProjectAnalysis struct {
 	// UnvalidatedCallResults maps call position to info about calls that need result validation
 	// Used by transform to validate results from functions that don't validate their returns
 	UnvalidatedCallResults map[int]*UnvalidatedCallResult
+
+	// CheckFunctions caches check functions generated while transforming the project's files,
+	// so a type validated in many files is only generated once
+	CheckFunctions *CheckFunctionCache
+
+	// StaleFiles holds the files AnalyseProjectIncremental re-collected because they, or a
+	// file they import from, changed. It's nil after a full analysis.
+	StaleFiles map[string]bool
 }
 
 // UnvalidatedCallResult describes a call whose result needs validation.
@@ -108,9 +119,21 @@ type FunctionInfo struct {
 	// ValidatesReturn indicates if this function validates its return value
 	ValidatesReturn bool
 
+	// CallbackReturnParam is the index of the callback parameter whose result this function
+	// returns, e.g. 0 for withRetry<T>(fn: () => T): T, or -1 if none. The return is then
+	// only as trustworthy as the callback passed at each call site.
+	CallbackReturnParam int
+
 	// ValidatesParams indicates which parameters are validated at entry
 	ValidatesParams []bool
 
+	// IgnoresParams, IgnoresReturns and IgnoresCasts record @typical-ignore-params,
+	// -returns and -casts directives. Casts are also ignored in functions nested in one
+	// with @typical-ignore-casts.
+	IgnoresParams  bool
+	IgnoresReturns bool
+	IgnoresCasts   bool
+
 	// MutatesParams indicates which parameters might be mutated by this function
 	MutatesParams []bool
 
@@ -250,8 +273,12 @@ type FileAnalysis struct {
 	// ExportedSymbols maps symbol names to whether they're exported
 	ExportedSymbols map[string]bool
 
-	// Version is used for incremental invalidation
+	// Version counts how many times AnalyseProjectIncremental has re-collected the file;
+	// it's unchanged when a file's functions were reused from the previous analysis
 	Version int32
+
+	// sourceFile is the AST the functions were collected from
+	sourceFile *ast.SourceFile
 }
 
 // AnalysisContext is passed through analysis phases.
@@ -273,6 +300,33 @@ type AnalysisContext struct {
 
 	// VisitedFunctions tracks functions visited during propagation
 	VisitedFunctions map[string]bool
+
+	// checkerMu serialises Checker calls from the phases that run concurrently
+	checkerMu sync.Mutex
+
+	// previous is the analysis AnalyseProjectIncremental reuses unchanged files from, and
+	// stale the files it has to re-collect
+	previous *ProjectAnalysis
+	stale    map[string]bool
+}
+
+// withChecker calls fn with the checker, which isn't safe for concurrent use, held.
+func (ctx *AnalysisContext) withChecker(fn func(c *checker.Checker)) {
+	ctx.checkerMu.Lock()
+	defer ctx.checkerMu.Unlock()
+	fn(ctx.Checker)
+}
+
+// typeFromTypeNode is checker.Checker_getTypeFromTypeNode, safe for concurrent use.
+func (ctx *AnalysisContext) typeFromTypeNode(node *ast.Node) (t *checker.Type) {
+	ctx.withChecker(func(c *checker.Checker) { t = checker.Checker_getTypeFromTypeNode(c, node) })
+	return t
+}
+
+// typeAtLocation is checker.Checker_GetTypeAtLocation, safe for concurrent use.
+func (ctx *AnalysisContext) typeAtLocation(node *ast.Node) (t *checker.Type) {
+	ctx.withChecker(func(c *checker.Checker) { t = checker.Checker_GetTypeAtLocation(c, node) })
+	return t
 }
 
 // NewProjectAnalysis creates a new empty ProjectAnalysis.
@@ -288,17 +342,121 @@ func NewProjectAnalysis() *ProjectAnalysis {
 		FilterTypeObjects:      make(map[string]TypeInfo),
 		DirtyExternalArgs:      make(map[string]*DirtyExternalArg),
 		UnvalidatedCallResults: make(map[int]*UnvalidatedCallResult),
+		CheckFunctions:         NewCheckFunctionCache(),
+	}
+}
+
+// CheckFunctionCache holds the check functions generated for each type across a project
+// build. Entries belong to the program they were generated from, and are dropped once a
+// file changes and a new program is built. Transforms of different files may share it
+// concurrently, but as types are keyed by their checker (see CheckFunctionKey), only files
+// transformed with the same checker reuse each other's check functions.
+type CheckFunctionCache struct {
+	mu        sync.Mutex
+	program   *compiler.Program
+	functions map[string]CachedCheckFunction
+	hits      int
+	misses    int
+}
+
+// CachedCheckFunction is a generated check function, as named by the generator.
+type CachedCheckFunction struct {
+	Name string
+	Code string
+}
+
+// NewCheckFunctionCache creates an empty CheckFunctionCache.
+func NewCheckFunctionCache() *CheckFunctionCache {
+	return &CheckFunctionCache{functions: make(map[string]CachedCheckFunction)}
+}
+
+// CheckFunctionKey returns the cache key for t, written as typeNode (nil if there's no
+// node). A type's id identifies it exactly within its checker, where its name would be
+// shared by unrelated User interfaces in two files (and by unions of them), but other
+// checkers of the same program number their types differently, so the checker is part of
+// the key. The node's text is too, since the check generated depends on it: a reference
+// name can match ignoreTypes, and T[] is checked differently from Array<T>.
+func CheckFunctionKey(c *checker.Checker, t *checker.Type, typeNode *ast.Node) string {
+	var nodeText string
+	if typeNode != nil {
+		if sf := ast.GetSourceFileOfNode(typeNode); sf != nil {
+			nodeText = strings.TrimSpace(sf.Text()[typeNode.Pos():typeNode.End()])
+		}
+	}
+	return fmt.Sprintf("%p#%d#%s", c, t.Id(), nodeText)
+}
+
+// Get returns the check function cached for key in program. A nil cache has nothing cached.
+func (c *CheckFunctionCache) Get(program *compiler.Program, key string) (CachedCheckFunction, bool) {
+	if c == nil {
+		return CachedCheckFunction{}, false
 	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn, ok := c.functions[key]
+	ok = ok && c.program == program
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return fn, ok
+}
+
+// Put caches the check function generated for key in program, dropping any generated from
+// an earlier program. It does nothing on a nil cache.
+func (c *CheckFunctionCache) Put(program *compiler.Program, key string, fn CachedCheckFunction) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.program != program {
+		c.program = program
+		c.functions = make(map[string]CachedCheckFunction)
+	}
+	c.functions[key] = fn
+}
+
+// Stats returns how many lookups found a cached check function and how many didn't.
+func (c *CheckFunctionCache) Stats() (hits, misses int) {
+	if c == nil {
+		return 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
 }
 
 // AnalyseProject performs whole-project analysis for cross-file validation tracking.
 func AnalyseProject(program *compiler.Program, c *checker.Checker, config Config) *ProjectAnalysis {
+	return AnalyseProjectIncremental(program, c, config, nil, nil)
+}
+
+// AnalyseProjectIncremental is AnalyseProject for a program built after the one previous
+// was analysed from, with changed holding the files edited since. A nil previous analyses
+// the whole project.
+//
+// Only collecting functions is incremental. Files that changed, or whose AST isn't the one
+// previously analysed, are walked again, as are their dependents: every file importing or
+// re-exporting from one of them, directly or not, since their parameter types can come from
+// it. The other files reuse the function nodes, exported symbols and parameter mutations
+// collected from them before. Everything else, including each function's FunctionInfo and
+// the call graph, is rebuilt for the whole project, as checker results can't be carried
+// over to another program's checker. previous isn't modified.
+func AnalyseProjectIncremental(program *compiler.Program, c *checker.Checker, config Config, previous *ProjectAnalysis, changed []string) *ProjectAnalysis {
 	ctx := &AnalysisContext{
 		Program:          program,
 		Checker:          c,
 		Config:           config,
 		ProjectAnalysis:  NewProjectAnalysis(),
 		VisitedFunctions: make(map[string]bool),
+		previous:         previous,
+	}
+	if previous != nil {
+		ctx.stale = staleFiles(ctx, changed)
+		ctx.ProjectAnalysis.CheckFunctions = previous.CheckFunctions
+		ctx.ProjectAnalysis.StaleFiles = ctx.stale
 	}
 
 	// Phase 1: Collect all functions from all source files
@@ -349,46 +507,152 @@ func (pa *ProjectAnalysis) ValidatesReturn(key string) bool {
 }
 
 // collectAllFunctions walks all source files and collects function declarations.
+// Files are walked concurrently, then added to the project in order.
 func collectAllFunctions(ctx *AnalysisContext) {
+	var files []*ast.SourceFile
 	for _, sf := range ctx.Program.SourceFiles() {
 		// Skip declaration files and node_modules
 		fileName := sf.FileName()
 		if isDeclarationFile(fileName) || isNodeModules(fileName) {
 			continue
 		}
+		files = append(files, sf)
+	}
 
-		fileAnalysis := &FileAnalysis{
-			FileName:        fileName,
-			Functions:       make([]*FunctionInfo, 0),
-			ExportedSymbols: make(map[string]bool),
+	fileAnalyses := make([]*FileAnalysis, len(files))
+	forEachConcurrently(len(files), ctx.Config.Concurrency, func(i int) {
+		if prev := ctx.reusableFile(files[i]); prev != nil {
+			fileAnalyses[i] = reuseFileFunctions(ctx, prev)
+			return
+		}
+		fileAnalyses[i] = collectFileFunctions(ctx, files[i])
+		if ctx.previous != nil && ctx.previous.Files[files[i].FileName()] != nil {
+			fileAnalyses[i].Version = ctx.previous.Files[files[i].FileName()].Version + 1
 		}
+	})
 
-		// First pass: collect exported symbols
-		collectExportedSymbols(sf, fileAnalysis)
+	for _, fileAnalysis := range fileAnalyses {
+		for _, funcInfo := range fileAnalysis.Functions {
+			ctx.ProjectAnalysis.CallGraph[funcInfo.Key] = funcInfo
+			if funcInfo.IsExported {
+				ctx.ProjectAnalysis.ExportedFunctions[funcInfo.Key] = true
+			}
+		}
+		ctx.ProjectAnalysis.Files[fileAnalysis.FileName] = fileAnalysis
+	}
+}
 
-		// Second pass: collect functions
-		var visit ast.Visitor
-		visit = func(node *ast.Node) bool {
-			if node == nil {
-				return false
+// collectFileFunctions collects the exported symbols and function declarations of one
+// source file, without touching the project analysis.
+func collectFileFunctions(ctx *AnalysisContext, sf *ast.SourceFile) *FileAnalysis {
+	fileAnalysis := &FileAnalysis{
+		FileName:        sf.FileName(),
+		Functions:       make([]*FunctionInfo, 0),
+		ExportedSymbols: make(map[string]bool),
+		sourceFile:      sf,
+	}
+
+	// First pass: collect exported symbols
+	collectExportedSymbols(sf, fileAnalysis)
+
+	// Second pass: collect functions
+	var visit ast.Visitor
+	visit = func(node *ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		if isFunctionLikeNode(node) {
+			if funcInfo := analyseFunctionNode(ctx, node, fileAnalysis); funcInfo != nil {
+				fileAnalysis.Functions = append(fileAnalysis.Functions, funcInfo)
 			}
-			if isFunctionLikeNode(node) {
-				funcInfo := analyseFunctionNode(ctx, node, fileAnalysis)
-				if funcInfo != nil {
-					fileAnalysis.Functions = append(fileAnalysis.Functions, funcInfo)
-					ctx.ProjectAnalysis.CallGraph[funcInfo.Key] = funcInfo
-					if funcInfo.IsExported {
-						ctx.ProjectAnalysis.ExportedFunctions[funcInfo.Key] = true
-					}
+		}
+		node.ForEachChild(visit)
+		return false
+	}
+	sf.AsNode().ForEachChild(visit)
+
+	return fileAnalysis
+}
+
+// reusableFile returns the previous analysis of sf if AnalyseProjectIncremental can reuse
+// its functions, or nil if it has to be re-collected.
+func (ctx *AnalysisContext) reusableFile(sf *ast.SourceFile) *FileAnalysis {
+	if ctx.previous == nil || ctx.stale[sf.FileName()] {
+		return nil
+	}
+	return ctx.previous.Files[sf.FileName()]
+}
+
+// reuseFileFunctions re-analyses the functions previously collected from a file, without
+// walking it again. Their types are looked up with the current checker, and their parameter
+// mutations, which only depend on the file itself, are copied.
+func reuseFileFunctions(ctx *AnalysisContext, prev *FileAnalysis) *FileAnalysis {
+	fileAnalysis := &FileAnalysis{
+		FileName:        prev.FileName,
+		Functions:       make([]*FunctionInfo, 0, len(prev.Functions)),
+		ExportedSymbols: prev.ExportedSymbols,
+		Version:         prev.Version,
+		sourceFile:      prev.sourceFile,
+	}
+	for _, prevInfo := range prev.Functions {
+		if funcInfo := analyseFunctionNode(ctx, prevInfo.Node, fileAnalysis); funcInfo != nil {
+			copy(funcInfo.MutatesParams, prevInfo.MutatesParams)
+			fileAnalysis.Functions = append(fileAnalysis.Functions, funcInfo)
+		}
+	}
+	return fileAnalysis
+}
+
+// staleFiles returns the files AnalyseProjectIncremental has to re-collect: those changed,
+// new or re-parsed since the previous analysis, and everything depending on them.
+func staleFiles(ctx *AnalysisContext, changed []string) map[string]bool {
+	stale := make(map[string]bool)
+	for _, fileName := range changed {
+		stale[fileName] = true
+	}
+
+	// importers maps each file to the files importing or re-exporting from it
+	importers := make(map[string][]string)
+	for _, sf := range ctx.Program.SourceFiles() {
+		fileName := sf.FileName()
+		if isDeclarationFile(fileName) || isNodeModules(fileName) {
+			continue
+		}
+		if prev := ctx.previous.Files[fileName]; prev == nil || prev.sourceFile != sf {
+			stale[fileName] = true
+		}
+		for _, stmt := range sf.Statements.Nodes {
+			var specifier *ast.Node
+			switch stmt.Kind {
+			case ast.KindImportDeclaration:
+				specifier = stmt.AsImportDeclaration().ModuleSpecifier
+			case ast.KindExportDeclaration:
+				specifier = stmt.AsExportDeclaration().ModuleSpecifier
+			}
+			if specifier == nil {
+				continue
+			}
+			if sym := ctx.Checker.GetSymbolAtLocation(specifier); sym != nil && len(sym.Declarations) > 0 {
+				if target := ast.GetSourceFileOfNode(sym.Declarations[0]); target != nil {
+					importers[target.FileName()] = append(importers[target.FileName()], fileName)
 				}
 			}
-			node.ForEachChild(visit)
-			return false
 		}
-		sf.AsNode().ForEachChild(visit)
+	}
 
-		ctx.ProjectAnalysis.Files[fileName] = fileAnalysis
+	// Dependents of stale files are stale too
+	queue := slices.Collect(maps.Keys(stale))
+	for len(queue) > 0 {
+		fileName := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		for _, importer := range importers[fileName] {
+			if !stale[importer] {
+				stale[importer] = true
+				queue = append(queue, importer)
+			}
+		}
 	}
+	return stale
 }
 
 // isFunctionLikeNode returns true if the node is a function-like declaration.
@@ -547,12 +811,12 @@ func analyseFunctionNode(ctx *AnalysisContext, node *ast.Node, fileAnalysis *Fil
 	// Get return type from checker
 	var checkerReturnType *checker.Type
 	if returnType != nil {
-		checkerReturnType = checker.Checker_getTypeFromTypeNode(ctx.Checker, returnType)
+		checkerReturnType = ctx.typeFromTypeNode(returnType)
 	}
 
 	// Get symbol for this function via its type
 	var funcSymbol *ast.Symbol
-	funcType := checker.Checker_GetTypeAtLocation(ctx.Checker, node)
+	funcType := ctx.typeAtLocation(node)
 	if funcType != nil {
 		funcSymbol = checker.Type_symbol(funcType)
 	}
@@ -581,6 +845,11 @@ func analyseFunctionNode(ctx *AnalysisContext, node *ast.Node, fileAnalysis *Fil
 		ValidatedVariables:      make(map[string]*VariableValidation),
 		BodyStart:               bodyStart,
 		BodyNode:                bodyNode,
+		CallbackReturnParam:     -1,
+	}
+	if sf := ast.GetSourceFileOfNode(node); sf != nil {
+		funcInfo.IgnoresParams, funcInfo.IgnoresReturns, _ = IgnoreDirectives(node, sf.Text())
+		funcInfo.IgnoresCasts = IgnoresCasts(node, sf.Text())
 	}
 
 	// Collect parameters
@@ -596,7 +865,7 @@ func analyseFunctionNode(ctx *AnalysisContext, node *ast.Node, fileAnalysis *Fil
 					}
 				}
 				if param.Type != nil {
-					paramInfo.Type = checker.Checker_getTypeFromTypeNode(ctx.Checker, param.Type)
+					paramInfo.Type = ctx.typeFromTypeNode(param.Type)
 					paramInfo.IsPrimitive = isPrimitiveType(paramInfo.Type)
 				}
 				paramInfo.IsOptional = param.QuestionToken != nil
@@ -605,6 +874,12 @@ func analyseFunctionNode(ctx *AnalysisContext, node *ast.Node, fileAnalysis *Fil
 		}
 	}
 
+	if hasReturnAnnotation {
+		ctx.withChecker(func(c *checker.Checker) {
+			funcInfo.CallbackReturnParam = findCallbackReturnParam(c, checkerReturnType, funcInfo.Parameters)
+		})
+	}
+
 	// Initialise boolean slices
 	paramCount := len(funcInfo.Parameters)
 	funcInfo.ValidatesParams = make([]bool, paramCount)
@@ -614,7 +889,7 @@ func analyseFunctionNode(ctx *AnalysisContext, node *ast.Node, fileAnalysis *Fil
 	funcInfo.ParamValidationReason = make([]string, paramCount)
 
 	// If config has ValidateParameters, mark all params as validated at entry
-	if ctx.Config.ValidateParameters {
+	if ctx.Config.ValidateParameters && !funcInfo.IgnoresParams {
 		for i := range funcInfo.ValidatesParams {
 			funcInfo.ValidatesParams[i] = true
 		}
@@ -623,6 +898,49 @@ func analyseFunctionNode(ctx *AnalysisContext, node *ast.Node, fileAnalysis *Fil
 	return funcInfo
 }
 
+// findCallbackReturnParam returns the index of the parameter whose call signature returns
+// returnType, when returnType is a type parameter - i.e. the function hands back whatever its
+// callback returns, as in withRetry<T>(fn: () => T): T. Returns -1 otherwise.
+func findCallbackReturnParam(c *checker.Checker, returnType *checker.Type, params []*ParameterInfo) int {
+	if returnType == nil || checker.Type_flags(returnType)&checker.TypeFlagsTypeParameter == 0 {
+		return -1
+	}
+	for i, param := range params {
+		if param.Type == nil {
+			continue
+		}
+		for _, sig := range checker.Checker_getSignaturesOfType(c, param.Type, checker.SignatureKindCall) {
+			if checker.Checker_getReturnTypeOfSignature(c, sig) == returnType {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// inlineCallbackValidatesReturn reports whether an argument is an inline arrow function that
+// validates its own return: it has a concrete return type annotation and returns are validated.
+func inlineCallbackValidatesReturn(ctx *AnalysisContext, arg *ast.Node) bool {
+	if arg == nil || arg.Kind != ast.KindArrowFunction || !ctx.Config.ValidateReturns {
+		return false
+	}
+	af := arg.AsArrowFunction()
+	if af == nil || af.Type == nil {
+		return false
+	}
+	returnType := checker.Checker_getTypeFromTypeNode(ctx.Checker, af.Type)
+	return returnType != nil && !shouldSkipType(returnType) && checker.Type_flags(returnType)&checker.TypeFlagsTypeParameter == 0
+}
+
+// callbackResultValidated reports whether a call to calleeFunc, which returns the result of
+// its callback parameter, passes an inline callback that validates that result itself.
+func callbackResultValidated(ctx *AnalysisContext, calleeFunc *FunctionInfo, call *ast.CallExpression) bool {
+	if calleeFunc.CallbackReturnParam < 0 || call.Arguments == nil || calleeFunc.CallbackReturnParam >= len(call.Arguments.Nodes) {
+		return false
+	}
+	return inlineCallbackValidatesReturn(ctx, call.Arguments.Nodes[calleeFunc.CallbackReturnParam])
+}
+
 // generateFunctionKey creates a unique key for a function.
 func generateFunctionKey(fileName, name string, pos int) string {
 	if name != "" {
@@ -797,7 +1115,7 @@ func analyseCallExpression(ctx *AnalysisContext, caller *FunctionInfo, call *ast
 					argCalleeFuncKey := resolveCalleeKey(ctx, argCallExpr)
 					if argCalleeFuncKey != "" {
 						argCalleeFunc := ctx.ProjectAnalysis.CallGraph[argCalleeFuncKey]
-						if argCalleeFunc != nil && argCalleeFunc.ValidatesReturn {
+						if argCalleeFunc != nil && (argCalleeFunc.ValidatesReturn || callbackResultValidated(ctx, argCalleeFunc, argCallExpr)) {
 							argInfo.IsValidated = true
 							argInfo.ValidationPath = append(argInfo.ValidationPath, "trusted-return")
 						}
@@ -882,6 +1200,10 @@ func analyseParameterMutations(ctx *AnalysisContext) {
 		if bodyNode == nil {
 			continue
 		}
+		// Reused files copied their mutations from the previous analysis
+		if ctx.previous != nil && !ctx.stale[funcInfo.FileName] {
+			continue
+		}
 
 		// Build parameter name to index map
 		paramIndices := make(map[string]int)
@@ -1215,120 +1537,128 @@ func checkClosureCaptures(innerFunc *ast.Node, paramIndices map[string]int, func
 // analyseValidatedVariables tracks which variables are validated within each function.
 // This is used to determine if arguments at call sites are already validated.
 func analyseValidatedVariables(ctx *AnalysisContext) {
+	funcs := make([]*FunctionInfo, 0, len(ctx.ProjectAnalysis.CallGraph))
 	for _, funcInfo := range ctx.ProjectAnalysis.CallGraph {
-		if funcInfo.BodyNode == nil {
-			continue
+		if funcInfo.BodyNode != nil {
+			funcs = append(funcs, funcInfo)
 		}
+	}
+	forEachConcurrently(len(funcs), ctx.Config.Concurrency, func(i int) {
+		analyseFunctionValidatedVariables(ctx, funcs[i])
+	})
+}
 
-		// Mark parameters as validated at function entry (position 0 = start of body)
-		if ctx.Config.ValidateParameters {
-			for _, param := range funcInfo.Parameters {
-				if param.Name != "" && param.Type != nil && !shouldSkipType(param.Type) {
-					funcInfo.ValidatedVariables[param.Name] = &VariableValidation{
-						Position: funcInfo.BodyStart,
-						Type:     param.Type,
-						Source:   "parameter",
-					}
+// analyseFunctionValidatedVariables tracks which variables are validated within funcInfo.
+// Only funcInfo is written, so functions can be analysed concurrently.
+func analyseFunctionValidatedVariables(ctx *AnalysisContext, funcInfo *FunctionInfo) {
+	// Mark parameters as validated at function entry (position 0 = start of body)
+	if ctx.Config.ValidateParameters && !funcInfo.IgnoresParams {
+		for _, param := range funcInfo.Parameters {
+			if param.Name != "" && param.Type != nil && !shouldSkipType(param.Type) {
+				funcInfo.ValidatedVariables[param.Name] = &VariableValidation{
+					Position: funcInfo.BodyStart,
+					Type:     param.Type,
+					Source:   "parameter",
 				}
 			}
 		}
+	}
 
-		// Walk the function body to find other validation points
-		var visit ast.Visitor
-		visit = func(node *ast.Node) bool {
-			if node == nil {
-				return false
-			}
+	// Walk the function body to find other validation points
+	var visit ast.Visitor
+	visit = func(node *ast.Node) bool {
+		if node == nil {
+			return false
+		}
 
-			switch node.Kind {
-			case ast.KindVariableDeclaration:
-				varDecl := node.AsVariableDeclaration()
-				if varDecl == nil || varDecl.Initializer == nil {
-					break
-				}
+		switch node.Kind {
+		case ast.KindVariableDeclaration:
+			varDecl := node.AsVariableDeclaration()
+			if varDecl == nil || varDecl.Initializer == nil {
+				break
+			}
 
-				// Get variable name
-				var varName string
-				if varDecl.Name() != nil && varDecl.Name().Kind == ast.KindIdentifier {
-					varName = varDecl.Name().AsIdentifier().Text
-				}
-				if varName == "" {
-					break
-				}
+			// Get variable name
+			var varName string
+			if varDecl.Name() != nil && varDecl.Name().Kind == ast.KindIdentifier {
+				varName = varDecl.Name().AsIdentifier().Text
+			}
+			if varName == "" {
+				break
+			}
 
-				// Check for cast: const x = expr as T
-				if varDecl.Initializer.Kind == ast.KindAsExpression {
-					asExpr := varDecl.Initializer.AsAsExpression()
-					if asExpr != nil && asExpr.Type != nil {
-						castType := checker.Checker_getTypeFromTypeNode(ctx.Checker, asExpr.Type)
-						if castType != nil && !shouldSkipType(castType) {
-							funcInfo.ValidatedVariables[varName] = &VariableValidation{
-								Position: node.Pos(),
-								Type:     castType,
-								Source:   "cast",
-							}
+			// Check for cast: const x = expr as T
+			if varDecl.Initializer.Kind == ast.KindAsExpression {
+				asExpr := varDecl.Initializer.AsAsExpression()
+				if asExpr != nil && asExpr.Type != nil {
+					castType := ctx.typeFromTypeNode(asExpr.Type)
+					if castType != nil && !shouldSkipType(castType) && !funcInfo.IgnoresCasts {
+						funcInfo.ValidatedVariables[varName] = &VariableValidation{
+							Position: node.Pos(),
+							Type:     castType,
+							Source:   "cast",
 						}
 					}
-					break
 				}
+				break
+			}
 
-				// Check for JSON.parse: const x: T = JSON.parse(...) or const x = JSON.parse<T>(...)
-				if varDecl.Initializer.Kind == ast.KindCallExpression {
-					callExpr := varDecl.Initializer.AsCallExpression()
-					if callExpr != nil && isJSONParseCall(callExpr) {
-						var targetType *checker.Type
+			// Check for JSON.parse: const x: T = JSON.parse(...) or const x = JSON.parse<T>(...)
+			if varDecl.Initializer.Kind == ast.KindCallExpression {
+				callExpr := varDecl.Initializer.AsCallExpression()
+				if callExpr != nil && isJSONParseCall(callExpr) {
+					var targetType *checker.Type
 
-						// Check explicit type annotation on variable
-						if varDecl.Type != nil {
-							targetType = checker.Checker_getTypeFromTypeNode(ctx.Checker, varDecl.Type)
-						}
+					// Check explicit type annotation on variable
+					if varDecl.Type != nil {
+						targetType = ctx.typeFromTypeNode(varDecl.Type)
+					}
 
-						// Check type argument on call: JSON.parse<T>(...)
-						if targetType == nil && callExpr.TypeArguments != nil && len(callExpr.TypeArguments.Nodes) > 0 {
-							targetType = checker.Checker_getTypeFromTypeNode(ctx.Checker, callExpr.TypeArguments.Nodes[0])
-						}
+					// Check type argument on call: JSON.parse<T>(...)
+					if targetType == nil && callExpr.TypeArguments != nil && len(callExpr.TypeArguments.Nodes) > 0 {
+						targetType = ctx.typeFromTypeNode(callExpr.TypeArguments.Nodes[0])
+					}
 
-						if targetType != nil && !shouldSkipType(targetType) {
-							funcInfo.ValidatedVariables[varName] = &VariableValidation{
-								Position: node.Pos(),
-								Type:     targetType,
-								Source:   "json-parse",
-							}
+					if targetType != nil && !shouldSkipType(targetType) {
+						funcInfo.ValidatedVariables[varName] = &VariableValidation{
+							Position: node.Pos(),
+							Type:     targetType,
+							Source:   "json-parse",
 						}
-						break
 					}
+					break
+				}
 
-					// Check for trusted function call
-					if len(ctx.Config.TrustedFunctions) > 0 {
-						funcName := getCallExpressionName(callExpr)
-						for _, re := range ctx.Config.TrustedFunctions {
-							if re.MatchString(funcName) {
-								// Get variable type
-								var targetType *checker.Type
-								if varDecl.Type != nil {
-									targetType = checker.Checker_getTypeFromTypeNode(ctx.Checker, varDecl.Type)
-								} else {
-									targetType = checker.Checker_GetTypeAtLocation(ctx.Checker, varDecl.Name())
-								}
-								if targetType != nil && !shouldSkipType(targetType) {
-									funcInfo.ValidatedVariables[varName] = &VariableValidation{
-										Position: node.Pos(),
-										Type:     targetType,
-										Source:   "trusted-call",
-									}
+				// Check for trusted function call
+				if len(ctx.Config.TrustedFunctions) > 0 {
+					funcName := getCallExpressionName(callExpr)
+					for _, re := range ctx.Config.TrustedFunctions {
+						if re.MatchString(funcName) {
+							// Get variable type
+							var targetType *checker.Type
+							if varDecl.Type != nil {
+								targetType = ctx.typeFromTypeNode(varDecl.Type)
+							} else {
+								targetType = ctx.typeAtLocation(varDecl.Name())
+							}
+							if targetType != nil && !shouldSkipType(targetType) {
+								funcInfo.ValidatedVariables[varName] = &VariableValidation{
+									Position: node.Pos(),
+									Type:     targetType,
+									Source:   "trusted-call",
 								}
-								break
 							}
+							break
 						}
 					}
 				}
 			}
-
-			node.ForEachChild(visit)
-			return false
 		}
-		funcInfo.BodyNode.ForEachChild(visit)
+
+		node.ForEachChild(visit)
+		return false
 	}
+	funcInfo.BodyNode.ForEachChild(visit)
 }
 
 // extendValidatedVariablesFromCalls marks variables as validated when they're assigned
@@ -1370,15 +1700,20 @@ func extendValidatedVariablesFromCalls(ctx *AnalysisContext) {
 					return false
 				}
 
-				// Check if initialiser is a call to a function that validates its return
-				if varDecl.Initializer.Kind == ast.KindCallExpression {
-					callExpr := varDecl.Initializer.AsCallExpression()
+				// Check if initialiser is a call to a function that validates its return.
+				// list.find(...)! counts as the call, as its assertion drops undefined unchecked
+				initializer := varDecl.Initializer
+				if call := ArrayFindAssertion(ctx.Checker, initializer); call != nil {
+					initializer = call
+				}
+				if initializer.Kind == ast.KindCallExpression {
+					callExpr := initializer.AsCallExpression()
 					if callExpr != nil {
 						calleeKey := resolveCalleeKey(ctx, callExpr)
 						calleeValidatesReturn := false
 						if calleeKey != "" {
 							calleeFunc := ctx.ProjectAnalysis.CallGraph[calleeKey]
-							if calleeFunc != nil && calleeFunc.ValidatesReturn {
+							if calleeFunc != nil && (calleeFunc.ValidatesReturn || callbackResultValidated(ctx, calleeFunc, callExpr)) {
 								calleeValidatesReturn = true
 								// Get variable type
 								var targetType *checker.Type
@@ -1475,7 +1810,7 @@ func extendValidatedVariablesFromCalls(ctx *AnalysisContext) {
 						calleeValidatesReturn := false
 						if calleeKey != "" {
 							calleeFunc := ctx.ProjectAnalysis.CallGraph[calleeKey]
-							if calleeFunc != nil && calleeFunc.ValidatesReturn {
+							if calleeFunc != nil && (calleeFunc.ValidatesReturn || callbackResultValidated(ctx, calleeFunc, callExpr)) {
 								calleeValidatesReturn = true
 							}
 						}
@@ -1704,6 +2039,22 @@ func IsVariableValidAtPosition(pa *ProjectAnalysis, funcKey string, varName stri
 	return !isVariableDirtyExported(pa, funcInfo, varName, validation.Position, atPosition, config)
 }
 
+// IsVariableValidAsType is like IsVariableValidAtPosition, but also requires the recorded
+// validation to be at least as strict as targetType (the validated type is assignable to it).
+// A variable validated as Partial<User> is not valid as User and must be validated again.
+// ref is the reference being checked, if known, so narrowing at it (e.g. by `"kind" in x`) counts.
+func IsVariableValidAsType(pa *ProjectAnalysis, c *checker.Checker, funcKey string, varName string, atPosition int, ref *ast.Node, targetType *checker.Type, config Config) bool {
+	if !IsVariableValidAtPosition(pa, funcKey, varName, atPosition, config) {
+		return false
+	}
+	if targetType == nil {
+		return true
+	}
+
+	validation := pa.GetFunctionInfo(funcKey).ValidatedVariables[varName]
+	return validation.Type != nil && checker.Checker_isTypeAssignableTo(c, NarrowedValidatedType(c, ref, validation.Type), targetType)
+}
+
 // isVariableDirtyExported checks if a variable was dirtied between two positions.
 // This version accepts ProjectAnalysis to look up internal functions.
 // Simplified rule: if a variable escapes (via function call, field, global, closure), it's dirty forever.
@@ -1945,7 +2296,8 @@ func analyseValidatedReturns(ctx *AnalysisContext) {
 		// A function validates its return if:
 		// 1. It has a return type annotation
 		// 2. ValidateReturns is enabled in config
-		if funcInfo.HasReturnTypeAnnotation && ctx.Config.ValidateReturns {
+		// 3. It doesn't just hand back its callback's result - that depends on the call site
+		if funcInfo.HasReturnTypeAnnotation && ctx.Config.ValidateReturns && funcInfo.CallbackReturnParam < 0 && !funcInfo.IgnoresReturns {
 			funcInfo.ValidatesReturn = true
 			ctx.ProjectAnalysis.ValidatedReturns[funcInfo.Key] = true
 		}
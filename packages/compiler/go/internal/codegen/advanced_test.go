@@ -2,6 +2,7 @@ package codegen
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -210,6 +211,134 @@ function testRecord(data: Record<string, number>): void {}
 	}
 }
 
+// TestUtilityTypeOptionality tests that Pick, Omit and chains of them keep each property's
+// optionality, readonly properties and nested structure from the original type.
+func TestUtilityTypeOptionality(t *testing.T) {
+	code := `
+interface Profile {
+	readonly id: number;
+	name: string;
+	nickname?: string;
+	address: { street: string; postcode?: number };
+	readonly tags?: readonly string[];
+}
+
+function testPick(p: Pick<Profile, "id" | "nickname">): void {}
+function testOmit(p: Omit<Profile, "name" | "tags">): void {}
+function testOmitPick(p: Omit<Pick<Profile, "id" | "nickname" | "address">, "id">): void {}
+function testPickOmit(p: Pick<Omit<Profile, "name">, "id" | "tags">): void {}
+function testPickPartial(p: Pick<Partial<Profile>, "id" | "name">): void {}
+function testRequiredPick(p: Required<Pick<Profile, "nickname" | "tags">>): void {}
+function testReadonlyPick(p: Readonly<Pick<Profile, "id" | "nickname">>): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	gen := NewGenerator(c, program)
+
+	tests := []struct {
+		funcName    string
+		optional    []string // Validated only when present
+		required    []string // Always validated
+		valid       string
+		invalid     string
+		invalidPath string // Start of the error the invalid value gets
+	}{
+		{
+			funcName:    "testPick",
+			optional:    []string{"_v.nickname"},
+			required:    []string{"_v.id"},
+			valid:       `{"id": 1}`,
+			invalid:     `{"nickname": "Al"}`,
+			invalidPath: "Expected value.id to be",
+		},
+		{
+			funcName:    "testOmit",
+			optional:    []string{"_v.nickname", "_v.address.postcode"},
+			required:    []string{"_v.id", "_v.address", "_v.address.street"},
+			valid:       `{"id": 1, "address": {"street": "Main"}}`,
+			invalid:     `{"id": 1, "address": {"street": "Main", "postcode": "2000"}}`,
+			invalidPath: "Expected value.address.postcode to be",
+		},
+		{
+			funcName:    "testOmitPick",
+			optional:    []string{"_v.nickname", "_v.address.postcode"},
+			required:    []string{"_v.address", "_v.address.street"},
+			valid:       `{"address": {"street": "Main"}}`,
+			invalid:     `{"nickname": "Al"}`,
+			invalidPath: "Expected value.address to be",
+		},
+		{
+			funcName:    "testPickOmit",
+			optional:    []string{"_v.tags"},
+			required:    []string{"_v.id"},
+			valid:       `{"id": 1}`,
+			invalid:     `{"id": 1, "tags": [1]}`,
+			invalidPath: "Expected value.tags[0] to be",
+		},
+		{
+			funcName: "testPickPartial",
+			optional: []string{"_v.id", "_v.name"},
+			valid:    `{}`,
+		},
+		{
+			funcName:    "testRequiredPick",
+			required:    []string{"_v.nickname", "_v.tags"},
+			valid:       `{"nickname": "Al", "tags": []}`,
+			invalid:     `{"nickname": "Al"}`,
+			invalidPath: "Expected value.tags to be",
+		},
+		{
+			funcName:    "testReadonlyPick",
+			optional:    []string{"_v.nickname"},
+			required:    []string{"_v.id"},
+			valid:       `{"id": 1}`,
+			invalid:     `{"id": "1"}`,
+			invalidPath: "Expected value.id to be",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.funcName, func(t *testing.T) {
+			paramType := findFunctionParamType(c, sourceFile, tc.funcName)
+			if paramType == nil {
+				t.Fatalf("Could not find type for %s", tc.funcName)
+			}
+
+			validator := gen.GenerateValidator(paramType, "param").Code
+			for _, prop := range tc.optional {
+				if !strings.Contains(validator, prop+" !== undefined") {
+					t.Errorf("Expected %s to be validated as optional:\n%s", prop, validator)
+				}
+			}
+			for _, prop := range tc.required {
+				if !strings.Contains(validator, prop) || strings.Contains(validator, prop+" !== undefined") {
+					t.Errorf("Expected %s to be validated as required:\n%s", prop, validator)
+				}
+			}
+
+			var value any
+			if err := json.Unmarshal([]byte(tc.valid), &value); err != nil {
+				t.Fatalf("invalid test value: %v", err)
+			}
+			if got := gen.CheckValue(paramType, value, "value"); got != "" {
+				t.Errorf("Expected %s to be valid, got %q", tc.valid, got)
+			}
+
+			if tc.invalid == "" {
+				return
+			}
+			if err := json.Unmarshal([]byte(tc.invalid), &value); err != nil {
+				t.Fatalf("invalid test value: %v", err)
+			}
+			if got := gen.CheckValue(paramType, value, "value"); !strings.HasPrefix(got, tc.invalidPath) {
+				t.Errorf("Expected %s to fail with %q..., got %q", tc.invalid, tc.invalidPath, got)
+			}
+		})
+	}
+}
+
 // TestLiteralTypes tests string, number, and boolean literals.
 func TestLiteralTypes(t *testing.T) {
 	code := `
@@ -529,6 +658,46 @@ function testDiscriminatedUnion(shape: Shape): void {}
 	})
 }
 
+// TestUnionMemberOrder tests that cheap union member checks run before structural ones.
+func TestUnionMemberOrder(t *testing.T) {
+	code := `
+interface BigObject {
+	id: string;
+	name: string;
+	tags: string[];
+	meta: Record<string, number>;
+}
+
+function testCheapFirst(value: BigObject | "none" | number): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	gen := NewGenerator(c, program)
+
+	paramType := findFunctionParamType(c, sourceFile, "testCheapFirst")
+	if paramType == nil {
+		t.Fatal("Could not find type for testCheapFirst")
+	}
+
+	validator := gen.GenerateValidator(paramType, "value").Code
+	t.Logf("Generated validator:\n%s", validator)
+
+	objectIdx := strings.Index(validator, `"object" === typeof _v`)
+	if objectIdx < 0 {
+		t.Fatalf("Expected validator to contain an object check")
+	}
+	for _, cheap := range []string{`"none" === _v`, `"number" === typeof _v`} {
+		idx := strings.Index(validator, cheap)
+		if idx < 0 {
+			t.Errorf("Expected validator to contain %q", cheap)
+		} else if idx > objectIdx {
+			t.Errorf("Expected %q to be checked before the object member", cheap)
+		}
+	}
+}
+
 // TestNestedTypes tests nested objects and arrays.
 func TestNestedTypes(t *testing.T) {
 	code := `
@@ -675,3 +844,672 @@ function testComplexIntersection(employee: Employee): void {}
 		})
 	}
 }
+
+// TestIntersectionMerging tests that intersections of interfaces are validated as one object.
+func TestIntersectionMerging(t *testing.T) {
+	code := `
+interface Identified { id: string | number; }
+interface Named { id: string; name: string; }
+interface Timestamped { createdAt: number; }
+
+function testMerged(record: Identified & Named & Timestamped): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	gen := NewGenerator(c, program)
+	paramType := findFunctionParamType(c, sourceFile, "testMerged")
+	if paramType == nil {
+		t.Fatal("Could not find type for testMerged")
+	}
+	validator := gen.GenerateValidator(paramType, "record").Code
+	t.Logf("Generated validator:\n%s", validator)
+
+	// Object-ness is checked once rather than once per member
+	if n := strings.Count(validator, `typeof _v === "object"`); n != 1 {
+		t.Errorf("Expected a single object check, got %d", n)
+	}
+	for _, expected := range []string{`_v.name`, `_v.createdAt`, `"string" === typeof _v.id`} {
+		if !strings.Contains(validator, expected) {
+			t.Errorf("Expected validator to contain %q", expected)
+		}
+	}
+	// id is validated against string & (string | number), which is string
+	if strings.Contains(validator, `"number" === typeof _v.id`) {
+		t.Error("Expected id to be validated against the intersection of its types")
+	}
+}
+
+func TestBrandRanges(t *testing.T) {
+	code := `
+type Percent = number & { readonly __min: 0; readonly __max: 100 };
+type Natural = number & { readonly __min: 0 };
+type Temperature = number & { readonly __max: -10 };
+
+function testBoth(p: Percent): void {}
+function testMin(n: Natural): void {}
+function testMax(t: Temperature): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	tests := []struct {
+		funcName string
+		check    string
+		expected string
+	}{
+		{"testBoth", `_v >= 0 && _v <= 100`, `number between 0 and 100`},
+		{"testMin", `_v >= 0`, `number >= 0`},
+		{"testMax", `_v <= -10`, `number <= -10`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.funcName, func(t *testing.T) {
+			gen := NewGenerator(c, program)
+			paramType := findFunctionParamType(c, sourceFile, tt.funcName)
+			if paramType == nil {
+				t.Fatalf("Could not find type for %s", tt.funcName)
+			}
+			validator := gen.GenerateValidator(paramType, "value").Code
+			t.Logf("Generated validator:\n%s", validator)
+
+			if !strings.Contains(validator, `"number" === typeof _v`) {
+				t.Error("Expected the primitive to be validated")
+			}
+			if !strings.Contains(validator, "if (!("+tt.check+"))") {
+				t.Errorf("Expected range check %q", tt.check)
+			}
+			if !strings.Contains(validator, tt.expected) {
+				t.Errorf("Expected error to state the range %q", tt.expected)
+			}
+			if strings.Contains(validator, "__min") || strings.Contains(validator, "__max") {
+				t.Error("Range markers are compile-time only and shouldn't be read at runtime")
+			}
+
+			// Checks (used for union members and hoisted functions) apply the range too
+			check := gen.GenerateCheckFunction(paramType, "value").Code
+			if !strings.Contains(check, tt.check) {
+				t.Errorf("Expected check function to contain %q, got:\n%s", tt.check, check)
+			}
+		})
+	}
+}
+
+func TestIntegerBrand(t *testing.T) {
+	code := `
+type Int = number & { readonly __int: true };
+type Port = number & { readonly __int: true; readonly __min: 1; readonly __max: 65535 };
+type Count = number & { readonly __integer: true };
+
+function testInt(id: Int): void {}
+function testPort(port: Port): void {}
+function testCustom(count: Count): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	tests := []struct {
+		funcName     string
+		integerBrand string
+		contains     []string
+	}{
+		{"testInt", DefaultIntegerBrand, []string{`Number.isInteger(_v)`, `to be integer`}},
+		{"testPort", DefaultIntegerBrand, []string{`Number.isInteger(_v)`, `_v >= 1 && _v <= 65535`}},
+		{"testCustom", "__integer", []string{`Number.isInteger(_v)`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.funcName, func(t *testing.T) {
+			gen := NewGenerator(c, program)
+			gen.SetIntegerBrand(tt.integerBrand)
+			paramType := findFunctionParamType(c, sourceFile, tt.funcName)
+			if paramType == nil {
+				t.Fatalf("Could not find type for %s", tt.funcName)
+			}
+			validator := gen.GenerateValidator(paramType, "value").Code
+			t.Logf("Generated validator:\n%s", validator)
+
+			for _, expected := range tt.contains {
+				if !strings.Contains(validator, expected) {
+					t.Errorf("Expected validator to contain %q", expected)
+				}
+			}
+			if strings.Contains(validator, tt.integerBrand) {
+				t.Error("The integer marker is compile-time only and shouldn't be read at runtime")
+			}
+
+			check := gen.GenerateCheckFunction(paramType, "value").Code
+			if !strings.Contains(check, `Number.isInteger(_v)`) {
+				t.Errorf("Expected check function to use Number.isInteger, got:\n%s", check)
+			}
+		})
+	}
+
+	// Without the marker configured, the brand is an ordinary object intersection
+	gen := NewGenerator(c, program)
+	paramType := findFunctionParamType(c, sourceFile, "testCustom")
+	if validator := gen.GenerateValidator(paramType, "value").Code; strings.Contains(validator, "Number.isInteger") {
+		t.Errorf("Expected no integer check for an unconfigured marker, got:\n%s", validator)
+	}
+}
+
+func TestCrossRealmBuiltins(t *testing.T) {
+	code := `
+class Account { id: string = ""; }
+class Lookup extends Map<string, number> {}
+
+function testBuiltins(value: { at: Date; tags: Set<string>; err: TypeError; grid: Date[][]; account: Account; lookup: Lookup }): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	paramType := findFunctionParamType(c, sourceFile, "testBuiltins")
+	if paramType == nil {
+		t.Fatal("Could not find type for testBuiltins")
+	}
+
+	tests := []struct {
+		name       string
+		crossRealm bool
+		expected   []string
+		unexpected []string
+	}{
+		{
+			name:       "instanceof by default",
+			expected:   []string{`_v.at instanceof Date`, `instanceof Set`, `_v.err instanceof TypeError`},
+			unexpected: []string{`Object.prototype.toString`},
+		},
+		{
+			name:       "toStringTag when cross-realm",
+			crossRealm: true,
+			expected: []string{
+				`Object.prototype.toString.call(_v.at) === "[object Date]"`,
+				`=== "[object Set]"`,
+				`Object.prototype.toString.call(_v.err) === "[object Error]"`, // Errors of every kind report "Error"
+			},
+			unexpected: []string{`instanceof Date`, `instanceof Set`, `instanceof TypeError`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator(c, program)
+			gen.SetCrossRealmBuiltins(tt.crossRealm)
+			validator := gen.GenerateValidator(paramType, "value").Code
+			t.Logf("Generated validator:\n%s", validator)
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(validator, expected) {
+					t.Errorf("Expected validator to contain %q", expected)
+				}
+			}
+			for _, unexpected := range tt.unexpected {
+				if strings.Contains(validator, unexpected) {
+					t.Errorf("Expected validator not to contain %q", unexpected)
+				}
+			}
+
+			// Arrays (including arrays of arrays) are realm-safe either way, and classes
+			// without a well-known tag keep instanceof
+			if strings.Contains(validator, "instanceof Array") || !strings.Contains(validator, "Array.isArray(_v.grid)") {
+				t.Error("Expected arrays to be checked with Array.isArray")
+			}
+			for _, class := range []string{"Account", "Lookup"} {
+				if !strings.Contains(validator, "instanceof "+class) {
+					t.Errorf("Expected %s to be checked with instanceof", class)
+				}
+			}
+		})
+	}
+}
+
+// TestTemplateIndexSignatures tests that index signatures and mapped types keyed by a template
+// literal validate the values of properties whose keys match the pattern.
+func TestTemplateIndexSignatures(t *testing.T) {
+	code := `
+type Scores = { [K in ` + "`score_${string}`" + `]: number };
+interface Limits {
+	name: string;
+	[key: ` + "`max_${number}`" + `]: string;
+}
+
+function testMapped(value: Scores): void {}
+function testSignature(value: Limits): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	gen := NewGenerator(c, program)
+
+	tests := []struct {
+		funcName        string
+		expectedContain []string
+	}{
+		{
+			funcName: "testMapped",
+			expectedContain: []string{
+				`typeof _v === "object" && _v !== null`, // Not treated as the empty object type
+				`if (/^score_.*?$/.test(_k`,             // Only keys matching the pattern
+				`"number" === typeof _v`,
+			},
+		},
+		{
+			funcName: "testSignature",
+			expectedContain: []string{
+				`"string" === typeof _v.name`,
+				`if (/^max_-?(?:0|[1-9][0-9]*)`,
+				`"string" === typeof _v`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.funcName, func(t *testing.T) {
+			paramType := findFunctionParamType(c, sourceFile, tc.funcName)
+			if paramType == nil {
+				t.Fatalf("Could not find type for %s", tc.funcName)
+			}
+
+			validator := gen.GenerateValidator(paramType, "value").Code
+			t.Logf("Generated validator for %s:\n%s", tc.funcName, validator)
+
+			for _, expected := range tc.expectedContain {
+				if !strings.Contains(validator, expected) {
+					t.Errorf("Expected validator to contain %q", expected)
+				}
+			}
+		})
+	}
+
+	// The boolean checks of nested objects and union members check matching keys too
+	gen.GenerateIsCheck(findFunctionParamType(c, sourceFile, "testSignature"))
+	helpers := strings.Join(gen.ioFuncs, "\n")
+	for _, expected := range []string{`Object.keys(input).every((_k`, `!/^max_-?(?:0|[1-9][0-9]*)`, `"string" === typeof input[_k`} {
+		if !strings.Contains(helpers, expected) {
+			t.Errorf("Expected is-check helpers to contain %q\nGot:\n%s", expected, helpers)
+		}
+	}
+}
+
+func TestPropertySourceOrder(t *testing.T) {
+	code := `
+interface Base {
+	zeta: string;
+}
+interface Ordered extends Base {
+	yankee: number;
+	alpha: boolean;
+	mike: string;
+}
+
+function test(value: Ordered): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	paramType := findFunctionParamType(c, sourceFile, "test")
+	if paramType == nil {
+		t.Fatal("Could not find type for test")
+	}
+
+	validator := NewGenerator(c, program).GenerateValidator(paramType, "value").Code
+	t.Logf("Generated validator:\n%s", validator)
+
+	// Properties are checked in declaration order, not alphabetically or in checker order
+	last := -1
+	for _, name := range []string{"zeta", "yankee", "alpha", "mike"} {
+		idx := strings.Index(validator, "_v."+name)
+		if idx < 0 {
+			t.Fatalf("Expected validator to check %s", name)
+		}
+		if idx < last {
+			t.Errorf("Expected %s to be checked after the properties declared before it", name)
+		}
+		last = idx
+	}
+
+	// A fresh generator produces identical output
+	for range 3 {
+		if again := NewGenerator(c, program).GenerateValidator(paramType, "value").Code; again != validator {
+			t.Errorf("Expected identical output across runs, got:\n%s", again)
+		}
+	}
+}
+
+func TestIndexSignatureValidation(t *testing.T) {
+	code := `
+interface User { name: string; }
+interface Headers {
+	host: number;
+	[key: string]: string | number;
+}
+
+function testRecord(value: Record<string, User>): void {}
+function testDeclared(value: Headers): void {}
+function testNumeric(value: Record<number, User>): void {}
+function testAny(value: Record<string, any>): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	gen := NewGenerator(c, program)
+
+	tests := []struct {
+		funcName          string
+		expectedContain   []string
+		unexpectedContain []string
+	}{
+		{
+			funcName: "testRecord",
+			expectedContain: []string{
+				`for (const _k`,
+				`"string" === typeof _v`, // Each value is validated as a User
+			},
+			unexpectedContain: []string{`continue;`},
+		},
+		{
+			funcName: "testDeclared",
+			expectedContain: []string{
+				`"number" === typeof _v.host`,
+				`=== "host") continue;`, // Declared properties aren't revalidated against the index type
+			},
+		},
+		{
+			funcName: "testNumeric",
+			expectedContain: []string{
+				`for (const _k`,
+				`if (String(+_k`, // Only numeric keys are covered by the signature
+			},
+		},
+		{
+			funcName:          "testAny",
+			expectedContain:   []string{`typeof _v === "object" && _v !== null`},
+			unexpectedContain: []string{`for (const _k`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.funcName, func(t *testing.T) {
+			paramType := findFunctionParamType(c, sourceFile, tc.funcName)
+			if paramType == nil {
+				t.Fatalf("Could not find type for %s", tc.funcName)
+			}
+
+			validator := gen.GenerateValidator(paramType, "value").Code
+			t.Logf("Generated validator for %s:\n%s", tc.funcName, validator)
+
+			for _, expected := range tc.expectedContain {
+				if !strings.Contains(validator, expected) {
+					t.Errorf("Expected validator to contain %q", expected)
+				}
+			}
+			for _, unexpected := range tc.unexpectedContain {
+				if strings.Contains(validator, unexpected) {
+					t.Errorf("Expected validator not to contain %q", unexpected)
+				}
+			}
+		})
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	code := `
+interface User { age: number; roles: string[]; }
+
+function testUser(user: User): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	paramType := findFunctionParamType(c, sourceFile, "testUser")
+	if paramType == nil {
+		t.Fatal("Could not find type for testUser")
+	}
+
+	gen := NewGenerator(c, program)
+	if check := gen.GenerateCheckFunction(paramType, "User").Code; strings.Contains(check, "_es") {
+		t.Errorf("Expected fail-fast check function by default, got:\n%s", check)
+	}
+
+	gen.SetCollectErrors(true)
+	check := gen.GenerateCheckFunction(paramType, "User").Code
+	t.Logf("Generated check function:\n%s", check)
+	for _, expected := range []string{
+		`const _es: string[] = [];`,
+		`_es.push("Expected "+_n+".age to be number, got "+`,
+		`"] to be string, got "+`,
+		`if (_es.length > 0) return _es.join("; ");`,
+	} {
+		if !strings.Contains(check, expected) {
+			t.Errorf("Expected check function to contain %q", expected)
+		}
+	}
+	// Only a value that isn't an object at all ends validation; failing properties carry on
+	if n := strings.Count(check, "break _l0;"); n != 1 {
+		t.Errorf("Expected one failure to end the whole validation, got %d", n)
+	}
+
+	validator := gen.GenerateValidator(paramType, "user").Code
+	if !strings.Contains(validator, `if (_es.length > 0) throw new TypeError(_es.join("; "));`) {
+		t.Errorf("Expected validator to throw the collected errors, got:\n%s", validator)
+	}
+}
+
+func TestStructuredErrors(t *testing.T) {
+	code := `
+interface User { age: number; }
+
+function testUser(user: User): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	paramType := findFunctionParamType(c, sourceFile, "testUser")
+	if paramType == nil {
+		t.Fatal("Could not find type for testUser")
+	}
+
+	gen := NewGenerator(c, program)
+	gen.SetStructuredErrors(true)
+
+	validator := gen.GenerateValidator(paramType, "user").Code
+	t.Logf("Generated validator:\n%s", validator)
+	if !strings.Contains(validator, `throw new TypicalError("Expected "+_n+".age to be number, got "+`) {
+		t.Error("Expected validator to throw a TypicalError with the usual message")
+	}
+	if !strings.Contains(validator, `, _n + ".age", "number", `) {
+		t.Error("Expected the TypicalError to carry the path and expected type")
+	}
+
+	check := gen.GenerateCheckFunction(paramType, "User").Code
+	t.Logf("Generated check function:\n%s", check)
+	if !strings.Contains(check, `: TypicalError | null`) || !strings.Contains(check, `return new TypicalError(`) {
+		t.Error("Expected check function to return TypicalErrors")
+	}
+
+	// Filter functions keep returning messages
+	filter := gen.GenerateFilterFunction(paramType, "User").Code
+	if strings.Contains(filter, "TypicalError") {
+		t.Errorf("Expected filter function to return plain messages, got:\n%s", filter)
+	}
+}
+
+func TestPatternDirective(t *testing.T) {
+	code := `
+interface Account {
+  /** @pattern ^[a-z]+$ */
+  name: string;
+  /** @pattern /^\d+$/ */
+  count: number;
+}
+
+function testAccount(account: Account): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	paramType := findFunctionParamType(c, sourceFile, "testAccount")
+	if paramType == nil {
+		t.Fatal("Could not find type for testAccount")
+	}
+
+	gen := NewGenerator(c, program)
+	validator := gen.GenerateValidator(paramType, "account").Code
+	t.Logf("Generated validator:\n%s", validator)
+	if !strings.Contains(validator, `/^[a-z]+$/.test(_v.name)`) {
+		t.Error("Expected validator to test name against its pattern")
+	}
+	if !strings.Contains(validator, "a string matching /^[a-z]+$/") {
+		t.Error("Expected the error to describe the pattern")
+	}
+	// The tag only applies to strings
+	if strings.Contains(validator, `/^\d+$/`) {
+		t.Error("Expected the pattern on a number property to be ignored")
+	}
+	if errMsg := gen.GetDirectiveError(); errMsg != "" {
+		t.Errorf("Unexpected directive error: %s", errMsg)
+	}
+}
+
+func TestPatternDirectiveInvalid(t *testing.T) {
+	code := `
+interface Account {
+  /** @pattern [a-z */
+  name: string;
+}
+
+function testAccount(account: Account): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	paramType := findFunctionParamType(c, sourceFile, "testAccount")
+	if paramType == nil {
+		t.Fatal("Could not find type for testAccount")
+	}
+
+	gen := NewGenerator(c, program)
+	gen.GenerateValidator(paramType, "account")
+	if errMsg := gen.GetDirectiveError(); !strings.Contains(errMsg, `invalid @pattern "[a-z"`) {
+		t.Errorf("Expected a directive error for the malformed pattern, got %q", errMsg)
+	}
+}
+
+func TestRangeDirectives(t *testing.T) {
+	code := `
+interface Order {
+  /** @min 1 @max 99 */
+  quantity: number;
+  /** @minLength 3 */
+  tags: string[];
+  /** @maxLength 10 */
+  count: number;
+}
+
+function testOrder(order: Order): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	paramType := findFunctionParamType(c, sourceFile, "testOrder")
+	if paramType == nil {
+		t.Fatal("Could not find type for testOrder")
+	}
+
+	gen := NewGenerator(c, program)
+	validator := gen.GenerateValidator(paramType, "order").Code
+	t.Logf("Generated validator:\n%s", validator)
+	for _, expected := range []string{
+		`"number" !== typeof _v.quantity || _v.quantity >= 1`,
+		`"number" !== typeof _v.quantity || _v.quantity <= 99`,
+		`null == _v.tags || _v.tags.length >= 3`,
+		`.quantity to be >= 1, got "+`,
+		`.tags to be of length >= 3, got "+`,
+	} {
+		if !strings.Contains(validator, expected) {
+			t.Errorf("Expected validator to contain %q", expected)
+		}
+	}
+	// Length tags only apply to strings and arrays
+	if strings.Contains(validator, "_v.count.length") {
+		t.Error("Expected @maxLength on a number property to be ignored")
+	}
+
+	if errMsg := gen.GetDirectiveError(); errMsg != "" {
+		t.Errorf("Unexpected directive error: %s", errMsg)
+	}
+}
+
+func TestRangeDirectiveInvalid(t *testing.T) {
+	code := `
+interface Order {
+  /** @min one */
+  quantity: number;
+}
+
+function testOrder(order: Order): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	paramType := findFunctionParamType(c, sourceFile, "testOrder")
+	if paramType == nil {
+		t.Fatal("Could not find type for testOrder")
+	}
+
+	gen := NewGenerator(c, program)
+	gen.GenerateValidator(paramType, "order")
+	if errMsg := gen.GetDirectiveError(); !strings.Contains(errMsg, `invalid @min "one" on property quantity`) {
+		t.Errorf("Expected a directive error for the bound, got %q", errMsg)
+	}
+}
+
+func TestValidateFunctionArity(t *testing.T) {
+	code := `
+interface Hooks {
+  onChange: (value: string, previous: string) => void;
+  onError: (...args: unknown[]) => void;
+}
+
+function testHooks(hooks: Hooks): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	paramType := findFunctionParamType(c, sourceFile, "testHooks")
+	if paramType == nil {
+		t.Fatal("Could not find type for testHooks")
+	}
+
+	// Off by default
+	gen := NewGenerator(c, program)
+	if validator := gen.GenerateValidator(paramType, "hooks").Code; strings.Contains(validator, ".length >=") {
+		t.Errorf("Expected no arity check by default, got:\n%s", validator)
+	}
+
+	gen = NewGenerator(c, program)
+	gen.SetValidateFunctionArity(true)
+	validator := gen.GenerateValidator(paramType, "hooks").Code
+	t.Logf("Generated validator:\n%s", validator)
+	if !strings.Contains(validator, `"function" === typeof _v.onChange && _v.onChange.length >= 2`) {
+		t.Error("Expected onChange to be checked for two parameters")
+	}
+	// Rest parameters make the length meaningless
+	if strings.Contains(validator, "_v.onError.length") {
+		t.Error("Expected onError with a rest parameter to only be checked with typeof")
+	}
+}
@@ -30,56 +30,70 @@ func (g *Generator) arrayCheck(t *checker.Type, expr string) string {
 		expr, expr, elemCheck)
 }
 
+// tupleLayout reads a tuple's element flags, returning its element types and infos, the index
+// of its rest element (-1 without one) and how many elements are required - i.e. neither
+// optional nor rest. Elements without info are treated as required.
+func (g *Generator) tupleLayout(t *checker.Type) (typeArgs []*checker.Type, elementInfos []checker.TupleElementInfo, restIndex int, minLen int) {
+	typeArgs = checker.Checker_getTypeArguments(g.checker, t)
+	if tupleType := checker.Type_TargetTupleType(t); tupleType != nil {
+		elementInfos = checker.TupleType_elementInfos(tupleType)
+	}
+
+	restIndex = -1
+	for i := range typeArgs {
+		if i >= len(elementInfos) {
+			minLen++
+			continue
+		}
+		flags := elementInfos[i].TupleElementFlags()
+		if flags&checker.ElementFlagsRest != 0 {
+			if restIndex < 0 {
+				restIndex = i
+			}
+		} else if flags&checker.ElementFlagsOptional == 0 {
+			minLen++
+		}
+	}
+	return typeArgs, elementInfos, restIndex, minLen
+}
+
 // tupleValidation generates validation statements for tuple types.
+// Optional elements are only validated when present, and a rest element is validated with a
+// loop over every element between the leading and trailing fixed ones.
 func (g *Generator) tupleValidation(t *checker.Type, expr string, nameExpr string) string {
 	var sb strings.Builder
 
 	// Check it's an array
 	sb.WriteString(g.validationError(fmt.Sprintf(`Array.isArray(%s)`, expr), nameExpr, "tuple", expr))
 
-	// Get tuple element types
-	typeArgs := checker.Checker_getTypeArguments(g.checker, t)
+	typeArgs, elementInfos, restIndex, minLen := g.tupleLayout(t)
 
-	// Get tuple type info for length checking and per-element flags
-	tupleType := checker.Type_TargetTupleType(t)
-	var elementInfos []checker.TupleElementInfo
-	if tupleType != nil {
-		elementInfos = checker.TupleType_elementInfos(tupleType)
-		combinedFlags := checker.TupleType_combinedFlags(tupleType)
-
-		if combinedFlags&checker.ElementFlagsRest != 0 {
-			// Has rest element - check minimum length
-			// Count non-rest elements to determine minimum length
-			minLen := 0
-			for _, info := range elementInfos {
-				if info.TupleElementFlags()&checker.ElementFlagsRest == 0 {
-					minLen++
-				}
-			}
-			if minLen > 0 {
-				sb.WriteString(g.validationError(
-					fmt.Sprintf(`%s.length >= %d`, expr, minLen),
-					nameExpr,
-					fmt.Sprintf("at least %d elements", minLen),
-					fmt.Sprintf(`%s.length`, expr)))
-			}
-		} else if combinedFlags&checker.ElementFlagsOptional != 0 {
-			// Has optional elements - check max length
+	// Check the length, accounting for optional and rest elements
+	switch {
+	case restIndex >= 0:
+		if minLen > 0 {
 			sb.WriteString(g.validationError(
-				fmt.Sprintf(`%s.length <= %d`, expr, len(typeArgs)),
+				fmt.Sprintf(`%s.length >= %d`, expr, minLen),
 				nameExpr,
-				fmt.Sprintf("at most %d elements", len(typeArgs)),
+				fmt.Sprintf("at least %d elements", minLen),
 				fmt.Sprintf(`%s.length`, expr)))
-		} else {
-			// Fixed length tuple
+		}
+	case minLen < len(typeArgs):
+		// Has optional elements - check the required ones are there, and no more than all of them
+		if minLen > 0 {
 			sb.WriteString(g.validationError(
-				fmt.Sprintf(`%s.length === %d`, expr, len(typeArgs)),
+				fmt.Sprintf(`%s.length >= %d`, expr, minLen),
 				nameExpr,
-				fmt.Sprintf("%d elements", len(typeArgs)),
+				fmt.Sprintf("at least %d elements", minLen),
 				fmt.Sprintf(`%s.length`, expr)))
 		}
-	} else {
-		// Fallback - assume fixed length
+		sb.WriteString(g.validationError(
+			fmt.Sprintf(`%s.length <= %d`, expr, len(typeArgs)),
+			nameExpr,
+			fmt.Sprintf("at most %d elements", len(typeArgs)),
+			fmt.Sprintf(`%s.length`, expr)))
+	default:
+		// Fixed length tuple
 		sb.WriteString(g.validationError(
 			fmt.Sprintf(`%s.length === %d`, expr, len(typeArgs)),
 			nameExpr,
@@ -87,47 +101,37 @@ func (g *Generator) tupleValidation(t *checker.Type, expr string, nameExpr strin
 			fmt.Sprintf(`%s.length`, expr)))
 	}
 
-	// Check if we have variadic tuple (with rest element)
-	hasRest := false
-	restIndex := -1
-	if elementInfos != nil {
-		for i, info := range elementInfos {
-			if info.TupleElementFlags()&checker.ElementFlagsRest != 0 {
-				hasRest = true
-				restIndex = i
-				break
-			}
+	// Validate leading fixed elements (all of them without a rest element) by index
+	fixedEnd := len(typeArgs)
+	if restIndex >= 0 {
+		fixedEnd = restIndex
+	}
+	for i := 0; i < fixedEnd; i++ {
+		elemExpr := fmt.Sprintf("%s[%d]", expr, i)
+		elemNameExpr := g.appendToName(nameExpr, fmt.Sprintf("[%d]", i))
+		elemValidation := g.memberValidation(typeArgs[i], elemExpr, elemNameExpr)
+		if elemValidation != "" && isOptionalTupleElement(elementInfos, i) {
+			elemValidation = fmt.Sprintf("if (%s.length > %d) { %s} ", expr, i, elemValidation)
 		}
+		sb.WriteString(elemValidation)
 	}
 
-	if hasRest && restIndex >= 0 {
+	if restIndex >= 0 {
 		// Variadic tuple: [leading..., ...rest[], ...trailing]
-		// Count trailing fixed elements (elements after the rest)
 		trailingCount := len(typeArgs) - restIndex - 1
 
-		// Validate leading fixed elements (before rest)
-		for i := 0; i < restIndex; i++ {
-			elemExpr := fmt.Sprintf("%s[%d]", expr, i)
-			elemNameExpr := g.appendToName(nameExpr, fmt.Sprintf("[%d]", i))
-			elemValidation := g.generateValidation(typeArgs[i], elemExpr, elemNameExpr)
-			if elemValidation != "" {
-				sb.WriteString(elemValidation)
-			}
-		}
-
-		// Validate rest elements with a loop
+		// Validate rest elements with a loop from restIndex to length - trailingCount
 		restType := typeArgs[restIndex]
 		idx := g.funcIdx
 		g.funcIdx++
 		iVar := fmt.Sprintf("_i%d", idx)
 		eVar := fmt.Sprintf("_e%d", idx)
-		// Loop from restIndex to length - trailingCount
 		loopEnd := fmt.Sprintf("%s.length - %d", expr, trailingCount)
 		if trailingCount == 0 {
 			loopEnd = fmt.Sprintf("%s.length", expr)
 		}
 		elemNameExpr := g.appendArrayIndex(nameExpr, iVar)
-		elemValidation := g.generateValidation(restType, eVar, elemNameExpr)
+		elemValidation := g.memberValidation(restType, eVar, elemNameExpr)
 		if elemValidation != "" {
 			sb.WriteString(fmt.Sprintf(`for (let %s = %d; %s < %s; %s++) { const %s: any = %s[%s]; %s} `,
 				iVar, restIndex, iVar, loopEnd, iVar, eVar, expr, iVar, elemValidation))
@@ -139,20 +143,7 @@ func (g *Generator) tupleValidation(t *checker.Type, expr string, nameExpr strin
 			// Access from end: arr[arr.length - trailingCount + i]
 			elemExpr := fmt.Sprintf("%s[%s.length - %d]", expr, expr, trailingCount-i)
 			elemNameExpr := g.appendToName(nameExpr, fmt.Sprintf("[%s.length - %d]", expr, trailingCount-i))
-			elemValidation := g.generateValidation(typeArgs[typeIdx], elemExpr, elemNameExpr)
-			if elemValidation != "" {
-				sb.WriteString(elemValidation)
-			}
-		}
-	} else {
-		// Simple tuple without rest - validate each element at fixed index
-		for i, elemType := range typeArgs {
-			elemExpr := fmt.Sprintf("%s[%d]", expr, i)
-			elemNameExpr := g.appendToName(nameExpr, fmt.Sprintf("[%d]", i))
-			elemValidation := g.generateValidation(elemType, elemExpr, elemNameExpr)
-			if elemValidation != "" {
-				sb.WriteString(elemValidation)
-			}
+			sb.WriteString(g.memberValidation(typeArgs[typeIdx], elemExpr, elemNameExpr))
 		}
 	}
 
@@ -161,8 +152,7 @@ func (g *Generator) tupleValidation(t *checker.Type, expr string, nameExpr strin
 
 // tupleCheck generates a JavaScript expression for tuple type checks.
 func (g *Generator) tupleCheck(t *checker.Type, expr string) string {
-	// Get tuple element types
-	typeArgs := checker.Checker_getTypeArguments(g.checker, t)
+	typeArgs, elementInfos, restIndex, minLen := g.tupleLayout(t)
 
 	if len(typeArgs) == 0 {
 		// Empty tuple - just check it's an array with length 0
@@ -174,44 +164,51 @@ func (g *Generator) tupleCheck(t *checker.Type, expr string) string {
 		fmt.Sprintf("Array.isArray(%s)", expr),
 	}
 
-	// Get tuple type info to check for rest/optional elements
-	// Use Type_TargetTupleType to safely get the tuple type from a reference
-	tupleType := checker.Type_TargetTupleType(t)
-	if tupleType != nil {
-		// Check minimum length (accounting for optional and rest elements)
-		combinedFlags := checker.TupleType_combinedFlags(tupleType)
-
-		// If there are rest elements, we can't check exact length
-		if combinedFlags&checker.ElementFlagsRest != 0 {
-			// Just check minimum length
-			minLen := 0
-			for i := 0; i < len(typeArgs); i++ {
-				// Count required elements
-				minLen++
-			}
-			if minLen > 0 {
-				checks = append(checks, fmt.Sprintf("%s.length >= %d", expr, minLen-1))
-			}
-		} else if combinedFlags&checker.ElementFlagsOptional != 0 {
-			// Has optional elements - check minimum and maximum
-			// For now, just check it's at least some length
+	// Check the length, accounting for optional and rest elements
+	if restIndex >= 0 || minLen < len(typeArgs) {
+		if minLen > 0 {
+			checks = append(checks, fmt.Sprintf("%s.length >= %d", expr, minLen))
+		}
+		if restIndex < 0 {
 			checks = append(checks, fmt.Sprintf("%s.length <= %d", expr, len(typeArgs)))
-		} else {
-			// Fixed length tuple
-			checks = append(checks, fmt.Sprintf("%s.length === %d", expr, len(typeArgs)))
 		}
 	} else {
-		// Fallback - assume fixed length
+		// Fixed length tuple
 		checks = append(checks, fmt.Sprintf("%s.length === %d", expr, len(typeArgs)))
 	}
 
-	// Add check for each element
-	for i, elemType := range typeArgs {
+	// Add check for each leading fixed element
+	fixedEnd := len(typeArgs)
+	if restIndex >= 0 {
+		fixedEnd = restIndex
+	}
+	for i := 0; i < fixedEnd; i++ {
 		accessor := fmt.Sprintf("%s[%d]", expr, i)
-		elemCheck := g.generateCheck(elemType, accessor)
+		elemCheck := g.generateCheck(typeArgs[i], accessor)
+		if isOptionalTupleElement(elementInfos, i) {
+			elemCheck = fmt.Sprintf("(%s.length <= %d || %s)", expr, i, elemCheck)
+		}
 		checks = append(checks, elemCheck)
 	}
 
+	if restIndex >= 0 {
+		// Rest elements are the slice between the leading and trailing fixed elements
+		trailingCount := len(typeArgs) - restIndex - 1
+		restSlice := fmt.Sprintf("%s.slice(%d)", expr, restIndex)
+		if trailingCount > 0 {
+			restSlice = fmt.Sprintf("%s.slice(%d, -%d)", expr, restIndex, trailingCount)
+		}
+		eVar := fmt.Sprintf("_e%d", g.funcIdx)
+		g.funcIdx++
+		checks = append(checks, fmt.Sprintf("%s.every((%s: any) => %s)", restSlice, eVar, g.generateCheck(typeArgs[restIndex], eVar)))
+
+		// Trailing fixed elements are addressed from the end
+		for i := 0; i < trailingCount; i++ {
+			accessor := fmt.Sprintf("%s[%s.length - %d]", expr, expr, trailingCount-i)
+			checks = append(checks, g.generateCheck(typeArgs[restIndex+1+i], accessor))
+		}
+	}
+
 	return "(" + joinWithAnd(checks) + ")"
 }
 
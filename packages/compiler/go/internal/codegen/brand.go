@@ -0,0 +1,31 @@
+package codegen
+
+import "fmt"
+
+// SetValidationBrand sets a sentinel stamped onto objects once validated. When set, check
+// functions return early for values that already carry it, so data passed through many
+// validated layers is only fully checked once. The brand doesn't record which type was
+// validated, so only use it where a validated value isn't reinterpreted as another type.
+func (g *Generator) SetValidationBrand(brand string) {
+	g.validationBrand = brand
+}
+
+// brandGuard returns a statement that makes a check function return null early when expr
+// carries the validation brand, or "" when no brand is configured.
+func (g *Generator) brandGuard(expr string) string {
+	if g.validationBrand == "" {
+		return ""
+	}
+	return fmt.Sprintf(`if (%s && %s.__validated === %s) return null; `, expr, expr, escapeJSStringQuoted(g.validationBrand))
+}
+
+// brandStamp returns a statement that stamps the validation brand onto expr after it passed
+// validation, or "" when no brand is configured. The property is non-enumerable so it doesn't
+// show up in JSON.stringify or Object.keys, and frozen objects are left alone.
+func (g *Generator) brandStamp(expr string) string {
+	if g.validationBrand == "" {
+		return ""
+	}
+	return fmt.Sprintf(`if (typeof %s === "object" && %s !== null && Object.isExtensible(%s) && !Object.prototype.hasOwnProperty.call(%s, "__validated")) Object.defineProperty(%s, "__validated", { value: %s }); `,
+		expr, expr, expr, expr, expr, escapeJSStringQuoted(g.validationBrand))
+}
@@ -0,0 +1,104 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// typeClass is how generateValidation checks a type at the top level. The walkers that
+// interpret types instead of generating code (CheckValue, CompareTypes, GenerateJSONSchema)
+// dispatch on it too, so they agree with the validators on what each type accepts.
+type typeClass int
+
+const (
+	typeUnchecked       typeClass = iota // any, unknown, symbols: nothing is checked
+	typeNever                            // no value is valid
+	typeTemplateLiteral                  // a string matching the template's pattern
+	typePrimitive                        // a primitive or literal, see primitiveKind
+	typeUnion                            // any one of the members
+	typeBranded                          // primitive & { __brand: ... }: the primitive
+	typeIntersection                     // every one of the members
+	typeBroadObject                      // the object type: any non-null object
+	typeEmptyObject                      // {} and interfaces without members: anything but null and undefined
+	typeFunction                         // a function
+	typeObject                           // arrays, tuples, classes, built-ins and object types
+)
+
+// classifyType returns how generateValidation checks t, and the types checked in its place:
+// the members of a union or intersection, or a branded type's primitive and brand object.
+// Intersections of plain object types are checked as one object, so they're typeObject.
+func (g *Generator) classifyType(t *checker.Type) (typeClass, []*checker.Type) {
+	flags := checker.Type_flags(t)
+
+	switch {
+	case flags&(checker.TypeFlagsAny|checker.TypeFlagsUnknown) != 0:
+		return typeUnchecked, nil
+	case flags&checker.TypeFlagsNever != 0:
+		return typeNever, nil
+	case flags&checker.TypeFlagsTemplateLiteral != 0:
+		return typeTemplateLiteral, nil
+	case isPrimitiveKind(t):
+		return typePrimitive, nil
+	case flags&checker.TypeFlagsUnion != 0:
+		return typeUnion, t.Types()
+	case flags&checker.TypeFlagsIntersection != 0:
+		if primitiveType, brandType := g.brandParts(t.Types()); primitiveType != nil {
+			return typeBranded, []*checker.Type{primitiveType, brandType}
+		}
+		if g.isMergeableObjectIntersection(t.Types()) {
+			return typeObject, nil
+		}
+		return typeIntersection, t.Types()
+	case flags&checker.TypeFlagsNonPrimitive != 0:
+		return typeBroadObject, nil
+	case flags&checker.TypeFlagsObject == 0:
+		return typeUnchecked, nil
+	case g.isEmptyObjectType(t):
+		return typeEmptyObject, nil
+	case g.isFunctionType(t):
+		return typeFunction, nil
+	}
+	return typeObject, nil
+}
+
+// primitiveKind returns the primitive a type is checked as ("string", "null", ...) and, for
+// literal types, a key identifying the literal's value. kind is "" for non-primitives.
+func primitiveKind(t *checker.Type) (kind string, literal string) {
+	flags := checker.Type_flags(t)
+	literalOf := func() string {
+		if lt := t.AsLiteralType(); lt != nil {
+			return fmt.Sprintf("%#v", lt.Value())
+		}
+		return ""
+	}
+	switch {
+	case flags&checker.TypeFlagsStringLiteral != 0:
+		return "string", literalOf()
+	case flags&checker.TypeFlagsNumberLiteral != 0:
+		return "number", literalOf()
+	case flags&checker.TypeFlagsBooleanLiteral != 0:
+		return "boolean", literalOf()
+	case flags&checker.TypeFlagsBigIntLiteral != 0:
+		return "bigint", bigIntLiteral(t)
+	case flags&checker.TypeFlagsString != 0:
+		return "string", ""
+	case flags&checker.TypeFlagsNumber != 0:
+		return "number", ""
+	case flags&checker.TypeFlagsBoolean != 0:
+		return "boolean", ""
+	case flags&checker.TypeFlagsBigInt != 0:
+		return "bigint", ""
+	case flags&checker.TypeFlagsNull != 0:
+		return "null", ""
+	case flags&(checker.TypeFlagsUndefined|checker.TypeFlagsVoid) != 0:
+		return "undefined", ""
+	}
+	return "", ""
+}
+
+// isPrimitiveKind reports whether t is checked as a primitive or literal.
+func isPrimitiveKind(t *checker.Type) bool {
+	kind, _ := primitiveKind(t)
+	return kind != ""
+}
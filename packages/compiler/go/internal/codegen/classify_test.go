@@ -0,0 +1,89 @@
+package codegen
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestClassifiedWalkers tests that CheckValue, GenerateJSONSchema and CompareTypes treat
+// brands, {} and object intersections the way the generated validators do.
+func TestClassifiedWalkers(t *testing.T) {
+	code := `
+type Email = string & { readonly __brand: "Email" };
+interface Named { name: string; }
+interface Aged { age: number; }
+
+function testEmail(value: Email): void {}
+function testString(value: string): void {}
+function testEmpty(value: {}): void {}
+function testNamed(value: Named): void {}
+function testBoth(value: Named & Aged): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	gen := NewGenerator(c, program)
+	email := findFunctionParamType(c, sourceFile, "testEmail")
+	str := findFunctionParamType(c, sourceFile, "testString")
+	empty := findFunctionParamType(c, sourceFile, "testEmpty")
+	named := findFunctionParamType(c, sourceFile, "testNamed")
+	both := findFunctionParamType(c, sourceFile, "testBoth")
+
+	checkValue := func(funcName string, value string) string {
+		t.Helper()
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			t.Fatalf("invalid value %s: %v", value, err)
+		}
+		return gen.CheckValue(findFunctionParamType(c, sourceFile, funcName), v, "value")
+	}
+	schema := func(funcName string) string {
+		t.Helper()
+		out, err := gen.GenerateJSONSchema(findFunctionParamType(c, sourceFile, funcName))
+		if err != nil {
+			t.Fatalf("GenerateJSONSchema(%s) failed: %v", funcName, err)
+		}
+		return out
+	}
+
+	// Brands are compile-time only
+	if msg := checkValue("testEmail", `"a@b.c"`); msg != "" {
+		t.Errorf("Expected a branded string to accept a string, got %q", msg)
+	}
+	if msg := checkValue("testEmail", `1`); !strings.Contains(msg, "to be string") {
+		t.Errorf("Expected a branded string to reject a number, got %q", msg)
+	}
+	if out := schema("testEmail"); strings.Contains(out, "__brand") || !strings.Contains(out, `"type": "string"`) {
+		t.Errorf("Expected the brand's schema to be a string\nGot:\n%s", out)
+	}
+	if changes := gen.CompareTypes(str, gen, email, "value"); len(changes) != 0 {
+		t.Errorf("Expected branding a string to change nothing, got %v", changes)
+	}
+
+	// {} accepts anything but null and undefined
+	if msg := checkValue("testEmpty", `"text"`); msg != "" {
+		t.Errorf("Expected {} to accept a string, got %q", msg)
+	}
+	if msg := checkValue("testEmpty", `null`); msg == "" {
+		t.Error("Expected {} to reject null")
+	}
+	if changes := gen.CompareTypes(str, gen, empty, "value"); len(changes) != 0 {
+		t.Errorf("Expected widening string to {} to change nothing, got %v", changes)
+	}
+	if changes := gen.CompareTypes(empty, gen, named, "value"); len(changes) != 1 || changes[0].Kind != "narrowed" {
+		t.Errorf("Expected narrowing {} to Named to be reported, got %v", changes)
+	}
+
+	// Object intersections are checked as one object
+	if msg := checkValue("testBoth", `{"name": "Ann"}`); !strings.Contains(msg, "value.age") {
+		t.Errorf("Expected the intersection to require age, got %q", msg)
+	}
+	if out := schema("testBoth"); strings.Contains(out, "allOf") || !strings.Contains(out, `"age"`) {
+		t.Errorf("Expected the intersection's schema to be one object\nGot:\n%s", out)
+	}
+	if changes := gen.CompareTypes(named, gen, both, "value"); len(changes) != 1 || changes[0].Kind != "added-required" {
+		t.Errorf("Expected adding Aged to require age, got %v", changes)
+	}
+}
@@ -0,0 +1,113 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// Coercion lists the primitive coercions applied by filter functions before validation.
+// Coercion only happens on the JSON.parse/filter paths, where the filtered result is
+// returned to the caller - plain validators never change the value they are given.
+type Coercion struct {
+	// StringToNumber converts numeric strings ("42", "1.5") to numbers.
+	// Empty and whitespace-only strings are left alone so they still fail validation.
+	StringToNumber bool
+	// StringToBoolean converts "true" and "false" to booleans.
+	StringToBoolean bool
+	// StringToDate converts strings that Date.parse understands to Date objects.
+	StringToDate bool
+	// NumericKeys normalises the keys of number-keyed records (Record<number, T>) to
+	// canonical numeric strings ("01" and " 1" become "1") before they're checked.
+	NumericKeys bool
+}
+
+// enabled reports whether any coercion rule is switched on.
+func (c Coercion) enabled() bool {
+	return c.StringToNumber || c.StringToBoolean || c.StringToDate || c.NumericKeys
+}
+
+// SetCoercion sets the coercion rules used by filter functions.
+func (g *Generator) SetCoercion(c Coercion) {
+	g.coercion = c
+}
+
+// coercionTarget returns the primitive kind a value of type t can be coerced to:
+// "number", "boolean", "Date", or "" if no rule applies.
+// For unions, null and undefined members are ignored (so optional props still coerce),
+// but the remaining members must all agree on the target.
+func (g *Generator) coercionTarget(t *checker.Type) string {
+	flags := checker.Type_flags(t)
+
+	// boolean is itself a union of true | false, so check it before unions
+	if flags&checker.TypeFlagsBoolean != 0 || flags&checker.TypeFlagsBooleanLiteral != 0 {
+		return "boolean"
+	}
+
+	if flags&checker.TypeFlagsUnion != 0 {
+		target := ""
+		for _, member := range t.Types() {
+			if checker.Type_flags(member)&(checker.TypeFlagsNull|checker.TypeFlagsUndefined) != 0 {
+				continue
+			}
+			memberTarget := g.coercionTarget(member)
+			if memberTarget == "" || (target != "" && memberTarget != target) {
+				return ""
+			}
+			target = memberTarget
+		}
+		return target
+	}
+
+	if flags&checker.TypeFlagsNumber != 0 {
+		return "number"
+	}
+
+	if flags&checker.TypeFlagsObject != 0 {
+		if sym := checker.Type_symbol(t); sym != nil && sym.Name == "Date" {
+			return "Date"
+		}
+	}
+
+	return ""
+}
+
+// coerceExpr returns an expression that coerces expr towards type t according to the
+// configured rules, or "" if no coercion applies. Values that can't be coerced are
+// passed through unchanged so the following validation reports them as usual.
+func (g *Generator) coerceExpr(t *checker.Type, expr string) string {
+	if !g.coercion.enabled() {
+		return ""
+	}
+
+	switch g.coercionTarget(t) {
+	case "number":
+		if g.coercion.StringToNumber {
+			return fmt.Sprintf(`("string" === typeof %s && %s.trim() !== "" && !isNaN(+%s) ? +%s : %s)`,
+				expr, expr, expr, expr, expr)
+		}
+	case "boolean":
+		if g.coercion.StringToBoolean {
+			return fmt.Sprintf(`("true" === %s ? true : "false" === %s ? false : %s)`, expr, expr, expr)
+		}
+	case "Date":
+		if g.coercion.StringToDate {
+			return fmt.Sprintf(`("string" === typeof %s && !isNaN(Date.parse(%s)) ? new Date(%s) : %s)`,
+				expr, expr, expr, expr)
+		}
+	}
+	return ""
+}
+
+// coercedValidation validates a non-object value in filtering mode, applying any configured
+// coercion first. Returns the validation statements and the expression holding the value
+// to copy into the filtered result.
+func (g *Generator) coercedValidation(t *checker.Type, expr string, nameExpr string) (string, string) {
+	coerced := g.coerceExpr(t, expr)
+	if coerced == "" {
+		return g.generateValidation(t, expr, nameExpr), expr
+	}
+	cVar := fmt.Sprintf("_c%d", g.funcIdx)
+	g.funcIdx++
+	return fmt.Sprintf("const %s: any = %s; ", cVar, coerced) + g.generateValidation(t, cVar, nameExpr), cVar
+}
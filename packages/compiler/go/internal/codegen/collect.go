@@ -0,0 +1,72 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// SetCollectErrors controls whether validation reports every failure rather than stopping at
+// the first. When enabled, a failing property, element or entry records its error and
+// validation carries on with the next one; the errors are joined with "; " and returned from
+// a check function, or thrown, once everything has been looked at. A value that isn't the
+// right kind of container at all still ends validation of its contents. Filter functions
+// always stop at the first error.
+func (g *Generator) SetCollectErrors(collect bool) {
+	g.collectErrors = collect
+}
+
+// collectedValidation returns the statements generated by validate. In collect mode they're
+// wrapped in a block that gathers the errors in _es and reports them all at the end.
+func (g *Generator) collectedValidation(validate func() string) string {
+	if !g.collectErrors || g.returnTupleErrors {
+		return validate()
+	}
+	g.collecting = true
+	defer func() { g.collecting = false }()
+
+	statements := g.errorScope(validate)
+	if statements == "" {
+		return ""
+	}
+	// Structured errors are combined into one carrying the first's details and every error
+	errors := `_es.join("; ")`
+	if g.structuredErrors {
+		errors = `new TypicalError(_es.map((_x) => _x.message).join("; "), _es[0].path, _es[0].expected, _es[0].received, _es[0].value, _es)`
+	}
+	report := g.ThrowError(errors)
+	if g.returnErrors {
+		report = "return " + errors
+	}
+	return fmt.Sprintf(`{ const _es: %s[] = []; %sif (_es.length > 0) %s; } `, g.ErrorType(), statements, report)
+}
+
+// errorScope returns the statements generated by validate. While collecting errors they're
+// labelled, so a failure inside them can record its error and skip the rest of them with
+// `break`, carrying on after the block.
+func (g *Generator) errorScope(validate func() string) string {
+	if !g.collecting {
+		return validate()
+	}
+	label := fmt.Sprintf("_l%d", g.funcIdx)
+	g.funcIdx++
+	outer := g.errorLabel
+	g.errorLabel = label
+	statements := validate()
+	g.errorLabel = outer
+
+	// Unused labels are a compile error with allowUnusedLabels: false
+	if !strings.Contains(statements, "break "+label+";") {
+		return statements
+	}
+	return fmt.Sprintf("%s: { %s} ", label, statements)
+}
+
+// memberValidation generates validation statements for a property, element or entry of a
+// value. While collecting errors, a failure in it doesn't stop its siblings being validated.
+func (g *Generator) memberValidation(t *checker.Type, expr string, nameExpr string) string {
+	return g.errorScope(func() string {
+		return g.generateValidation(t, expr, nameExpr)
+	})
+}
@@ -0,0 +1,225 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/microsoft/typescript-go/shim/ast"
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// TypeChange is a way a new version of a type rejects values that the old version accepted.
+type TypeChange struct {
+	Path    string `json:"path"`
+	Kind    string `json:"kind"` // "added-required", "made-required", "narrowed" or "changed"
+	Message string `json:"message"`
+}
+
+// CompareTypes reports the ways newType rejects values that oldType accepts, for checking
+// an API change is backward compatible. The old and new versions usually come from separate
+// programs, so each is interpreted by its own generator: g for oldType and newGen for newType.
+// The walk follows validation - extra properties are always allowed, so removing a property
+// is compatible - and returns nil if every value valid under oldType is valid under newType.
+func (g *Generator) CompareTypes(oldType *checker.Type, newGen *Generator, newType *checker.Type, name string) []TypeChange {
+	g.reset()
+	return g.compareTypes(typeMembers(oldType), newGen, newType, name, make(map[[2]*checker.Type]bool))
+}
+
+// compareTypes checks that newType accepts the values of each of the old type's union members.
+func (g *Generator) compareTypes(oldMembers []*checker.Type, newGen *Generator, newType *checker.Type, path string, visited map[[2]*checker.Type]bool) []TypeChange {
+	if newClass, _ := newGen.classifyType(newType); newClass == typeUnchecked {
+		return nil
+	}
+
+	var changes []TypeChange
+	for _, old := range oldMembers {
+		_, oldClass := g.checkedType(old)
+		if oldClass == typeUnchecked {
+			changes = append(changes, TypeChange{path, "narrowed", fmt.Sprintf("%s: narrowed from %s to %s", path, g.getExpectedType(old), newGen.getExpectedType(newType))})
+			continue
+		}
+		if oldClass == typeNever {
+			continue
+		}
+
+		// Find the new member that accepts old's values, or failing that one of the same kind
+		// (both objects, say) whose differences are worth reporting in detail
+		var closest []TypeChange
+		covered := false
+		for _, candidate := range typeMembers(newType) {
+			if !g.sameKind(old, newGen, candidate) {
+				continue
+			}
+			memberChanges := g.compareMember(old, newGen, candidate, path, visited)
+			if len(memberChanges) == 0 {
+				covered = true
+				break
+			}
+			if closest == nil {
+				closest = memberChanges
+			}
+		}
+		switch {
+		case covered:
+		case closest != nil && len(typeMembers(newType)) == 1 && oldClass != typePrimitive:
+			changes = append(changes, closest...)
+		case len(oldMembers) > 1 || len(typeMembers(newType)) > 1:
+			changes = append(changes, TypeChange{path, "narrowed", fmt.Sprintf("%s: no longer accepts %s", path, g.getExpectedType(old))})
+		default:
+			changes = append(changes, TypeChange{path, "changed", fmt.Sprintf("%s: changed from %s to %s", path, g.getExpectedType(old), newGen.getExpectedType(newType))})
+		}
+	}
+	return changes
+}
+
+// compareMember compares a single old union member with a single new one of the same kind.
+func (g *Generator) compareMember(old *checker.Type, newGen *Generator, newType *checker.Type, path string, visited map[[2]*checker.Type]bool) []TypeChange {
+	old, oldClass := g.checkedType(old)
+	newType, newClass := newGen.checkedType(newType)
+
+	// {} accepts anything but null and undefined, which sameKind has already ruled out
+	if newClass == typeEmptyObject {
+		return nil
+	}
+	if oldClass == typeEmptyObject {
+		return []TypeChange{{path, "narrowed", fmt.Sprintf("%s: narrowed from %s to %s", path, g.getExpectedType(old), newGen.getExpectedType(newType))}}
+	}
+
+	if oldClass == typePrimitive {
+		oldKind, oldLiteral := primitiveKind(old)
+		newKind, newLiteral := primitiveKind(newType)
+		if newKind == oldKind && (newLiteral == "" || newLiteral == oldLiteral) {
+			return nil
+		}
+		return []TypeChange{{path, "changed", fmt.Sprintf("%s: changed from %s to %s", path, g.getExpectedType(old), newGen.getExpectedType(newType))}}
+	}
+
+	// Recursive types come back round to a pair already being compared
+	pair := [2]*checker.Type{old, newType}
+	if visited[pair] {
+		return nil
+	}
+	visited[pair] = true
+	defer delete(visited, pair)
+
+	oldIsArray := checker.Checker_isArrayType(g.checker, old)
+	if oldIsArray && checker.Checker_isArrayType(newGen.checker, newType) {
+		oldArgs := checker.Checker_getTypeArguments(g.checker, old)
+		newArgs := checker.Checker_getTypeArguments(newGen.checker, newType)
+		if len(oldArgs) == 0 || len(newArgs) == 0 {
+			return nil
+		}
+		return g.compareTypes(typeMembers(oldArgs[0]), newGen, newArgs[0], path+"[]", visited)
+	}
+
+	if g.isPlainObjectType(old) && newGen.isPlainObjectType(newType) {
+		return g.compareProperties(old, newGen, newType, path, visited)
+	}
+
+	// Tuples, classes, built-ins and functions are compared by what validation expects of them
+	if g.getExpectedType(old) == newGen.getExpectedType(newType) {
+		return nil
+	}
+	return []TypeChange{{path, "changed", fmt.Sprintf("%s: changed from %s to %s", path, g.getExpectedType(old), newGen.getExpectedType(newType))}}
+}
+
+// compareProperties compares the properties of two plain object types.
+func (g *Generator) compareProperties(old *checker.Type, newGen *Generator, newType *checker.Type, path string, visited map[[2]*checker.Type]bool) []TypeChange {
+	oldProps := make(map[string]*ast.Symbol)
+	for _, prop := range checker.Checker_getPropertiesOfType(g.checker, old) {
+		oldProps[prop.Name] = prop
+	}
+
+	var changes []TypeChange
+	for _, prop := range checker.Checker_getPropertiesOfType(newGen.checker, newType) {
+		propPath := path + "." + prop.Name
+		newPropType := newGen.propertyReadType(prop)
+		required := !isOptionalProperty(prop) && !acceptsMissingValue(newPropType)
+
+		oldProp, existed := oldProps[prop.Name]
+		if !existed {
+			if required {
+				changes = append(changes, TypeChange{propPath, "added-required", fmt.Sprintf("%s: added required property of type %s", propPath, newGen.getExpectedType(newPropType))})
+			}
+			continue
+		}
+
+		oldPropType := g.propertyReadType(oldProp)
+		oldMembers := typeMembers(oldPropType)
+		if isOptionalProperty(oldProp) {
+			// A missing value is compared here rather than as an undefined union member
+			if required {
+				changes = append(changes, TypeChange{propPath, "made-required", fmt.Sprintf("%s: optional property made required", propPath)})
+			}
+			oldMembers = withoutUndefined(oldMembers)
+		}
+		changes = append(changes, g.compareTypes(oldMembers, newGen, newPropType, propPath, visited)...)
+	}
+	return changes
+}
+
+// sameKind reports whether an old and new type are checked the same way at the top level -
+// as the same primitive, or both as objects - so comparing them in detail is meaningful.
+// {} is checked as anything but null and undefined, so every other old type matches it.
+func (g *Generator) sameKind(old *checker.Type, newGen *Generator, newType *checker.Type) bool {
+	old, oldClass := g.checkedType(old)
+	newType, newClass := newGen.checkedType(newType)
+	oldKind, _ := primitiveKind(old)
+	if newClass == typeEmptyObject {
+		return oldKind != "null" && oldKind != "undefined"
+	}
+	if oldClass == typePrimitive || newClass == typePrimitive {
+		newKind, _ := primitiveKind(newType)
+		return oldKind == newKind
+	}
+	return isObjectClass(oldClass) && isObjectClass(newClass)
+}
+
+// checkedType returns the type t is checked as, with its class. Brands are compile-time only,
+// so a branded type is checked as its primitive.
+func (g *Generator) checkedType(t *checker.Type) (*checker.Type, typeClass) {
+	class, parts := g.classifyType(t)
+	if class == typeBranded {
+		return g.checkedType(parts[0])
+	}
+	return t, class
+}
+
+// isObjectClass reports whether a type of the class is checked as some kind of object.
+func isObjectClass(class typeClass) bool {
+	switch class {
+	case typeIntersection, typeBroadObject, typeEmptyObject, typeFunction, typeObject:
+		return true
+	}
+	return false
+}
+
+// isPlainObjectType reports whether t is validated property by property, rather than as an
+// array, tuple, class instance, built-in or function.
+func (g *Generator) isPlainObjectType(t *checker.Type) bool {
+	if class, _ := g.classifyType(t); class != typeObject {
+		return false
+	}
+	if checker.Type_flags(t)&checker.TypeFlagsIntersection != 0 {
+		return true // merged into one object
+	}
+	return !checker.Checker_isArrayOrTupleType(g.checker, t) && g.isBuiltinClassType(t) == "" && !g.isClassType(t)
+}
+
+// typeMembers returns the members of a union type, or the type itself.
+func typeMembers(t *checker.Type) []*checker.Type {
+	if checker.Type_flags(t)&checker.TypeFlagsUnion != 0 {
+		return t.Types()
+	}
+	return []*checker.Type{t}
+}
+
+// withoutUndefined drops undefined and void from a list of union members.
+func withoutUndefined(members []*checker.Type) []*checker.Type {
+	var result []*checker.Type
+	for _, m := range members {
+		if checker.Type_flags(m)&(checker.TypeFlagsUndefined|checker.TypeFlagsVoid) == 0 {
+			result = append(result, m)
+		}
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package codegen
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestCompareTypes tests reporting breaking changes between two versions of a type.
+func TestCompareTypes(t *testing.T) {
+	code := `
+interface User {
+	name: string;
+	role: "admin" | "user";
+	nick?: string;
+	tags: string[];
+}
+
+interface Extended {
+	name: string;
+	role: "admin" | "user" | "guest";
+	nick?: string;
+	tags: string[];
+	avatar?: string;
+}
+
+interface Breaking {
+	name: number;
+	role: "admin";
+	nick: string;
+	tags: number[];
+	email: string;
+}
+
+function testUser(user: User): void {}
+function testExtended(user: Extended): void {}
+function testBreaking(user: Breaking): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	gen := NewGenerator(c, program)
+	userType := findFunctionParamType(c, sourceFile, "testUser")
+	extendedType := findFunctionParamType(c, sourceFile, "testExtended")
+	breakingType := findFunctionParamType(c, sourceFile, "testBreaking")
+	if userType == nil || extendedType == nil || breakingType == nil {
+		t.Fatal("could not find param types")
+	}
+
+	// Widened unions and new optional properties accept every old value
+	if changes := gen.CompareTypes(userType, gen, extendedType, "user"); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+
+	var messages []string
+	for _, change := range gen.CompareTypes(userType, gen, breakingType, "user") {
+		messages = append(messages, change.Message)
+	}
+	expected := []string{
+		"user.name: changed from string to number",
+		"user.role: no longer accepts 'user'",
+		"user.nick: optional property made required",
+		"user.tags[]: changed from string to number",
+		"user.email: added required property of type string",
+	}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("expected changes %q, got %q", expected, messages)
+	}
+
+	// Removing an option is only breaking in one direction
+	if changes := gen.CompareTypes(extendedType, gen, userType, "user"); len(changes) != 1 || changes[0].Kind != "narrowed" {
+		t.Errorf("expected a single narrowed change, got %v", changes)
+	}
+}
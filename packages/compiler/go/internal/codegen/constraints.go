@@ -0,0 +1,152 @@
+package codegen
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/microsoft/typescript-go/shim/ast"
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// constraintDirectiveRegex matches JSDoc range tags such as `@min 0` or `@maxLength 64`.
+var constraintDirectiveRegex = regexp.MustCompile(`@(min|max|minLength|maxLength)[ \t]+([^\s*]+)`)
+
+// constraint is a bound from a JSDoc range tag.
+type constraint struct {
+	tag   string // min, max, minLength or maxLength
+	limit string // The bound as a JavaScript number literal
+}
+
+// operator returns the comparison a value has to pass to satisfy the constraint.
+func (c constraint) operator() string {
+	if c.tag == "min" || c.tag == "minLength" {
+		return ">="
+	}
+	return "<="
+}
+
+// isLength reports whether the constraint bounds a string or array length rather than a number.
+func (c constraint) isLength() bool {
+	return strings.HasSuffix(c.tag, "Length")
+}
+
+// condition returns a boolean expression that's true when expr satisfies the constraint.
+// Values of the wrong type are let through, they're reported by the type's own validation.
+func (c constraint) condition(expr string) string {
+	if c.isLength() {
+		return fmt.Sprintf(`null == %s || %s.length %s %s`, expr, expr, c.operator(), c.limit)
+	}
+	return fmt.Sprintf(`"number" !== typeof %s || %s %s %s`, expr, expr, c.operator(), c.limit)
+}
+
+// expected describes the constraint for error messages, e.g. ">= 0" or "of length <= 64".
+func (c constraint) expected() string {
+	if c.isLength() {
+		return fmt.Sprintf("of length %s %s", c.operator(), c.limit)
+	}
+	return fmt.Sprintf("%s %s", c.operator(), c.limit)
+}
+
+// declarationConstraints returns the range tags on a declaration's JSDoc that apply to t:
+// @min and @max on numbers, @minLength and @maxLength on strings and arrays. A tag with a
+// bound that isn't a number is recorded for GetDirectiveError.
+func (g *Generator) declarationConstraints(decl *ast.Node, t *checker.Type, name string) []constraint {
+	sf := ast.GetSourceFileOfNode(decl)
+	if sf == nil {
+		return nil
+	}
+	comments := leadingComments(sf.Text(), decl.Pos())
+	if decl.Kind == ast.KindParameter {
+		comments = parameterComments(sf.Text(), decl.Pos())
+	}
+	var constraints []constraint
+	for _, m := range constraintDirectiveRegex.FindAllStringSubmatch(comments, -1) {
+		c := constraint{tag: m[1]}
+		if c.isLength() && !g.isLengthType(t) || !c.isLength() && !isNumberType(t) {
+			continue
+		}
+		limit, err := strconv.ParseFloat(m[2], 64)
+		if err != nil || math.IsInf(limit, 0) || math.IsNaN(limit) || c.isLength() && (limit < 0 || limit != math.Trunc(limit)) {
+			if g.directiveError == "" {
+				g.directiveError = fmt.Sprintf("invalid @%s %q on %s", m[1], m[2], name)
+			}
+			continue
+		}
+		c.limit = strconv.FormatFloat(limit, 'g', -1, 64)
+		constraints = append(constraints, c)
+	}
+	return constraints
+}
+
+// propertyConstraints returns the range tags that apply to a property, from its first
+// declaration that has any.
+func (g *Generator) propertyConstraints(prop *ast.Symbol, propType *checker.Type) []constraint {
+	for _, decl := range prop.Declarations {
+		if constraints := g.declarationConstraints(decl, propType, "property "+prop.Name); len(constraints) > 0 {
+			return constraints
+		}
+	}
+	return nil
+}
+
+// constraintValidation generates a statement for each constraint, checking expr satisfies it.
+func (g *Generator) constraintValidation(constraints []constraint, expr, nameExpr string) string {
+	var sb strings.Builder
+	for _, c := range constraints {
+		sb.WriteString(g.validationErrorWithValue(c.condition(expr), nameExpr, c.expected(), expr))
+	}
+	return sb.String()
+}
+
+// refinementValidation generates the checks a property's JSDoc tags add on top of its
+// type: a @pattern to match and any range constraints.
+func (g *Generator) refinementValidation(prop *ast.Symbol, propType *checker.Type, expr, nameExpr string) string {
+	return g.patternValidation(g.propertyPattern(prop, propType), expr, nameExpr) +
+		g.constraintValidation(g.propertyConstraints(prop, propType), expr, nameExpr)
+}
+
+// refinementCheck is refinementValidation as a boolean expression, along with what the
+// property is expected to be, e.g. "number >= 0 and <= 100". The check is "" when the
+// property has no refinements, and the expected type is then unchanged.
+func (g *Generator) refinementCheck(prop *ast.Symbol, propType *checker.Type, expr string) (string, string) {
+	var checks, bounds []string
+	expected := g.getExpectedType(propType)
+	if literal := g.propertyPattern(prop, propType); literal != "" {
+		checks = append(checks, patternCheck(literal, expr))
+		expected = "a string matching " + literal
+	}
+	for _, c := range g.propertyConstraints(prop, propType) {
+		checks = append(checks, "("+c.condition(expr)+")")
+		bounds = append(bounds, c.expected())
+	}
+	if len(bounds) > 0 {
+		expected += " " + strings.Join(bounds, " and ")
+	}
+	return strings.Join(checks, " && "), expected
+}
+
+// GenerateParameterConstraints generates validation for the range tags in a parameter's JSDoc,
+// e.g. function page(/** @min 1 */ n: number). It's appended after the parameter's type
+// validation, and is "" when there aren't any.
+func (g *Generator) GenerateParameterConstraints(param *ast.Node, t *checker.Type, paramName string) string {
+	constraints := g.declarationConstraints(param, t, "parameter "+paramName)
+	return g.constraintValidation(constraints, paramName, `"`+paramName+`"`)
+}
+
+// isNumberType reports whether t is number-like, allowing for null and undefined.
+func isNumberType(t *checker.Type) bool {
+	return everyMember(t, func(member *checker.Type) bool {
+		return checker.Type_flags(member)&(checker.TypeFlagsNumber|checker.TypeFlagsNumberLiteral) != 0
+	})
+}
+
+// isLengthType reports whether t is made up of strings and arrays, allowing for null and
+// undefined, so .length can be bounded.
+func (g *Generator) isLengthType(t *checker.Type) bool {
+	return everyMember(t, func(member *checker.Type) bool {
+		return isStringType(member) || checker.Checker_isArrayType(g.checker, member)
+	})
+}
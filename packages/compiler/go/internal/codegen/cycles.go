@@ -0,0 +1,56 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// SetCycleSafeValidation makes check functions safe for data that is cyclic at runtime, such
+// as a graph whose nodes refer back to each other. Recursive types are validated by check
+// functions calling themselves, which would never return on a cycle; when enabled, each call
+// records the objects it has checked and against which type, and nested calls skip an object
+// already being checked against the same type. The record is passed on as a third argument.
+func (g *Generator) SetCycleSafeValidation(safe bool) {
+	g.cycleSafeValidation = safe
+}
+
+// checkFunctionParams returns the parameter list of a check function.
+func (g *Generator) checkFunctionParams() string {
+	if g.cycleSafeValidation {
+		return "_v: any, _n: string, _seen?: WeakMap<object, Set<string>>"
+	}
+	return "_v: any, _n: string"
+}
+
+// cycleGuard returns a statement that makes a check function for t return null early for an
+// object already being checked against t further up the same validation, or "" when cycle
+// safety is off. Visited objects are recorded per type, so an object shared by differently
+// typed fields is still checked against each of them. The record only lasts while the check
+// is in progress - cycleRelease removes it however the check ends, so an object that failed
+// one union member isn't then taken as valid elsewhere.
+func (g *Generator) cycleGuard(t *checker.Type) string {
+	if !g.cycleSafeValidation {
+		return ""
+	}
+	key := escapeJSStringQuoted(g.checker.TypeToString(t))
+	return fmt.Sprintf(`let _c; if (typeof _v === "object" && _v !== null) { _seen ??= new WeakMap(); _c = _seen.get(_v); if (_c === undefined) _seen.set(_v, _c = new Set()); if (_c.has(%s)) return null; _c.add(%s); } try { `, key, key)
+}
+
+// cycleRelease closes the block opened by cycleGuard, removing t's record of the object
+// once its check returns.
+func (g *Generator) cycleRelease(t *checker.Type) string {
+	if !g.cycleSafeValidation {
+		return ""
+	}
+	return fmt.Sprintf(`} finally { _c?.delete(%s); } `, escapeJSStringQuoted(g.checker.TypeToString(t)))
+}
+
+// seenArg returns the argument passing the visited objects on to a nested check function
+// call. Only check functions have them; other validators start each call afresh.
+func (g *Generator) seenArg() string {
+	if g.cycleSafeValidation && g.returnErrors {
+		return ", _seen"
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/microsoft/typescript-go/shim/ast"
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// errorDirectiveRegex matches `@typical-error "message"` in a comment.
+var errorDirectiveRegex = regexp.MustCompile(`@typical-error\s+"((?:[^"\\]|\\.)*)"`)
+
+// assertDirectiveRegex matches a `// @typical-assert TypeName [target]` line comment, where
+// target is an identifier or property path such as req.body.
+var assertDirectiveRegex = regexp.MustCompile(`(?m)^//\s*@typical-assert\s+([A-Za-z_$][\w$]*)(?:[ \t]+([A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)*))?[ \t]*$`)
+
+// AssertDirective returns the type name and target of a `// @typical-assert TypeName [target]`
+// comment in the comments leading up to pos. target is "" when the directive doesn't name one.
+// typeName is "" if there's no directive.
+func AssertDirective(text string, pos int) (typeName, target string) {
+	m := assertDirectiveRegex.FindStringSubmatch(leadingComments(text, pos))
+	if m == nil {
+		return "", ""
+	}
+	return m[1], m[2]
+}
+
+// typeErrorMessage returns the message of a `@typical-error "..."` directive on the type
+// alias t was declared with, or "" if there isn't one.
+func (g *Generator) typeErrorMessage(t *checker.Type) string {
+	if alias := checker.Type_alias(t); alias != nil && alias.Symbol() != nil {
+		return typeAliasErrorDirective(alias.Symbol())
+	}
+	return ""
+}
+
+// propertyErrorMessage returns the message of a `@typical-error "..."` directive on a
+// property declaration. Failing that, a directive on the type alias named in the property's
+// type annotation is used when the checker hasn't kept the alias on propType - as happens for
+// aliases of primitives (`type Age = number`) and optional properties (`Role | undefined`) -
+// since typeErrorMessage can't find it there.
+func (g *Generator) propertyErrorMessage(prop *ast.Symbol, propType *checker.Type) string {
+	for _, decl := range prop.Declarations {
+		if msg := errorDirective(decl); msg != "" {
+			return msg
+		}
+	}
+	if checker.Type_alias(propType) != nil {
+		return ""
+	}
+	for _, decl := range prop.Declarations {
+		typeNode := decl.Type()
+		if typeNode == nil || typeNode.Kind != ast.KindTypeReference {
+			continue
+		}
+		if sym := g.checker.GetSymbolAtLocation(typeNode.AsTypeReferenceNode().TypeName); sym != nil {
+			if msg := typeAliasErrorDirective(sym); msg != "" {
+				return msg
+			}
+		}
+	}
+	return ""
+}
+
+// customErrorValidation checks expr against t with a single boolean check, failing with
+// message instead of the detailed "Expected ... got ..." error.
+func (g *Generator) customErrorValidation(t *checker.Type, expr, nameExpr, message string) string {
+	check := g.generateCheck(t, expr)
+	if check == "" || check == "true" {
+		return ""
+	}
+	return fmt.Sprintf(`if (!(%s)) %s; `, check, g.fail(escapeJSStringQuoted(message), nameExpr, g.getExpectedType(t), gotExprFor(expr), expr))
+}
+
+// customErrorFiltering is customErrorValidation for filter functions, which go on to run the
+// regular filtering validation to copy the value. Coerced values are left to the regular
+// validation, since the raw value may only match the type once coerced.
+func (g *Generator) customErrorFiltering(t *checker.Type, expr, nameExpr, message string) string {
+	if message == "" || g.coerceExpr(t, expr) != "" {
+		return ""
+	}
+	return g.customErrorValidation(t, expr, nameExpr, message)
+}
+
+// typeAliasErrorDirective returns the message of a directive on a type alias declaration.
+func typeAliasErrorDirective(sym *ast.Symbol) string {
+	for _, decl := range sym.Declarations {
+		if decl.Kind == ast.KindTypeAliasDeclaration {
+			if msg := errorDirective(decl); msg != "" {
+				return msg
+			}
+		}
+	}
+	return ""
+}
+
+// errorDirective returns the message of a `@typical-error "..."` directive in the comments
+// leading up to decl, or "" if there isn't one.
+func errorDirective(decl *ast.Node) string {
+	sf := ast.GetSourceFileOfNode(decl)
+	if sf == nil {
+		return ""
+	}
+	m := errorDirectiveRegex.FindStringSubmatch(leadingComments(sf.Text(), decl.Pos()))
+	if m == nil {
+		return ""
+	}
+	if msg, err := strconv.Unquote(`"` + m[1] + `"`); err == nil {
+		return msg
+	}
+	return m[1]
+}
+
+// leadingComments returns the comments between pos and the next token. Comments on the same
+// line as pos belong to the previous declaration (`a: string; // ...`), so they're skipped.
+func leadingComments(text string, pos int) string {
+	return scanComments(text, pos, pos > 0)
+}
+
+// parameterComments returns the comments before a parameter. Unlike declarations, parameters
+// are often written on one line (`(a: number, /** @min 1 */ b: number)`), so comments on the
+// same line as pos are kept.
+func parameterComments(text string, pos int) string {
+	return scanComments(text, pos, false)
+}
+
+// scanComments returns the comments between pos and the next token, skipping any on the
+// line pos is on when sameLine is set.
+func scanComments(text string, pos int, sameLine bool) string {
+	var sb strings.Builder
+	i := pos
+	for i < len(text) {
+		switch {
+		case text[i] == '\n':
+			sameLine = false
+			i++
+		case text[i] == ' ' || text[i] == '\t' || text[i] == '\r':
+			i++
+		case strings.HasPrefix(text[i:], "//"):
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			if !sameLine {
+				sb.WriteString(text[i : i+end])
+				sb.WriteByte('\n')
+			}
+			i += end
+		case strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				return sb.String()
+			}
+			if !sameLine {
+				sb.WriteString(text[i : i+end+4])
+				sb.WriteByte('\n')
+			}
+			i += end + 4
+		default:
+			return sb.String()
+		}
+	}
+	return sb.String()
+}
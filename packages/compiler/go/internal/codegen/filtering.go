@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/microsoft/typescript-go/shim/ast"
 	"github.com/microsoft/typescript-go/shim/checker"
 )
 
@@ -11,29 +12,12 @@ import (
 // The throw happens at the call site so stack traces are correct.
 func (g *Generator) filteringThrow(nameExpr, expected, expr string) string {
 	errorMsg := g.buildErrorMessage(nameExpr, expected, gotExprFor(expr))
-	return fmt.Sprintf(`throw new TypeError(%s)`, errorMsg)
-}
-
-// filteringError builds an error message for filtering validation.
-// Used for return [error, null] statements in filter functions.
-func filteringError(nameExpr, expected, gotExpr string) string {
-	// Build: "Expected " + nameExpr + " to be <expected>, got " + gotExpr
-	// Optimized when nameExpr is a literal
-	if isStringLiteral(nameExpr) {
-		return fmt.Sprintf(`"Expected %s to be %s, got "+%s`, extractStringLiteral(nameExpr), escapeJSString(expected), gotExpr)
-	}
-	// Optimise: if nameExpr ends with a string literal like `_n + ".foo"`, combine with " to be"
-	if idx := strings.LastIndex(nameExpr, `+ "`); idx != -1 && strings.HasSuffix(nameExpr, `"`) {
-		prefix := strings.TrimSuffix(nameExpr[:idx], " ")
-		trailingLit := nameExpr[idx+3 : len(nameExpr)-1]
-		return fmt.Sprintf(`"Expected "+%s+"%s to be %s, got "+%s`, prefix, trailingLit, escapeJSString(expected), gotExpr)
-	}
-	return fmt.Sprintf(`"Expected "+%s+" to be %s, got "+%s`, nameExpr, escapeJSString(expected), gotExpr)
+	return g.ThrowMessage(errorMsg)
 }
 
 // filteringReturn generates a return [error, null] statement with optimized error message.
-func filteringReturn(nameExpr, expected, gotExpr string) string {
-	return fmt.Sprintf(`return [%s, null]`, filteringError(nameExpr, expected, gotExpr))
+func (g *Generator) filteringReturn(nameExpr, expected, gotExpr string) string {
+	return fmt.Sprintf(`return [%s, null]`, g.buildErrorMessage(nameExpr, expected, gotExpr))
 }
 
 // filteringNameExpr builds the name expression for a nested property.
@@ -79,6 +63,13 @@ func (g *Generator) GenerateFilteringValidator(t *checker.Type, typeName string)
 // generateFilteringValidation generates statements that validate AND reconstruct the object.
 // resultExpr is the variable to assign the filtered result to (e.g., "_r")
 func (g *Generator) generateFilteringValidation(t *checker.Type, expr string, nameExpr string, resultExpr string) string {
+	// A `@typical-error "..."` directive on the type alias fails first with its message
+	custom := g.customErrorFiltering(t, expr, nameExpr, g.typeErrorMessage(t))
+	return custom + g.typeFilteringValidation(t, expr, nameExpr, resultExpr)
+}
+
+// typeFilteringValidation dispatches filtering validation on the kind of type.
+func (g *Generator) typeFilteringValidation(t *checker.Type, expr string, nameExpr string, resultExpr string) string {
 	flags := checker.Type_flags(t)
 
 	// Handle any/unknown - just return the value as-is
@@ -88,7 +79,7 @@ func (g *Generator) generateFilteringValidation(t *checker.Type, expr string, na
 
 	// Depth limit
 	if g.depth > MaxTypeDepth {
-		return fmt.Sprintf(`throw new TypeError("Type validation too deep at " + %s); `, nameExpr)
+		return g.ThrowMessage(`"Type validation too deep at " + `+nameExpr) + "; "
 	}
 	g.depth++
 	defer func() { g.depth-- }()
@@ -148,8 +139,32 @@ func (g *Generator) generateFilteringValidation(t *checker.Type, expr string, na
 func (g *Generator) primitiveFilteringValidation(t *checker.Type, expr string, nameExpr string, resultExpr string) string {
 	flags := checker.Type_flags(t)
 
-	var expected string
-	var check string
+	check, expected := g.primitiveFilteringCheck(t, expr)
+	if check == "" {
+		return ""
+	}
+
+	gotExpr := fmt.Sprintf("typeof %s", expr)
+	if g.strictNumbers && flags&checker.TypeFlagsNumber != 0 {
+		gotExpr = numberGotExpr(gotExpr, expr)
+	}
+
+	if coerced := g.coerceExpr(t, expr); coerced != "" {
+		// Coerce first, then validate the coerced value
+		coercedCheck, _ := g.primitiveFilteringCheck(t, resultExpr)
+		return fmt.Sprintf(`const %s = %s; if (!(%s)) %s; `,
+			resultExpr, coerced, coercedCheck,
+			g.filteringThrow(nameExpr, expected, gotExpr))
+	}
+
+	return fmt.Sprintf(`if (!(%s)) %s; const %s = %s; `,
+		check, g.filteringThrow(nameExpr, expected, gotExpr), resultExpr, expr)
+}
+
+// primitiveFilteringCheck returns the check that expr holds a value of the primitive type t,
+// and the type name for its error message. check is empty if t isn't a primitive.
+func (g *Generator) primitiveFilteringCheck(t *checker.Type, expr string) (check string, expected string) {
+	flags := checker.Type_flags(t)
 
 	switch {
 	case flags&checker.TypeFlagsStringLiteral != 0:
@@ -186,24 +201,27 @@ func (g *Generator) primitiveFilteringValidation(t *checker.Type, expr string, n
 			expected = "boolean"
 			check = fmt.Sprintf(`"boolean" === typeof %s`, expr)
 		}
+	case flags&checker.TypeFlagsBigIntLiteral != 0:
+		if literal := bigIntLiteral(t); literal != "" {
+			expected = literal
+			check = fmt.Sprintf(`%s === %s`, literal, expr)
+		} else {
+			expected = "bigint"
+			check = fmt.Sprintf(`"bigint" === typeof %s`, expr)
+		}
 	case flags&checker.TypeFlagsString != 0:
 		expected = "string"
 		check = fmt.Sprintf(`"string" === typeof %s`, expr)
 	case flags&checker.TypeFlagsNumber != 0:
-		expected = "number"
-		check = fmt.Sprintf(`"number" === typeof %s`, expr)
+		check, expected = g.numberCheck(expr)
 	case flags&checker.TypeFlagsBoolean != 0:
 		expected = "boolean"
 		check = fmt.Sprintf(`"boolean" === typeof %s`, expr)
 	case flags&checker.TypeFlagsBigInt != 0:
 		expected = "bigint"
 		check = fmt.Sprintf(`"bigint" === typeof %s`, expr)
-	default:
-		return ""
 	}
-
-	return fmt.Sprintf(`if (!(%s)) %s; const %s = %s; `,
-		check, g.filteringThrow(nameExpr, expected, fmt.Sprintf("typeof %s", expr)), resultExpr, expr)
+	return check, expected
 }
 
 // objectFilteringValidation - validates AND reconstructs the object
@@ -215,9 +233,16 @@ func (g *Generator) objectFilteringValidation(t *checker.Type, expr string, name
 	if g.isClassType(t) {
 		sym := checker.Type_symbol(t)
 		if sym != nil && !g.isTypeOnlyImport(sym) {
-			sb.WriteString(fmt.Sprintf(`if (!(%s instanceof %s)) %s; `,
-				expr, sym.Name, g.filteringThrow(nameExpr, sym.Name+" instance", expr)))
+			if coerced := g.coerceExpr(t, expr); coerced != "" {
+				expr = coerced
+			}
 			sb.WriteString(fmt.Sprintf("const %s = %s; ", resultExpr, expr))
+			sb.WriteString(fmt.Sprintf(`if (!(%s instanceof %s)) %s; `,
+				resultExpr, sym.Name, g.filteringThrow(nameExpr, sym.Name+" instance", resultExpr)))
+			if valid := g.validDateCheck(resultExpr, g.isBuiltinClassType(t)); valid != "" {
+				sb.WriteString(fmt.Sprintf(`if (!(%s)) %s; `,
+					valid, g.ThrowMessage(g.buildErrorMessage(nameExpr, "a valid Date", `"Invalid Date"`))))
+			}
 			return sb.String()
 		}
 	}
@@ -236,9 +261,9 @@ func (g *Generator) objectFilteringValidation(t *checker.Type, expr string, name
 	sb.WriteString(fmt.Sprintf("const %s: any = {}; ", resultExpr))
 
 	// Validate and copy each property
-	props := checker.Checker_getPropertiesOfType(g.checker, t)
+	props := g.sortedProperties(t)
 	for _, prop := range props {
-		propType := checker.Checker_getTypeOfSymbol(g.checker, prop)
+		propType := g.propertyReadType(prop)
 		propName := prop.Name
 
 		propFlags := checker.Type_flags(propType)
@@ -263,6 +288,8 @@ func (g *Generator) objectFilteringValidation(t *checker.Type, expr string, name
 		}
 
 		propNameExpr := filteringNameExpr(nameExpr, propName)
+		custom := g.customErrorFiltering(propType, accessor, propNameExpr, g.propertyErrorMessage(prop, propType)) +
+			g.refinementValidation(prop, propType, accessor, propNameExpr)
 
 		needsRecursiveFilter := propFlags&checker.TypeFlagsObject != 0 && !g.isFunctionType(propType)
 
@@ -273,16 +300,22 @@ func (g *Generator) objectFilteringValidation(t *checker.Type, expr string, name
 				tempVar := fmt.Sprintf("_t%d", g.funcIdx)
 				g.funcIdx++
 				nestedValidation := g.generateFilteringValidation(propType, accessor, propNameExpr, tempVar)
-				sb.WriteString(fmt.Sprintf("if (%s !== undefined) { %s%s = %s; } ",
-					accessor, nestedValidation, resultAccessor, tempVar))
+				guard, presenceValidation := g.optionalPropertyGuard(prop, propType, expr, accessor, propNameExpr)
+				sb.WriteString(fmt.Sprintf("if (%s) { %s%s%s = %s; } ",
+					guard, presenceValidation+custom, nestedValidation, resultAccessor, tempVar))
 			} else {
 				// Primitive - validate and assign
-				propValidation := g.generateValidation(propType, accessor, propNameExpr)
-				sb.WriteString(fmt.Sprintf("if (%s !== undefined) { %s%s = %s; } ",
-					accessor, propValidation, resultAccessor, accessor))
+				propValidation, valueExpr := g.coercedValidation(propType, accessor, propNameExpr)
+				guard, presenceValidation := g.optionalPropertyGuard(prop, propType, expr, accessor, propNameExpr)
+				sb.WriteString(fmt.Sprintf("if (%s) { %s%s%s = %s; } ",
+					guard, presenceValidation+custom, propValidation, resultAccessor, valueExpr))
 			}
 		} else {
 			// Required property
+			if presence := g.requiredPresenceCheck(prop, propType, expr); presence != "" {
+				sb.WriteString(fmt.Sprintf(`if (!(%s)) %s; `, presence, g.filteringThrow(propNameExpr, "present", accessor)))
+			}
+			sb.WriteString(custom)
 			if needsRecursiveFilter {
 				// Nested object - recursively filter
 				tempVar := fmt.Sprintf("_t%d", g.funcIdx)
@@ -292,13 +325,17 @@ func (g *Generator) objectFilteringValidation(t *checker.Type, expr string, name
 				sb.WriteString(fmt.Sprintf("%s = %s; ", resultAccessor, tempVar))
 			} else {
 				// Primitive or function - validate and assign directly
-				propValidation := g.generateValidation(propType, accessor, propNameExpr)
+				propValidation, valueExpr := g.coercedValidation(propType, accessor, propNameExpr)
 				sb.WriteString(propValidation)
-				sb.WriteString(fmt.Sprintf("%s = %s; ", resultAccessor, accessor))
+				sb.WriteString(fmt.Sprintf("%s = %s; ", resultAccessor, valueExpr))
 			}
 		}
 	}
 
+	// Validate and copy the dynamic keys of a string index signature (Record<string, T>)
+	sb.WriteString(g.indexSignatureFiltering(t, props, expr, nameExpr, resultExpr, false))
+	sb.WriteString(g.droppedFunctionCheck(t, props, expr, nameExpr, false))
+
 	return sb.String()
 }
 
@@ -335,10 +372,10 @@ func (g *Generator) arrayFilteringValidation(t *checker.Type, expr string, nameE
 					iVar, iVar, expr, iVar, eVar, expr, iVar, elemFiltering, resultExpr, filteredVar))
 			} else {
 				// Just validate and push
-				elemValidation := g.generateValidation(elemType, eVar,
+				elemValidation, valueExpr := g.coercedValidation(elemType, eVar,
 					fmt.Sprintf(`%s + "[" + %s + "]"`, nameExpr, iVar))
 				sb.WriteString(fmt.Sprintf(`for (let %s = 0; %s < %s.length; %s++) { const %s: any = %s[%s]; %s%s.push(%s); } `,
-					iVar, iVar, expr, iVar, eVar, expr, iVar, elemValidation, resultExpr, eVar))
+					iVar, iVar, expr, iVar, eVar, expr, iVar, elemValidation, resultExpr, valueExpr))
 			}
 			return sb.String()
 		}
@@ -357,41 +394,82 @@ func (g *Generator) tupleFilteringValidation(t *checker.Type, expr string, nameE
 	sb.WriteString(fmt.Sprintf(`if (!Array.isArray(%s)) %s; `,
 		expr, g.filteringThrow(nameExpr, "tuple", fmt.Sprintf("typeof %s", expr))))
 
-	// Get tuple elements
-	typeArgs := checker.Checker_getTypeArguments(g.checker, t)
+	sb.WriteString(g.tupleFilteringElements(t, expr, nameExpr, resultExpr, g.generateFilteringValidation,
+		g.ThrowMessage))
+
+	return sb.String()
+}
+
+// tupleFilteringElements generates the length check and per-element filtering shared by the
+// throwing and reusable tuple filters. Optional elements are only filtered when present and
+// a rest element covers every element between the fixed ones, so tuples such as
+// Parameters<typeof f> keep their optional and rest parameters.
+// filterElem filters object elements, and fail turns an error message into the failure statement.
+func (g *Generator) tupleFilteringElements(t *checker.Type, expr string, nameExpr string, resultExpr string,
+	filterElem func(t *checker.Type, expr string, nameExpr string, resultExpr string) string, fail func(msg string) string) string {
+	var sb strings.Builder
+
+	typeArgs, elementInfos, restIndex, minLen := g.tupleLayout(t)
 
 	// Check length - build optimised error message
-	lenErrorMsg := concatStrings(`"Expected "`, nameExpr)
-	lenErrorMsg = concatStrings(lenErrorMsg, fmt.Sprintf(`" to have at least %d elements, got " + %s.length`, len(typeArgs), expr))
-	sb.WriteString(fmt.Sprintf(`if (%s.length < %d) throw new TypeError(%s); `,
-		expr, len(typeArgs), lenErrorMsg))
+	if minLen > 0 {
+		lenErrorMsg := g.tupleLengthErrorMessage(nameExpr, minLen, expr)
+		sb.WriteString(fmt.Sprintf(`if (%s.length < %d) %s; `, expr, minLen, fail(lenErrorMsg)))
+	}
 
 	sb.WriteString(fmt.Sprintf("const %s: any[] = []; ", resultExpr))
 
-	for i, elemType := range typeArgs {
+	// filterElement validates one element and stores the filtered value at target
+	filterElement := func(elemType *checker.Type, eVar string, elemNameExpr string, target string) string {
 		flags := checker.Type_flags(elemType)
-		iVar := fmt.Sprintf("%d", i)
-		eVar := fmt.Sprintf("%s[%d]", expr, i)
-		// Optimise: combine static index with nameExpr if it's a literal
-		var elemNameExpr string
+		if flags&checker.TypeFlagsObject != 0 && !g.isFunctionType(elemType) {
+			filteredVar := fmt.Sprintf("_tf%d", g.funcIdx)
+			g.funcIdx++
+			return filterElem(elemType, eVar, elemNameExpr, filteredVar) + fmt.Sprintf("%s = %s; ", target, filteredVar)
+		}
+		elemValidation, valueExpr := g.coercedValidation(elemType, eVar, elemNameExpr)
+		return elemValidation + fmt.Sprintf("%s = %s; ", target, valueExpr)
+	}
+
+	// Optimise: combine static index with nameExpr if it's a literal
+	staticName := func(i int) string {
 		if isStringLiteral(nameExpr) {
-			elemNameExpr = fmt.Sprintf(`"%s[%d]"`, extractStringLiteral(nameExpr), i)
-		} else {
-			elemNameExpr = fmt.Sprintf(`%s + "[%d]"`, nameExpr, i)
+			return fmt.Sprintf(`"%s[%d]"`, extractStringLiteral(nameExpr), i)
 		}
+		return fmt.Sprintf(`%s + "[%d]"`, nameExpr, i)
+	}
 
-		needsFiltering := flags&checker.TypeFlagsObject != 0 && !g.isFunctionType(elemType)
+	fixedEnd := len(typeArgs)
+	if restIndex >= 0 {
+		fixedEnd = restIndex
+	}
+	for i := 0; i < fixedEnd; i++ {
+		code := filterElement(typeArgs[i], fmt.Sprintf("%s[%d]", expr, i), staticName(i), fmt.Sprintf("%s[%d]", resultExpr, i))
+		if isOptionalTupleElement(elementInfos, i) {
+			code = fmt.Sprintf("if (%s.length > %d) { %s} ", expr, i, code)
+		}
+		sb.WriteString(code)
+	}
 
-		if needsFiltering {
-			filteredVar := fmt.Sprintf("_tf%d", g.funcIdx)
-			g.funcIdx++
-			elemFiltering := g.generateFilteringValidation(elemType, eVar, elemNameExpr, filteredVar)
-			sb.WriteString(elemFiltering)
-			sb.WriteString(fmt.Sprintf("%s[%s] = %s; ", resultExpr, iVar, filteredVar))
-		} else {
-			elemValidation := g.generateValidation(elemType, eVar, elemNameExpr)
-			sb.WriteString(elemValidation)
-			sb.WriteString(fmt.Sprintf("%s[%s] = %s; ", resultExpr, iVar, eVar))
+	if restIndex >= 0 {
+		trailingCount := len(typeArgs) - restIndex - 1
+		idx := g.funcIdx
+		g.funcIdx++
+		iVar := fmt.Sprintf("_i%d", idx)
+		eVar := fmt.Sprintf("_e%d", idx)
+		loopEnd := fmt.Sprintf("%s.length", expr)
+		if trailingCount > 0 {
+			loopEnd = fmt.Sprintf("%s.length - %d", expr, trailingCount)
+		}
+		code := filterElement(typeArgs[restIndex], eVar, fmt.Sprintf(`%s + "[" + %s + "]"`, nameExpr, iVar), fmt.Sprintf("%s[%s]", resultExpr, iVar))
+		sb.WriteString(fmt.Sprintf(`for (let %s = %d; %s < %s; %s++) { const %s: any = %s[%s]; %s} `,
+			iVar, restIndex, iVar, loopEnd, iVar, eVar, expr, iVar, code))
+
+		// Trailing fixed elements are addressed from the end
+		for i := 0; i < trailingCount; i++ {
+			indexExpr := fmt.Sprintf("%s.length - %d", expr, trailingCount-i)
+			sb.WriteString(filterElement(typeArgs[restIndex+1+i], fmt.Sprintf("%s[%s]", expr, indexExpr),
+				fmt.Sprintf(`%s + "[" + (%s) + "]"`, nameExpr, indexExpr), fmt.Sprintf("%s[%s]", resultExpr, indexExpr)))
 		}
 	}
 
@@ -457,6 +535,13 @@ func (g *Generator) unionFilteringValidation(t *checker.Type, expr string, nameE
 // This is used for reusable filter functions that return [error, result] tuples.
 // The caller handles the throw at the call site for proper source maps.
 func (g *Generator) generateReusableFilteringValidation(t *checker.Type, expr string, nameExpr string, resultExpr string) string {
+	// A `@typical-error "..."` directive on the type alias fails first with its message
+	custom := g.customErrorFiltering(t, expr, nameExpr, g.typeErrorMessage(t))
+	return custom + g.reusableTypeFilteringValidation(t, expr, nameExpr, resultExpr)
+}
+
+// reusableTypeFilteringValidation dispatches reusable filtering validation on the kind of type.
+func (g *Generator) reusableTypeFilteringValidation(t *checker.Type, expr string, nameExpr string, resultExpr string) string {
 	flags := checker.Type_flags(t)
 
 	// Handle any/unknown - just return the value as-is
@@ -484,13 +569,13 @@ func (g *Generator) generateReusableFilteringValidation(t *checker.Type, expr st
 	// Handle null - just validate and assign
 	if flags&checker.TypeFlagsNull != 0 {
 		return fmt.Sprintf(`if (%s !== null) %s; const %s = null; `,
-			expr, filteringReturn(nameExpr, "null", fmt.Sprintf("typeof %s", expr)), resultExpr)
+			expr, g.filteringReturn(nameExpr, "null", fmt.Sprintf("typeof %s", expr)), resultExpr)
 	}
 
 	// Handle undefined
 	if flags&checker.TypeFlagsUndefined != 0 || flags&checker.TypeFlagsVoid != 0 {
 		return fmt.Sprintf(`if (%s !== undefined) %s; const %s = undefined; `,
-			expr, filteringReturn(nameExpr, "undefined", fmt.Sprintf("typeof %s", expr)), resultExpr)
+			expr, g.filteringReturn(nameExpr, "undefined", fmt.Sprintf("typeof %s", expr)), resultExpr)
 	}
 
 	// Primitives - just validate and assign
@@ -526,62 +611,26 @@ func (g *Generator) generateReusableFilteringValidation(t *checker.Type, expr st
 func (g *Generator) reusablePrimitiveFilteringValidation(t *checker.Type, expr string, nameExpr string, resultExpr string) string {
 	flags := checker.Type_flags(t)
 
-	var expected string
-	var check string
-
-	switch {
-	case flags&checker.TypeFlagsStringLiteral != 0:
-		lt := t.AsLiteralType()
-		if lt != nil {
-			if str, ok := lt.Value().(string); ok {
-				expected = fmt.Sprintf("%q", str)
-				check = fmt.Sprintf(`%q === %s`, str, expr)
-			}
-		}
-		if check == "" {
-			expected = "string"
-			check = fmt.Sprintf(`"string" === typeof %s`, expr)
-		}
-	case flags&checker.TypeFlagsNumberLiteral != 0:
-		lt := t.AsLiteralType()
-		if lt != nil {
-			expected = fmt.Sprintf("%v", lt.Value())
-			check = fmt.Sprintf(`%v === %s`, lt.Value(), expr)
-		}
-		if check == "" {
-			expected = "number"
-			check = fmt.Sprintf(`"number" === typeof %s`, expr)
-		}
-	case flags&checker.TypeFlagsBooleanLiteral != 0:
-		lt := t.AsLiteralType()
-		if lt != nil {
-			if b, ok := lt.Value().(bool); ok {
-				expected = fmt.Sprintf("%t", b)
-				check = fmt.Sprintf(`%t === %s`, b, expr)
-			}
-		}
-		if check == "" {
-			expected = "boolean"
-			check = fmt.Sprintf(`"boolean" === typeof %s`, expr)
-		}
-	case flags&checker.TypeFlagsString != 0:
-		expected = "string"
-		check = fmt.Sprintf(`"string" === typeof %s`, expr)
-	case flags&checker.TypeFlagsNumber != 0:
-		expected = "number"
-		check = fmt.Sprintf(`"number" === typeof %s`, expr)
-	case flags&checker.TypeFlagsBoolean != 0:
-		expected = "boolean"
-		check = fmt.Sprintf(`"boolean" === typeof %s`, expr)
-	case flags&checker.TypeFlagsBigInt != 0:
-		expected = "bigint"
-		check = fmt.Sprintf(`"bigint" === typeof %s`, expr)
-	default:
+	check, expected := g.primitiveFilteringCheck(t, expr)
+	if check == "" {
 		return ""
 	}
 
+	gotExpr := fmt.Sprintf("typeof %s", expr)
+	if g.strictNumbers && flags&checker.TypeFlagsNumber != 0 {
+		gotExpr = numberGotExpr(gotExpr, expr)
+	}
+
+	if coerced := g.coerceExpr(t, expr); coerced != "" {
+		// Coerce first, then validate the coerced value
+		coercedCheck, _ := g.primitiveFilteringCheck(t, resultExpr)
+		return fmt.Sprintf(`const %s = %s; if (!(%s)) %s; `,
+			resultExpr, coerced, coercedCheck,
+			g.filteringReturn(nameExpr, expected, gotExpr))
+	}
+
 	return fmt.Sprintf(`if (!(%s)) %s; const %s = %s; `,
-		check, filteringReturn(nameExpr, expected, fmt.Sprintf("typeof %s", expr)), resultExpr, expr)
+		check, g.filteringReturn(nameExpr, expected, gotExpr), resultExpr, expr)
 }
 
 // reusableObjectFilteringValidation - validates AND reconstructs the object, returning error on failure
@@ -592,10 +641,16 @@ func (g *Generator) reusableObjectFilteringValidation(t *checker.Type, expr stri
 	if g.isClassType(t) {
 		sym := checker.Type_symbol(t)
 		if sym != nil && !g.isTypeOnlyImport(sym) {
-			gotExpr := fmt.Sprintf(`(%s === null ? "null" : %s?.constructor?.name ?? typeof %s)`, expr, expr, expr)
-			sb.WriteString(fmt.Sprintf(`if (!(%s instanceof %s)) %s; `,
-				expr, sym.Name, filteringReturn(nameExpr, sym.Name+" instance", gotExpr)))
+			if coerced := g.coerceExpr(t, expr); coerced != "" {
+				expr = coerced
+			}
 			sb.WriteString(fmt.Sprintf("const %s = %s; ", resultExpr, expr))
+			gotExpr := fmt.Sprintf(`(%s === null ? "null" : %s?.constructor?.name ?? typeof %s)`, resultExpr, resultExpr, resultExpr)
+			sb.WriteString(fmt.Sprintf(`if (!(%s instanceof %s)) %s; `,
+				resultExpr, sym.Name, g.filteringReturn(nameExpr, sym.Name+" instance", gotExpr)))
+			if valid := g.validDateCheck(resultExpr, g.isBuiltinClassType(t)); valid != "" {
+				sb.WriteString(fmt.Sprintf(`if (!(%s)) %s; `, valid, g.filteringReturn(nameExpr, "a valid Date", `"Invalid Date"`)))
+			}
 			return sb.String()
 		}
 	}
@@ -609,15 +664,15 @@ func (g *Generator) reusableObjectFilteringValidation(t *checker.Type, expr stri
 	// Check it's an object and not null
 	gotExpr := fmt.Sprintf(`(%s === null ? "null" : typeof %s)`, expr, expr)
 	sb.WriteString(fmt.Sprintf(`if (typeof %s !== "object" || %s === null) %s; `,
-		expr, expr, filteringReturn(nameExpr, typeName, gotExpr)))
+		expr, expr, g.filteringReturn(nameExpr, typeName, gotExpr)))
 
 	// Create result object
 	sb.WriteString(fmt.Sprintf("const %s: any = {}; ", resultExpr))
 
 	// Validate and copy each property
-	props := checker.Checker_getPropertiesOfType(g.checker, t)
+	props := g.sortedProperties(t)
 	for _, prop := range props {
-		propType := checker.Checker_getTypeOfSymbol(g.checker, prop)
+		propType := g.propertyReadType(prop)
 		propName := prop.Name
 
 		propFlags := checker.Type_flags(propType)
@@ -627,7 +682,7 @@ func (g *Generator) reusableObjectFilteringValidation(t *checker.Type, expr stri
 			propKey := escapeJSStringQuoted(propName)
 			propNameExpr := filteringNameExpr(nameExpr, propName)
 			sb.WriteString(fmt.Sprintf(`if (%s in %s) %s; `,
-				propKey, expr, filteringReturn(propNameExpr, "never (property must not exist)", `"present"`)))
+				propKey, expr, g.filteringReturn(propNameExpr, "never (property must not exist)", `"present"`)))
 			continue
 		}
 
@@ -642,6 +697,8 @@ func (g *Generator) reusableObjectFilteringValidation(t *checker.Type, expr stri
 		}
 
 		propNameExpr := filteringNameExpr(nameExpr, propName)
+		custom := g.customErrorFiltering(propType, accessor, propNameExpr, g.propertyErrorMessage(prop, propType)) +
+			g.refinementValidation(prop, propType, accessor, propNameExpr)
 
 		needsRecursiveFilter := propFlags&checker.TypeFlagsObject != 0 && !g.isFunctionType(propType)
 
@@ -652,16 +709,22 @@ func (g *Generator) reusableObjectFilteringValidation(t *checker.Type, expr stri
 				tempVar := fmt.Sprintf("_t%d", g.funcIdx)
 				g.funcIdx++
 				nestedValidation := g.generateReusableFilteringValidation(propType, accessor, propNameExpr, tempVar)
-				sb.WriteString(fmt.Sprintf("if (%s !== undefined) { %s%s = %s; } ",
-					accessor, nestedValidation, resultAccessor, tempVar))
+				guard, presenceValidation := g.optionalPropertyGuard(prop, propType, expr, accessor, propNameExpr)
+				sb.WriteString(fmt.Sprintf("if (%s) { %s%s%s = %s; } ",
+					guard, presenceValidation+custom, nestedValidation, resultAccessor, tempVar))
 			} else {
 				// Primitive - validate and assign using reusable validation
-				propValidation := g.generateValidation(propType, accessor, propNameExpr)
-				sb.WriteString(fmt.Sprintf("if (%s !== undefined) { %s%s = %s; } ",
-					accessor, propValidation, resultAccessor, accessor))
+				propValidation, valueExpr := g.coercedValidation(propType, accessor, propNameExpr)
+				guard, presenceValidation := g.optionalPropertyGuard(prop, propType, expr, accessor, propNameExpr)
+				sb.WriteString(fmt.Sprintf("if (%s) { %s%s%s = %s; } ",
+					guard, presenceValidation+custom, propValidation, resultAccessor, valueExpr))
 			}
 		} else {
 			// Required property
+			if presence := g.requiredPresenceCheck(prop, propType, expr); presence != "" {
+				sb.WriteString(fmt.Sprintf(`if (!(%s)) %s; `, presence, g.filteringReturn(propNameExpr, "present", gotExprFor(accessor))))
+			}
+			sb.WriteString(custom)
 			if needsRecursiveFilter {
 				// Nested object - recursively filter
 				tempVar := fmt.Sprintf("_t%d", g.funcIdx)
@@ -671,13 +734,17 @@ func (g *Generator) reusableObjectFilteringValidation(t *checker.Type, expr stri
 				sb.WriteString(fmt.Sprintf("%s = %s; ", resultAccessor, tempVar))
 			} else {
 				// Primitive or function - validate and assign directly
-				propValidation := g.generateValidation(propType, accessor, propNameExpr)
+				propValidation, valueExpr := g.coercedValidation(propType, accessor, propNameExpr)
 				sb.WriteString(propValidation)
-				sb.WriteString(fmt.Sprintf("%s = %s; ", resultAccessor, accessor))
+				sb.WriteString(fmt.Sprintf("%s = %s; ", resultAccessor, valueExpr))
 			}
 		}
 	}
 
+	// Validate and copy the dynamic keys of a string index signature (Record<string, T>)
+	sb.WriteString(g.indexSignatureFiltering(t, props, expr, nameExpr, resultExpr, true))
+	sb.WriteString(g.droppedFunctionCheck(t, props, expr, nameExpr, true))
+
 	return sb.String()
 }
 
@@ -687,7 +754,7 @@ func (g *Generator) reusableArrayFilteringValidation(t *checker.Type, expr strin
 
 	// Check it's an array
 	sb.WriteString(fmt.Sprintf(`if (!Array.isArray(%s)) %s; `,
-		expr, filteringReturn(nameExpr, "array", fmt.Sprintf("typeof %s", expr))))
+		expr, g.filteringReturn(nameExpr, "array", fmt.Sprintf("typeof %s", expr))))
 
 	// Get element type
 	typeArgs := checker.Checker_getTypeArguments(g.checker, t)
@@ -714,10 +781,10 @@ func (g *Generator) reusableArrayFilteringValidation(t *checker.Type, expr strin
 					iVar, iVar, expr, iVar, eVar, expr, iVar, elemFiltering, resultExpr, filteredVar))
 			} else {
 				// Just validate and push
-				elemValidation := g.generateValidation(elemType, eVar,
+				elemValidation, valueExpr := g.coercedValidation(elemType, eVar,
 					fmt.Sprintf(`%s + "[" + %s + "]"`, nameExpr, iVar))
 				sb.WriteString(fmt.Sprintf(`for (let %s = 0; %s < %s.length; %s++) { const %s: any = %s[%s]; %s%s.push(%s); } `,
-					iVar, iVar, expr, iVar, eVar, expr, iVar, elemValidation, resultExpr, eVar))
+					iVar, iVar, expr, iVar, eVar, expr, iVar, elemValidation, resultExpr, valueExpr))
 			}
 			return sb.String()
 		}
@@ -734,45 +801,10 @@ func (g *Generator) reusableTupleFilteringValidation(t *checker.Type, expr strin
 
 	// Check it's an array
 	sb.WriteString(fmt.Sprintf(`if (!Array.isArray(%s)) %s; `,
-		expr, filteringReturn(nameExpr, "tuple", fmt.Sprintf("typeof %s", expr))))
+		expr, g.filteringReturn(nameExpr, "tuple", fmt.Sprintf("typeof %s", expr))))
 
-	// Get tuple elements
-	typeArgs := checker.Checker_getTypeArguments(g.checker, t)
-
-	// Check length - build optimised error message
-	lenErrorMsg := concatStrings(`"Expected "`, nameExpr)
-	lenErrorMsg = concatStrings(lenErrorMsg, fmt.Sprintf(`" to have at least %d elements, got " + %s.length`, len(typeArgs), expr))
-	sb.WriteString(fmt.Sprintf(`if (%s.length < %d) return [%s, null]; `,
-		expr, len(typeArgs), lenErrorMsg))
-
-	sb.WriteString(fmt.Sprintf("const %s: any[] = []; ", resultExpr))
-
-	for i, elemType := range typeArgs {
-		flags := checker.Type_flags(elemType)
-		iVar := fmt.Sprintf("%d", i)
-		eVar := fmt.Sprintf("%s[%d]", expr, i)
-		// Optimise: combine static index with nameExpr if it's a literal
-		var elemNameExpr string
-		if isStringLiteral(nameExpr) {
-			elemNameExpr = fmt.Sprintf(`"%s[%d]"`, extractStringLiteral(nameExpr), i)
-		} else {
-			elemNameExpr = fmt.Sprintf(`%s + "[%d]"`, nameExpr, i)
-		}
-
-		needsFiltering := flags&checker.TypeFlagsObject != 0 && !g.isFunctionType(elemType)
-
-		if needsFiltering {
-			filteredVar := fmt.Sprintf("_tf%d", g.funcIdx)
-			g.funcIdx++
-			elemFiltering := g.generateReusableFilteringValidation(elemType, eVar, elemNameExpr, filteredVar)
-			sb.WriteString(elemFiltering)
-			sb.WriteString(fmt.Sprintf("%s[%s] = %s; ", resultExpr, iVar, filteredVar))
-		} else {
-			elemValidation := g.generateValidation(elemType, eVar, elemNameExpr)
-			sb.WriteString(elemValidation)
-			sb.WriteString(fmt.Sprintf("%s[%s] = %s; ", resultExpr, iVar, eVar))
-		}
-	}
+	sb.WriteString(g.tupleFilteringElements(t, expr, nameExpr, resultExpr, g.generateReusableFilteringValidation,
+		func(msg string) string { return fmt.Sprintf("return [%s, null]", msg) }))
 
 	return sb.String()
 }
@@ -827,7 +859,104 @@ func (g *Generator) reusableUnionFilteringValidation(t *checker.Type, expr strin
 	// Final else - return error
 	expected := g.getUnionDescription(t)
 	sb.WriteString(fmt.Sprintf(`} else { %s; } `,
-		filteringReturn(nameExpr, expected, fmt.Sprintf("typeof %s", expr))))
+		g.filteringReturn(nameExpr, expected, fmt.Sprintf("typeof %s", expr))))
 
 	return sb.String()
 }
+
+// indexSignatureFiltering validates and copies the values of a string index signature
+// (Record<string, T> or { [key: string]: T }) into the filtered result.
+// Keys belonging to declared properties are skipped as they've already been handled.
+// Without a string index signature, a number index signature (Record<number, T>) is used
+// instead: JSON keys are always strings, so each key must be a numeric string, which
+// Coercion.NumericKeys normalises first ("01" -> "1").
+// reusable selects the [error, null] returning variant used by filter functions.
+func (g *Generator) indexSignatureFiltering(t *checker.Type, props []*ast.Symbol, expr string, nameExpr string, resultExpr string, reusable bool) string {
+	valueType, numericKeys := g.indexSignatureValueType(t)
+	if valueType == nil {
+		return ""
+	}
+
+	idx := g.funcIdx
+	g.funcIdx++
+	kVar := fmt.Sprintf("_k%d", idx)
+	vVar := fmt.Sprintf("_v%d", idx)
+	valNameExpr := g.appendArrayIndex(nameExpr, kVar)
+
+	var body strings.Builder
+
+	// Skip declared properties
+	if skip := declaredKeyCondition(kVar, props); skip != "" {
+		body.WriteString(fmt.Sprintf("if (%s) continue; ", skip))
+	}
+
+	body.WriteString(fmt.Sprintf("const %s: any = %s[%s]; ", vVar, expr, kVar))
+
+	// The result is keyed by the normalised key when numeric keys are coerced
+	resultKey := kVar
+	if numericKeys {
+		if g.coercion.NumericKeys {
+			resultKey = fmt.Sprintf("_nk%d", idx)
+			body.WriteString(fmt.Sprintf(`const %s = %s.trim() !== "" && !isNaN(+%s) ? String(+%s) : %s; `,
+				resultKey, kVar, kVar, kVar, kVar))
+		}
+		// A numeric string is one that round-trips through Number, as TypeScript defines it
+		errorMsg := g.buildErrorMessage(valNameExpr, "numeric key", fmt.Sprintf(`"'" + %s + "'"`, kVar))
+		if reusable {
+			body.WriteString(fmt.Sprintf("if (String(+%s) !== %s) return [%s, null]; ", resultKey, resultKey, errorMsg))
+		} else {
+			body.WriteString(fmt.Sprintf("if (String(+%s) !== %s) %s; ", resultKey, resultKey, g.ThrowMessage(errorMsg)))
+		}
+	}
+
+	flags := checker.Type_flags(valueType)
+	if flags&checker.TypeFlagsObject != 0 && !g.isFunctionType(valueType) {
+		tempVar := fmt.Sprintf("_t%d", g.funcIdx)
+		g.funcIdx++
+		if reusable {
+			body.WriteString(g.generateReusableFilteringValidation(valueType, vVar, valNameExpr, tempVar))
+		} else {
+			body.WriteString(g.generateFilteringValidation(valueType, vVar, valNameExpr, tempVar))
+		}
+		body.WriteString(fmt.Sprintf("%s[%s] = %s; ", resultExpr, resultKey, tempVar))
+	} else {
+		validation, valueExpr := g.coercedValidation(valueType, vVar, valNameExpr)
+		body.WriteString(validation)
+		body.WriteString(fmt.Sprintf("%s[%s] = %s; ", resultExpr, resultKey, valueExpr))
+	}
+
+	return fmt.Sprintf("for (const %s in %s) { %s} ", kVar, expr, body.String())
+}
+
+// droppedFunctionCheck rejects function-valued properties the type doesn't declare, which
+// filtering would silently drop (with rejectDroppedFunctions). JSON.parse can't produce
+// functions, so in practice this catches data lost by JSON.stringify. Types with an index
+// signature declare every key, so have nothing to drop.
+func (g *Generator) droppedFunctionCheck(t *checker.Type, props []*ast.Symbol, expr string, nameExpr string, reusable bool) string {
+	if !g.rejectDroppedFunctions {
+		return ""
+	}
+	for _, keyType := range []*checker.Type{checker.Checker_stringType(g.checker), checker.Checker_numberType(g.checker)} {
+		if keyType != nil && checker.Checker_getIndexTypeOfType(g.checker, t, keyType) != nil {
+			return ""
+		}
+	}
+
+	kVar := fmt.Sprintf("_k%d", g.funcIdx)
+	g.funcIdx++
+	conds := []string{fmt.Sprintf(`typeof %s[%s] === "function"`, expr, kVar)}
+	for _, prop := range props {
+		conds = append(conds, fmt.Sprintf("%s !== %s", kVar, escapeJSStringQuoted(prop.Name)))
+	}
+
+	keyNameExpr := fmt.Sprintf(`%s + "." + %s`, nameExpr, kVar)
+	if isStringLiteral(nameExpr) {
+		keyNameExpr = fmt.Sprintf(`"%s." + %s`, extractStringLiteral(nameExpr), kVar)
+	}
+	const expected = "a declared property (functions are dropped)"
+	fail := g.filteringThrow(keyNameExpr, expected, fmt.Sprintf("%s[%s]", expr, kVar))
+	if reusable {
+		fail = g.filteringReturn(keyNameExpr, expected, `"function"`)
+	}
+	return fmt.Sprintf("for (const %s in %s) if (%s) %s; ", kVar, expr, strings.Join(conds, " && "), fail)
+}
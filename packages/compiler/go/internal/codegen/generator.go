@@ -42,22 +42,49 @@ type Generator struct {
 	checker  *checker.Checker
 	program  *compiler.Program // TypeScript program for lib file detection
 	ioFuncs  []string          // _io0, _io1, etc. (is-check functions)
+	ioBodies map[string]string // _io function body -> name, so identical shapes share one helper
 	funcIdx  int               // Counter for generating unique function names
 	visiting map[string]bool   // Track types being visited for circular refs (by symbol name)
 	depth    int               // Current recursion depth
 
 	// Configuration
-	maxGeneratedFunctions int              // Max _io functions before erroring (0 = unlimited)
-	ignoreTypes           []*regexp.Regexp // Patterns for types to skip validation
+	maxGeneratedFunctions  int              // Max _io functions before erroring (0 = unlimited)
+	ignoreTypes            []*regexp.Regexp // Patterns for types to skip validation
+	strictOptionalPresence bool             // Validate optional props by hasOwnProperty, rejecting explicit undefined
+	coercion               Coercion         // Primitive coercions applied by filter functions
+	warnTypes              []*regexp.Regexp // Patterns for types whose validation warns instead of throwing
+	errorFormat            string           // Error message template (%path, %expected, %got); "" for the default
+	rejectEmptyObjects     bool             // Require the keys of required props whose value check accepts undefined
+	validationBrand        string           // Sentinel stamped on validated objects; check functions skip branded values
+	objectAcceptsFunctions bool             // Let the broad object type accept functions as well as non-null objects
+	degradeOnComplexity    bool             // Past maxGeneratedFunctions, stop descending instead of erroring
+	sampleRate             float64          // Fraction of calls validated; 0 or >= 1 validates every call
+	guardEnvVar            string           // Env var whose "production" value switches validation off
+	cycleSafeValidation    bool             // Check functions track visited objects so cyclic data terminates
+	crossRealmBuiltins     bool             // Check built-ins by their toStringTag rather than instanceof
+	rejectDroppedFunctions bool             // Filters reject undeclared function-valued properties they'd drop
+	functionDeclarations   bool             // Check and filter functions are function declarations, not arrow consts
+	strictNumbers          bool             // The number type rejects NaN and the infinities
+	integerBrand           string           // Brand marker (number & { __int: true }) declaring an integer
+	validateDateValues     bool             // Date instances must hold a valid time, not NaN
+	validateFunctionArity  bool             // Function types also check length against their required parameters
+	collectErrors          bool             // Validation reports every failure, not just the first
+	structuredErrors       bool             // Failures are TypicalError objects rather than message strings
+	errorConstructor       string           // Error class thrown with failure messages, e.g. TypeError
 
 	// Error tracking
 	complexityError string   // Set when max functions exceeded; contains error message
+	directiveError  string   // Set when a JSDoc tag such as @pattern is malformed
 	typeStack       []string // Stack of type names being processed (for error context)
 
 	// Mode for reusable validators
 	returnErrors      bool // If true, generate "return <error>" instead of "throw new TypeError(<error>)"
 	returnTupleErrors bool // If true, generate "return [<error>, null]" for filter functions
 
+	// Error collection (SetCollectErrors)
+	collecting bool   // Failures push to _es and break out of errorLabel instead of ending validation
+	errorLabel string // Label of the innermost statements a failure skips the rest of
+
 	// Available reusable check functions - maps type key to function name
 	// When set, the generator will call these functions instead of inlining validation
 	availableCheckFunctions map[string]string // type key (from checker.TypeToString) -> "_check_X"
@@ -96,6 +123,12 @@ func getTypeKey(t *checker.Type) string {
 // DefaultMaxGeneratedFunctions is the default limit for generated helper functions.
 const DefaultMaxGeneratedFunctions = 50
 
+// DefaultIntegerBrand is the default brand marker for integers: number & { __int: true }.
+const DefaultIntegerBrand = "__int"
+
+// DefaultErrorConstructor is the error class validation failures throw by default.
+const DefaultErrorConstructor = "TypeError"
+
 // NewGenerator creates a new validator code generator with default settings.
 func NewGenerator(c *checker.Checker, program *compiler.Program) *Generator {
 	return NewGeneratorWithConfig(c, program, DefaultMaxGeneratedFunctions)
@@ -115,17 +148,25 @@ func NewGeneratorWithIgnoreTypes(c *checker.Checker, program *compiler.Program,
 		checker:               c,
 		program:               program,
 		ioFuncs:               make([]string, 0),
+		ioBodies:              make(map[string]string),
 		visiting:              make(map[string]bool),
 		depth:                 0,
 		maxGeneratedFunctions: maxFuncs,
 		ignoreTypes:           ignoreTypes,
+		integerBrand:          DefaultIntegerBrand,
+		errorConstructor:      DefaultErrorConstructor,
 	}
 }
 
 // shouldIgnoreType checks if a type name matches any ignore pattern.
 // Returns the matching pattern (for error messages) or empty string if not ignored.
 func (g *Generator) shouldIgnoreType(typeName string) string {
-	for _, re := range g.ignoreTypes {
+	return matchTypePattern(g.ignoreTypes, typeName)
+}
+
+// matchTypePattern returns the first of patterns matching typeName, or empty string if none do.
+func matchTypePattern(patterns []*regexp.Regexp, typeName string) string {
+	for _, re := range patterns {
 		if re.MatchString(typeName) {
 			return re.String() // Return the pattern that matched
 		}
@@ -171,17 +212,21 @@ func (g *Generator) GenerateValidator(t *checker.Type, typeName string) Validato
 
 	// Reset state for each validator
 	g.ioFuncs = make([]string, 0)
+	g.ioBodies = make(map[string]string)
 	g.funcIdx = 0
 	g.visiting = make(map[string]bool)
 	g.depth = 0
 
 	// Generate validation statements
-	statements := g.generateValidation(t, "_v", "_n")
+	statements := g.collectedValidation(func() string {
+		return g.generateValidation(t, "_v", "_n")
+	})
 
 	// Build the validator function
 	// Use explicit 'any' types for strict mode compatibility
 	var sb strings.Builder
 	sb.WriteString("((_v: any, _n: string) => { ")
+	sb.WriteString(g.sampleGuard("_v"))
 
 	// Note: _got helper is hoisted at file level by the transformer, not inlined here
 
@@ -192,7 +237,12 @@ func (g *Generator) GenerateValidator(t *checker.Type, typeName string) Validato
 	}
 
 	// Add validation statements
-	sb.WriteString(statements)
+	sb.WriteString(g.warnOnFailure(t, statements))
+
+	// Values that only warned didn't pass, so they aren't branded
+	if !g.ShouldWarnType(t) {
+		sb.WriteString(g.brandStamp("_v"))
+	}
 
 	sb.WriteString("return _v; })")
 
@@ -234,17 +284,21 @@ func (g *Generator) GenerateValidatorFromNode(t *checker.Type, typeNode *ast.Nod
 
 	// Reset state for each validator
 	g.ioFuncs = make([]string, 0)
+	g.ioBodies = make(map[string]string)
 	g.funcIdx = 0
 	g.visiting = make(map[string]bool)
 	g.depth = 0
 
 	// Generate validation statements using node for better detection
-	statements := g.generateValidationFromNode(t, typeNode, "_v", "_n")
+	statements := g.collectedValidation(func() string {
+		return g.generateValidationFromNode(t, typeNode, "_v", "_n")
+	})
 
 	// Build the validator function
 	// Use explicit 'any' types for strict mode compatibility
 	var sb strings.Builder
 	sb.WriteString("((_v: any, _n: string) => { ")
+	sb.WriteString(g.sampleGuard("_v"))
 
 	// Note: _got helper is hoisted at file level by the transformer, not inlined here
 
@@ -255,7 +309,12 @@ func (g *Generator) GenerateValidatorFromNode(t *checker.Type, typeNode *ast.Nod
 	}
 
 	// Add validation statements
-	sb.WriteString(statements)
+	sb.WriteString(g.warnOnFailure(t, statements))
+
+	// Values that only warned didn't pass, so they aren't branded
+	if !g.ShouldWarnType(t) {
+		sb.WriteString(g.brandStamp("_v"))
+	}
 
 	sb.WriteString("return _v; })")
 
@@ -266,6 +325,7 @@ func (g *Generator) GenerateValidatorFromNode(t *checker.Type, typeNode *ast.Nod
 // Useful for testing individual type checks.
 func (g *Generator) GenerateIsCheck(t *checker.Type) string {
 	g.ioFuncs = make([]string, 0)
+	g.ioBodies = make(map[string]string)
 	g.funcIdx = 0
 	g.visiting = make(map[string]bool)
 	g.depth = 0
@@ -295,6 +355,7 @@ func (g *Generator) GenerateInlineValidationContinued(t *checker.Type, typeNode
 	// Don't reset - preserve funcIdx to avoid duplicate _io names
 	// Only clear the ioFuncs and visiting state for this generation
 	g.ioFuncs = make([]string, 0)
+	g.ioBodies = make(map[string]string)
 	g.visiting = make(map[string]bool)
 	g.depth = 0
 	g.complexityError = ""
@@ -303,6 +364,58 @@ func (g *Generator) GenerateInlineValidationContinued(t *checker.Type, typeNode
 	return g.generateInlineValidationInternal(t, typeNode, paramName)
 }
 
+// GenerateRestValidationContinued generates inline validation for a rest parameter such as
+// ...ids: number[]. The rest array is always an array, so only the arguments in it are
+// checked against the element type. Like GenerateInlineValidationContinued, funcIdx isn't
+// reset. Returns "" if t isn't an array type, e.g. a tuple, which is validated as usual.
+func (g *Generator) GenerateRestValidationContinued(t *checker.Type, typeNode *ast.Node, paramName string) string {
+	if !checker.Checker_isArrayType(g.checker, t) {
+		return ""
+	}
+	typeArgs := checker.Checker_getTypeArguments(g.checker, t)
+	if len(typeArgs) == 0 {
+		return ""
+	}
+	elemType := typeArgs[0]
+	if flags := checker.Type_flags(elemType); flags&checker.TypeFlagsAny != 0 || flags&checker.TypeFlagsUnknown != 0 {
+		return ""
+	}
+	var elemTypeNode *ast.Node
+	if typeNode != nil && typeNode.Kind == ast.KindArrayType {
+		elemTypeNode = typeNode.AsArrayTypeNode().ElementType
+	}
+
+	g.ioFuncs = make([]string, 0)
+	g.ioBodies = make(map[string]string)
+	g.visiting = make(map[string]bool)
+	g.depth = 0
+	g.complexityError = ""
+	g.typeStack = nil
+
+	idx := g.funcIdx
+	g.funcIdx++
+	iVar := fmt.Sprintf("_i%d", idx)
+	eVar := fmt.Sprintf("_e%d", idx)
+	elemNameExpr := g.appendArrayIndex(`"`+paramName+`"`, iVar)
+	validation := g.collectedValidation(func() string {
+		elemValidation := g.errorScope(func() string {
+			if elemTypeNode != nil {
+				return g.generateValidationFromNode(elemType, elemTypeNode, eVar, elemNameExpr)
+			}
+			return g.generateValidation(elemType, eVar, elemNameExpr)
+		})
+		if elemValidation == "" {
+			return ""
+		}
+		return fmt.Sprintf(`for (let %s = 0; %s < %s.length; %s++) { const %s: any = %s[%s]; %s} `,
+			iVar, iVar, paramName, iVar, eVar, paramName, iVar, elemValidation)
+	})
+	if validation == "" {
+		return ""
+	}
+	return g.inlineValidationBlock(t, validation)
+}
+
 // ResetFuncIdx resets the function index counter. Call this at the start of a new function scope.
 func (g *Generator) ResetFuncIdx() {
 	g.funcIdx = 0
@@ -312,15 +425,19 @@ func (g *Generator) ResetFuncIdx() {
 // In normal mode: throw new TypeError(errorExpr)
 // In returnErrors mode: return errorExpr
 // In returnTupleErrors mode: return [errorExpr, null]
+// While collecting errors: { _es.push(errorExpr); break <label>; }
 // The errorExpr should be a string expression that evaluates to the error message.
 func (g *Generator) throwOrReturn(errorExpr string) string {
+	if g.collecting {
+		return fmt.Sprintf("{ _es.push(%s); break %s; }", errorExpr, g.errorLabel)
+	}
 	if g.returnTupleErrors {
 		return fmt.Sprintf("return [%s, null]", errorExpr)
 	}
 	if g.returnErrors {
 		return fmt.Sprintf("return %s", errorExpr)
 	}
-	return fmt.Sprintf("throw new TypeError(%s)", errorExpr)
+	return g.ThrowError(errorExpr)
 }
 
 // isStringLiteral checks if the expression is a simple JS string literal (e.g., `"user"`)
@@ -408,14 +525,9 @@ func gotExprForWithValue(expr string) string {
 // The throw happens at the call site so stack traces point to the right location.
 func (g *Generator) validationError(condition, nameExpr, expected, expr string) string {
 	// Build error message: "Expected " + name + " to be <expected>, got " + gotExpr
-	errorMsg := g.buildErrorMessage(nameExpr, expected, gotExprFor(expr))
-	if g.returnTupleErrors {
-		return fmt.Sprintf(`if (!(%s)) return [%s, null]; `, condition, errorMsg)
-	}
-	if g.returnErrors {
-		return fmt.Sprintf(`if (!(%s)) return %s; `, condition, errorMsg)
-	}
-	return fmt.Sprintf(`if (!(%s)) throw new TypeError(%s); `, condition, errorMsg)
+	gotExpr := gotExprFor(expr)
+	errorMsg := g.buildErrorMessage(nameExpr, expected, gotExpr)
+	return fmt.Sprintf(`if (!(%s)) %s; `, condition, g.fail(errorMsg, nameExpr, expected, gotExpr, expr))
 }
 
 // validationErrorWithValue generates a conditional error with value display.
@@ -423,19 +535,17 @@ func (g *Generator) validationError(condition, nameExpr, expected, expr string)
 // Used for literal type mismatches where showing the value helps debugging.
 func (g *Generator) validationErrorWithValue(condition, nameExpr, expected, expr string) string {
 	// Build error message: "Expected " + name + " to be <expected>, got " + typeof + " (" + truncated_value + ")"
-	errorMsg := g.buildErrorMessage(nameExpr, expected, gotExprForWithValue(expr))
-	if g.returnTupleErrors {
-		return fmt.Sprintf(`if (!(%s)) return [%s, null]; `, condition, errorMsg)
-	}
-	if g.returnErrors {
-		return fmt.Sprintf(`if (!(%s)) return %s; `, condition, errorMsg)
-	}
-	return fmt.Sprintf(`if (!(%s)) throw new TypeError(%s); `, condition, errorMsg)
+	gotExpr := gotExprForWithValue(expr)
+	errorMsg := g.buildErrorMessage(nameExpr, expected, gotExpr)
+	return fmt.Sprintf(`if (!(%s)) %s; `, condition, g.fail(errorMsg, nameExpr, expected, gotExpr, expr))
 }
 
 // buildErrorMessage builds an optimised error message expression.
-// Format: "Expected " + name + " to be <expected>, got " + gotExpr
+// Format: "Expected " + name + " to be <expected>, got " + gotExpr, or the configured ErrorFormat.
 func (g *Generator) buildErrorMessage(nameExpr, expected, gotExpr string) string {
+	if g.errorFormat != "" {
+		return g.formatErrorMessage(nameExpr, expected, gotExpr)
+	}
 	// Optimise: if nameExpr is a string literal, combine at compile time
 	if isStringLiteral(nameExpr) {
 		return fmt.Sprintf(`"Expected %s to be %s, got "+%s`, extractStringLiteral(nameExpr), escapeJSString(expected), gotExpr)
@@ -456,16 +566,15 @@ func (g *Generator) buildErrorMessage(nameExpr, expected, gotExpr string) string
 
 // unconditionalError generates an unconditional error statement.
 // Used for cases like 'never' type or depth limit exceeded.
-func (g *Generator) unconditionalError(nameExpr, message string) string {
+// The message is appended to the name by default; with a custom ErrorFormat, expected
+// and the value of expr fill the template instead.
+func (g *Generator) unconditionalError(nameExpr, message, expected, expr string) string {
 	// Build error message: nameExpr + message
 	errorMsg := concatStrings(nameExpr, escapeJSStringQuoted(message))
-	if g.returnErrors || g.returnTupleErrors {
-		if g.returnTupleErrors {
-			return fmt.Sprintf(`return [%s, null]; `, errorMsg)
-		}
-		return fmt.Sprintf(`return %s; `, errorMsg)
+	if g.errorFormat != "" {
+		errorMsg = g.formatErrorMessage(nameExpr, expected, gotExprFor(expr))
 	}
-	return fmt.Sprintf(`throw new TypeError(%s); `, errorMsg)
+	return fmt.Sprintf(`%s; `, g.fail(errorMsg, nameExpr, expected, gotExprFor(expr), expr))
 }
 
 // CheckFunctionResult contains the result of check function generation.
@@ -507,6 +616,7 @@ func (g *Generator) GenerateCheckFunction(t *checker.Type, typeName string) Chec
 
 	// Reset state and enable returnErrors mode
 	g.ioFuncs = make([]string, 0)
+	g.ioBodies = make(map[string]string)
 	g.funcIdx = 0
 	g.visiting = make(map[string]bool)
 	g.depth = 0
@@ -514,14 +624,20 @@ func (g *Generator) GenerateCheckFunction(t *checker.Type, typeName string) Chec
 
 	// Generate validation statements that return errors
 	// Use "_n" as the name expression - it's passed as a parameter
-	statements := g.generateValidation(t, "_v", "_n")
+	statements := g.collectedValidation(func() string {
+		return g.generateValidation(t, "_v", "_n")
+	})
 
 	// Reset returnErrors mode
 	g.returnErrors = false
 
 	// Build the check function - takes (value, name) parameters
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("const %s = (_v: any, _n: string): string | null => { ", funcName))
+	sb.WriteString(g.functionHead(funcName, g.checkFunctionParams(), g.ErrorType()+" | null"))
+
+	// Skip values already stamped as validated, or already being checked further up a cycle
+	sb.WriteString(g.brandGuard("_v"))
+	sb.WriteString(g.cycleGuard(t))
 
 	// Add helper functions
 	for _, fn := range g.ioFuncs {
@@ -533,7 +649,10 @@ func (g *Generator) GenerateCheckFunction(t *checker.Type, typeName string) Chec
 	sb.WriteString(statements)
 
 	// Return null if validation passes
-	sb.WriteString("return null; }")
+	sb.WriteString(g.brandStamp("_v"))
+	sb.WriteString("return null; ")
+	sb.WriteString(g.cycleRelease(t))
+	sb.WriteString("}")
 
 	return CheckFunctionResult{
 		Name: funcName,
@@ -577,6 +696,7 @@ func (g *Generator) GenerateCheckFunctionFromNode(t *checker.Type, typeNode *ast
 
 	// Reset state and enable returnErrors mode
 	g.ioFuncs = make([]string, 0)
+	g.ioBodies = make(map[string]string)
 	g.funcIdx = 0
 	g.visiting = make(map[string]bool)
 	g.depth = 0
@@ -584,14 +704,20 @@ func (g *Generator) GenerateCheckFunctionFromNode(t *checker.Type, typeNode *ast
 
 	// Generate validation statements that return errors
 	// Use "_n" as the name expression - it's passed as a parameter
-	statements := g.generateValidationFromNode(t, typeNode, "_v", "_n")
+	statements := g.collectedValidation(func() string {
+		return g.generateValidationFromNode(t, typeNode, "_v", "_n")
+	})
 
 	// Reset returnErrors mode
 	g.returnErrors = false
 
 	// Build the check function - takes (value, name) parameters
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("const %s = (_v: any, _n: string): string | null => { ", funcName))
+	sb.WriteString(g.functionHead(funcName, g.checkFunctionParams(), g.ErrorType()+" | null"))
+
+	// Skip values already stamped as validated, or already being checked further up a cycle
+	sb.WriteString(g.brandGuard("_v"))
+	sb.WriteString(g.cycleGuard(t))
 
 	// Add helper functions
 	for _, fn := range g.ioFuncs {
@@ -603,7 +729,10 @@ func (g *Generator) GenerateCheckFunctionFromNode(t *checker.Type, typeNode *ast
 	sb.WriteString(statements)
 
 	// Return null if validation passes
-	sb.WriteString("return null; }")
+	sb.WriteString(g.brandStamp("_v"))
+	sb.WriteString("return null; ")
+	sb.WriteString(g.cycleRelease(t))
+	sb.WriteString("}")
 
 	return CheckFunctionResult{
 		Name: funcName,
@@ -666,6 +795,7 @@ func (g *Generator) GenerateFilterFunction(t *checker.Type, typeName string) Fil
 
 	// Reset state and enable returnTupleErrors mode for filter functions
 	g.ioFuncs = make([]string, 0)
+	g.ioBodies = make(map[string]string)
 	g.funcIdx = 0
 	g.visiting = make(map[string]bool)
 	g.depth = 0
@@ -680,7 +810,7 @@ func (g *Generator) GenerateFilterFunction(t *checker.Type, typeName string) Fil
 
 	// Build the filter function - takes (value, name) parameters, returns [error, result] tuple
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("const %s = (_v: any, _n: string): [string | null, any] => { ", funcName))
+	sb.WriteString(g.functionHead(funcName, "_v: any, _n: string", "[string | null, any]"))
 
 	// Add helper functions
 	for _, fn := range g.ioFuncs {
@@ -736,6 +866,7 @@ func (g *Generator) GenerateFilterFunctionFromNode(t *checker.Type, typeNode *as
 
 	// Reset state and enable returnTupleErrors mode for filter functions
 	g.ioFuncs = make([]string, 0)
+	g.ioBodies = make(map[string]string)
 	g.funcIdx = 0
 	g.visiting = make(map[string]bool)
 	g.depth = 0
@@ -750,7 +881,7 @@ func (g *Generator) GenerateFilterFunctionFromNode(t *checker.Type, typeNode *as
 
 	// Build the filter function - takes (value, name) parameters, returns [error, result] tuple
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("const %s = (_v: any, _n: string): [string | null, any] => { ", funcName))
+	sb.WriteString(g.functionHead(funcName, "_v: any, _n: string", "[string | null, any]"))
 
 	// Add helper functions
 	for _, fn := range g.ioFuncs {
@@ -772,12 +903,20 @@ func (g *Generator) GenerateFilterFunctionFromNode(t *checker.Type, typeNode *as
 
 // generateInlineValidationInternal is the common implementation for inline validation.
 func (g *Generator) generateInlineValidationInternal(t *checker.Type, typeNode *ast.Node, paramName string) string {
-	var validation string
-	if typeNode != nil {
-		validation = g.generateValidationFromNode(t, typeNode, paramName, `"`+paramName+`"`)
-	} else {
-		validation = g.generateValidation(t, paramName, `"`+paramName+`"`)
-	}
+	validation := g.collectedValidation(func() string {
+		if typeNode != nil {
+			return g.generateValidationFromNode(t, typeNode, paramName, `"`+paramName+`"`)
+		}
+		return g.generateValidation(t, paramName, `"`+paramName+`"`)
+	})
+
+	return g.inlineValidationBlock(t, validation)
+}
+
+// inlineValidationBlock finishes inline validation of a value of type t, prepending any
+// helper functions it uses.
+func (g *Generator) inlineValidationBlock(t *checker.Type, validation string) string {
+	validation = g.warnOnFailure(t, validation)
 
 	// If there are helper functions, prepend them to the validation
 	// Note: _got helper is hoisted at file level by the transformer, not inlined here
@@ -788,15 +927,16 @@ func (g *Generator) generateInlineValidationInternal(t *checker.Type, typeNode *
 			sb.WriteString("; ")
 		}
 		sb.WriteString(validation)
-		return sb.String()
+		return g.sampleBlock(sb.String())
 	}
 
-	return validation
+	return g.sampleBlock(validation)
 }
 
 // GenerateIsCheckFromNode generates an is-check using the type node to detect arrays.
 func (g *Generator) GenerateIsCheckFromNode(t *checker.Type, typeNode *ast.Node) string {
 	g.ioFuncs = make([]string, 0)
+	g.ioBodies = make(map[string]string)
 	g.funcIdx = 0
 	g.visiting = make(map[string]bool)
 	g.depth = 0
@@ -814,6 +954,12 @@ func (g *Generator) GetComplexityError() string {
 	return g.complexityError
 }
 
+// GetDirectiveError returns the first malformed JSDoc tag, such as an invalid @pattern,
+// found during generation. Returns empty string if there wasn't one.
+func (g *Generator) GetDirectiveError() string {
+	return g.directiveError
+}
+
 // checkComplexityLimit checks if we've exceeded the max generated functions limit.
 // If exceeded, sets complexityError with details about the type and location.
 // Returns true if limit exceeded (generation should stop).
@@ -841,9 +987,10 @@ func (g *Generator) checkComplexityLimit(typeName string) bool {
 
 // checkComplexityLimitWithType is like checkComplexityLimit but accepts a Type for richer error info.
 // It extracts source file location and property names from the type.
+// With degradeOnComplexity, exceeding the limit isn't an error - the caller just stops descending.
 func (g *Generator) checkComplexityLimitWithType(t *checker.Type) bool {
 	if g.maxGeneratedFunctions > 0 && len(g.ioFuncs) >= g.maxGeneratedFunctions {
-		if g.complexityError == "" {
+		if g.complexityError == "" && !g.degradeOnComplexity {
 			typeName := "anonymous"
 			sourceFile := ""
 			var propNames []string
@@ -923,6 +1070,150 @@ func (g *Generator) ClearContext() {
 	g.typeStack = nil
 }
 
+// DefaultErrorFormat is the error message template used when no ErrorFormat is set.
+const DefaultErrorFormat = "Expected %path to be %expected, got %got"
+
+// SetErrorFormat sets the error message template. The placeholders %path (the value's name,
+// e.g. "user.address.street"), %expected and %got are substituted at runtime.
+// An empty format, or DefaultErrorFormat, keeps the built-in messages.
+func (g *Generator) SetErrorFormat(format string) {
+	if format == DefaultErrorFormat {
+		format = ""
+	}
+	g.errorFormat = format
+}
+
+// formatErrorMessage renders the error message template as a JS string expression.
+func (g *Generator) formatErrorMessage(nameExpr, expected, gotExpr string) string {
+	placeholders := []struct{ name, expr string }{
+		{"%path", nameExpr},
+		{"%expected", escapeJSStringQuoted(expected)},
+		{"%got", gotExpr},
+	}
+
+	result := `""`
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			result = concatStrings(result, escapeJSStringQuoted(literal.String()))
+			literal.Reset()
+		}
+	}
+
+	rest := g.errorFormat
+	for rest != "" {
+		matched := false
+		for _, ph := range placeholders {
+			if strings.HasPrefix(rest, ph.name) {
+				flush()
+				result = concatStrings(result, ph.expr)
+				rest = rest[len(ph.name):]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			literal.WriteByte(rest[0])
+			rest = rest[1:]
+		}
+	}
+	flush()
+	return result
+}
+
+// tupleLengthErrorMessage builds the error message for a tuple with too few elements.
+func (g *Generator) tupleLengthErrorMessage(nameExpr string, minLength int, expr string) string {
+	if g.errorFormat != "" {
+		return g.formatErrorMessage(nameExpr, fmt.Sprintf("at least %d elements", minLength), expr+".length")
+	}
+	lenErrorMsg := concatStrings(`"Expected "`, nameExpr)
+	return concatStrings(lenErrorMsg, fmt.Sprintf(`" to have at least %d elements, got " + %s.length`, minLength, expr))
+}
+
+// SetStrictOptionalPresence controls how optional properties are detected.
+// When enabled, an optional property is validated whenever it is an own property of the object,
+// so `{ prop: undefined }` is rejected for `prop?: T` unless T includes undefined.
+func (g *Generator) SetStrictOptionalPresence(strict bool) {
+	g.strictOptionalPresence = strict
+}
+
+// SetRejectEmptyObjects controls whether required properties must be present as keys.
+// Properties typed any, unknown or including undefined otherwise accept a missing key, so
+// `{}` would pass for `{ data: unknown }`.
+func (g *Generator) SetRejectEmptyObjects(reject bool) {
+	g.rejectEmptyObjects = reject
+}
+
+// SetObjectAcceptsFunctions controls whether the broad `object` type accepts functions.
+// TypeScript allows assigning a function to `object`, but most code using `object` means
+// a plain data object, so by default only non-null objects pass.
+func (g *Generator) SetObjectAcceptsFunctions(accept bool) {
+	g.objectAcceptsFunctions = accept
+}
+
+// SetDegradeOnComplexity controls what happens when a type needs more helper functions than
+// maxGeneratedFunctions allows. By default generation fails with a complexity error; when
+// enabled, objects past the limit are only checked to be objects, marked /* depth-limited */.
+func (g *Generator) SetDegradeOnComplexity(degrade bool) {
+	g.degradeOnComplexity = degrade
+}
+
+// SetCrossRealmBuiltins controls how built-in class instances (Date, Map, ...) are checked.
+// instanceof fails for values created in another realm, such as an iframe or a vm context,
+// which has its own Date; when enabled, built-ins with a well-known toStringTag are checked
+// with Object.prototype.toString instead. Arrays always use the realm-safe Array.isArray.
+func (g *Generator) SetCrossRealmBuiltins(crossRealm bool) {
+	g.crossRealmBuiltins = crossRealm
+}
+
+// SetRejectDroppedFunctions controls whether filter functions reject function-valued
+// properties the type doesn't declare. Filtering drops undeclared properties, which for
+// JSON.stringify hides a function that was meant to be serialised as data.
+func (g *Generator) SetRejectDroppedFunctions(reject bool) {
+	g.rejectDroppedFunctions = reject
+}
+
+// SetFunctionDeclarations controls how check and filter functions are declared. By default
+// they're arrow functions assigned to consts, which can't be called before the assignment
+// runs; function declarations are hoisted, so the order they're emitted in doesn't matter.
+func (g *Generator) SetFunctionDeclarations(declarations bool) {
+	g.functionDeclarations = declarations
+}
+
+// SetStrictNumbers controls whether the number type accepts NaN, Infinity and -Infinity.
+// typeof reports "number" for all three, but they're rarely wanted and JSON can't represent
+// them. Number literal types are checked by value either way.
+func (g *Generator) SetStrictNumbers(strict bool) {
+	g.strictNumbers = strict
+}
+
+// SetIntegerBrand sets the brand marker that declares a branded number an integer, so
+// number & { __int: true } is checked with Number.isInteger. "" turns the marker off.
+func (g *Generator) SetIntegerBrand(name string) {
+	g.integerBrand = name
+}
+
+// SetValidateDateValues controls whether Date-typed values must be valid dates. An
+// instanceof check accepts new Date("garbage"), whose time is NaN.
+func (g *Generator) SetValidateDateValues(validate bool) {
+	g.validateDateValues = validate
+}
+
+// SetValidateFunctionArity controls whether function-typed values must also take at least
+// as many parameters as the type declares, so a callback of the wrong shape fails.
+func (g *Generator) SetValidateFunctionArity(validate bool) {
+	g.validateFunctionArity = validate
+}
+
+// functionHead returns the start of a check or filter function declaration, up to and
+// including the opening brace of its body.
+func (g *Generator) functionHead(name, params, returnType string) string {
+	if g.functionDeclarations {
+		return fmt.Sprintf("function %s(%s): %s { ", name, params, returnType)
+	}
+	return fmt.Sprintf("const %s = (%s): %s => { ", name, params, returnType)
+}
+
 // SetAvailableCheckFunctions sets the map of available reusable check functions.
 // When generating validation for a type that has an entry in this map,
 // the generator will call the check function instead of inlining validation.
@@ -934,6 +1225,16 @@ func (g *Generator) SetAvailableCheckFunctions(funcs map[string]string) {
 // expr: the expression to validate (e.g. "_v", "_v.name")
 // nameExpr: JS expression for the name in error messages (e.g. "_n", "_n + '.name'")
 func (g *Generator) generateValidation(t *checker.Type, expr string, nameExpr string) string {
+	statements := g.typeValidation(t, expr, nameExpr)
+	// The root type's warning is handled by the caller (see warnOnFailure)
+	if g.depth > 0 && g.ShouldWarnType(t) {
+		return g.nestedWarnOnFailure(statements)
+	}
+	return statements
+}
+
+// typeValidation generates the validation statements for generateValidation.
+func (g *Generator) typeValidation(t *checker.Type, expr string, nameExpr string) string {
 	flags := checker.Type_flags(t)
 
 	// Handle any/unknown - skip validation
@@ -943,7 +1244,7 @@ func (g *Generator) generateValidation(t *checker.Type, expr string, nameExpr st
 
 	// Depth limit - complex types like React.FormEvent have very deep hierarchies
 	if g.depth > MaxTypeDepth {
-		return g.unconditionalError(nameExpr, " - Type validation too deep, likely a complex library type")
+		return g.unconditionalError(nameExpr, " - Type validation too deep, likely a complex library type", "a shallower type", expr)
 	}
 	g.depth++
 	defer func() { g.depth-- }()
@@ -953,20 +1254,21 @@ func (g *Generator) generateValidation(t *checker.Type, expr string, nameExpr st
 	if g.depth > 1 && g.availableCheckFunctions != nil {
 		typeStr := g.checker.TypeToString(t)
 		if checkFuncName, ok := g.availableCheckFunctions[typeStr]; ok {
-			// Generate a call to the reusable check function
-			if g.returnErrors {
-				// In returnErrors mode: return the error message
-				return fmt.Sprintf(`{ const _t = %s(%s, %s); if (_t !== null) return _t; } `, checkFuncName, expr, nameExpr)
-			} else if g.returnTupleErrors {
-				// In returnTupleErrors mode: return [error, null] tuple
-				return fmt.Sprintf(`{ const _t = %s(%s, %s); if (_t !== null) return [_t, null]; } `, checkFuncName, expr, nameExpr)
-			} else {
-				// In inline validation mode: throw the error
-				return fmt.Sprintf(`{ const _t = %s(%s, %s); if (_t !== null) throw new TypeError(_t); } `, checkFuncName, expr, nameExpr)
+			// Generate a call to the reusable check function, failing with its error.
+			// Filter functions return messages, so they take a structured error's message
+			failure := "_t"
+			if g.returnTupleErrors {
+				failure = g.ErrorMessage("_t")
 			}
+			return fmt.Sprintf(`{ const _t = %s(%s, %s%s); if (_t !== null) %s; } `, checkFuncName, expr, nameExpr, g.seenArg(), g.throwOrReturn(failure))
 		}
 	}
 
+	// A `@typical-error "..."` directive on the type alias replaces the detailed errors
+	if message := g.typeErrorMessage(t); message != "" {
+		return g.customErrorValidation(t, expr, nameExpr, message)
+	}
+
 	// Cycle detection for recursive types - use type key based on symbol
 	typeKey := getTypeKey(t)
 	if typeKey != "" {
@@ -983,7 +1285,7 @@ func (g *Generator) generateValidation(t *checker.Type, expr string, nameExpr st
 
 	// Handle never - always fails
 	if flags&checker.TypeFlagsNever != 0 {
-		return g.unconditionalError(nameExpr, " should never have a value")
+		return g.unconditionalError(nameExpr, " should never have a value", "never", expr)
 	}
 
 	// Template literal types
@@ -1006,8 +1308,12 @@ func (g *Generator) generateValidation(t *checker.Type, expr string, nameExpr st
 		return g.intersectionValidation(t, expr, nameExpr)
 	}
 
-	// Objects (includes arrays, tuples, interfaces)
-	if flags&checker.TypeFlagsObject != 0 {
+	// Objects (includes arrays, tuples, interfaces, and the broad object and {} types)
+	if flags&(checker.TypeFlagsObject|checker.TypeFlagsNonPrimitive) != 0 {
+		// Broad object types say nothing about properties, only what kind of value fits
+		if check, expected := g.broadObjectCheck(t, expr); check != "" {
+			return g.validationError(check, nameExpr, expected, expr)
+		}
 		// Function types can only be validated with typeof === "function"
 		if g.isFunctionType(t) {
 			// Try to get a meaningful type name for the error message
@@ -1016,6 +1322,13 @@ func (g *Generator) generateValidation(t *checker.Type, expr string, nameExpr st
 				typeName = sym.Name
 			}
 			check := fmt.Sprintf(`"function" === typeof %s`, expr)
+			if arity := g.functionArity(t); arity > 0 {
+				check = fmt.Sprintf(`%s && %s.length >= %d`, check, expr, arity)
+				typeName = fmt.Sprintf("%s taking at least %d parameter", typeName, arity)
+				if arity > 1 {
+					typeName += "s"
+				}
+			}
 			return g.validationError(check, nameExpr, typeName, expr)
 		}
 		return g.objectValidation(t, expr, nameExpr)
@@ -1051,6 +1364,47 @@ func (g *Generator) isFunctionType(t *checker.Type) bool {
 	return false
 }
 
+// functionArity returns how many parameters a function type requires, for checking a
+// function's length against when validateFunctionArity is set. It's 0, meaning no check, if
+// the type has more than one signature, any optional or rest parameters, or isn't declared
+// by a function type or function.
+func (g *Generator) functionArity(t *checker.Type) int {
+	if !g.validateFunctionArity || len(checker.Checker_getSignaturesOfType(g.checker, t, checker.SignatureKindCall)) != 1 {
+		return 0
+	}
+	sym := checker.Type_symbol(t)
+	if sym == nil || len(sym.Declarations) != 1 {
+		return 0
+	}
+	var params *ast.NodeList
+	switch decl := sym.Declarations[0]; decl.Kind {
+	case ast.KindFunctionType:
+		params = decl.AsFunctionTypeNode().Parameters
+	case ast.KindFunctionDeclaration:
+		params = decl.AsFunctionDeclaration().Parameters
+	case ast.KindFunctionExpression:
+		params = decl.AsFunctionExpression().Parameters
+	case ast.KindArrowFunction:
+		params = decl.AsArrowFunction().Parameters
+	}
+	if params == nil {
+		return 0
+	}
+	arity := 0
+	for _, node := range params.Nodes {
+		param := node.AsParameterDeclaration()
+		// A this parameter isn't counted in a function's length
+		if name := param.Name(); name != nil && name.Kind == ast.KindIdentifier && name.AsIdentifier().Text == "this" {
+			continue
+		}
+		if param.QuestionToken != nil || param.Initializer != nil || param.DotDotDotToken != nil {
+			return 0
+		}
+		arity++
+	}
+	return arity
+}
+
 // isBuiltInWithToJSON checks if a type is a built-in type that has toJSON method
 // and should be passed through to JSON.stringify rather than filtered.
 // Examples: Date, Map, Set, RegExp (though RegExp becomes {} in JSON).
@@ -1145,6 +1499,8 @@ func (g *Generator) isClassType(t *checker.Type) bool {
 
 // generateValidationFromNode generates validation using AST node for better detection.
 func (g *Generator) generateValidationFromNode(t *checker.Type, typeNode *ast.Node, expr string, nameExpr string) string {
+	typeNode = unwrapReadonlyArrayNode(typeNode)
+
 	// Check AST node kind first for array types
 	if typeNode != nil && typeNode.Kind == ast.KindArrayType {
 		return g.arrayValidationFromNode(t, typeNode, expr, nameExpr)
@@ -1154,6 +1510,24 @@ func (g *Generator) generateValidationFromNode(t *checker.Type, typeNode *ast.No
 	return g.generateValidation(t, expr, nameExpr)
 }
 
+// unwrapReadonlyArrayNode returns the array type inside `readonly T[]`, which is a type
+// operator rather than an array type node. Other nodes are returned unchanged.
+func unwrapReadonlyArrayNode(typeNode *ast.Node) *ast.Node {
+	if typeNode == nil || typeNode.Kind != ast.KindTypeOperator {
+		return typeNode
+	}
+	if op := typeNode.AsTypeOperatorNode(); op.Operator == ast.KindReadonlyKeyword && op.Type != nil && op.Type.Kind == ast.KindArrayType {
+		return op.Type
+	}
+	return typeNode
+}
+
+// isArraySymbolName reports whether a type's symbol name is one of the array interfaces.
+// ReadonlyArray<T> and readonly T[] are plain arrays at runtime.
+func isArraySymbolName(name string) bool {
+	return name == "Array" || name == "ReadonlyArray"
+}
+
 // primitiveValidation generates validation for primitive types.
 func (g *Generator) primitiveValidation(t *checker.Type, expr string, nameExpr string) string {
 	flags := checker.Type_flags(t)
@@ -1201,12 +1575,20 @@ func (g *Generator) primitiveValidation(t *checker.Type, expr string, nameExpr s
 			expected = "boolean"
 			check = fmt.Sprintf(`"boolean" === typeof %s`, expr)
 		}
+	case flags&checker.TypeFlagsBigIntLiteral != 0:
+		if literal := bigIntLiteral(t); literal != "" {
+			expected = literal
+			check = fmt.Sprintf(`%s === %s`, literal, expr)
+			isLiteral = true
+		} else {
+			expected = "bigint"
+			check = fmt.Sprintf(`"bigint" === typeof %s`, expr)
+		}
 	case flags&checker.TypeFlagsString != 0:
 		expected = "string"
 		check = fmt.Sprintf(`"string" === typeof %s`, expr)
 	case flags&checker.TypeFlagsNumber != 0:
-		expected = "number"
-		check = fmt.Sprintf(`"number" === typeof %s`, expr)
+		check, expected = g.numberCheck(expr)
 	case flags&checker.TypeFlagsBoolean != 0:
 		expected = "boolean"
 		check = fmt.Sprintf(`"boolean" === typeof %s`, expr)
@@ -1231,6 +1613,11 @@ func (g *Generator) primitiveValidation(t *checker.Type, expr string, nameExpr s
 	if isLiteral {
 		return g.validationErrorWithValue(check, nameExpr, expected, expr)
 	}
+	if g.strictNumbers && flags&checker.TypeFlagsNumber != 0 {
+		gotExpr := numberGotExpr(gotExprFor(expr), expr)
+		errorMsg := g.buildErrorMessage(nameExpr, expected, gotExpr)
+		return fmt.Sprintf(`if (!(%s)) %s; `, check, g.fail(errorMsg, nameExpr, expected, gotExpr, expr))
+	}
 	return g.validationError(check, nameExpr, expected, expr)
 }
 
@@ -1288,17 +1675,26 @@ func (g *Generator) unionValidation(t *checker.Type, expr string, nameExpr strin
 				// Generate: if (undefined === expr) { } else { call check function }
 				var sb strings.Builder
 				sb.WriteString(fmt.Sprintf("if (undefined === %s) { } else { ", expr))
-				sb.WriteString(fmt.Sprintf("const _t = %s(%s, %s); if (_t !== null) return _t; ", checkFuncName, expr, nameExpr))
+				sb.WriteString(fmt.Sprintf("const _t = %s(%s, %s%s); if (_t !== null) %s; ", checkFuncName, expr, nameExpr, g.seenArg(), g.throwOrReturn("_t")))
 				sb.WriteString("} ")
 				return sb.String()
 			}
 		}
 	}
 
+	// Discriminated unions ({ kind: "a", ... } | { kind: "b", ... }) switch on the tag,
+	// which matters for arrays of big unions where every element would try each member.
+	// Nullable ones (Shape | null) check for null and undefined first
+	if nullish, objects := splitNullish(members); len(objects) > 1 {
+		if prop, literals := g.discriminant(objects); prop != "" {
+			return g.discriminatedUnionValidation(t, objects, nullish, prop, literals, expr, nameExpr)
+		}
+	}
+
 	var sb strings.Builder
 
-	// Generate if-else chain for each member
-	for i, member := range members {
+	// Generate if-else chain for each member, cheapest checks first
+	for i, member := range orderUnionMembers(members) {
 		check := g.generateCheck(member, expr)
 		if i == 0 {
 			sb.WriteString(fmt.Sprintf("if (%s) { } ", check))
@@ -1312,7 +1708,7 @@ func (g *Generator) unionValidation(t *checker.Type, expr string, nameExpr strin
 	// For unions of literals (string/number/boolean), show the actual value in the error
 	gotExpr := g.getGotExpression(t, expr)
 	errorMsg := g.buildErrorMessage(nameExpr, expected, gotExpr)
-	sb.WriteString(fmt.Sprintf(`else %s; `, g.throwOrReturn(errorMsg)))
+	sb.WriteString(fmt.Sprintf(`else %s; `, g.fail(errorMsg, nameExpr, expected, gotExpr, expr)))
 
 	return sb.String()
 }
@@ -1322,28 +1718,24 @@ func (g *Generator) intersectionValidation(t *checker.Type, expr string, nameExp
 	members := t.Types()
 
 	// Handle branded/opaque types: primitive & { readonly __brand: ... }
-	// These are compile-time only - at runtime, just validate the primitive.
-	// A branded type has exactly 2 parts: a primitive and an object (the brand).
-	if len(members) == 2 {
-		var primitiveType *checker.Type
-		var objectType *checker.Type
-
-		for _, m := range members {
-			mFlags := checker.Type_flags(m)
-			// Check for primitive types (string, number, bigint, symbol)
-			if mFlags&(checker.TypeFlagsString|checker.TypeFlagsNumber|checker.TypeFlagsBigInt|checker.TypeFlagsESSymbol) != 0 {
-				primitiveType = m
-			} else if mFlags&checker.TypeFlagsObject != 0 {
-				objectType = m
-			}
+	// These are compile-time only - at runtime, just validate the primitive,
+	// plus any range the brand declares (number & { __min: 0, __max: 100 })
+	if primitiveType, brandType := g.brandParts(members); primitiveType != nil {
+		validation := g.generateValidation(primitiveType, expr, nameExpr)
+		if g.isIntegerBrand(primitiveType, brandType) {
+			validation = g.validationErrorWithValue(fmt.Sprintf("Number.isInteger(%s)", expr), nameExpr, "integer", expr)
 		}
-
-		// If we have a primitive and an object, check if the object looks like a brand
-		// (has only phantom/brand properties like __brand, _tag, _type, etc.)
-		if primitiveType != nil && objectType != nil && g.isBrandObject(objectType) {
-			// Just validate the primitive - the brand is compile-time only
-			return g.generateValidation(primitiveType, expr, nameExpr)
+		if condition, expected := g.brandRange(primitiveType, brandType, expr); condition != "" {
+			validation += g.validationErrorWithValue(condition, nameExpr, expected, expr)
 		}
+		return validation
+	}
+
+	// Intersections of plain object types (A & B & C) are validated as a single object. The
+	// checker merges their properties, intersecting the types of any declared by more than
+	// one member, so object-ness is checked once and each property in one pass
+	if g.isMergeableObjectIntersection(members) {
+		return g.objectValidation(t, expr, nameExpr)
 	}
 
 	// For regular intersections, validate each constituent
@@ -1357,9 +1749,74 @@ func (g *Generator) intersectionValidation(t *checker.Type, expr string, nameExp
 	return strings.Join(statements, "")
 }
 
+// brandParts splits a branded type (primitive & { readonly __brand: ... }) into its primitive
+// and brand object. A branded type has exactly 2 parts; returns nils for anything else.
+func (g *Generator) brandParts(members []*checker.Type) (primitiveType, brandType *checker.Type) {
+	if len(members) != 2 {
+		return nil, nil
+	}
+	for _, m := range members {
+		mFlags := checker.Type_flags(m)
+		// Check for primitive types (string, number, bigint, symbol)
+		if mFlags&(checker.TypeFlagsString|checker.TypeFlagsNumber|checker.TypeFlagsBigInt|checker.TypeFlagsESSymbol) != 0 {
+			primitiveType = m
+		} else if mFlags&checker.TypeFlagsObject != 0 {
+			brandType = m
+		}
+	}
+	// The object must look like a brand (only phantom properties like __brand, _tag, _type, etc.)
+	if primitiveType == nil || brandType == nil || !g.isBrandObject(brandType) {
+		return nil, nil
+	}
+	return primitiveType, brandType
+}
+
+// brandRange returns the range check for expr declared by a numeric brand's __min/__max
+// markers, and its description for errors, e.g. "number between 0 and 100". Returns ""
+// if the brand has no range.
+func (g *Generator) brandRange(primitiveType, brandType *checker.Type, expr string) (condition, expected string) {
+	if checker.Type_flags(primitiveType)&checker.TypeFlagsNumber == 0 {
+		return "", ""
+	}
+	lower := g.brandBound(brandType, "__min")
+	upper := g.brandBound(brandType, "__max")
+	switch {
+	case lower != "" && upper != "":
+		return fmt.Sprintf("%s >= %s && %s <= %s", expr, lower, expr, upper), fmt.Sprintf("number between %s and %s", lower, upper)
+	case lower != "":
+		return fmt.Sprintf("%s >= %s", expr, lower), "number >= " + lower
+	case upper != "":
+		return fmt.Sprintf("%s <= %s", expr, upper), "number <= " + upper
+	}
+	return "", ""
+}
+
+// isIntegerBrand checks whether a branded number's brand carries the integer marker,
+// e.g. number & { __int: true }.
+func (g *Generator) isIntegerBrand(primitiveType, brandType *checker.Type) bool {
+	if g.integerBrand == "" || checker.Type_flags(primitiveType)&checker.TypeFlagsNumber == 0 {
+		return false
+	}
+	return checker.Checker_getPropertyOfType(g.checker, brandType, g.integerBrand) != nil
+}
+
+// brandBound returns the numeric literal value of a brand's range marker, or "".
+func (g *Generator) brandBound(brandType *checker.Type, name string) string {
+	prop := checker.Checker_getPropertyOfType(g.checker, brandType, name)
+	if prop == nil {
+		return ""
+	}
+	propType := checker.Checker_getTypeOfSymbol(g.checker, prop)
+	if checker.Type_flags(propType)&checker.TypeFlagsNumberLiteral == 0 {
+		return ""
+	}
+	return literalExpr(propType)
+}
+
 // isBrandObject checks if an object type looks like a branding/phantom type.
 // These are objects with only properties like __brand, _tag, _type, __opaque, etc.
-// that are used only for compile-time type discrimination.
+// that are used only for compile-time type discrimination, or the numeric range
+// markers __min and __max, or the integer marker (__int by default).
 func (g *Generator) isBrandObject(t *checker.Type) bool {
 	props := checker.Checker_getPropertiesOfType(g.checker, t)
 	if len(props) == 0 {
@@ -1369,6 +1826,16 @@ func (g *Generator) isBrandObject(t *checker.Type) bool {
 	// All properties must look like brand markers
 	for _, prop := range props {
 		name := prop.Name
+		if (name == "__min" || name == "__max") && g.brandBound(t, name) != "" {
+			continue
+		}
+		if g.integerBrand != "" && name == g.integerBrand {
+			continue
+		}
+		// string & { [brand]: true } with a unique symbol brand can't be a real property
+		if isSymbolKeyedProperty(prop) {
+			continue
+		}
 		// Common branding patterns: __brand, _brand, __tag, _tag, __type, __opaque, __nominal
 		if !strings.HasPrefix(name, "__") && !strings.HasPrefix(name, "_") {
 			return false
@@ -1398,14 +1865,29 @@ func (g *Generator) objectValidation(t *checker.Type, expr string, nameExpr stri
 	}
 
 	// Check for Array type via symbol name
-	if sym := checker.Type_symbol(t); sym != nil && sym.Name == "Array" {
+	if sym := checker.Type_symbol(t); sym != nil && isArraySymbolName(sym.Name) {
 		return g.arrayValidation(t, expr, nameExpr)
 	}
 
+	// Maps validate their entries (ReadonlyMap is an interface, so isn't a built-in class)
+	if keyType, valueType, ok := g.mapEntryTypes(t); ok {
+		return g.mapValidation(keyType, valueType, expr, nameExpr)
+	}
+
+	// Sets validate their members (ReadonlySet is an interface, so isn't a built-in class)
+	if elemType, ok := g.setElementType(t); ok {
+		return g.setValidation(elemType, expr, nameExpr)
+	}
+
 	// Built-in classes use instanceof check - they're classes at runtime
 	if className := g.isBuiltinClassType(t); className != "" {
-		check := fmt.Sprintf(`%s instanceof %s`, expr, className)
-		return g.validationError(check, nameExpr, className+" instance", expr)
+		check := g.builtinInstanceCheck(expr, className)
+		validation := g.validationError(check, nameExpr, className+" instance", expr)
+		if valid := g.validDateCheck(expr, className); valid != "" {
+			errorMsg := g.buildErrorMessage(nameExpr, "a valid Date", `"Invalid Date"`)
+			validation += fmt.Sprintf(`if (!(%s)) %s; `, valid, g.fail(errorMsg, nameExpr, "a valid Date", `"Invalid Date"`, expr))
+		}
+		return validation
 	}
 
 	// Check if this is a class type - use instanceof check
@@ -1434,9 +1916,9 @@ func (g *Generator) objectValidation(t *checker.Type, expr string, nameExpr stri
 	sb.WriteString(g.validationError(check, nameExpr, typeName, expr))
 
 	// Validate each property
-	props := checker.Checker_getPropertiesOfType(g.checker, t)
+	props := g.sortedProperties(t)
 	for _, prop := range props {
-		propType := checker.Checker_getTypeOfSymbol(g.checker, prop)
+		propType := g.propertyReadType(prop)
 		propName := prop.Name
 
 		// Handle 'never' type properties - they must NOT be defined
@@ -1459,35 +1941,70 @@ func (g *Generator) objectValidation(t *checker.Type, expr string, nameExpr stri
 		// Generate name expression for error messages (optimised for static names)
 		propNameExpr := g.appendToName(nameExpr, "."+propName)
 
-		// Generate validation for this property
-		propValidation := g.generateValidation(propType, accessor, propNameExpr)
+		// Generate validation for this property, then any @pattern or range tags it has
+		propValidation := g.errorScope(func() string {
+			if message := g.propertyErrorMessage(prop, propType); message != "" {
+				return g.customErrorValidation(propType, accessor, propNameExpr, message)
+			}
+			return g.generateValidation(propType, accessor, propNameExpr) + g.refinementValidation(prop, propType, accessor, propNameExpr)
+		})
 
 		if isOptionalProperty(prop) {
-			// Optional: only validate if defined
-			if propValidation != "" {
-				sb.WriteString(fmt.Sprintf(`if (%s !== undefined) { %s} `, accessor, propValidation))
+			// Optional: only validate if present
+			guard, presenceValidation := g.optionalPropertyGuard(prop, propType, expr, accessor, propNameExpr)
+			if propValidation != "" || presenceValidation != "" {
+				sb.WriteString(fmt.Sprintf(`if (%s) { %s%s} `, guard, presenceValidation, propValidation))
 			}
 		} else {
+			if presence := g.requiredPresenceCheck(prop, propType, expr); presence != "" {
+				sb.WriteString(g.validationError(presence, propNameExpr, "present", accessor))
+			}
 			sb.WriteString(propValidation)
 		}
 	}
 
-	// Check for string index signature and validate all values
-	stringType := checker.Checker_stringType(g.checker)
-	if stringType != nil {
-		indexValueType := checker.Checker_getIndexTypeOfType(g.checker, t, stringType)
-		if indexValueType != nil {
-			// Generate validation for index signature values
-			// Use for...in loop to validate all values
+	// Check for an index signature and validate the values of the remaining keys
+	// (skipped for any and unknown value types, as elsewhere)
+	indexValueType, numericKeys := g.indexSignatureValueType(t)
+	if indexValueType != nil && checker.Type_flags(indexValueType)&(checker.TypeFlagsAny|checker.TypeFlagsUnknown) == 0 {
+		// Use for...in loop to validate all values
+		idx := g.funcIdx
+		g.funcIdx++
+		kVar := fmt.Sprintf("_k%d", idx)
+		vVar := fmt.Sprintf("_v%d", idx)
+		valNameExpr := g.appendArrayIndex(nameExpr, kVar)
+		valueValidation := g.memberValidation(indexValueType, vVar, valNameExpr)
+		if valueValidation != "" {
+			// Declared properties have been validated above, and a number index signature
+			// says nothing about keys that aren't numeric strings
+			skip := declaredKeyCondition(kVar, props)
+			if numericKeys {
+				skip = strings.TrimPrefix(fmt.Sprintf("%s || String(+%s) !== %s", skip, kVar, kVar), " || ")
+			}
+			if skip != "" {
+				skip = fmt.Sprintf("if (%s) continue; ", skip)
+			}
+			sb.WriteString(fmt.Sprintf(`for (const %s in %s) { %sconst %s: any = %s[%s]; %s} `,
+				kVar, expr, skip, vVar, expr, kVar, valueValidation))
+		}
+	}
+
+	// Index signatures with template literal keys only apply to keys matching the pattern
+	sb.WriteString(g.templateIndexValidation(t, expr, nameExpr))
+
+	// Symbol keys aren't visited by for...in, so symbol index signatures are validated separately
+	if symbolType := checker.Checker_esSymbolType(g.checker); symbolType != nil {
+		if indexValueType := checker.Checker_getIndexTypeOfType(g.checker, t, symbolType); indexValueType != nil {
 			idx := g.funcIdx
 			g.funcIdx++
-			kVar := fmt.Sprintf("_k%d", idx)
+			sVar := fmt.Sprintf("_s%d", idx)
 			vVar := fmt.Sprintf("_v%d", idx)
-			valNameExpr := g.appendArrayIndex(nameExpr, kVar)
-			valueValidation := g.generateValidation(indexValueType, vVar, valNameExpr)
+			// Symbols can't be concatenated into the name, so they're converted with String()
+			valNameExpr := g.appendArrayIndex(nameExpr, "String("+sVar+")")
+			valueValidation := g.memberValidation(indexValueType, vVar, valNameExpr)
 			if valueValidation != "" {
-				sb.WriteString(fmt.Sprintf(`for (const %s in %s) { const %s: any = %s[%s]; %s} `,
-					kVar, expr, vVar, expr, kVar, valueValidation))
+				sb.WriteString(fmt.Sprintf(`for (const %s of Object.getOwnPropertySymbols(%s)) { const %s: any = (%s as any)[%s]; %s} `,
+					sVar, expr, vVar, expr, sVar, valueValidation))
 			}
 		}
 	}
@@ -1516,7 +2033,7 @@ func (g *Generator) arrayValidation(t *checker.Type, expr string, nameExpr strin
 			iVar := fmt.Sprintf("_i%d", idx)
 			eVar := fmt.Sprintf("_e%d", idx)
 			elemNameExpr := g.appendArrayIndex(nameExpr, iVar)
-			elemValidation := g.generateValidation(elemType, eVar, elemNameExpr)
+			elemValidation := g.memberValidation(elemType, eVar, elemNameExpr)
 			if elemValidation != "" {
 				// Use 'any' type for element to satisfy strict mode
 				sb.WriteString(fmt.Sprintf(`for (let %s = 0; %s < %s.length; %s++) { const %s: any = %s[%s]; %s} `,
@@ -1550,7 +2067,9 @@ func (g *Generator) arrayValidationFromNode(t *checker.Type, typeNode *ast.Node,
 					iVar := fmt.Sprintf("_i%d", idx)
 					eVar := fmt.Sprintf("_e%d", idx)
 					elemNameExpr := g.appendArrayIndex(nameExpr, iVar)
-					elemValidation := g.generateValidationFromNode(elemType, arrayType.ElementType, eVar, elemNameExpr)
+					elemValidation := g.errorScope(func() string {
+						return g.generateValidationFromNode(elemType, arrayType.ElementType, eVar, elemNameExpr)
+					})
 					if elemValidation != "" {
 						// Use 'any' type for element to satisfy strict mode
 						sb.WriteString(fmt.Sprintf(`for (let %s = 0; %s < %s.length; %s++) { const %s: any = %s[%s]; %s} `,
@@ -1571,6 +2090,10 @@ func (g *Generator) getUnionDescription(t *checker.Type) string {
 		return "union"
 	}
 
+	if description := g.enumDescription(t); description != "" {
+		return description
+	}
+
 	var parts []string
 	for _, memberType := range members {
 		parts = append(parts, g.getExpectedType(memberType))
@@ -1578,6 +2101,25 @@ func (g *Generator) getUnionDescription(t *checker.Type) string {
 	return strings.Join(parts, " | ")
 }
 
+// enumDescription describes an enum's union of member types by the enum's name and its
+// members, e.g. "Color (Red | Green | Blue)", rather than their values. Returns "" if t
+// isn't an enum.
+func (g *Generator) enumDescription(t *checker.Type) string {
+	sym := checker.Type_symbol(t)
+	if sym == nil || sym.Flags&ast.SymbolFlagsEnum == 0 {
+		return ""
+	}
+	var names []string
+	for _, member := range t.Types() {
+		memberSym := checker.Type_symbol(member)
+		if memberSym == nil || memberSym.Flags&ast.SymbolFlagsEnumMember == 0 {
+			return ""
+		}
+		names = append(names, memberSym.Name)
+	}
+	return fmt.Sprintf("%s (%s)", sym.Name, strings.Join(names, " | "))
+}
+
 // isLiteralUnion checks if a union type consists entirely of literal types (string, number, boolean literals).
 func (g *Generator) isLiteralUnion(t *checker.Type) bool {
 	flags := checker.Type_flags(t)
@@ -1588,8 +2130,8 @@ func (g *Generator) isLiteralUnion(t *checker.Type) bool {
 	members := t.Types()
 	for _, member := range members {
 		memberFlags := checker.Type_flags(member)
-		// Check if it's a literal type (string literal, number literal, boolean literal, null, undefined)
-		isLiteral := memberFlags&(checker.TypeFlagsStringLiteral|checker.TypeFlagsNumberLiteral|checker.TypeFlagsBooleanLiteral|checker.TypeFlagsNull|checker.TypeFlagsUndefined) != 0
+		// Check if it's a literal type (string, number, boolean or bigint literal, null, undefined)
+		isLiteral := memberFlags&(checker.TypeFlagsStringLiteral|checker.TypeFlagsNumberLiteral|checker.TypeFlagsBooleanLiteral|checker.TypeFlagsBigIntLiteral|checker.TypeFlagsNull|checker.TypeFlagsUndefined) != 0
 		if !isLiteral {
 			return false
 		}
@@ -1638,7 +2180,7 @@ func (g *Generator) generateCheck(t *checker.Type, expr string) string {
 			// Generate a call to the reusable check function
 			// For checks (boolean expressions), we call the function and check if it returns null
 			// Pass empty name since we only care about the null check, not the error message
-			return fmt.Sprintf(`(%s(%s, "") === null)`, checkFuncName, expr)
+			return fmt.Sprintf(`(%s(%s, ""%s) === null)`, checkFuncName, expr, g.seenArg())
 		}
 	}
 
@@ -1680,8 +2222,11 @@ func (g *Generator) generateCheck(t *checker.Type, expr string) string {
 		return g.intersectionCheck(t, expr)
 	}
 
-	// Check for object types (includes arrays, tuples, interfaces)
-	if flags&checker.TypeFlagsObject != 0 {
+	// Check for object types (includes arrays, tuples, interfaces, and the broad object and {} types)
+	if flags&(checker.TypeFlagsObject|checker.TypeFlagsNonPrimitive) != 0 {
+		if check, _ := g.broadObjectCheck(t, expr); check != "" {
+			return "(" + check + ")"
+		}
 		return g.objectTypeCheck(t, expr)
 	}
 
@@ -1691,6 +2236,8 @@ func (g *Generator) generateCheck(t *checker.Type, expr string) string {
 
 // generateCheckFromNode uses both type and AST node for more accurate detection
 func (g *Generator) generateCheckFromNode(t *checker.Type, typeNode *ast.Node, expr string) string {
+	typeNode = unwrapReadonlyArrayNode(typeNode)
+
 	// Check AST node kind first for array types
 	if typeNode != nil && typeNode.Kind == ast.KindArrayType {
 		return g.arrayCheckFromNode(t, typeNode, expr)
@@ -1731,6 +2278,11 @@ func (g *Generator) getExpectedType(t *checker.Type) string {
 			}
 		}
 		return "boolean"
+	case flags&checker.TypeFlagsBigIntLiteral != 0:
+		if literal := bigIntLiteral(t); literal != "" {
+			return literal
+		}
+		return "bigint"
 	case flags&checker.TypeFlagsString != 0:
 		return "string"
 	case flags&checker.TypeFlagsNumber != 0:
@@ -1745,7 +2297,12 @@ func (g *Generator) getExpectedType(t *checker.Type) string {
 		return "undefined"
 	case flags&checker.TypeFlagsVoid != 0:
 		return "void"
+	case flags&checker.TypeFlagsNonPrimitive != 0:
+		return "object"
 	case flags&checker.TypeFlagsObject != 0:
+		if g.isEmptyObjectType(t) {
+			return "{}"
+		}
 		if checker.Checker_isArrayType(g.checker, t) {
 			return "array"
 		}
@@ -1819,6 +2376,7 @@ func escapeJSStringQuoted(s string) string {
 // reset resets the generator state for a new generation.
 func (g *Generator) reset() {
 	g.ioFuncs = make([]string, 0)
+	g.ioBodies = make(map[string]string)
 	g.funcIdx = 0
 	g.visiting = make(map[string]bool)
 	g.depth = 0
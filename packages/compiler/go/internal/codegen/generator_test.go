@@ -815,3 +815,67 @@ interface User {
 		t.Errorf("Expected function name _filter_User, got %s", result.Name)
 	}
 }
+
+// TestCoercedFilteringCheck tests that coerced primitives are checked through the result
+// variable, even when the value's expression also appears elsewhere in the check.
+func TestCoercedFilteringCheck(t *testing.T) {
+	c, sourceFile, program, cleanup := setupTestProject(t, `function testCount(count: number): void {}`)
+	defer cleanup()
+
+	gen := NewGenerator(c, program)
+	gen.SetCoercion(Coercion{StringToNumber: true})
+	numberType := findFunctionParamType(c, sourceFile, "testCount")
+
+	for name, code := range map[string]string{
+		"throwing": gen.primitiveFilteringValidation(numberType, "t", `"t"`, "_r"),
+		"reusable": gen.reusablePrimitiveFilteringValidation(numberType, "t", `"t"`, "_r"),
+	} {
+		if !strings.Contains(code, `if (!("number" === typeof _r))`) {
+			t.Errorf("%s: expected the coerced value to be checked\nGot: %s", name, code)
+		}
+		if strings.Contains(code, "_rypeof") {
+			t.Errorf("%s: check was rewritten inside typeof\nGot: %s", name, code)
+		}
+	}
+}
+
+// TestObjectAssertCheckPresence tests that assertion checks decide whether optional
+// properties are present the same way objectCheck does.
+func TestObjectAssertCheckPresence(t *testing.T) {
+	c, sourceFile, program, cleanup := setupTestProject(t, `interface Profile { bio?: string; }
+function testProfile(profile: Profile): void {}`)
+	defer cleanup()
+
+	gen := NewGenerator(c, program)
+	profileType := findFunctionParamType(c, sourceFile, "testProfile")
+
+	if code := gen.objectAssertCheck(profileType, "p", `"p"`); !strings.Contains(code, "(undefined === p.bio || ") {
+		t.Errorf("Expected undefined to pass for bio\nGot: %s", code)
+	}
+
+	gen.SetStrictOptionalPresence(true)
+	code := gen.objectAssertCheck(profileType, "p", `"p"`)
+	if !strings.Contains(code, `(!Object.prototype.hasOwnProperty.call(p, "bio") || (undefined !== p.bio && `) {
+		t.Errorf("Expected an explicit undefined bio to be rejected\nGot: %s", code)
+	}
+}
+
+// TestObjectAssertCheckRequiredPresence tests that assertion checks require the keys of
+// required properties that accept undefined when RejectEmptyObjects is set.
+func TestObjectAssertCheckRequiredPresence(t *testing.T) {
+	c, sourceFile, program, cleanup := setupTestProject(t, `interface Event { payload: unknown; }
+function testEvent(event: Event): void {}`)
+	defer cleanup()
+
+	gen := NewGenerator(c, program)
+	eventType := findFunctionParamType(c, sourceFile, "testEvent")
+
+	if code := gen.objectAssertCheck(eventType, "e", `"e"`); strings.Contains(code, `"payload" in e`) {
+		t.Errorf("Expected a missing payload to pass by default\nGot: %s", code)
+	}
+
+	gen.SetRejectEmptyObjects(true)
+	if code := gen.objectAssertCheck(eventType, "e", `"e"`); !strings.Contains(code, `("payload" in e && `) {
+		t.Errorf("Expected the payload key to be required\nGot: %s", code)
+	}
+}
@@ -0,0 +1,297 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// jsonSchemaDialect is the JSON Schema draft that GenerateJSONSchema emits.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is a schema being built, marshalled with its keys sorted.
+type jsonSchema = map[string]any
+
+// jsonSchemaBuilder holds the named object types collected while walking a type.
+type jsonSchemaBuilder struct {
+	defs  map[string]jsonSchema
+	names map[string]string // type key (from checker.TypeToString) -> $defs name
+}
+
+// GenerateJSONSchema describes the JSON values t accepts as a JSON Schema (draft 2020-12)
+// document, for API docs generated from the same types the validators check.
+// The walk mirrors generateValidation: named object types go in $defs, which also lets
+// recursive types refer to themselves, and undefined is dropped from unions because a
+// missing property is how JSON spells it. Types JSON can't carry - functions, bigints,
+// built-in classes other than Date - are an error rather than a schema that would
+// silently accept anything.
+func (g *Generator) GenerateJSONSchema(t *checker.Type) (string, error) {
+	g.reset()
+	b := &jsonSchemaBuilder{defs: make(map[string]jsonSchema), names: make(map[string]string)}
+
+	root, err := g.jsonSchemaFor(b, t, "value")
+	if err != nil {
+		return "", err
+	}
+	root["$schema"] = jsonSchemaDialect
+	if len(b.defs) > 0 {
+		root["$defs"] = b.defs
+	}
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON Schema: %w", err)
+	}
+	return string(data), nil
+}
+
+// jsonSchemaFor mirrors generateValidation, returning the schema for t. name is the path
+// used in errors, as in CheckValue.
+func (g *Generator) jsonSchemaFor(b *jsonSchemaBuilder, t *checker.Type, name string) (jsonSchema, error) {
+	class, parts := g.classifyType(t)
+
+	switch class {
+	case typeUnchecked:
+		return jsonSchema{}, nil
+	case typeNever:
+		return jsonSchema{"not": jsonSchema{}}, nil
+	case typeTemplateLiteral:
+		schema := jsonSchema{"type": "string"}
+		if pattern := g.parseTemplateLiteral(t); pattern != nil {
+			schema["pattern"] = "^" + pattern.toRegexPattern() + "$"
+		}
+		return schema, nil
+	case typePrimitive:
+		return g.primitiveJSONSchema(t, name)
+	case typeUnion:
+		return g.unionJSONSchema(b, parts, name)
+	case typeBranded:
+		// Brands are compile-time only
+		return g.jsonSchemaFor(b, parts[0], name)
+	case typeIntersection:
+		var allOf []jsonSchema
+		for _, member := range parts {
+			schema, err := g.jsonSchemaFor(b, member, name)
+			if err != nil {
+				return nil, err
+			}
+			allOf = append(allOf, schema)
+		}
+		return jsonSchema{"allOf": allOf}, nil
+	case typeBroadObject:
+		return jsonSchema{"type": []string{"object", "array"}}, nil
+	case typeEmptyObject:
+		return jsonSchema{"not": jsonSchema{"type": "null"}}, nil
+	case typeFunction:
+		return nil, fmt.Errorf("%s: functions have no JSON representation", name)
+	}
+
+	return g.objectJSONSchema(b, t, name)
+}
+
+// primitiveJSONSchema mirrors primitiveValidation.
+func (g *Generator) primitiveJSONSchema(t *checker.Type, name string) (jsonSchema, error) {
+	flags := checker.Type_flags(t)
+
+	switch {
+	case flags&checker.TypeFlagsStringLiteral != 0:
+		if lt := t.AsLiteralType(); lt != nil {
+			if str, ok := lt.Value().(string); ok {
+				return jsonSchema{"const": str}, nil
+			}
+		}
+		return jsonSchema{"type": "string"}, nil
+	case flags&checker.TypeFlagsNumberLiteral != 0:
+		if lt := t.AsLiteralType(); lt != nil {
+			if value, err := strconv.ParseFloat(fmt.Sprintf("%v", lt.Value()), 64); err == nil {
+				return jsonSchema{"const": value}, nil
+			}
+		}
+		return jsonSchema{"type": "number"}, nil
+	case flags&checker.TypeFlagsBooleanLiteral != 0:
+		if lt := t.AsLiteralType(); lt != nil {
+			if b, ok := lt.Value().(bool); ok {
+				return jsonSchema{"const": b}, nil
+			}
+		}
+		return jsonSchema{"type": "boolean"}, nil
+	case flags&checker.TypeFlagsString != 0:
+		return jsonSchema{"type": "string"}, nil
+	case flags&checker.TypeFlagsNumber != 0:
+		return jsonSchema{"type": "number"}, nil
+	case flags&checker.TypeFlagsBoolean != 0:
+		return jsonSchema{"type": "boolean"}, nil
+	case flags&(checker.TypeFlagsBigInt|checker.TypeFlagsBigIntLiteral) != 0:
+		return nil, fmt.Errorf("%s: bigints have no JSON representation", name)
+	case flags&checker.TypeFlagsNull != 0:
+		return jsonSchema{"type": "null"}, nil
+	case flags&(checker.TypeFlagsUndefined|checker.TypeFlagsVoid) != 0:
+		return nil, fmt.Errorf("%s: undefined has no JSON representation", name)
+	}
+	return jsonSchema{}, nil
+}
+
+// unionJSONSchema mirrors unionValidation. Literal unions (including enums) become an enum,
+// anything else anyOf. undefined members are dropped - they only say a property may be
+// missing, which the object's required list already covers.
+func (g *Generator) unionJSONSchema(b *jsonSchemaBuilder, members []*checker.Type, name string) (jsonSchema, error) {
+	members = withoutUndefined(members)
+	if len(members) == 0 {
+		return nil, fmt.Errorf("%s: undefined has no JSON representation", name)
+	}
+	if len(members) == 1 {
+		return g.jsonSchemaFor(b, members[0], name)
+	}
+
+	var schemas []jsonSchema
+	var values []any
+	for _, member := range members {
+		schema, err := g.jsonSchemaFor(b, member, name)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+		if value, ok := schema["const"]; ok && len(schema) == 1 {
+			values = append(values, value)
+		}
+	}
+	if len(values) == len(schemas) {
+		return jsonSchema{"enum": values}, nil
+	}
+	return jsonSchema{"anyOf": schemas}, nil
+}
+
+// objectJSONSchema mirrors objectValidation, arrayValidation and tupleValidation. Named
+// object types are added to $defs once and referenced from then on.
+func (g *Generator) objectJSONSchema(b *jsonSchemaBuilder, t *checker.Type, name string) (jsonSchema, error) {
+	isArray := checker.Checker_isArrayType(g.checker, t)
+	if sym := checker.Type_symbol(t); sym != nil && isArraySymbolName(sym.Name) {
+		isArray = true
+	}
+
+	if isArray {
+		schema := jsonSchema{"type": "array"}
+		typeArgs := checker.Checker_getTypeArguments(g.checker, t)
+		if len(typeArgs) == 0 {
+			return schema, nil
+		}
+		items, err := g.jsonSchemaFor(b, typeArgs[0], name+"[]")
+		if err != nil {
+			return nil, err
+		}
+		schema["items"] = items
+		return schema, nil
+	}
+
+	if checker.IsTupleType(t) {
+		return g.tupleJSONSchema(b, t, name)
+	}
+
+	// Dates serialise to ISO strings; other built-in classes don't survive JSON
+	if className := g.isBuiltinClassType(t); className != "" {
+		if className == "Date" {
+			return jsonSchema{"type": "string", "format": "date-time"}, nil
+		}
+		return nil, fmt.Errorf("%s: %s instances have no JSON representation", name, className)
+	}
+
+	key := g.checker.TypeToString(t)
+	named := isGoodTypeName(key)
+	if named {
+		if defName, ok := b.names[key]; ok {
+			return jsonSchema{"$ref": "#/$defs/" + defName}, nil
+		}
+		defName := sanitizeFunctionName(key)
+		for i := 2; b.defs[defName] != nil; i++ {
+			defName = fmt.Sprintf("%s%d", sanitizeFunctionName(key), i)
+		}
+		// Claim the name before walking the properties so recursive references find it
+		b.names[key] = defName
+		b.defs[defName] = jsonSchema{}
+	}
+
+	schema := jsonSchema{"type": "object"}
+	properties := jsonSchema{}
+	required := []string{}
+	for _, prop := range g.sortedProperties(t) {
+		propType := g.propertyReadType(prop)
+		propName := name + "." + prop.Name
+
+		// never properties must not exist
+		if checker.Type_flags(propType)&checker.TypeFlagsNever != 0 {
+			properties[prop.Name] = false
+			continue
+		}
+
+		propSchema, err := g.jsonSchemaFor(b, propType, propName)
+		if err != nil {
+			return nil, err
+		}
+		properties[prop.Name] = propSchema
+		if !isOptionalProperty(prop) {
+			required = append(required, prop.Name)
+		}
+	}
+	if len(properties) > 0 {
+		schema["properties"] = properties
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	if stringType := checker.Checker_stringType(g.checker); stringType != nil {
+		if indexValueType := checker.Checker_getIndexTypeOfType(g.checker, t, stringType); indexValueType != nil {
+			additional, err := g.jsonSchemaFor(b, indexValueType, name+"[]")
+			if err != nil {
+				return nil, err
+			}
+			schema["additionalProperties"] = additional
+		}
+	}
+
+	if !named {
+		return schema, nil
+	}
+	defName := b.names[key]
+	b.defs[defName] = schema
+	return jsonSchema{"$ref": "#/$defs/" + defName}, nil
+}
+
+// tupleJSONSchema mirrors tupleValidation. Elements after a rest element can't be placed
+// by index, so only the leading elements and the overall length are described for them.
+func (g *Generator) tupleJSONSchema(b *jsonSchemaBuilder, t *checker.Type, name string) (jsonSchema, error) {
+	typeArgs, _, restIndex, minLen := g.tupleLayout(t)
+
+	leading := typeArgs
+	if restIndex >= 0 {
+		leading = typeArgs[:restIndex]
+	}
+
+	schema := jsonSchema{"type": "array", "minItems": minLen}
+	prefixItems := make([]jsonSchema, 0, len(leading))
+	for i, elemType := range leading {
+		elemSchema, err := g.jsonSchemaFor(b, elemType, fmt.Sprintf("%s[%d]", name, i))
+		if err != nil {
+			return nil, err
+		}
+		prefixItems = append(prefixItems, elemSchema)
+	}
+	if len(prefixItems) > 0 {
+		schema["prefixItems"] = prefixItems
+	}
+
+	switch {
+	case restIndex < 0:
+		schema["items"] = false
+		schema["maxItems"] = len(typeArgs)
+	case restIndex == len(typeArgs)-1:
+		items, err := g.jsonSchemaFor(b, typeArgs[restIndex], fmt.Sprintf("%s[%d]", name, restIndex))
+		if err != nil {
+			return nil, err
+		}
+		schema["items"] = items
+	}
+	return schema, nil
+}
@@ -0,0 +1,118 @@
+package codegen
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestGenerateJSONSchema tests describing types as JSON Schema documents.
+func TestGenerateJSONSchema(t *testing.T) {
+	code := `
+enum Role { Admin = "admin", User = "user" }
+
+interface User {
+	name: string;
+	role: Role;
+	nick?: string;
+	tags: string[];
+	point: [number, number];
+	created: Date;
+	id: ` + "`user-${number}`" + `;
+}
+
+interface Tree {
+	value: number;
+	children?: Tree[];
+}
+
+function testUser(user: User): void {}
+function testTree(tree: Tree): void {}
+function testUnion(value: string | number | null): void {}
+function testCallback(fn: () => void): void {}
+function testBig(value: { count: bigint }): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	gen := NewGenerator(c, program)
+
+	schemaFor := func(funcName string) map[string]any {
+		t.Helper()
+		paramType := findFunctionParamType(c, sourceFile, funcName)
+		if paramType == nil {
+			t.Fatalf("Could not find type for %s", funcName)
+		}
+		out, err := gen.GenerateJSONSchema(paramType)
+		if err != nil {
+			t.Fatalf("GenerateJSONSchema(%s) failed: %v", funcName, err)
+		}
+		var schema map[string]any
+		if err := json.Unmarshal([]byte(out), &schema); err != nil {
+			t.Fatalf("GenerateJSONSchema(%s) produced invalid JSON: %v\n%s", funcName, err, out)
+		}
+		if schema["$schema"] != jsonSchemaDialect {
+			t.Errorf("Expected $schema %q, got %v", jsonSchemaDialect, schema["$schema"])
+		}
+		return schema
+	}
+
+	user := schemaFor("testUser")
+	if user["$ref"] != "#/$defs/User" {
+		t.Fatalf("Expected a reference to User, got %v", user)
+	}
+	userDef := user["$defs"].(map[string]any)["User"].(map[string]any)
+	if !reflect.DeepEqual(userDef["required"], []any{"name", "role", "tags", "point", "created", "id"}) {
+		t.Errorf("Unexpected required properties: %v", userDef["required"])
+	}
+	props := userDef["properties"].(map[string]any)
+	expected := map[string]any{
+		"name":    map[string]any{"type": "string"},
+		"role":    map[string]any{"enum": []any{"admin", "user"}},
+		"nick":    map[string]any{"type": "string"},
+		"tags":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		"created": map[string]any{"type": "string", "format": "date-time"},
+		"point": map[string]any{
+			"type":        "array",
+			"prefixItems": []any{map[string]any{"type": "number"}, map[string]any{"type": "number"}},
+			"items":       false,
+			"minItems":    float64(2),
+			"maxItems":    float64(2),
+		},
+	}
+	for name, want := range expected {
+		if !reflect.DeepEqual(props[name], want) {
+			t.Errorf("Unexpected schema for %s: got %v, want %v", name, props[name], want)
+		}
+	}
+	if id := props["id"].(map[string]any); id["type"] != "string" || id["pattern"] == nil {
+		t.Errorf("Expected id to be a string with a pattern, got %v", id)
+	}
+
+	// Recursive types refer back to their own definition
+	tree := schemaFor("testTree")
+	treeDef := tree["$defs"].(map[string]any)["Tree"].(map[string]any)
+	children := treeDef["properties"].(map[string]any)["children"]
+	if want := map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/Tree"}}; !reflect.DeepEqual(children, want) {
+		t.Errorf("Expected children to refer to Tree, got %v", children)
+	}
+
+	union := schemaFor("testUnion")
+	anyOf, ok := union["anyOf"].([]any)
+	if !ok || len(anyOf) != 3 {
+		t.Errorf("Expected anyOf with 3 members, got %v", union)
+	}
+
+	// Types JSON can't carry are an error
+	for funcName, wantErr := range map[string]string{
+		"testCallback": "functions have no JSON representation",
+		"testBig":      "value.count: bigints have no JSON representation",
+	} {
+		_, err := gen.GenerateJSONSchema(findFunctionParamType(c, sourceFile, funcName))
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("Expected %s to fail with %q, got %v", funcName, wantErr, err)
+		}
+	}
+}
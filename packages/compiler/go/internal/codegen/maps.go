@@ -0,0 +1,89 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elliots/typical/packages/compiler/internal/utils"
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// mapEntryTypes returns the key and value types of a Map<K, V> or ReadonlyMap<K, V> from the
+// default library. ok is false for any other type, or when the type arguments aren't known.
+func (g *Generator) mapEntryTypes(t *checker.Type) (keyType, valueType *checker.Type, ok bool) {
+	if g.program == nil {
+		return nil, nil, false
+	}
+	sym := checker.Type_symbol(t)
+	if sym == nil || (sym.Name != "Map" && sym.Name != "ReadonlyMap") || !utils.IsSymbolFromDefaultLibrary(g.program, sym) {
+		return nil, nil, false
+	}
+	typeArgs := checker.Checker_getTypeArguments(g.checker, t)
+	if len(typeArgs) != 2 {
+		return nil, nil, false
+	}
+	return typeArgs[0], typeArgs[1], true
+}
+
+// skipsEntryValidation checks whether a Map key or value type, or a Set element type,
+// accepts anything, so the entries needn't be looked at.
+func skipsEntryValidation(t *checker.Type) bool {
+	return checker.Type_flags(t)&(checker.TypeFlagsAny|checker.TypeFlagsUnknown) != 0
+}
+
+// mapValidation generates validation statements for Map and ReadonlyMap types: an instance
+// check, then each entry's key and value are validated. ReadonlyMap has no constructor, but
+// its values are Maps at runtime.
+func (g *Generator) mapValidation(keyType, valueType *checker.Type, expr string, nameExpr string) string {
+	var sb strings.Builder
+
+	check := g.builtinInstanceCheck(expr, "Map")
+	sb.WriteString(g.validationError(check, nameExpr, "Map instance", expr))
+
+	if skipsEntryValidation(keyType) && skipsEntryValidation(valueType) {
+		return sb.String()
+	}
+
+	// Use unique variable names for nested maps
+	idx := g.funcIdx
+	g.funcIdx++
+	kVar := fmt.Sprintf("_mk%d", idx)
+	vVar := fmt.Sprintf("_mv%d", idx)
+
+	var entryValidation string
+	if !skipsEntryValidation(keyType) {
+		entryValidation += g.memberValidation(keyType, kVar, g.appendToName(nameExpr, " key"))
+	}
+	if !skipsEntryValidation(valueType) {
+		// Keys may be symbols or objects, so they're converted with String()
+		entryValidation += g.memberValidation(valueType, vVar, g.appendArrayIndex(nameExpr, "String("+kVar+")"))
+	}
+	if entryValidation != "" {
+		sb.WriteString(fmt.Sprintf(`for (const [%s, %s] of %s) { %s} `, kVar, vVar, expr, entryValidation))
+	}
+
+	return sb.String()
+}
+
+// mapCheck generates a boolean expression checking a Map or ReadonlyMap and its entries.
+func (g *Generator) mapCheck(keyType, valueType *checker.Type, expr string) string {
+	check := g.builtinInstanceCheck(expr, "Map")
+
+	var entryChecks []string
+	idx := g.funcIdx
+	g.funcIdx++
+	kVar := fmt.Sprintf("_mk%d", idx)
+	vVar := fmt.Sprintf("_mv%d", idx)
+	if !skipsEntryValidation(keyType) {
+		entryChecks = append(entryChecks, g.generateCheck(keyType, kVar))
+	}
+	if !skipsEntryValidation(valueType) {
+		entryChecks = append(entryChecks, g.generateCheck(valueType, vVar))
+	}
+	if len(entryChecks) == 0 {
+		return "(" + check + ")"
+	}
+
+	return fmt.Sprintf(`(%s && Array.from(%s).every(([%s, %s]: [any, any]) => %s))`,
+		check, expr, kVar, vVar, strings.Join(entryChecks, " && "))
+}
@@ -1,7 +1,9 @@
 package codegen
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/microsoft/typescript-go/shim/ast"
@@ -104,12 +106,51 @@ func (g *Generator) isBuiltinClassTypeRecursive(t *checker.Type, visited map[*ch
 	return false
 }
 
+// realmSafeTags maps built-in classes to the tag Object.prototype.toString reports for their
+// instances. Classes not listed (DOM classes, whose instances report their most derived
+// class, and user subclasses of built-ins) are always checked with instanceof.
+var realmSafeTags = map[string]string{
+	"Date": "Date", "RegExp": "RegExp", "Promise": "Promise",
+	"Map": "Map", "Set": "Set", "WeakMap": "WeakMap", "WeakSet": "WeakSet",
+	"ArrayBuffer": "ArrayBuffer", "SharedArrayBuffer": "SharedArrayBuffer", "DataView": "DataView",
+	"Int8Array": "Int8Array", "Uint8Array": "Uint8Array", "Uint8ClampedArray": "Uint8ClampedArray",
+	"Int16Array": "Int16Array", "Uint16Array": "Uint16Array", "Int32Array": "Int32Array",
+	"Uint32Array": "Uint32Array", "Float32Array": "Float32Array", "Float64Array": "Float64Array",
+	"BigInt64Array": "BigInt64Array", "BigUint64Array": "BigUint64Array",
+	"URL": "URL", "URLSearchParams": "URLSearchParams",
+	// Every error reports "Error", so a TypeError check accepts any error from another realm
+	"Error": "Error", "TypeError": "Error", "RangeError": "Error", "SyntaxError": "Error",
+	"ReferenceError": "Error", "EvalError": "Error", "URIError": "Error", "AggregateError": "Error",
+}
+
+// builtinInstanceCheck returns the check that expr is an instance of a built-in class: an
+// instanceof check, or with crossRealmBuiltins a realm-safe toStringTag comparison.
+func (g *Generator) builtinInstanceCheck(expr, className string) string {
+	if tag, ok := realmSafeTags[className]; ok && g.crossRealmBuiltins {
+		return fmt.Sprintf(`Object.prototype.toString.call(%s) === "[object %s]"`, expr, tag)
+	}
+	return fmt.Sprintf(`%s instanceof %s`, expr, className)
+}
+
+// validDateCheck returns the condition that a Date instance holds a valid time, rather
+// than NaN from parsing garbage, when validateDateValues is on. Returns "" for other classes.
+func (g *Generator) validDateCheck(expr, className string) string {
+	if !g.validateDateValues || className != "Date" {
+		return ""
+	}
+	return fmt.Sprintf("!isNaN(%s.getTime())", expr)
+}
+
 // objectTypeCheck generates a JavaScript expression for object type checks.
 // This handles both regular objects (interfaces) and arrays.
 // Note: cycle detection is handled by generateCheck which calls this.
 func (g *Generator) objectTypeCheck(t *checker.Type, expr string) string {
-	// Function types can only be checked with typeof - we can't validate signatures at runtime
+	// Function types can only be checked with typeof, and optionally length - we can't
+	// validate signatures at runtime
 	if g.isFunctionType(t) {
+		if arity := g.functionArity(t); arity > 0 {
+			return fmt.Sprintf(`("function" === typeof %s && %s.length >= %d)`, expr, expr, arity)
+		}
 		return fmt.Sprintf(`("function" === typeof %s)`, expr)
 	}
 
@@ -133,7 +174,7 @@ func (g *Generator) objectTypeCheck(t *checker.Type, expr string) string {
 
 	// Check for Array type via the symbol name
 	if sym := checker.Type_symbol(t); sym != nil {
-		if sym.Name == "Array" {
+		if isArraySymbolName(sym.Name) {
 			return g.arrayCheck(t, expr)
 		}
 	}
@@ -146,16 +187,85 @@ func (g *Generator) objectTypeCheck(t *checker.Type, expr string) string {
 		}
 	}
 
+	// Maps check their entries (ReadonlyMap is an interface, so isn't a built-in class)
+	if keyType, valueType, ok := g.mapEntryTypes(t); ok {
+		return g.mapCheck(keyType, valueType, expr)
+	}
+
+	// Sets check their members (ReadonlySet is an interface, so isn't a built-in class)
+	if elemType, ok := g.setElementType(t); ok {
+		return g.setCheck(elemType, expr)
+	}
+
 	// Built-in classes use instanceof check - they're classes at runtime
 	// (but not Array, which needs element validation - handled above)
 	if className := g.isBuiltinClassType(t); className != "" {
-		return fmt.Sprintf(`(%s instanceof %s)`, expr, className)
+		if valid := g.validDateCheck(expr, className); valid != "" {
+			return "(" + g.builtinInstanceCheck(expr, className) + " && " + valid + ")"
+		}
+		return "(" + g.builtinInstanceCheck(expr, className) + ")"
 	}
 
 	// Regular object type - create _io function
 	return g.objectCheck(t, expr)
 }
 
+// broadObjectCheck returns the check and expected-type description for the types that
+// constrain only the kind of value, not its properties:
+//   - object accepts any non-null object (and functions, with objectAcceptsFunctions)
+//   - {} and empty interfaces accept anything except null and undefined, primitives included
+//
+// Returns "" for every other type.
+func (g *Generator) broadObjectCheck(t *checker.Type, expr string) (string, string) {
+	if checker.Type_flags(t)&checker.TypeFlagsNonPrimitive != 0 {
+		if g.objectAcceptsFunctions {
+			return fmt.Sprintf(`(typeof %s === "object" && %s !== null) || typeof %s === "function"`, expr, expr, expr), "object"
+		}
+		return fmt.Sprintf(`typeof %s === "object" && %s !== null`, expr, expr), "object"
+	}
+	if g.isEmptyObjectType(t) {
+		return fmt.Sprintf(`%s !== null && %s !== undefined`, expr, expr), "{}"
+	}
+	return "", ""
+}
+
+// isMergeableObjectIntersection reports whether every member of an intersection is a plain
+// object type (an interface or object literal type), so the intersection can be validated as
+// one object. Arrays, classes, built-ins and functions have their own checks, and members
+// with a @typical-error message need their own failure.
+func (g *Generator) isMergeableObjectIntersection(members []*checker.Type) bool {
+	for _, m := range members {
+		if checker.Type_flags(m)&checker.TypeFlagsObject == 0 {
+			return false
+		}
+		if checker.Checker_isArrayOrTupleType(g.checker, m) || g.isBuiltinClassType(m) != "" || g.isClassType(m) || g.isFunctionType(m) {
+			return false
+		}
+		if g.typeErrorMessage(m) != "" {
+			return false
+		}
+	}
+	return len(members) > 1
+}
+
+// isEmptyObjectType checks for {} and interfaces with no members, which any non-nullish
+// value satisfies. Classes are excluded since they're still checked with instanceof.
+func (g *Generator) isEmptyObjectType(t *checker.Type) bool {
+	if checker.Type_flags(t)&checker.TypeFlagsObject == 0 || g.isClassType(t) {
+		return false
+	}
+	if len(checker.Checker_getPropertiesOfType(g.checker, t)) > 0 || g.isFunctionType(t) {
+		return false
+	}
+	if checker.Checker_isArrayOrTupleType(g.checker, t) {
+		return false
+	}
+	if stringType := checker.Checker_stringType(g.checker); stringType != nil && checker.Checker_getIndexTypeOfType(g.checker, t, stringType) != nil {
+		return false
+	}
+	return len(g.templateIndexKeys(t)) == 0
+}
+
 // looksLikeArrayType checks if an anonymous type appears to be an array
 func (g *Generator) looksLikeArrayType(t *checker.Type) bool {
 	props := checker.Checker_getPropertiesOfType(g.checker, t)
@@ -208,6 +318,10 @@ func (g *Generator) objectCheck(t *checker.Type, expr string) string {
 	// Check complexity limit before creating another _io function
 	// Use the richer error reporting version that includes source file and properties
 	if g.checkComplexityLimitWithType(t) {
+		if g.degradeOnComplexity {
+			// Out of budget: keep the object check but don't look at properties
+			return fmt.Sprintf(`/* depth-limited */ "object" === typeof %s && null !== %s`, expr, expr)
+		}
 		// Return a simple check that will pass - the error will be propagated up
 		return "true"
 	}
@@ -221,11 +335,11 @@ func (g *Generator) objectCheck(t *checker.Type, expr string) string {
 	g.funcIdx++
 
 	// Get all properties of the type
-	props := checker.Checker_getPropertiesOfType(g.checker, t)
+	props := g.sortedProperties(t)
 
 	var checks []string
 	for _, prop := range props {
-		propType := checker.Checker_getTypeOfSymbol(g.checker, prop)
+		propType := g.propertyReadType(prop)
 		propName := prop.Name
 
 		// Handle 'never' type properties - they must NOT be defined
@@ -246,28 +360,54 @@ func (g *Generator) objectCheck(t *checker.Type, expr string) string {
 		// Push property name for context
 		g.pushType(propName)
 
-		// Generate check for this property
+		// Generate check for this property, including any @pattern or range tags it has
 		check := g.generateCheck(propType, accessor)
+		if refinement, _ := g.refinementCheck(prop, propType, accessor); refinement != "" {
+			check = fmt.Sprintf("(%s && %s)", check, refinement)
+		}
 
 		g.popType()
 
+		if presence := g.requiredPresenceCheck(prop, propType, "input"); presence != "" {
+			checks = append(checks, presence)
+		}
+
 		// Handle optional properties
 		if isOptionalProperty(prop) {
-			check = fmt.Sprintf("(undefined === %s || %s)", accessor, check)
+			check = g.optionalPropertyCheck(prop, "input", accessor, check)
 		}
 
 		checks = append(checks, check)
 	}
 
-	// Check for string index signature and validate all values
-	stringType := checker.Checker_stringType(g.checker)
-	if stringType != nil {
-		indexValueType := checker.Checker_getIndexTypeOfType(g.checker, t, stringType)
-		if indexValueType != nil {
-			// Generate a check for index signature values
+	// Check for an index signature and validate the values of the remaining keys
+	// (skipped for any and unknown value types, as elsewhere)
+	indexValueType, numericKeys := g.indexSignatureValueType(t)
+	if indexValueType != nil && checker.Type_flags(indexValueType)&(checker.TypeFlagsAny|checker.TypeFlagsUnknown) == 0 {
+		// Declared properties have been checked above, and a number index signature
+		// says nothing about keys that aren't numeric strings
+		skip := declaredKeyCondition("k", props)
+		if numericKeys {
+			skip = strings.TrimPrefix(skip+" || String(+k) !== k", " || ")
+		}
+		if skip == "" {
 			// Use Object.values().every() to validate all values
 			valueCheck := g.generateCheck(indexValueType, "v")
 			checks = append(checks, fmt.Sprintf("Object.values(input).every((v: any) => %s)", valueCheck))
+		} else {
+			valueCheck := g.generateCheck(indexValueType, "input[k]")
+			checks = append(checks, fmt.Sprintf("Object.keys(input).every((k: string) => %s || %s)", skip, valueCheck))
+		}
+	}
+
+	// Index signatures with template literal keys only apply to keys matching the pattern
+	checks = append(checks, g.templateIndexChecks(t, "input")...)
+
+	// Symbol keys aren't included in Object.values, so symbol index signatures are checked separately
+	if symbolType := checker.Checker_esSymbolType(g.checker); symbolType != nil {
+		if indexValueType := checker.Checker_getIndexTypeOfType(g.checker, t, symbolType); indexValueType != nil {
+			valueCheck := g.generateCheck(indexValueType, "input[s]")
+			checks = append(checks, fmt.Sprintf("Object.getOwnPropertySymbols(input).every((s: any) => %s)", valueCheck))
 		}
 	}
 
@@ -277,8 +417,15 @@ func (g *Generator) objectCheck(t *checker.Type, expr string) string {
 		funcBody = strings.Join(checks, " && ")
 	}
 
-	// Add the function to our list (use 'any' type for strict mode)
-	g.ioFuncs = append(g.ioFuncs, fmt.Sprintf("const %s = (input: any) => %s", funcName, funcBody))
+	// Reuse an existing helper with the same body. Nested helpers are added before their
+	// parents, so identical shapes reference the same nested names and dedupe bottom-up.
+	if existing, ok := g.ioBodies[funcBody]; ok {
+		funcName = existing
+	} else {
+		g.ioBodies[funcBody] = funcName
+		// Add the function to our list (use 'any' type for strict mode)
+		g.ioFuncs = append(g.ioFuncs, fmt.Sprintf("const %s = (input: any) => %s", funcName, funcBody))
+	}
 
 	// Return the object check expression
 	return fmt.Sprintf(`"object" === typeof %s && null !== %s && %s(%s)`,
@@ -299,14 +446,14 @@ func (g *Generator) objectAssertCheck(t *checker.Type, expr string, path string)
 	objectCheck := fmt.Sprintf(`"object" === typeof %s && null !== %s`, expr, expr)
 
 	// Get properties and generate individual checks
-	props := checker.Checker_getPropertiesOfType(g.checker, t)
+	props := g.sortedProperties(t)
 
 	var propChecks []string
 	propChecks = append(propChecks, fmt.Sprintf("(%s || _errorFactory && _errorFactory({ path: %s, expected: \"object\", value: %s }))",
 		objectCheck, path, expr))
 
 	for _, prop := range props {
-		propType := checker.Checker_getTypeOfSymbol(g.checker, prop)
+		propType := g.propertyReadType(prop)
 		propName := prop.Name
 
 		accessor := fmt.Sprintf("%s.%s", expr, propName)
@@ -316,12 +463,18 @@ func (g *Generator) objectAssertCheck(t *checker.Type, expr string, path string)
 
 		propPath := fmt.Sprintf(`%s + ".%s"`, path, propName)
 
-		// Generate check for this property
+		// Generate check for this property, including any @pattern or range tags it has
 		check := g.generateCheck(propType, accessor)
-		expected := g.getExpectedType(propType)
+		refinement, expected := g.refinementCheck(prop, propType, accessor)
+		if refinement != "" {
+			check = fmt.Sprintf("(%s && %s)", check, refinement)
+		}
 
+		if presence := g.requiredPresenceCheck(prop, propType, expr); presence != "" {
+			check = fmt.Sprintf("(%s && %s)", presence, check)
+		}
 		if isOptionalProperty(prop) {
-			check = fmt.Sprintf("(undefined === %s || %s)", accessor, check)
+			check = g.optionalPropertyCheck(prop, expr, accessor, check)
 		}
 
 		propChecks = append(propChecks, fmt.Sprintf("(%s || _errorFactory && _errorFactory({ path: %s, expected: %q, value: %s }))",
@@ -339,6 +492,191 @@ func isOptionalProperty(prop *ast.Symbol) bool {
 	return flags&ast.SymbolFlagsOptional != 0
 }
 
+// optionalPropertyGuard returns the condition under which an optional property is validated,
+// plus any extra validation to run inside that condition.
+// By default the property is validated when it is not undefined, so `{ p: undefined }` and `{}`
+// are treated the same. The comparison is strict: `{ p: null }` isn't missing, so it's validated
+// and rejected unless the type includes null. With strictOptionalPresence, presence is decided
+// by hasOwnProperty and an explicit `p: undefined` is rejected unless the declared type itself
+// includes undefined.
+func (g *Generator) optionalPropertyGuard(prop *ast.Symbol, propType *checker.Type, objExpr, accessor, nameExpr string) (string, string) {
+	if !g.strictOptionalPresence || g.declaredTypeIncludesUndefined(prop) {
+		return fmt.Sprintf("%s !== undefined", accessor), ""
+	}
+	guard := fmt.Sprintf("Object.prototype.hasOwnProperty.call(%s, %s)", objExpr, escapeJSStringQuoted(prop.Name))
+	check := fmt.Sprintf("%s !== undefined", accessor)
+	return guard, g.validationError(check, nameExpr, g.getExpectedTypeExcludingUndefined(propType), accessor)
+}
+
+// optionalPropertyCheck is optionalPropertyGuard for boolean checks: it wraps check, the
+// check of an optional property's value, so that it passes when the property is missing.
+func (g *Generator) optionalPropertyCheck(prop *ast.Symbol, objExpr, accessor, check string) string {
+	if g.strictOptionalPresence && !g.declaredTypeIncludesUndefined(prop) {
+		return fmt.Sprintf("(!Object.prototype.hasOwnProperty.call(%s, %s) || (undefined !== %s && %s))",
+			objExpr, escapeJSStringQuoted(prop.Name), accessor, check)
+	}
+	return fmt.Sprintf("(undefined === %s || %s)", accessor, check)
+}
+
+// requiredPresenceCheck returns a condition that a required property's key is present, when
+// RejectEmptyObjects is enabled and validating the value alone would accept a missing key -
+// i.e. the property is typed any, unknown or includes undefined. Returns "" otherwise.
+func (g *Generator) requiredPresenceCheck(prop *ast.Symbol, propType *checker.Type, objExpr string) string {
+	if !g.rejectEmptyObjects || isOptionalProperty(prop) || !acceptsMissingValue(propType) {
+		return ""
+	}
+	return fmt.Sprintf("%s in %s", escapeJSStringQuoted(prop.Name), objExpr)
+}
+
+// acceptsMissingValue checks whether undefined - the value read for a missing key - is valid for t.
+func acceptsMissingValue(t *checker.Type) bool {
+	missing := checker.TypeFlagsAny | checker.TypeFlagsUnknown | checker.TypeFlagsUndefined | checker.TypeFlagsVoid
+	if checker.Type_flags(t)&missing != 0 {
+		return true
+	}
+	if checker.Type_flags(t)&checker.TypeFlagsUnion != 0 {
+		for _, member := range t.Types() {
+			if checker.Type_flags(member)&missing != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// indexSignatureValueType returns the value type of a type's string index signature
+// (Record<string, T> or { [key: string]: T }), falling back to its number index signature
+// (Record<number, T>), in which case numericKeys is true. Returns nil without either.
+func (g *Generator) indexSignatureValueType(t *checker.Type) (valueType *checker.Type, numericKeys bool) {
+	if stringType := checker.Checker_stringType(g.checker); stringType != nil {
+		if valueType = checker.Checker_getIndexTypeOfType(g.checker, t, stringType); valueType != nil {
+			return valueType, false
+		}
+	}
+	if numberType := checker.Checker_numberType(g.checker); numberType != nil {
+		if valueType = checker.Checker_getIndexTypeOfType(g.checker, t, numberType); valueType != nil {
+			return valueType, true
+		}
+	}
+	return nil, false
+}
+
+// declaredKeyCondition returns a condition matching keys that belong to declared properties,
+// which index signature loops skip as they've already been handled. Returns "" without any.
+func declaredKeyCondition(kVar string, props []*ast.Symbol) string {
+	conds := make([]string, 0, len(props))
+	for _, prop := range props {
+		conds = append(conds, fmt.Sprintf("%s === %s", kVar, escapeJSStringQuoted(prop.Name)))
+	}
+	return strings.Join(conds, " || ")
+}
+
+// sortedProperties returns the properties of a type in source order, so generated validators
+// and their first reported error don't depend on the checker's property ordering. Properties
+// are ordered by declaring file then position; those without a declaration (e.g. synthesised
+// by a mapped type over a string literal union) come last, alphabetically. Symbol-keyed
+// properties are left out.
+func (g *Generator) sortedProperties(t *checker.Type) []*ast.Symbol {
+	props := slices.DeleteFunc(slices.Clone(checker.Checker_getPropertiesOfType(g.checker, t)), isSymbolKeyedProperty)
+	position := func(prop *ast.Symbol) (string, int, bool) {
+		decl := prop.ValueDeclaration
+		if decl == nil && len(prop.Declarations) > 0 {
+			decl = prop.Declarations[0]
+		}
+		if decl == nil {
+			return "", 0, false
+		}
+		sf := ast.GetSourceFileOfNode(decl)
+		if sf == nil {
+			return "", 0, false
+		}
+		return sf.FileName(), decl.Pos(), true
+	}
+	slices.SortStableFunc(props, func(a, b *ast.Symbol) int {
+		aFile, aPos, aOk := position(a)
+		bFile, bPos, bOk := position(b)
+		if aOk != bOk {
+			if aOk {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(strings.Compare(aFile, bFile), cmp.Compare(aPos, bPos), strings.Compare(a.Name, b.Name))
+	})
+	return props
+}
+
+// propertyReadType returns the type seen when reading a property. For accessor pairs whose
+// types diverge (get x(): A; set x(v: B)), values are validated against the getter's type.
+func (g *Generator) propertyReadType(prop *ast.Symbol) *checker.Type {
+	// Instantiated symbols (e.g. Box<string>) would lose their type arguments via the declaration
+	if prop.Flags&ast.SymbolFlagsGetAccessor != 0 && prop.Flags&ast.SymbolFlagsSetAccessor != 0 && prop.CheckFlags&ast.CheckFlagsInstantiated == 0 {
+		for _, decl := range prop.Declarations {
+			if decl.Kind == ast.KindGetAccessor && decl.Type() != nil {
+				return checker.Checker_getTypeFromTypeNode(g.checker, decl.Type())
+			}
+		}
+	}
+	return checker.Checker_getTypeOfSymbol(g.checker, prop)
+}
+
+// declaredTypeIncludesUndefined checks whether an optional property's declared type annotation
+// explicitly allows undefined (e.g. `p?: string | undefined`). The checker adds undefined to every
+// optional property type, so the annotation is the only way to tell the two apart.
+// Returns true when there is no annotation to inspect, keeping the lenient behaviour.
+func (g *Generator) declaredTypeIncludesUndefined(prop *ast.Symbol) bool {
+	decl := prop.ValueDeclaration
+	// Mapped type members (Readonly<T>) have no value declaration but keep the original's declarations
+	if decl == nil && len(prop.Declarations) > 0 {
+		decl = prop.Declarations[0]
+	}
+	if decl == nil || decl.Type() == nil {
+		return true
+	}
+	declType := checker.Checker_getTypeFromTypeNode(g.checker, decl.Type())
+	if declType == nil {
+		return true
+	}
+	undefinedLike := checker.TypeFlagsUndefined | checker.TypeFlagsVoid | checker.TypeFlagsAny | checker.TypeFlagsUnknown
+	if checker.Type_flags(declType)&undefinedLike != 0 {
+		return true
+	}
+	if utils.IsUnionType(declType) {
+		for _, member := range utils.UnionTypeParts(declType) {
+			if checker.Type_flags(member)&undefinedLike != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getExpectedTypeExcludingUndefined describes a type for error messages, leaving out the
+// undefined member that optionality adds.
+func (g *Generator) getExpectedTypeExcludingUndefined(t *checker.Type) string {
+	if checker.Type_flags(t)&checker.TypeFlagsUnion == 0 {
+		return g.getExpectedType(t)
+	}
+	var parts []string
+	for _, member := range t.Types() {
+		if checker.Type_flags(member)&checker.TypeFlagsUndefined != 0 {
+			continue
+		}
+		parts = append(parts, g.getExpectedType(member))
+	}
+	if len(parts) == 0 {
+		return g.getExpectedType(t)
+	}
+	return strings.Join(parts, " | ")
+}
+
+// isSymbolKeyedProperty reports whether prop is keyed by a symbol, such as [Symbol.iterator]
+// or [key] for a unique symbol key, or is a #private field. The checker gives these internal
+// names that can't be used to read the property, and JSON ignores them, so they're skipped.
+func isSymbolKeyedProperty(prop *ast.Symbol) bool {
+	return strings.HasPrefix(prop.Name, ast.InternalSymbolNamePrefix)
+}
+
 // needsQuoting checks if a property name needs to be quoted in JavaScript.
 func needsQuoting(name string) bool {
 	if len(name) == 0 {
@@ -0,0 +1,134 @@
+package codegen
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/microsoft/typescript-go/shim/ast"
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// patternDirectiveRegex matches a JSDoc `@pattern` tag, up to the end of its line or comment.
+var patternDirectiveRegex = regexp.MustCompile(`(?m)@pattern[ \t]+(.*?)[ \t]*(?:\*/.*)?$`)
+
+// propertyPattern returns a JavaScript regular expression literal for a `@pattern` tag on a
+// string property's declaration, or "" if there isn't one. The tag is ignored on properties
+// of other types. A malformed pattern is recorded for GetDirectiveError.
+func (g *Generator) propertyPattern(prop *ast.Symbol, propType *checker.Type) string {
+	if !isStringType(propType) {
+		return ""
+	}
+	for _, decl := range prop.Declarations {
+		sf := ast.GetSourceFileOfNode(decl)
+		if sf == nil {
+			continue
+		}
+		m := patternDirectiveRegex.FindStringSubmatch(leadingComments(sf.Text(), decl.Pos()))
+		if m == nil {
+			continue
+		}
+		literal, err := regexLiteral(m[1])
+		if err != nil {
+			if g.directiveError == "" {
+				g.directiveError = fmt.Sprintf("invalid @pattern %q on property %s: %v", m[1], prop.Name, err)
+			}
+			return ""
+		}
+		return literal
+	}
+	return ""
+}
+
+// patternValidation generates a statement checking that expr, if it's a string, matches the
+// regular expression literal. Values that aren't strings are left to the type's validation.
+func (g *Generator) patternValidation(literal, expr, nameExpr string) string {
+	if literal == "" {
+		return ""
+	}
+	check := fmt.Sprintf(`"string" !== typeof %s || %s.test(%s)`, expr, literal, expr)
+	return g.validationErrorWithValue(check, nameExpr, "a string matching "+literal, expr)
+}
+
+// patternCheck is patternValidation as a boolean expression, or "" without a pattern.
+func patternCheck(literal, expr string) string {
+	if literal == "" {
+		return ""
+	}
+	return fmt.Sprintf(`("string" !== typeof %s || %s.test(%s))`, expr, literal, expr)
+}
+
+// isStringType reports whether t is string-like, allowing for null and undefined as in
+// optional and nullable properties.
+func isStringType(t *checker.Type) bool {
+	return everyMember(t, func(member *checker.Type) bool {
+		return checker.Type_flags(member)&(checker.TypeFlagsString|checker.TypeFlagsStringLiteral|checker.TypeFlagsTemplateLiteral) != 0
+	})
+}
+
+// everyMember reports whether every member of t other than null and undefined matches,
+// and there's at least one such member.
+func everyMember(t *checker.Type, match func(*checker.Type) bool) bool {
+	members := []*checker.Type{t}
+	if checker.Type_flags(t)&checker.TypeFlagsUnion != 0 {
+		members = t.Types()
+	}
+	found := false
+	for _, member := range members {
+		if checker.Type_flags(member)&(checker.TypeFlagsUndefined|checker.TypeFlagsNull) != 0 {
+			continue
+		}
+		if !match(member) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// regexLiteral returns a JavaScript regular expression literal for a pattern, written either
+// as its source (^[a-z]+$) or as a literal with flags (/^[a-z]+$/i). The g and y flags make
+// test() stateful, so they aren't allowed. Patterns are parsed with Go's regexp syntax to
+// catch mistakes such as unbalanced brackets; lookarounds and backreferences, which Go
+// doesn't support, are let through.
+func regexLiteral(pattern string) (string, error) {
+	source, flags := pattern, ""
+	if end := strings.LastIndex(pattern, "/"); strings.HasPrefix(pattern, "/") && end > 0 {
+		source, flags = pattern[1:end], pattern[end+1:]
+		if strings.Trim(flags, "dimsuv") != "" {
+			return "", fmt.Errorf("unsupported flags %q", flags)
+		}
+	}
+	if source == "" {
+		return "", errors.New("empty pattern")
+	}
+	if _, err := syntax.Parse(source, syntax.Perl); err != nil {
+		var syntaxErr *syntax.Error
+		if !errors.As(err, &syntaxErr) || (syntaxErr.Code != syntax.ErrInvalidPerlOp && syntaxErr.Code != syntax.ErrInvalidEscape) {
+			return "", err
+		}
+	}
+
+	// Slashes would end the literal early, so they're escaped
+	var sb strings.Builder
+	sb.WriteByte('/')
+	for i := 0; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			sb.WriteByte('\\')
+			if i+1 < len(source) {
+				i++
+				sb.WriteByte(source[i])
+			}
+		case '/':
+			sb.WriteString(`\/`)
+		default:
+			sb.WriteByte(source[i])
+		}
+	}
+	sb.WriteByte('/')
+	sb.WriteString(flags)
+	return sb.String(), nil
+}
@@ -18,7 +18,8 @@ func (g *Generator) primitiveCheck(t *checker.Type, expr string) string {
 
 	// Number type
 	if flags&checker.TypeFlagsNumber != 0 {
-		return fmt.Sprintf(`"number" === typeof %s`, expr)
+		check, _ := g.numberCheck(expr)
+		return check
 	}
 
 	// Boolean type
@@ -81,6 +82,43 @@ func (g *Generator) primitiveCheck(t *checker.Type, expr string) string {
 		return fmt.Sprintf(`"boolean" === typeof %s`, expr)
 	}
 
+	// BigInt literal type (10n)
+	if flags&checker.TypeFlagsBigIntLiteral != 0 {
+		if literal := bigIntLiteral(t); literal != "" {
+			return fmt.Sprintf(`%s === %s`, literal, expr)
+		}
+		return fmt.Sprintf(`"bigint" === typeof %s`, expr)
+	}
+
 	// Not a primitive type
 	return ""
 }
+
+// numberCheck returns the check and expected-type description for the number type. With
+// strictNumbers, NaN and the infinities are rejected too.
+func (g *Generator) numberCheck(expr string) (string, string) {
+	if g.strictNumbers {
+		return fmt.Sprintf(`"number" === typeof %s && Number.isFinite(%s)`, expr, expr), "a finite number"
+	}
+	return fmt.Sprintf(`"number" === typeof %s`, expr), "number"
+}
+
+// numberGotExpr wraps the "got" expression for a failed number check so that a non-finite
+// number is shown by value ("got NaN") rather than as its type.
+func numberGotExpr(gotExpr, expr string) string {
+	return fmt.Sprintf(`("number" === typeof %s ? String(%s) : %s)`, expr, expr, gotExpr)
+}
+
+// bigIntLiteral returns a bigint literal type's value as JavaScript source, e.g. 10n or -5n,
+// or "" if it can't be read. The checker holds it as a jsnum.PseudoBigInt.
+func bigIntLiteral(t *checker.Type) string {
+	lt := t.AsLiteralType()
+	if lt == nil {
+		return ""
+	}
+	value, ok := lt.Value().(fmt.Stringer)
+	if !ok {
+		return ""
+	}
+	return value.String() + "n"
+}
@@ -0,0 +1,62 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SetSampleRate sets the fraction of calls that are validated, for hot paths where always
+// validating costs too much. Rates of 0 (unset) or 1 and above validate every call.
+// Sampling is applied where validation is invoked, so nested check function calls within a
+// sampled validation always run. Filtering (JSON.parse and JSON.stringify) is never sampled,
+// since skipping it would let undeclared properties through.
+func (g *Generator) SetSampleRate(rate float64) {
+	g.sampleRate = rate
+}
+
+// SetGuardEnvVar sets an environment variable that switches validation off in production:
+// validators only run when process is defined and process.env[name] isn't "production".
+// Bundlers that define the variable (e.g. NODE_ENV) turn the guard into a constant, so a
+// production build can drop the validation as dead code. The guarded value is passed through
+// either way, and filtering (JSON.parse and JSON.stringify) isn't guarded.
+func (g *Generator) SetGuardEnvVar(name string) {
+	g.guardEnvVar = name
+}
+
+// SampleCondition returns the JS condition that is true for the calls to validate, or ""
+// when every call is validated. It combines the GuardEnvVar check, first so it can be
+// constant-folded, with the SampleRate draw.
+func (g *Generator) SampleCondition() string {
+	var conds []string
+	if g.guardEnvVar != "" {
+		envVar := "process.env." + g.guardEnvVar
+		if needsQuoting(g.guardEnvVar) {
+			envVar = fmt.Sprintf("process.env[%q]", g.guardEnvVar)
+		}
+		conds = append(conds, `typeof process !== "undefined" && `+envVar+` !== "production"`)
+	}
+	if g.sampleRate > 0 && g.sampleRate < 1 {
+		conds = append(conds, "Math.random() < "+strconv.FormatFloat(g.sampleRate, 'g', -1, 64))
+	}
+	return strings.Join(conds, " && ")
+}
+
+// sampleGuard returns a statement that makes a validator return skipped early for calls that
+// aren't sampled, or "" when every call is validated.
+func (g *Generator) sampleGuard(skipped string) string {
+	cond := g.SampleCondition()
+	if cond == "" {
+		return ""
+	}
+	return fmt.Sprintf(`if (!(%s)) return %s; `, cond, skipped)
+}
+
+// sampleBlock wraps inline validation statements so they only run for sampled calls.
+func (g *Generator) sampleBlock(statements string) string {
+	cond := g.SampleCondition()
+	if cond == "" || statements == "" {
+		return statements
+	}
+	return fmt.Sprintf(`if (%s) { %s} `, cond, statements)
+}
@@ -0,0 +1,158 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/microsoft/typescript-go/shim/ast"
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// GenerateSerializableCheck generates a function that checks a value can cross a structured
+// clone boundary (postMessage, structuredClone) before it gets there.
+// The returned string is a JavaScript function: (value, name) => value
+// The declared type decides where to look: any field typed as a function or symbol is
+// checked, and the error names the offending field rather than failing with a DataCloneError.
+// Returns "" if nothing in the type can hold a non-serialisable value.
+func (g *Generator) GenerateSerializableCheck(t *checker.Type) string {
+	g.reset()
+
+	statements := g.serializableValidation(t, "_v", "_n")
+	if statements == "" {
+		return ""
+	}
+
+	return "((_v: any, _n: string) => { " + statements + "return _v; })"
+}
+
+// serializableValidation generates statements that fail if expr, or anything reachable from
+// it through the properties of t, is a function or symbol. Unlike generateValidation it
+// doesn't check the shape of the value - only the places t says could hold something
+// structuredClone would reject.
+func (g *Generator) serializableValidation(t *checker.Type, expr string, nameExpr string) string {
+	flags := checker.Type_flags(t)
+
+	if flags&checker.TypeFlagsAny != 0 || flags&checker.TypeFlagsUnknown != 0 {
+		return ""
+	}
+
+	if g.depth > MaxTypeDepth {
+		return ""
+	}
+	g.depth++
+	defer func() { g.depth-- }()
+
+	// Recursive types - the first visit already covers every field
+	typeKey := getTypeKey(t)
+	if typeKey != "" {
+		if g.visiting[typeKey] {
+			return ""
+		}
+		g.visiting[typeKey] = true
+		defer delete(g.visiting, typeKey)
+	}
+
+	if flags&checker.TypeFlagsESSymbolLike != 0 {
+		return g.validationError(fmt.Sprintf(`typeof %s !== "symbol"`, expr), nameExpr, "serialisable", expr)
+	}
+
+	// Union and intersection members each check their own possible values, so checks
+	// from a member the value doesn't belong to are harmless
+	if flags&(checker.TypeFlagsUnion|checker.TypeFlagsIntersection) != 0 {
+		var sb strings.Builder
+		seen := make(map[string]bool)
+		for _, member := range t.Types() {
+			stmt := g.serializableValidation(member, expr, nameExpr)
+			if stmt != "" && !seen[stmt] {
+				seen[stmt] = true
+				sb.WriteString(stmt)
+			}
+		}
+		return sb.String()
+	}
+
+	if flags&checker.TypeFlagsObject == 0 {
+		return ""
+	}
+
+	if g.isFunctionType(t) {
+		return g.validationError(fmt.Sprintf(`typeof %s !== "function"`, expr), nameExpr, "serialisable", expr)
+	}
+
+	if checker.Checker_isArrayType(g.checker, t) {
+		typeArgs := checker.Checker_getTypeArguments(g.checker, t)
+		if len(typeArgs) == 0 {
+			return ""
+		}
+		idx := g.funcIdx
+		g.funcIdx++
+		iVar := fmt.Sprintf("_i%d", idx)
+		eVar := fmt.Sprintf("_e%d", idx)
+		elemValidation := g.serializableValidation(typeArgs[0], eVar, g.appendArrayIndex(nameExpr, iVar))
+		if elemValidation == "" {
+			return ""
+		}
+		return fmt.Sprintf(`if (Array.isArray(%s)) { for (let %s = 0; %s < %s.length; %s++) { const %s: any = %s[%s]; %s} } `,
+			expr, iVar, iVar, expr, iVar, eVar, expr, iVar, elemValidation)
+	}
+
+	var sb strings.Builder
+
+	if checker.IsTupleType(t) {
+		for i, elemType := range checker.Checker_getTypeArguments(g.checker, t) {
+			sb.WriteString(g.serializableValidation(elemType, fmt.Sprintf("%s[%d]", expr, i), g.appendToName(nameExpr, fmt.Sprintf("[%d]", i))))
+		}
+	} else {
+		// Built-in classes (Date, Map, ArrayBuffer...) are cloned by the runtime itself
+		if g.isBuiltinClassType(t) != "" {
+			return ""
+		}
+
+		for _, prop := range checker.Checker_getPropertiesOfType(g.checker, t) {
+			// Class methods live on the prototype, which structuredClone drops rather than
+			// rejects, as it does symbol-keyed properties
+			if isClassMethod(prop) || isSymbolKeyedProperty(prop) {
+				continue
+			}
+			propName := prop.Name
+			accessor := fmt.Sprintf("%s.%s", expr, propName)
+			if needsQuoting(propName) {
+				accessor = fmt.Sprintf(`%s[%q]`, expr, propName)
+			}
+			sb.WriteString(g.serializableValidation(g.propertyReadType(prop), accessor, g.appendToName(nameExpr, "."+propName)))
+		}
+
+		if stringType := checker.Checker_stringType(g.checker); stringType != nil {
+			if indexValueType := checker.Checker_getIndexTypeOfType(g.checker, t, stringType); indexValueType != nil {
+				idx := g.funcIdx
+				g.funcIdx++
+				kVar := fmt.Sprintf("_k%d", idx)
+				vVar := fmt.Sprintf("_v%d", idx)
+				valueValidation := g.serializableValidation(indexValueType, vVar, g.appendArrayIndex(nameExpr, kVar))
+				if valueValidation != "" {
+					sb.WriteString(fmt.Sprintf(`for (const %s in %s) { const %s: any = %s[%s]; %s} `,
+						kVar, expr, vVar, expr, kVar, valueValidation))
+				}
+			}
+		}
+	}
+
+	if sb.Len() == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`if (typeof %s === "object" && %s !== null) { %s} `, expr, expr, sb.String())
+}
+
+// isClassMethod reports whether prop is a method declared in a class, so it lives on the
+// prototype. Methods of interfaces and object types are own properties holding functions.
+func isClassMethod(prop *ast.Symbol) bool {
+	if prop.Flags&ast.SymbolFlagsMethod == 0 {
+		return false
+	}
+	for _, decl := range prop.Declarations {
+		if decl.Parent != nil && (decl.Parent.Kind == ast.KindClassDeclaration || decl.Parent.Kind == ast.KindClassExpression) {
+			return true
+		}
+	}
+	return false
+}
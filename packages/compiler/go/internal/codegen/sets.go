@@ -0,0 +1,122 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elliots/typical/packages/compiler/internal/utils"
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// setElementType returns the element type of a Set<T> or ReadonlySet<T> from the default
+// library. ok is false for any other type, or when the type argument isn't known.
+func (g *Generator) setElementType(t *checker.Type) (elemType *checker.Type, ok bool) {
+	if g.program == nil {
+		return nil, false
+	}
+	sym := checker.Type_symbol(t)
+	if sym == nil || (sym.Name != "Set" && sym.Name != "ReadonlySet") || !utils.IsSymbolFromDefaultLibrary(g.program, sym) {
+		return nil, false
+	}
+	typeArgs := checker.Checker_getTypeArguments(g.checker, t)
+	if len(typeArgs) != 1 {
+		return nil, false
+	}
+	return typeArgs[0], true
+}
+
+// setValidation generates validation statements for Set and ReadonlySet types: an instance
+// check, then each member is validated against the element type. ReadonlySet has no
+// constructor, but its values are Sets at runtime.
+func (g *Generator) setValidation(elemType *checker.Type, expr string, nameExpr string) string {
+	var sb strings.Builder
+
+	check := g.builtinInstanceCheck(expr, "Set")
+	sb.WriteString(g.validationError(check, nameExpr, "Set instance", expr))
+
+	if skipsEntryValidation(elemType) {
+		return sb.String()
+	}
+
+	// Use unique variable names for nested sets
+	eVar := fmt.Sprintf("_e%d", g.funcIdx)
+	g.funcIdx++
+	elemNameExpr := g.appendToName(nameExpr, " member")
+
+	var elemValidation string
+	if values := g.literalUnionValues(elemType); values != nil {
+		// Membership in a literal union is a single includes() call rather than an if/else chain
+		check := fmt.Sprintf(`[%s].includes(%s)`, strings.Join(values, ", "), eVar)
+		expected := g.getUnionDescription(elemType)
+		gotExpr := g.getGotExpression(elemType, eVar)
+		errorMsg := g.buildErrorMessage(elemNameExpr, expected, gotExpr)
+		elemValidation = g.errorScope(func() string {
+			return fmt.Sprintf(`if (!(%s)) %s; `, check, g.fail(errorMsg, elemNameExpr, expected, gotExpr, eVar))
+		})
+	} else {
+		elemValidation = g.memberValidation(elemType, eVar, elemNameExpr)
+	}
+	if elemValidation != "" {
+		sb.WriteString(fmt.Sprintf(`for (const %s of %s) { %s} `, eVar, expr, elemValidation))
+	}
+
+	return sb.String()
+}
+
+// setCheck generates a boolean expression checking a Set or ReadonlySet and its members.
+func (g *Generator) setCheck(elemType *checker.Type, expr string) string {
+	check := g.builtinInstanceCheck(expr, "Set")
+	if skipsEntryValidation(elemType) {
+		return "(" + check + ")"
+	}
+
+	// Use unique variable names for nested sets
+	eVar := fmt.Sprintf("_e%d", g.funcIdx)
+	g.funcIdx++
+	var elemCheck string
+	if values := g.literalUnionValues(elemType); values != nil {
+		elemCheck = fmt.Sprintf(`[%s].includes(%s)`, strings.Join(values, ", "), eVar)
+	} else {
+		elemCheck = g.generateCheck(elemType, eVar)
+	}
+
+	return fmt.Sprintf(`(%s && Array.from(%s).every((%s: any) => %s))`, check, expr, eVar, elemCheck)
+}
+
+// literalUnionValues returns the JavaScript values of a literal union's members, or nil if t
+// isn't a literal union.
+func (g *Generator) literalUnionValues(t *checker.Type) []string {
+	if !g.isLiteralUnion(t) {
+		return nil
+	}
+	var values []string
+	for _, member := range t.Types() {
+		flags := checker.Type_flags(member)
+		switch {
+		case flags&checker.TypeFlagsNull != 0:
+			values = append(values, "null")
+		case flags&checker.TypeFlagsUndefined != 0:
+			values = append(values, "undefined")
+		case flags&checker.TypeFlagsBigIntLiteral != 0:
+			literal := bigIntLiteral(member)
+			if literal == "" {
+				return nil
+			}
+			values = append(values, literal)
+		default:
+			lt := member.AsLiteralType()
+			if lt == nil {
+				return nil
+			}
+			switch v := lt.Value().(type) {
+			case string:
+				values = append(values, escapeJSStringQuoted(v))
+			case bool:
+				values = append(values, fmt.Sprintf("%t", v))
+			default:
+				values = append(values, fmt.Sprintf("%v", v))
+			}
+		}
+	}
+	return values
+}
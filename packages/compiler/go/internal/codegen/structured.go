@@ -0,0 +1,66 @@
+package codegen
+
+import "fmt"
+
+// TypicalErrorClass returns the declaration of the error thrown with structured errors, a
+// subclass of the error constructor. It's hoisted once into each file that throws one. The
+// message is the same as a plain error's; errors lists each failure when errors are collected.
+func (g *Generator) TypicalErrorClass() string {
+	return fmt.Sprintf(`class TypicalError extends %s { path: string; expected: string; received: string; value: unknown; errors: TypicalError[]; constructor(message: string, path: string, expected: string, received: string, value: unknown, errors: TypicalError[] = []) { super(message); this.path = path; this.expected = expected; this.received = received; this.value = value; this.errors = errors; } }`,
+		g.errorConstructor)
+}
+
+// SetErrorConstructor sets the error class validation failures throw, which is constructed
+// with the error message, e.g. ValidationError for throw new ValidationError(message).
+func (g *Generator) SetErrorConstructor(name string) {
+	g.errorConstructor = name
+}
+
+// SetStructuredErrors controls whether failures are reported as TypicalError objects
+// carrying the path, expected type, what was received and the value itself, rather than as
+// message strings. Check functions then return a TypicalError or null. Filter functions for
+// JSON.parse and JSON.stringify keep returning messages.
+func (g *Generator) SetStructuredErrors(structured bool) {
+	g.structuredErrors = structured
+}
+
+// ErrorType returns the TypeScript type of the errors check functions return.
+func (g *Generator) ErrorType() string {
+	if g.structuredErrors {
+		return "TypicalError"
+	}
+	return "string"
+}
+
+// ThrowError returns a statement throwing the error a check function returned in errorExpr.
+func (g *Generator) ThrowError(errorExpr string) string {
+	if g.structuredErrors {
+		return "throw " + errorExpr
+	}
+	return g.ThrowMessage(errorExpr)
+}
+
+// ThrowMessage returns a statement throwing the error constructor with the message in
+// messageExpr, as filter functions report failures with messages.
+func (g *Generator) ThrowMessage(messageExpr string) string {
+	return fmt.Sprintf("throw new %s(%s)", g.errorConstructor, messageExpr)
+}
+
+// ErrorMessage returns an expression for the message of the error a check function
+// returned in errorExpr.
+func (g *Generator) ErrorMessage(errorExpr string) string {
+	if g.structuredErrors {
+		return errorExpr + ".message"
+	}
+	return errorExpr
+}
+
+// fail returns the statement run when expr, named by nameExpr, isn't the expected type.
+// errorMsg is the message; gotExpr describes what was received instead.
+func (g *Generator) fail(errorMsg, nameExpr, expected, gotExpr, expr string) string {
+	if !g.structuredErrors || g.returnTupleErrors {
+		return g.throwOrReturn(errorMsg)
+	}
+	return g.throwOrReturn(fmt.Sprintf("new TypicalError(%s, %s, %s, %s, %s)",
+		errorMsg, nameExpr, escapeJSStringQuoted(expected), gotExpr, expr))
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/microsoft/typescript-go/shim/ast"
 	"github.com/microsoft/typescript-go/shim/checker"
 )
 
@@ -43,6 +44,12 @@ const (
 	bigintPattern  = `-?(?:0|[1-9][0-9]*)n?`
 )
 
+// templatePrimitiveFlags are the type flags that can appear as a template literal placeholder
+// and map to a regex pattern.
+const templatePrimitiveFlags = checker.TypeFlagsString | checker.TypeFlagsNumber | checker.TypeFlagsBoolean |
+	checker.TypeFlagsBigInt | checker.TypeFlagsStringLiteral | checker.TypeFlagsNumberLiteral |
+	checker.TypeFlagsBooleanLiteral
+
 // parseTemplateLiteral converts a TypeScript template literal type to TemplatePattern.
 func (g *Generator) parseTemplateLiteral(t *checker.Type) *TemplatePattern {
 	// Get the template literal type data
@@ -162,10 +169,109 @@ func (g *Generator) typeToTemplatePart(t *checker.Type) TemplatePart {
 		}
 	}
 
+	// Branded primitive placeholder like `/users/${UserId}` where
+	// UserId = number & { __brand: "UserId" } - the brand is compile-time only,
+	// so the placeholder matches whatever its primitive member matches.
+	if flags&checker.TypeFlagsIntersection != 0 {
+		for _, member := range t.Types() {
+			if checker.Type_flags(member)&templatePrimitiveFlags != 0 {
+				return g.typeToTemplatePart(member)
+			}
+		}
+	}
+
+	// Generic placeholder - use its constraint if it has one
+	if flags&checker.TypeFlagsTypeParameter != 0 {
+		if constraint := checker.Checker_getBaseConstraintOfType(g.checker, t); constraint != nil && constraint != t {
+			return g.typeToTemplatePart(constraint)
+		}
+	}
+
 	// Fallback: any string
 	return TemplatePart{Kind: PartKindAny}
 }
 
+// templateIndexKeys returns the template literal key types of t's index signatures, as in
+// `{ [key: `prefix_${string}`]: number }` or the mapped type `{ [K in `prefix_${string}`]: number }`.
+// The checker keeps no public list of a type's index signatures, so the keys are read from
+// its declarations; each is then confirmed with Checker_getIndexTypeOfType.
+func (g *Generator) templateIndexKeys(t *checker.Type) []*checker.Type {
+	sym := checker.Type_symbol(t)
+	if sym == nil {
+		return nil
+	}
+
+	var keyNodes []*ast.Node
+	for _, decl := range sym.Declarations {
+		if decl.Kind == ast.KindMappedType {
+			if constraint := decl.AsMappedTypeNode().TypeParameter.AsTypeParameter().Constraint; constraint != nil {
+				keyNodes = append(keyNodes, constraint)
+			}
+		}
+	}
+	if index := sym.Members[ast.InternalSymbolNameIndex]; index != nil {
+		for _, decl := range index.Declarations {
+			for _, param := range decl.AsIndexSignatureDeclaration().Parameters.Nodes {
+				if param.Type() != nil {
+					keyNodes = append(keyNodes, param.Type())
+				}
+			}
+		}
+	}
+
+	var keys []*checker.Type
+	for _, node := range keyNodes {
+		for _, key := range typeMembers(checker.Checker_getTypeFromTypeNode(g.checker, node)) {
+			if checker.IsTemplateLiteralType(key) && checker.Checker_getIndexTypeOfType(g.checker, t, key) != nil {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// templateIndexValidation validates the values of properties whose keys match one of t's
+// template literal index signatures. Other keys are extra properties, allowed as usual.
+func (g *Generator) templateIndexValidation(t *checker.Type, expr string, nameExpr string) string {
+	var sb strings.Builder
+	for _, key := range g.templateIndexKeys(t) {
+		pattern := g.parseTemplateLiteral(key)
+		if pattern == nil {
+			continue
+		}
+		idx := g.funcIdx
+		g.funcIdx++
+		kVar := fmt.Sprintf("_k%d", idx)
+		vVar := fmt.Sprintf("_v%d", idx)
+		valNameExpr := g.appendArrayIndex(nameExpr, kVar)
+		valueValidation := g.memberValidation(checker.Checker_getIndexTypeOfType(g.checker, t, key), vVar, valNameExpr)
+		if valueValidation != "" {
+			sb.WriteString(fmt.Sprintf(`for (const %s in %s) { if (/^%s$/.test(%s)) { const %s: any = %s[%s]; %s} } `,
+				kVar, expr, pattern.toRegexPattern(), kVar, vVar, expr, kVar, valueValidation))
+		}
+	}
+	return sb.String()
+}
+
+// templateIndexChecks is templateIndexValidation for boolean checks: a condition per template
+// literal index signature of t, that every key of expr matching it has a valid value.
+func (g *Generator) templateIndexChecks(t *checker.Type, expr string) []string {
+	var checks []string
+	for _, key := range g.templateIndexKeys(t) {
+		pattern := g.parseTemplateLiteral(key)
+		valueType := checker.Checker_getIndexTypeOfType(g.checker, t, key)
+		if pattern == nil || valueType == nil || checker.Type_flags(valueType)&(checker.TypeFlagsAny|checker.TypeFlagsUnknown) != 0 {
+			continue
+		}
+		kVar := fmt.Sprintf("_k%d", g.funcIdx)
+		g.funcIdx++
+		valueCheck := g.generateCheck(valueType, fmt.Sprintf("%s[%s]", expr, kVar))
+		checks = append(checks, fmt.Sprintf("Object.keys(%s).every((%s: string) => !/^%s$/.test(%s) || %s)",
+			expr, kVar, pattern.toRegexPattern(), kVar, valueCheck))
+	}
+	return checks
+}
+
 // RenderAsCheck generates a JavaScript boolean expression for validation using regex.
 func (tp *TemplatePattern) RenderAsCheck(expr string) string {
 	pattern := tp.toRegexPattern()
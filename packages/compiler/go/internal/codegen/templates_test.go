@@ -39,7 +39,13 @@ func TestTemplateLiteralTypes(t *testing.T) {
 		"\n" +
 		"// Nested type reference\n" +
 		"type Status = \"active\" | \"inactive\";\n" +
-		"function testTypeRef(x: `status_${Status}`): void {}\n"
+		"function testTypeRef(x: `status_${Status}`): void {}\n" +
+		"\n" +
+		"// Branded and aliased primitive placeholders\n" +
+		"type UserId = number & { readonly __brand: \"UserId\" };\n" +
+		"type Slug = string;\n" +
+		"function testBranded(x: `/users/${UserId}`): void {}\n" +
+		"function testAliased(x: `/posts/${Slug}/${UserId}`): void {}\n"
 
 	c, sourceFile, program, cleanup := setupTestProject(t, code)
 	defer cleanup()
@@ -146,6 +152,24 @@ func TestTemplateLiteralTypes(t *testing.T) {
 				`"status_inactive"`,
 			},
 		},
+		{
+			funcName:    "testBranded",
+			description: "Branded number placeholder resolves to the number pattern",
+			expectedContain: []string{
+				`"string" === typeof`,
+				`/^\/users\/-?(?:0|[1-9][0-9]*)`,
+			},
+			expectedNot: []string{
+				`/^\/users\/.*?$/`,
+			},
+		},
+		{
+			funcName:    "testAliased",
+			description: "Aliased string and branded number placeholders",
+			expectedContain: []string{
+				`/^\/posts\/.*?\/-?(?:0|[1-9][0-9]*)`,
+			},
+		},
 	}
 
 	for _, tc := range tests {
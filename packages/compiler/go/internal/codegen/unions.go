@@ -1,8 +1,11 @@
 package codegen
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/microsoft/typescript-go/shim/ast"
 	"github.com/microsoft/typescript-go/shim/checker"
 )
 
@@ -20,9 +23,21 @@ func (g *Generator) unionCheck(t *checker.Type, expr string) string {
 		return g.generateCheck(members[0], expr)
 	}
 
-	// Generate check for each member
+	// Discriminated unions dispatch on the tag, so only the matching member is checked
+	if nullish, objects := splitNullish(members); len(objects) > 1 {
+		if prop, literals := g.discriminant(objects); prop != "" {
+			checks := make([]string, 0, len(nullish)+1)
+			for _, member := range nullish {
+				checks = append(checks, g.generateCheck(member, expr))
+			}
+			checks = append(checks, g.discriminatedUnionCheck(objects, prop, literals, expr))
+			return "(" + strings.Join(checks, " || ") + ")"
+		}
+	}
+
+	// Generate check for each member, cheapest first
 	var checks []string
-	for _, member := range members {
+	for _, member := range orderUnionMembers(members) {
 		check := g.generateCheck(member, expr)
 		checks = append(checks, check)
 	}
@@ -31,6 +46,72 @@ func (g *Generator) unionCheck(t *checker.Type, expr string) string {
 	return "(" + strings.Join(checks, " || ") + ")"
 }
 
+// orderUnionMembers sorts union members so the cheapest checks run first - literal and
+// null/undefined comparisons, then typeof checks, then template literal patterns, then
+// structural object checks - so a common input like a string doesn't walk every property
+// of an object member before matching. Members are only reordered when those with different
+// costs can never match the same value (a string member and an object member, say), so the
+// chain stays equivalent; "a" | string keeps its declared order.
+func orderUnionMembers(members []*checker.Type) []*checker.Type {
+	costs := make([]int, len(members))
+	kindCosts := make(map[string]int)
+	for i, member := range members {
+		kind, cost := unionMemberCost(member)
+		if prev, ok := kindCosts[kind]; ok && prev != cost {
+			return members
+		}
+		kindCosts[kind] = cost
+		costs[i] = cost
+	}
+
+	order := make([]int, len(members))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return costs[order[i]] < costs[order[j]]
+	})
+
+	sorted := make([]*checker.Type, len(members))
+	for i, idx := range order {
+		sorted[i] = members[idx]
+	}
+	return sorted
+}
+
+// unionMemberCost returns the runtime kind a union member's check matches (what typeof
+// would report, with null kept apart from objects) and a rough cost for that check.
+func unionMemberCost(t *checker.Type) (string, int) {
+	flags := checker.Type_flags(t)
+	switch {
+	case flags&checker.TypeFlagsNull != 0:
+		return "null", 0
+	case flags&(checker.TypeFlagsUndefined|checker.TypeFlagsVoid) != 0:
+		return "undefined", 0
+	case flags&checker.TypeFlagsStringLiteral != 0:
+		return "string", 0
+	case flags&checker.TypeFlagsNumberLiteral != 0:
+		return "number", 0
+	case flags&checker.TypeFlagsBooleanLiteral != 0:
+		return "boolean", 0
+	case flags&checker.TypeFlagsBigIntLiteral != 0:
+		return "bigint", 0
+	case flags&checker.TypeFlagsString != 0:
+		return "string", 1
+	case flags&checker.TypeFlagsNumber != 0:
+		return "number", 1
+	case flags&checker.TypeFlagsBoolean != 0:
+		return "boolean", 1
+	case flags&checker.TypeFlagsBigInt != 0:
+		return "bigint", 1
+	case flags&checker.TypeFlagsESSymbol != 0:
+		return "symbol", 1
+	case flags&(checker.TypeFlagsTemplateLiteral|checker.TypeFlagsStringMapping) != 0:
+		return "string", 2
+	}
+	return "object", 3
+}
+
 // intersectionCheck generates a JavaScript expression for intersection type checks.
 func (g *Generator) intersectionCheck(t *checker.Type, expr string) string {
 	// Get intersection member types
@@ -45,6 +126,19 @@ func (g *Generator) intersectionCheck(t *checker.Type, expr string) string {
 		return g.generateCheck(members[0], expr)
 	}
 
+	// Branded primitives check the primitive (Number.isInteger for integer brands), and any
+	// range the brand declares
+	if primitiveType, brandType := g.brandParts(members); primitiveType != nil {
+		check := g.generateCheck(primitiveType, expr)
+		if g.isIntegerBrand(primitiveType, brandType) {
+			check = fmt.Sprintf("Number.isInteger(%s)", expr)
+		}
+		if condition, _ := g.brandRange(primitiveType, brandType, expr); condition != "" {
+			check = "(" + check + " && " + condition + ")"
+		}
+		return check
+	}
+
 	// Generate check for each member - all must pass
 	var checks []string
 	for _, member := range members {
@@ -55,3 +149,130 @@ func (g *Generator) intersectionCheck(t *checker.Type, expr string) string {
 	// Join with AND
 	return "(" + strings.Join(checks, " && ") + ")"
 }
+
+// discriminant finds the property that tells the members of an object union apart:
+// required in every member, with a distinct string or number literal type in each.
+// Returns the property name and each member's literal type, or "" if there isn't one.
+func (g *Generator) discriminant(members []*checker.Type) (string, []*checker.Type) {
+	for _, member := range members {
+		if checker.Type_flags(member)&checker.TypeFlagsObject == 0 || checker.Checker_isArrayType(g.checker, member) || checker.IsTupleType(member) {
+			return "", nil
+		}
+	}
+
+	for _, prop := range checker.Checker_getPropertiesOfType(g.checker, members[0]) {
+		if isSymbolKeyedProperty(prop) {
+			continue
+		}
+		var literals []*checker.Type
+		seen := make(map[string]bool)
+		for _, member := range members {
+			memberProp := checker.Checker_getPropertyOfType(g.checker, member, prop.Name)
+			if memberProp == nil || memberProp.Flags&ast.SymbolFlagsOptional != 0 {
+				break
+			}
+			propType := checker.Checker_getTypeOfSymbol(g.checker, memberProp)
+			value := literalExpr(propType)
+			if value == "" || seen[value] {
+				break
+			}
+			seen[value] = true
+			literals = append(literals, propType)
+		}
+		if len(literals) == len(members) {
+			return prop.Name, literals
+		}
+	}
+	return "", nil
+}
+
+// literalExpr returns the JS expression for a string, number or bigint literal type, or "" otherwise.
+func literalExpr(t *checker.Type) string {
+	flags := checker.Type_flags(t)
+	lt := t.AsLiteralType()
+	if lt == nil {
+		return ""
+	}
+	if flags&checker.TypeFlagsStringLiteral != 0 {
+		if str, ok := lt.Value().(string); ok {
+			return escapeJSStringQuoted(str)
+		}
+	}
+	if flags&checker.TypeFlagsNumberLiteral != 0 {
+		return fmt.Sprintf("%v", lt.Value())
+	}
+	if flags&checker.TypeFlagsBigIntLiteral != 0 {
+		return bigIntLiteral(t)
+	}
+	return ""
+}
+
+// splitNullish separates a union's null, undefined and void members from the rest.
+func splitNullish(members []*checker.Type) (nullish, rest []*checker.Type) {
+	for _, member := range members {
+		if checker.Type_flags(member)&(checker.TypeFlagsNull|checker.TypeFlagsUndefined|checker.TypeFlagsVoid) != 0 {
+			nullish = append(nullish, member)
+		} else {
+			rest = append(rest, member)
+		}
+	}
+	return nullish, rest
+}
+
+// discriminantAccessor returns the expression reading a discriminant property of expr.
+func discriminantAccessor(expr, prop string) string {
+	if needsQuoting(prop) {
+		return fmt.Sprintf(`%s[%q]`, expr, prop)
+	}
+	return fmt.Sprintf("%s.%s", expr, prop)
+}
+
+// discriminatedUnionValidation validates a discriminated union by switching on its tag,
+// so only the matching member's validation runs rather than trying each member in turn.
+// Each case gets its own block so the members' const temporaries (_i/_e etc.) can't clash.
+// nullish holds the union's null/undefined members, which are accepted before the switch.
+func (g *Generator) discriminatedUnionValidation(t *checker.Type, members []*checker.Type, nullish []*checker.Type, prop string, literals []*checker.Type, expr string, nameExpr string) string {
+	var sb strings.Builder
+
+	check := fmt.Sprintf(`typeof %s === "object" && %s !== null`, expr, expr)
+	sb.WriteString(g.validationError(check, nameExpr, g.getUnionDescription(t), expr))
+
+	accessor := discriminantAccessor(expr, prop)
+
+	var expected []string
+	sb.WriteString(fmt.Sprintf("switch (%s) { ", accessor))
+	for i, member := range members {
+		sb.WriteString(fmt.Sprintf("case %s: { %sbreak; } ", literalExpr(literals[i]), g.generateValidation(member, expr, nameExpr)))
+		expected = append(expected, g.getExpectedType(literals[i]))
+	}
+
+	tagNameExpr := g.appendToName(nameExpr, "."+prop)
+	tagExpected := strings.Join(expected, " | ")
+	gotExpr := gotExprForWithValue(accessor)
+	errorMsg := g.buildErrorMessage(tagNameExpr, tagExpected, gotExpr)
+	sb.WriteString(fmt.Sprintf("default: %s; } ", g.fail(errorMsg, tagNameExpr, tagExpected, gotExpr, accessor)))
+
+	if len(nullish) > 0 {
+		var checks []string
+		for _, member := range nullish {
+			checks = append(checks, g.generateCheck(member, expr))
+		}
+		return fmt.Sprintf("if (%s) { } else { %s} ", strings.Join(checks, " || "), sb.String())
+	}
+	return sb.String()
+}
+
+// discriminatedUnionCheck generates a boolean expression checking a discriminated union by
+// its tag, e.g. (x.kind === "a" ? <check A> : x.kind === "b" ? <check B> : false), so only
+// the matching member's shape is checked.
+func (g *Generator) discriminatedUnionCheck(members []*checker.Type, prop string, literals []*checker.Type, expr string) string {
+	accessor := discriminantAccessor(expr, prop)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`("object" === typeof %s && null !== %s && (`, expr, expr))
+	for i, member := range members {
+		sb.WriteString(fmt.Sprintf("%s === %s ? %s : ", accessor, literalExpr(literals[i]), g.generateCheck(member, expr)))
+	}
+	sb.WriteString("false))")
+	return sb.String()
+}
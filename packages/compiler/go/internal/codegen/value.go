@@ -0,0 +1,413 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// undefinedValue stands in for a missing property or tuple element, which JSON can't express.
+type undefinedValue struct{}
+
+// CheckValue checks a decoded JSON value (from encoding/json, so null, bool, float64, string,
+// []any or map[string]any) against a type by interpreting the type directly, for tooling
+// that has a value to test but nowhere to run the generated validator.
+// Returns "" if the value is valid, otherwise the message the validator would throw.
+func (g *Generator) CheckValue(t *checker.Type, value any, name string) string {
+	g.reset()
+	return g.valueError(t, value, name)
+}
+
+// valueError mirrors generateValidation for a concrete value. Recursive types need no cycle
+// detection here - the walk follows the value, which is finite.
+func (g *Generator) valueError(t *checker.Type, v any, name string) string {
+	class, parts := g.classifyType(t)
+
+	switch class {
+	case typeUnchecked:
+		return ""
+	case typeNever:
+		return name + " should never have a value"
+	case typeTemplateLiteral:
+		str, ok := v.(string)
+		pattern := g.parseTemplateLiteral(t)
+		if pattern == nil {
+			if !ok {
+				return valueErrorMessage(name, "template literal", valueTypeWithValue(v))
+			}
+			return ""
+		}
+		// The pattern is written for a JavaScript regex literal; if RE2 can't take it, only
+		// string-ness is checked
+		re, err := regexp.Compile("^" + pattern.toRegexPattern() + "$")
+		if !ok || (err == nil && !re.MatchString(str)) {
+			return valueErrorMessage(name, pattern.getExpectedDescription(), valueTypeWithValue(v))
+		}
+		return ""
+	case typePrimitive:
+		msg, _ := g.primitiveValueError(t, v, name)
+		return msg
+	case typeUnion:
+		for _, member := range parts {
+			if g.valueError(member, v, name) == "" {
+				return ""
+			}
+		}
+		if len(parts) == 1 {
+			return g.valueError(parts[0], v, name)
+		}
+		got := valueTypeOf(v)
+		if g.isLiteralUnion(t) {
+			if str, ok := v.(string); ok {
+				got = "'" + str + "'"
+			} else {
+				got = valueString(v)
+			}
+		}
+		return valueErrorMessage(name, g.getUnionDescription(t), got)
+	case typeBranded:
+		// Brands are compile-time only
+		return g.valueError(parts[0], v, name)
+	case typeIntersection:
+		for _, member := range parts {
+			if msg := g.valueError(member, v, name); msg != "" {
+				return msg
+			}
+		}
+		return ""
+	case typeBroadObject:
+		switch v.(type) {
+		case map[string]any, []any:
+			return ""
+		}
+		return valueErrorMessage(name, "object", valueConstructorName(v))
+	case typeEmptyObject:
+		if _, missing := v.(undefinedValue); missing || v == nil {
+			return valueErrorMessage(name, "{}", valueConstructorName(v))
+		}
+		return ""
+	case typeFunction:
+		typeName := "function"
+		if sym := checker.Type_symbol(t); sym != nil && isGoodTypeName(sym.Name) {
+			typeName = sym.Name
+		}
+		return valueErrorMessage(name, typeName, valueConstructorName(v))
+	}
+
+	return g.objectValueError(t, v, name)
+}
+
+// primitiveValueError mirrors primitiveValidation. handled is false if t isn't a primitive.
+func (g *Generator) primitiveValueError(t *checker.Type, v any, name string) (msg string, handled bool) {
+	flags := checker.Type_flags(t)
+
+	literal := func(expected string, ok bool) (string, bool) {
+		if ok {
+			return "", true
+		}
+		return valueErrorMessage(name, expected, valueTypeWithValue(v)), true
+	}
+	primitive := func(expected string, ok bool) (string, bool) {
+		if ok {
+			return "", true
+		}
+		return valueErrorMessage(name, expected, valueConstructorName(v)), true
+	}
+
+	switch {
+	case flags&checker.TypeFlagsStringLiteral != 0:
+		if lt := t.AsLiteralType(); lt != nil {
+			if str, ok := lt.Value().(string); ok {
+				return literal(fmt.Sprintf("%q", str), v == str)
+			}
+		}
+		_, ok := v.(string)
+		return primitive("string", ok)
+	case flags&checker.TypeFlagsNumberLiteral != 0:
+		if lt := t.AsLiteralType(); lt != nil {
+			want, err := strconv.ParseFloat(fmt.Sprintf("%v", lt.Value()), 64)
+			got, ok := v.(float64)
+			return literal(fmt.Sprintf("%v", lt.Value()), err == nil && ok && got == want)
+		}
+		_, ok := v.(float64)
+		return primitive("number", ok)
+	case flags&checker.TypeFlagsBooleanLiteral != 0:
+		if lt := t.AsLiteralType(); lt != nil {
+			if b, ok := lt.Value().(bool); ok {
+				return literal(fmt.Sprintf("%t", b), v == b)
+			}
+		}
+		_, ok := v.(bool)
+		return primitive("boolean", ok)
+	case flags&checker.TypeFlagsString != 0:
+		_, ok := v.(string)
+		return primitive("string", ok)
+	case flags&checker.TypeFlagsNumber != 0:
+		_, ok := v.(float64)
+		return primitive("number", ok)
+	case flags&checker.TypeFlagsBoolean != 0:
+		_, ok := v.(bool)
+		return primitive("boolean", ok)
+	case flags&(checker.TypeFlagsBigInt|checker.TypeFlagsBigIntLiteral) != 0:
+		// JSON has no bigints
+		return primitive("bigint", false)
+	case flags&checker.TypeFlagsNull != 0:
+		return primitive("null", v == nil)
+	case flags&checker.TypeFlagsUndefined != 0:
+		_, ok := v.(undefinedValue)
+		return primitive("undefined", ok)
+	case flags&checker.TypeFlagsVoid != 0:
+		_, ok := v.(undefinedValue)
+		return primitive("void", ok)
+	}
+	return "", false
+}
+
+// objectValueError mirrors objectValidation, arrayValidation and tupleValidation.
+func (g *Generator) objectValueError(t *checker.Type, v any, name string) string {
+	isArray := checker.Checker_isArrayType(g.checker, t)
+	if sym := checker.Type_symbol(t); sym != nil && isArraySymbolName(sym.Name) {
+		isArray = true
+	}
+
+	if isArray {
+		arr, ok := v.([]any)
+		if !ok {
+			return valueErrorMessage(name, "array", valueConstructorName(v))
+		}
+		typeArgs := checker.Checker_getTypeArguments(g.checker, t)
+		if len(typeArgs) == 0 {
+			return ""
+		}
+		for i, elem := range arr {
+			if msg := g.valueError(typeArgs[0], elem, fmt.Sprintf("%s[%d]", name, i)); msg != "" {
+				return msg
+			}
+		}
+		return ""
+	}
+
+	if checker.IsTupleType(t) {
+		return g.tupleValueError(t, v, name)
+	}
+
+	// JSON can't produce class instances, built-in or otherwise
+	if className := g.isBuiltinClassType(t); className != "" {
+		return valueErrorMessage(name, className+" instance", valueConstructorName(v))
+	}
+	if g.isClassType(t) {
+		if sym := checker.Type_symbol(t); sym != nil && !g.isTypeOnlyImport(sym) {
+			return valueErrorMessage(name, sym.Name+" instance", valueConstructorName(v))
+		}
+	}
+
+	typeName := "object"
+	if sym := checker.Type_symbol(t); sym != nil && isGoodTypeName(sym.Name) {
+		typeName = sym.Name
+	}
+
+	// Arrays are objects too, with their indices as keys
+	var obj map[string]any
+	switch value := v.(type) {
+	case map[string]any:
+		obj = value
+	case []any:
+		obj = make(map[string]any, len(value))
+		for i, elem := range value {
+			obj[strconv.Itoa(i)] = elem
+		}
+	default:
+		return valueErrorMessage(name, typeName, valueConstructorName(v))
+	}
+
+	for _, prop := range checker.Checker_getPropertiesOfType(g.checker, t) {
+		if isSymbolKeyedProperty(prop) {
+			continue
+		}
+		propType := g.propertyReadType(prop)
+		propName := name + "." + prop.Name
+		propValue, present := obj[prop.Name]
+
+		if checker.Type_flags(propType)&checker.TypeFlagsNever != 0 {
+			if present {
+				return valueErrorMessage(propName, "never (property must not exist)", valueConstructorName(v))
+			}
+			continue
+		}
+
+		if !present {
+			propValue = undefinedValue{}
+		}
+
+		if isOptionalProperty(prop) {
+			if !present {
+				continue
+			}
+		} else if g.rejectEmptyObjects && !present && acceptsMissingValue(propType) {
+			return valueErrorMessage(propName, "present", "undefined")
+		}
+
+		if msg := g.valueError(propType, propValue, propName); msg != "" {
+			return msg
+		}
+	}
+
+	if stringType := checker.Checker_stringType(g.checker); stringType != nil {
+		if indexValueType := checker.Checker_getIndexTypeOfType(g.checker, t, stringType); indexValueType != nil {
+			// Map iteration order is random - sort so the first error reported is stable
+			keys := make([]string, 0, len(obj))
+			for key := range obj {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if msg := g.valueError(indexValueType, obj[key], name+"["+key+"]"); msg != "" {
+					return msg
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// tupleValueError mirrors tupleValidation.
+func (g *Generator) tupleValueError(t *checker.Type, v any, name string) string {
+	arr, ok := v.([]any)
+	if !ok {
+		return valueErrorMessage(name, "tuple", valueConstructorName(v))
+	}
+
+	typeArgs, elementInfos, restIndex, minLen := g.tupleLayout(t)
+
+	switch {
+	case restIndex >= 0:
+		if len(arr) < minLen {
+			return valueErrorMessage(name, fmt.Sprintf("at least %d elements", minLen), strconv.Itoa(len(arr)))
+		}
+	case minLen < len(typeArgs):
+		if len(arr) > len(typeArgs) {
+			return valueErrorMessage(name, fmt.Sprintf("at most %d elements", len(typeArgs)), strconv.Itoa(len(arr)))
+		}
+	default:
+		if len(arr) != len(typeArgs) {
+			return valueErrorMessage(name, fmt.Sprintf("%d elements", len(typeArgs)), strconv.Itoa(len(arr)))
+		}
+	}
+
+	if restIndex < 0 {
+		for i, elemType := range typeArgs {
+			if i >= len(arr) {
+				if isOptionalTupleElement(elementInfos, i) {
+					continue
+				}
+				return valueErrorMessage(fmt.Sprintf("%s[%d]", name, i), g.getExpectedType(elemType), "undefined")
+			}
+			if msg := g.valueError(elemType, arr[i], fmt.Sprintf("%s[%d]", name, i)); msg != "" {
+				return msg
+			}
+		}
+		return ""
+	}
+
+	trailingCount := len(typeArgs) - restIndex - 1
+	for i := 0; i < len(arr); i++ {
+		elemType := typeArgs[restIndex]
+		if i < restIndex {
+			elemType = typeArgs[i]
+		} else if i >= len(arr)-trailingCount {
+			elemType = typeArgs[restIndex+1+i-(len(arr)-trailingCount)]
+		}
+		if msg := g.valueError(elemType, arr[i], fmt.Sprintf("%s[%d]", name, i)); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// valueErrorMessage builds the default "Expected name to be X, got Y" message.
+func valueErrorMessage(name, expected, got string) string {
+	return fmt.Sprintf("Expected %s to be %s, got %s", name, expected, got)
+}
+
+// valueTypeOf returns what JavaScript's typeof gives for a decoded JSON value.
+func valueTypeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case undefinedValue:
+		return "undefined"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "object"
+	}
+}
+
+// valueConstructorName mirrors gotExprFor: null, undefined, or the value's constructor name.
+func valueConstructorName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case undefinedValue:
+		return "undefined"
+	case string:
+		return "String"
+	case float64:
+		return "Number"
+	case bool:
+		return "Boolean"
+	case []any:
+		return "Array"
+	default:
+		return "Object"
+	}
+}
+
+// valueTypeWithValue mirrors gotExprForWithValue: typeof plus the value, truncated to 50 chars.
+func valueTypeWithValue(v any) string {
+	s := valueString(v)
+	if len(s) > 50 {
+		s = s[:47] + "..."
+	}
+	return valueTypeOf(v) + " (" + s + ")"
+}
+
+// valueString approximates JavaScript's String() for a decoded JSON value.
+func valueString(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return "null"
+	case undefinedValue:
+		return "undefined"
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	case []any:
+		parts := make([]string, len(value))
+		for i, elem := range value {
+			if elem != nil {
+				parts[i] = valueString(elem)
+			}
+		}
+		return strings.Join(parts, ",")
+	default:
+		return "[object Object]"
+	}
+}
+
+// isOptionalTupleElement reports whether the tuple element at index i may be left out.
+func isOptionalTupleElement(infos []checker.TupleElementInfo, i int) bool {
+	return i < len(infos) && infos[i].TupleElementFlags()&checker.ElementFlagsOptional != 0
+}
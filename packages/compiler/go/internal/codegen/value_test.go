@@ -0,0 +1,125 @@
+package codegen
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestCheckValue tests checking JSON values against types without generating code.
+func TestCheckValue(t *testing.T) {
+	code := `
+interface Address {
+	street: string;
+	postcode?: number;
+}
+
+interface User {
+	name: string;
+	role: "admin" | "user";
+	tags: string[];
+	address: Address;
+	point: [number, number];
+	meta: Record<string, boolean>;
+}
+
+interface Tree {
+	value: number;
+	children?: Tree[];
+}
+
+function testUser(user: User): void {}
+function testTree(tree: Tree): void {}
+function testId(id: ` + "`user-${number}`" + `): void {}
+`
+
+	c, sourceFile, program, cleanup := setupTestProject(t, code)
+	defer cleanup()
+
+	gen := NewGenerator(c, program)
+
+	validUser := `{"name": "Ann", "role": "admin", "tags": ["a"], "address": {"street": "Main"}, "point": [1, 2], "meta": {"x": true}}`
+
+	tests := []struct {
+		name          string
+		funcName      string
+		value         string
+		expectedError string
+	}{
+		{name: "valid object", funcName: "testUser", value: validUser},
+		{
+			name:          "not an object",
+			funcName:      "testUser",
+			value:         `"Ann"`,
+			expectedError: "Expected value to be User, got String",
+		},
+		{
+			name:          "literal union mismatch",
+			funcName:      "testUser",
+			value:         `{"name": "Ann", "role": "owner", "tags": [], "address": {"street": "Main"}, "point": [1, 2], "meta": {}}`,
+			expectedError: "Expected value.role to be 'admin' | 'user', got 'owner'",
+		},
+		{
+			name:          "missing required property",
+			funcName:      "testUser",
+			value:         `{"name": "Ann", "role": "user", "tags": [], "point": [1, 2], "meta": {}}`,
+			expectedError: "Expected value.address to be Address, got undefined",
+		},
+		{
+			name:          "wrong optional property",
+			funcName:      "testUser",
+			value:         `{"name": "Ann", "role": "user", "tags": [], "address": {"street": "Main", "postcode": "2000"}, "point": [1, 2], "meta": {}}`,
+			expectedError: "Expected value.address.postcode to be undefined | number, got string",
+		},
+		{
+			name:          "array element",
+			funcName:      "testUser",
+			value:         `{"name": "Ann", "role": "user", "tags": ["a", 2], "address": {"street": "Main"}, "point": [1, 2], "meta": {}}`,
+			expectedError: "Expected value.tags[1] to be string, got Number",
+		},
+		{
+			name:          "tuple length",
+			funcName:      "testUser",
+			value:         `{"name": "Ann", "role": "user", "tags": [], "address": {"street": "Main"}, "point": [1], "meta": {}}`,
+			expectedError: "Expected value.point to be 2 elements, got 1",
+		},
+		{
+			name:          "index signature value",
+			funcName:      "testUser",
+			value:         `{"name": "Ann", "role": "user", "tags": [], "address": {"street": "Main"}, "point": [1, 2], "meta": {"x": 1}}`,
+			expectedError: "Expected value.meta[x] to be boolean, got Number",
+		},
+		{name: "recursive valid", funcName: "testTree", value: `{"value": 1, "children": [{"value": 2, "children": []}]}`},
+		{
+			name:          "recursive invalid",
+			funcName:      "testTree",
+			value:         `{"value": 1, "children": [{"value": "2"}]}`,
+			expectedError: "Expected value.children[0].value to be number, got String",
+		},
+		{name: "template literal valid", funcName: "testId", value: `"user-42"`},
+		{
+			name:          "template literal invalid",
+			funcName:      "testId",
+			value:         `"user-abc"`,
+			expectedError: "Expected value to be `user-${number}`, got string (user-abc)",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			paramType := findFunctionParamType(c, sourceFile, tc.funcName)
+			if paramType == nil {
+				t.Fatalf("could not find param type for %s", tc.funcName)
+			}
+
+			var value any
+			if err := json.Unmarshal([]byte(tc.value), &value); err != nil {
+				t.Fatalf("invalid test value: %v", err)
+			}
+
+			got := gen.CheckValue(paramType, value, "value")
+			if got != tc.expectedError {
+				t.Errorf("expected error %q, got %q", tc.expectedError, got)
+			}
+		})
+	}
+}
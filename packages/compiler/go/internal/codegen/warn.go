@@ -0,0 +1,53 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// SetWarnTypes sets the patterns for types whose validation failures are logged with
+// console.warn instead of thrown. Matching uses the same type names as ignoreTypes.
+func (g *Generator) SetWarnTypes(patterns []*regexp.Regexp) {
+	g.warnTypes = patterns
+}
+
+// ShouldWarnType reports whether validation failures for t should be logged and
+// execution continue, rather than throwing, because t matches a warn pattern. Types are
+// matched by the same names as ignoreTypes.
+func (g *Generator) ShouldWarnType(t *checker.Type) bool {
+	if len(g.warnTypes) == 0 || t == nil {
+		return false
+	}
+	if matchTypePattern(g.warnTypes, g.checker.TypeToString(t)) != "" {
+		return true
+	}
+	sym := checker.Type_symbol(t)
+	return sym != nil && sym.Name != "" && matchTypePattern(g.warnTypes, sym.Name) != ""
+}
+
+// warnOnFailure wraps throw-mode validation statements for t so that, if t is a warn type,
+// the first failure is logged and execution carries on. Other types are returned unchanged.
+func (g *Generator) warnOnFailure(t *checker.Type, statements string) string {
+	if statements == "" || g.returnErrors || g.returnTupleErrors || !g.ShouldWarnType(t) {
+		return statements
+	}
+	return "try { " + statements + "} catch (_w: any) { console.warn(_w.message); } "
+}
+
+// nestedWarnOnFailure wraps the validation statements of a warn type found inside another
+// type, such as a property or array element, so that its failure is logged and the rest of
+// the value is still checked. Check functions return their failure instead of throwing it,
+// so there the statements run in a function whose result is logged. Filter functions
+// rebuild the value as they go and still fail.
+func (g *Generator) nestedWarnOnFailure(statements string) string {
+	if statements == "" || g.returnTupleErrors {
+		return statements
+	}
+	if g.returnErrors {
+		return fmt.Sprintf("{ const _w = ((): any => { %sreturn null; })(); if (_w !== null) console.warn(%s); } ",
+			statements, g.ErrorMessage("_w"))
+	}
+	return "try { " + statements + "} catch (_w: any) { console.warn(_w.message); } "
+}
@@ -3,8 +3,10 @@ package server
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 
 	"github.com/microsoft/typescript-go/shim/bundled"
@@ -29,12 +31,15 @@ type APIOptions struct {
 	Cwd                string
 	FS                 vfs.FS
 	DefaultLibraryPath string
+	Config             transform.Config // Base config for transforms, before request options
 }
 
 type projectInfo struct {
 	path     tspath.Path
 	project  *project.Project
 	analysis *analyse.ProjectAnalysis // cached project analysis
+	changed  []string                 // files edited since analysis, for the next one to re-collect
+	stale    map[string]bool          // files re-collected since TakeStaleFiles was last called
 }
 
 type API struct {
@@ -46,6 +51,7 @@ type API struct {
 	nextId       int
 	fileVersions map[string]int32 // track version per file for overlays
 	openFiles    map[string]bool  // track which files have been opened via DidOpenFile
+	config       transform.Config // base config, from typical.config.json or the defaults
 }
 
 func NewAPI(opts *APIOptions) *API {
@@ -66,6 +72,7 @@ func NewAPI(opts *APIOptions) *API {
 		projects:     make(map[string]*projectInfo),
 		fileVersions: make(map[string]int32),
 		openFiles:    make(map[string]bool),
+		config:       opts.Config,
 	}
 }
 
@@ -104,7 +111,7 @@ func (a *API) LoadProject(configFileName string) (*ProjectResponse, error) {
 	}, nil
 }
 
-func (a *API) TransformFile(projectId, fileName, content string, ignoreTypes []string, maxGeneratedFunctions int) (*TransformResponse, error) {
+func (a *API) TransformFile(projectId, fileName, content string, ignoreTypes []string, maxGeneratedFunctions int, excludePatterns []string, sidecarValidators bool) (*TransformResponse, error) {
 	debugf("[DEBUG] TransformFile called: project=%s file=%s contentLen=%d ignoreTypes=%v maxFuncs=%d\n", projectId, fileName, len(content), ignoreTypes, maxGeneratedFunctions)
 
 	a.mu.Lock()
@@ -131,8 +138,10 @@ func (a *API) TransformFile(projectId, fileName, content string, ignoreTypes []s
 		version := a.fileVersions[fileName]
 		isOpen := a.openFiles[fileName]
 
-		// Invalidate project analysis cache when any file changes
-		projInfo.analysis = nil
+		// Mark the project analysis stale, to be redone for the files that changed
+		if !slices.Contains(projInfo.changed, fileName) {
+			projInfo.changed = append(projInfo.changed, fileName)
+		}
 		debugf("[DEBUG] Invalidated project analysis due to file change\n")
 		a.mu.Unlock()
 
@@ -181,17 +190,24 @@ func (a *API) TransformFile(projectId, fileName, content string, ignoreTypes []s
 	defer release()
 	debugf("[DEBUG] Got type checker\n")
 
-	// Build config with ignore patterns and max functions limit
-	config := transform.DefaultConfig()
-	config.IgnoreTypes = transform.CompileIgnorePatterns(ignoreTypes)
+	// Build config with ignore patterns and max functions limit, which replace the base
+	// config's when given
+	config := a.config
+	if len(ignoreTypes) > 0 {
+		config.IgnoreTypes = transform.CompileIgnorePatterns(ignoreTypes)
+	}
 	if maxGeneratedFunctions > 0 {
 		config.MaxGeneratedFunctions = maxGeneratedFunctions
 	}
+	if len(excludePatterns) > 0 {
+		config.ExcludePatterns = excludePatterns
+	}
+	config.SidecarValidators = config.SidecarValidators || sidecarValidators
 
 	// Lazy project analysis: compute if not cached
 	a.mu.Lock()
-	if projInfo.analysis == nil {
-		debugf("[DEBUG] Computing project analysis...\n")
+	if projInfo.analysis == nil || len(projInfo.changed) > 0 {
+		debugf("[DEBUG] Computing project analysis (%d files changed)...\n", len(projInfo.changed))
 		analyseConfig := analyse.Config{
 			ValidateParameters:     config.ValidateParameters,
 			ValidateReturns:        config.ValidateReturns,
@@ -200,8 +216,16 @@ func (a *API) TransformFile(projectId, fileName, content string, ignoreTypes []s
 			TransformJSONStringify: config.TransformJSONStringify,
 			IgnoreTypes:            config.IgnoreTypes,
 			PureFunctions:          config.PureFunctions,
+			Concurrency:            config.AnalysisConcurrency,
+		}
+		projInfo.analysis = analyse.AnalyseProjectIncremental(program, checker, analyseConfig, projInfo.analysis, projInfo.changed)
+		projInfo.changed = nil
+		for fileName := range projInfo.analysis.StaleFiles {
+			if projInfo.stale == nil {
+				projInfo.stale = make(map[string]bool)
+			}
+			projInfo.stale[fileName] = true
 		}
-		projInfo.analysis = analyse.AnalyseProject(program, checker, analyseConfig)
 		debugf("[DEBUG] Project analysis complete: %d functions found\n", len(projInfo.analysis.CallGraph))
 	}
 	projectAnalysis := projInfo.analysis
@@ -210,18 +234,50 @@ func (a *API) TransformFile(projectId, fileName, content string, ignoreTypes []s
 	// Pass project analysis to transform config
 	config.ProjectAnalysis = projectAnalysis
 
+	// Excluded files (e.g. tests with partial mocks) are still part of the project analysis
+	// above, but pass through unchanged
+	if config.IsExcludedFile(fileName) {
+		debugf("[DEBUG] File matches excludePatterns, skipping transform\n")
+		return &TransformResponse{Code: sourceFile.Text()}, nil
+	}
+
 	// Transform the file with source map
 	debugf("[DEBUG] Starting transform...\n")
-	code, sourceMap, err := transform.TransformFileWithSourceMapAndError(sourceFile, checker, program, config)
+	code, sourceMap, sidecar, err := transform.TransformFileWithSidecar(sourceFile, checker, program, config)
 	if err != nil {
 		return nil, err
 	}
 	debugf("[DEBUG] Transform complete, code length: %d\n", len(code))
 
-	return &TransformResponse{
+	resp := &TransformResponse{
 		Code:      code,
 		SourceMap: sourceMap,
-	}, nil
+	}
+	if sidecar != nil {
+		// Only write when it changed, so watchers don't see a rebuild loop
+		if existing, err := os.ReadFile(sidecar.FileName); err != nil || string(existing) != sidecar.Code {
+			if err := os.WriteFile(sidecar.FileName, []byte(sidecar.Code), 0o644); err != nil {
+				return nil, fmt.Errorf("failed to write sidecar %s: %w", sidecar.FileName, err)
+			}
+		}
+		resp.SidecarFileName = sidecar.FileName
+	}
+	return resp, nil
+}
+
+// TakeStaleFiles returns the files of a project that incremental analysis has re-collected
+// since it was last called, because they or a file they import from changed, and forgets
+// them. Their transformed output can differ even if they didn't change themselves.
+func (a *API) TakeStaleFiles(projectId string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	projInfo, ok := a.projects[projectId]
+	if !ok {
+		return nil
+	}
+	stale := slices.Sorted(maps.Keys(projInfo.stale))
+	projInfo.stale = nil
+	return stale
 }
 
 // TransformSource transforms a standalone TypeScript source string without needing a project.
@@ -279,8 +335,10 @@ func (a *API) TransformSource(fileName, source string, ignoreTypes []string, max
 	defer release()
 
 	// Build config with ignore patterns and max functions limit
-	config := transform.DefaultConfig()
-	config.IgnoreTypes = transform.CompileIgnorePatterns(ignoreTypes)
+	config := a.config
+	if len(ignoreTypes) > 0 {
+		config.IgnoreTypes = transform.CompileIgnorePatterns(ignoreTypes)
+	}
 	if maxGeneratedFunctions > 0 {
 		config.MaxGeneratedFunctions = maxGeneratedFunctions
 	}
@@ -295,6 +353,7 @@ func (a *API) TransformSource(fileName, source string, ignoreTypes []string, max
 		TransformJSONStringify: config.TransformJSONStringify,
 		IgnoreTypes:            config.IgnoreTypes,
 		PureFunctions:          config.PureFunctions,
+		Concurrency:            config.AnalysisConcurrency,
 	}
 	projectAnalysis := analyse.AnalyseProject(program, checker, analyseConfig)
 	config.ProjectAnalysis = projectAnalysis
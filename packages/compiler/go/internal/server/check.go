@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/elliots/typical/packages/compiler/internal/analyse"
+	"github.com/elliots/typical/packages/compiler/internal/transform"
+)
+
+// CheckFailure is a validation point that typical wanted to validate but had to skip,
+// or a file whose validators would need more helper functions than maxGeneratedFunctions
+// allows, in which case Complexity holds the generator's error and Item is unset.
+type CheckFailure struct {
+	FileName   string
+	Item       analyse.ValidationItem
+	Complexity string
+}
+
+func (f CheckFailure) String() string {
+	if f.Complexity != "" {
+		return fmt.Sprintf("%s: %s", f.FileName, f.Complexity)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s %q of type %s not validated: %s",
+		f.FileName, f.Item.StartLine, f.Item.StartColumn+1, f.Item.Kind, f.Item.Name, f.Item.TypeString, f.Item.SkipReason)
+}
+
+// CheckProject analyses every root file of a tsconfig project and returns the
+// validation points that were skipped because their types are generic or too complex,
+// excluding any whose type matches config.AllowedSkips. With config.NoAnyBoundaries,
+// parameters and return types of exported functions typed any are returned too.
+// Types only turn out to be too complex while their validators are generated, so each
+// file is also transformed, and one exceeding config.MaxGeneratedFunctions fails even
+// with DegradeOnComplexity, whose depth-limited checks skip the rest of the type.
+func (a *API) CheckProject(configFileName string, config transform.Config) ([]CheckFailure, error) {
+	resp, err := a.LoadProject(configFileName)
+	if err != nil {
+		return nil, err
+	}
+	defer a.Release(resp.Id)
+
+	a.mu.Lock()
+	projInfo := a.projects[resp.Id]
+	a.mu.Unlock()
+
+	program := projInfo.project.GetProgram()
+	checker, release := program.GetTypeChecker(context.Background())
+	defer release()
+
+	analyseConfig := analyse.Config{
+		ValidateParameters:     config.ValidateParameters,
+		ValidateReturns:        config.ValidateReturns,
+		ValidateCasts:          config.ValidateCasts,
+		TransformJSONParse:     config.TransformJSONParse,
+		TransformJSONStringify: config.TransformJSONStringify,
+		IgnoreTypes:            config.IgnoreTypes,
+		PureFunctions:          config.PureFunctions,
+		TrustedFunctions:       config.TrustedFunctions,
+		Concurrency:            config.AnalysisConcurrency,
+	}
+
+	// Project analysis knows which functions are exported from elsewhere (export { f })
+	var projectAnalysis *analyse.ProjectAnalysis
+	if config.NoAnyBoundaries {
+		projectAnalysis = analyse.AnalyseProject(program, checker, analyseConfig)
+	}
+
+	var failures []CheckFailure
+	for _, fileName := range resp.RootFiles {
+		if analyse.IsDeclarationFile(fileName) {
+			continue
+		}
+		sourceFile := program.GetSourceFile(fileName)
+		if sourceFile == nil {
+			continue
+		}
+
+		result := analyse.AnalyseFileWithProjectAnalysis(sourceFile, checker, program, analyseConfig, projectAnalysis)
+		for _, item := range result.Items {
+			anyBoundary := config.NoAnyBoundaries && item.IsAnyBoundary()
+			if !(item.IsGap() || anyBoundary) || config.IsAllowedSkip(item.TypeString) {
+				continue
+			}
+			failures = append(failures, CheckFailure{FileName: fileName, Item: item})
+		}
+
+		// Too complex is only known once validators are generated; degrading would hide it
+		transformConfig := config
+		transformConfig.DegradeOnComplexity = false
+		if _, _, err := transform.TransformFileWithSourceMapAndError(sourceFile, checker, program, transformConfig); err != nil {
+			var complexityErr *transform.ComplexityError
+			if !errors.As(err, &complexityErr) {
+				return nil, err
+			}
+			failures = append(failures, CheckFailure{FileName: fileName, Complexity: complexityErr.Message})
+		}
+	}
+
+	debugf("[DEBUG] CheckProject complete, %d unvalidated items\n", len(failures))
+
+	return failures, nil
+}
+
+// Check runs CheckProject against the server's API, for check-only CLI runs.
+func (s *Server) Check(configFileName string, config transform.Config) ([]CheckFailure, error) {
+	return s.api.CheckProject(configFileName, config)
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elliots/typical/packages/compiler/internal/transform"
+)
+
+// checkProject runs check-only mode against a temporary project with a single file.
+func checkProject(t *testing.T, code string, config transform.Config) []CheckFailure {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"tsconfig.json": `{"compilerOptions":{"strict":true,"target":"ES2020","module":"ESNext"},"include":["*.ts"]}`,
+		"test.ts":       code,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	s, err := New(&Options{In: strings.NewReader(""), Out: io.Discard, Err: io.Discard, Cwd: dir})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	failures, err := s.Check("tsconfig.json", config)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	return failures
+}
+
+func TestCheckProject(t *testing.T) {
+	nested := `
+interface Order {
+	customer: { name: string } | null;
+	address: { street: string } | null;
+}
+export function save(order: Order): void {}
+`
+
+	tests := []struct {
+		name     string
+		code     string
+		config   func(*transform.Config)
+		expected []string
+	}{
+		{
+			name:     "generic parameter fails",
+			code:     "export function keep<T>(value: T): void {}\n",
+			expected: []string{`parameter "value" of type T not validated`},
+		},
+		{
+			name: "allowed skip passes",
+			code: "export function keep<T>(value: T): void {}\n",
+			config: func(c *transform.Config) {
+				c.AllowedSkips = transform.CompileIgnorePatterns([]string{"T"})
+			},
+		},
+		{
+			name: "validated types pass",
+			code: nested,
+		},
+		{
+			name: "too complex fails",
+			code: nested,
+			config: func(c *transform.Config) {
+				c.MaxGeneratedFunctions = 1
+			},
+			expected: []string{"Type complexity limit exceeded"},
+		},
+		{
+			name: "too complex fails when degraded",
+			code: nested,
+			config: func(c *transform.Config) {
+				c.MaxGeneratedFunctions = 1
+				c.DegradeOnComplexity = true
+			},
+			expected: []string{"Type complexity limit exceeded"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := transform.DefaultConfig()
+			if tt.config != nil {
+				tt.config(&config)
+			}
+			failures := checkProject(t, tt.code, config)
+			if len(failures) != len(tt.expected) {
+				t.Fatalf("Expected %d failures, got %v", len(tt.expected), failures)
+			}
+			for i, want := range tt.expected {
+				if got := failures[i].String(); !strings.Contains(got, want) {
+					t.Errorf("Expected failure to contain %q, got %q", want, got)
+				}
+			}
+		})
+	}
+}
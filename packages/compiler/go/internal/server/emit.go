@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/microsoft/typescript-go/shim/ast"
+	"github.com/microsoft/typescript-go/shim/checker"
+	"github.com/microsoft/typescript-go/shim/compiler"
+
+	"github.com/elliots/typical/packages/compiler/internal/transform"
+)
+
+// EmitValidators loads a tsconfig project and returns a standalone module of validators
+// for the exported types of one of its files, without transforming the file.
+func (a *API) EmitValidators(configFileName, fileName string, config transform.Config) (string, error) {
+	return a.emitForFile(configFileName, fileName, config, transform.EmitValidators)
+}
+
+// ValidatorsHash loads a tsconfig project and returns the typical-hash that EmitValidators
+// would record for one of its files, for checking committed validators are up to date.
+func (a *API) ValidatorsHash(configFileName, fileName string, config transform.Config) (string, error) {
+	return a.emitForFile(configFileName, fileName, config, transform.ValidatorsHash)
+}
+
+// emitForFile runs emit against a file of a tsconfig project.
+func (a *API) emitForFile(configFileName, fileName string, config transform.Config,
+	emit func(*ast.SourceFile, *checker.Checker, *compiler.Program, transform.Config) (string, error)) (string, error) {
+	resp, err := a.LoadProject(configFileName)
+	if err != nil {
+		return "", err
+	}
+	defer a.Release(resp.Id)
+
+	a.mu.Lock()
+	projInfo := a.projects[resp.Id]
+	a.mu.Unlock()
+
+	fileName = a.toAbsolutePath(fileName)
+	program := projInfo.project.GetProgram()
+	sourceFile := program.GetSourceFile(fileName)
+	if sourceFile == nil {
+		return "", fmt.Errorf("source file not found: %s", fileName)
+	}
+
+	c, release := program.GetTypeChecker(context.Background())
+	defer release()
+
+	return emit(sourceFile, c, program, config)
+}
+
+// EmitValidators runs EmitValidators against the server's API, for validator-only CLI runs.
+func (s *Server) EmitValidators(configFileName, fileName string, config transform.Config) (string, error) {
+	return s.api.EmitValidators(configFileName, fileName, config)
+}
+
+// ValidatorsHash runs ValidatorsHash against the server's API, for CLI staleness checks.
+func (s *Server) ValidatorsHash(configFileName, fileName string, config transform.Config) (string, error) {
+	return s.api.ValidatorsHash(configFileName, fileName, config)
+}
@@ -57,6 +57,7 @@ const (
 	MethodTransformSource = "transformSource"
 	MethodRelease         = "release"
 	MethodAnalyseFile     = "analyseFile"
+	MethodWatch           = "watch" // Prefixes results streamed by watch mode, see Server.Watch
 )
 
 // Request/Response types
@@ -77,6 +78,8 @@ type TransformFileParams struct {
 	Content               string   `json:"content,omitempty"`               // Optional: file content for live preview
 	IgnoreTypes           []string `json:"ignoreTypes,omitempty"`           // Glob patterns for types to skip
 	MaxGeneratedFunctions int      `json:"maxGeneratedFunctions,omitempty"` // Max helper functions before error (0 = default 50)
+	ExcludePatterns       []string `json:"excludePatterns,omitempty"`       // Glob patterns for files to pass through unchanged
+	SidecarValidators     bool     `json:"sidecarValidators,omitempty"`     // Write hoisted validators to a companion module
 }
 
 type TransformSourceParams struct {
@@ -87,8 +90,9 @@ type TransformSourceParams struct {
 }
 
 type TransformResponse struct {
-	Code      string                  `json:"code"`
-	SourceMap *transform.RawSourceMap `json:"sourceMap,omitempty"`
+	Code            string                  `json:"code"`
+	SourceMap       *transform.RawSourceMap `json:"sourceMap,omitempty"`
+	SidecarFileName string                  `json:"sidecarFileName,omitempty"` // Companion module written with sidecarValidators
 }
 
 // AnalyseFileParams contains parameters for the analyseFile method
@@ -110,7 +114,7 @@ type ValidationItem struct {
 	StartColumn int    `json:"startColumn"`          // 0-based column
 	EndLine     int    `json:"endLine"`              // 1-based line number
 	EndColumn   int    `json:"endColumn"`            // 0-based column
-	Kind        string `json:"kind"`                 // "parameter", "return", "cast", "json-parse", "json-stringify"
+	Kind        string `json:"kind"`                 // "parameter", "return", "cast", "json-parse", "json-stringify", "parameter-reassignment"
 	Name        string `json:"name"`                 // param name, "return value", or expression text
 	Status      string `json:"status"`               // "validated" or "skipped"
 	TypeString  string `json:"typeString"`           // e.g. "User", "string | null"
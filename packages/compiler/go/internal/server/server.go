@@ -7,10 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
 	"strings"
 
 	"github.com/microsoft/typescript-go/shim/bundled"
 	"github.com/microsoft/typescript-go/shim/vfs/osvfs"
+
+	"github.com/elliots/typical/packages/compiler/internal/transform"
 )
 
 var (
@@ -31,6 +34,10 @@ type Options struct {
 	Out io.Writer
 	Err io.Writer
 	Cwd string
+
+	// ConfigFile is the config file to load. When empty, typical.config.json is loaded
+	// from Cwd if it's there, and the defaults used otherwise.
+	ConfigFile string
 }
 
 type Server struct {
@@ -39,13 +46,31 @@ type Server struct {
 	stderr io.Writer
 	cwd    string
 	api    *API
+	config transform.Config
 }
 
-func New(opts *Options) *Server {
+// New creates a server, loading its config file. It fails if the config file is invalid,
+// or if Options.ConfigFile was given and doesn't exist.
+func New(opts *Options) (*Server, error) {
 	if opts.Cwd == "" {
 		panic("Cwd is required")
 	}
 
+	config := transform.DefaultConfig()
+	configFile := opts.ConfigFile
+	if configFile == "" {
+		configFile = transform.FindConfigFile(opts.Cwd)
+	} else if !filepath.IsAbs(configFile) {
+		configFile = filepath.Join(opts.Cwd, configFile)
+	}
+	if configFile != "" {
+		loaded, err := transform.LoadConfigFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		config = loaded
+	}
+
 	fs := bundled.WrapFS(osvfs.FS())
 	defaultLibPath := bundled.LibPath()
 
@@ -54,15 +79,22 @@ func New(opts *Options) *Server {
 		w:      bufio.NewWriter(opts.Out),
 		stderr: opts.Err,
 		cwd:    opts.Cwd,
+		config: config,
 	}
 
 	s.api = NewAPI(&APIOptions{
 		Cwd:                opts.Cwd,
 		FS:                 fs,
 		DefaultLibraryPath: defaultLibPath,
+		Config:             config,
 	})
 
-	return s
+	return s, nil
+}
+
+// Config returns the config the server loaded, for CLI modes that run without requests.
+func (s *Server) Config() transform.Config {
+	return s.config
 }
 
 func (s *Server) Run() error {
@@ -118,7 +150,7 @@ func (s *Server) handleRequest(method string, payload []byte) ([]byte, error) {
 		if err := json.Unmarshal(payload, &params); err != nil {
 			return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
 		}
-		resp, err := s.api.TransformFile(params.Project, params.FileName, params.Content, params.IgnoreTypes, params.MaxGeneratedFunctions)
+		resp, err := s.api.TransformFile(params.Project, params.FileName, params.Content, params.IgnoreTypes, params.MaxGeneratedFunctions, params.ExcludePatterns, params.SidecarValidators)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/elliots/typical/packages/compiler/internal/analyse"
+)
+
+// Watch mode (typical --watch) transforms every root file of a tsconfig project, then
+// re-transforms files as they change on disk, until stdin is closed. Nothing is read from
+// stdin; closing it is how a client stops the watcher.
+//
+// Results are streamed on stdout in the same framing as responses to requests (see
+// writeMessage), with the file's absolute path in the request ID:
+//
+//	[MessageTypeResponse, "watch:<file>", TransformResponse JSON]  the file was transformed
+//	[MessageTypeError,    "watch:<file>", error message]            transforming it failed
+//
+// Files are polled for changes to their modification time or size every interval, so no
+// platform file notification support is needed. Changed files are re-read and passed to
+// TransformFile as content, so the next project analysis re-collects their functions, and
+// are then streamed followed by the files depending on them, which it re-collected too. Files
+// added to the project after the watcher starts aren't picked up until it's restarted.
+
+// fileStamp is what Watch compares to tell whether a file changed.
+type fileStamp struct {
+	modTime int64
+	size    int64
+}
+
+// Watch runs watch mode against a tsconfig project, polling every interval.
+func (s *Server) Watch(configFileName string, interval time.Duration) error {
+	resp, err := s.api.LoadProject(configFileName)
+	if err != nil {
+		return err
+	}
+	defer s.api.Release(resp.Id)
+
+	stdinClosed := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, s.r)
+		close(stdinClosed)
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seen := make(map[string]fileStamp)
+	for {
+		var changed []string
+		for _, fileName := range resp.RootFiles {
+			if analyse.IsDeclarationFile(fileName) {
+				continue
+			}
+			info, err := os.Stat(fileName)
+			if err != nil {
+				// Deleted, or mid-save; transformed again once it's back
+				delete(seen, fileName)
+				continue
+			}
+			stamp := fileStamp{modTime: info.ModTime().UnixNano(), size: info.Size()}
+			prev, transformed := seen[fileName]
+			if transformed && prev == stamp {
+				continue
+			}
+			seen[fileName] = stamp
+			if transformed {
+				changed = append(changed, fileName)
+			} else if err := s.watchTransform(resp.Id, fileName, false); err != nil {
+				return err
+			}
+		}
+		if err := s.watchTransformChanged(resp.Id, changed, resp.RootFiles); err != nil {
+			return err
+		}
+
+		select {
+		case <-stdinClosed:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchTransformChanged re-transforms the files that changed on disk, then every other root
+// file depending on them, whose validators can come from the types the changes edited.
+func (s *Server) watchTransformChanged(projectId string, changed, rootFiles []string) error {
+	if len(changed) == 0 {
+		return nil
+	}
+	s.api.TakeStaleFiles(projectId)
+	for _, fileName := range changed {
+		if err := s.watchTransform(projectId, fileName, true); err != nil {
+			return err
+		}
+	}
+	for _, fileName := range s.api.TakeStaleFiles(projectId) {
+		if slices.Contains(changed, fileName) || !slices.Contains(rootFiles, fileName) {
+			continue
+		}
+		if err := s.watchTransform(projectId, fileName, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchTransform transforms one file and streams the result. changed re-reads the file
+// from disk rather than using the project's copy. Only failures to write the result are
+// returned; transform errors are streamed.
+func (s *Server) watchTransform(projectId, fileName string, changed bool) error {
+	requestId := MethodWatch + ":" + fileName
+
+	var content string
+	if changed {
+		data, err := os.ReadFile(fileName)
+		if err != nil {
+			return s.sendError(requestId, err)
+		}
+		content = string(data)
+	}
+
+	resp, err := s.api.TransformFile(projectId, fileName, content, nil, 0, nil, false)
+	if err != nil {
+		return s.sendError(requestId, err)
+	}
+	result, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return s.sendResponse(requestId, result)
+}
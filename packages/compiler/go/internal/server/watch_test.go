@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// watchSession is watch mode running against a temporary project.
+type watchSession struct {
+	t       *testing.T
+	dir     string
+	stdin   io.WriteCloser
+	results *Server // reads the results, which are framed like requests
+	done    chan error
+}
+
+// startWatch writes files to a temporary directory, with a tsconfig.json including them,
+// and starts watch mode on it.
+func startWatch(t *testing.T, files map[string]string) *watchSession {
+	t.Helper()
+	dir := t.TempDir()
+	files["tsconfig.json"] = `{"compilerOptions":{"strict":true,"target":"ES2020","module":"ESNext"},"include":["*.ts"]}`
+	w := &watchSession{t: t, dir: dir, done: make(chan error, 1)}
+	for name, content := range files {
+		w.write(name, content)
+	}
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	s, err := New(&Options{In: inR, Out: outW, Err: io.Discard, Cwd: dir})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	go func() {
+		w.done <- s.Watch("tsconfig.json", 10*time.Millisecond)
+		outW.Close()
+	}()
+	w.stdin = inW
+	w.results = &Server{r: bufio.NewReader(outR)}
+	return w
+}
+
+func (w *watchSession) write(name, content string) {
+	w.t.Helper()
+	if err := os.WriteFile(filepath.Join(w.dir, name), []byte(content), 0o644); err != nil {
+		w.t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+// next reads the next streamed result, returning the base name of its file and its code.
+func (w *watchSession) next() (string, string) {
+	w.t.Helper()
+	messageType, requestId, payload, err := w.results.readRequest()
+	if err != nil {
+		w.t.Fatalf("failed to read watch result: %v", err)
+	}
+	if messageType != MessageTypeResponse || !strings.HasPrefix(requestId, MethodWatch+":") {
+		w.t.Fatalf("Expected a watch response, got %s %s: %s", messageType, requestId, payload)
+	}
+	var resp TransformResponse
+	if err := json.Unmarshal(payload, &resp); err != nil {
+		w.t.Fatalf("invalid response for %s: %v", requestId, err)
+	}
+	return filepath.Base(strings.TrimPrefix(requestId, MethodWatch+":")), resp.Code
+}
+
+// stop closes stdin and checks the watcher returns without streaming anything more.
+func (w *watchSession) stop() {
+	w.t.Helper()
+	w.stdin.Close()
+	if _, requestId, _, err := w.results.readRequest(); err != io.EOF {
+		w.t.Errorf("Expected no more results, got %s (%v)", requestId, err)
+	}
+	if err := <-w.done; err != nil {
+		w.t.Errorf("Watch failed: %v", err)
+	}
+}
+
+func TestWatchTransformsProject(t *testing.T) {
+	w := startWatch(t, map[string]string{
+		"user.ts": "export interface User { name: string; }\n",
+		"save.ts": "import type { User } from \"./user\";\nexport function save(user: User): void {}\n",
+	})
+
+	results := make(map[string]string)
+	for range 2 {
+		name, code := w.next()
+		results[name] = code
+	}
+	if _, ok := results["user.ts"]; !ok {
+		t.Errorf("Expected user.ts to be transformed, got %v", results)
+	}
+	if !strings.Contains(results["save.ts"], ".name") {
+		t.Errorf("Expected save.ts to validate User.name\nGot:\n%s", results["save.ts"])
+	}
+	w.stop()
+}
+
+func TestWatchRetransformsDependents(t *testing.T) {
+	w := startWatch(t, map[string]string{
+		"user.ts":  "export interface User { name: string; }\n",
+		"save.ts":  "import type { User } from \"./user\";\nexport function save(user: User): void {}\n",
+		"other.ts": "export function add(a: number, b: number): number { return a + b; }\n",
+	})
+	for range 3 {
+		w.next()
+	}
+
+	// Editing an interface re-transforms its file, then the file importing it, but not others
+	w.write("user.ts", "export interface User { name: string; age: number; }\n")
+	if name, _ := w.next(); name != "user.ts" {
+		t.Errorf("Expected user.ts to be re-transformed first, got %s", name)
+	}
+	name, code := w.next()
+	if name != "save.ts" {
+		t.Errorf("Expected save.ts to be re-transformed as a dependent, got %s", name)
+	}
+	if !strings.Contains(code, ".age") {
+		t.Errorf("Expected save.ts to validate the new User.age\nGot:\n%s", code)
+	}
+	w.stop()
+}
@@ -96,3 +96,15 @@ func TupleType_elementInfos(t *checker.TupleType) []checker.TupleElementInfo {
 func Checker_stringType(v *checker.Checker) *checker.Type {
 	return ((*extra_Checker)(unsafe.Pointer(v))).stringType
 }
+
+// Checker_numberType returns the checker's built-in number type.
+// This is needed to query number index signatures (Record<number, T>) using Checker_getIndexTypeOfType.
+func Checker_numberType(v *checker.Checker) *checker.Type {
+	return ((*extra_Checker)(unsafe.Pointer(v))).numberType
+}
+
+// Checker_esSymbolType returns the checker's built-in symbol type.
+// This is needed to query symbol index signatures ([key: symbol]: T) using Checker_getIndexTypeOfType.
+func Checker_esSymbolType(v *checker.Checker) *checker.Type {
+	return ((*extra_Checker)(unsafe.Pointer(v))).esSymbolType
+}
@@ -0,0 +1,86 @@
+package transform
+
+import (
+	"strings"
+
+	"github.com/microsoft/typescript-go/shim/ast"
+	"github.com/microsoft/typescript-go/shim/checker"
+)
+
+// resolveTypeName finds the type a `@typical-assert` directive names: a type alias, interface,
+// class or enum declared at the top level of the file, or one imported into it. Returns nil if
+// there's no such type in scope.
+func resolveTypeName(c *checker.Checker, sourceFile *ast.SourceFile, name string) *checker.Type {
+	var nameNode *ast.Node
+	for _, stmt := range sourceFile.Statements.Nodes {
+		switch stmt.Kind {
+		case ast.KindTypeAliasDeclaration, ast.KindInterfaceDeclaration, ast.KindClassDeclaration, ast.KindEnumDeclaration:
+			if declName := stmt.Name(); declName != nil && declName.Text() == name {
+				nameNode = declName
+			}
+		case ast.KindImportDeclaration:
+			if n := findImportedName(stmt, name); n != nil {
+				nameNode = n
+			}
+		}
+		if nameNode != nil {
+			break
+		}
+	}
+	if nameNode == nil {
+		return nil
+	}
+	sym := c.GetSymbolAtLocation(nameNode)
+	if sym == nil {
+		return nil
+	}
+	// Imports are aliases, which the declared type resolves through
+	return checker.Checker_getDeclaredTypeOfSymbol(c, sym)
+}
+
+// findImportedName returns the local name node of a default or named import called name
+// within an import declaration, or nil.
+func findImportedName(node *ast.Node, name string) *ast.Node {
+	var found *ast.Node
+	node.ForEachChild(func(child *ast.Node) bool {
+		switch child.Kind {
+		case ast.KindImportClause, ast.KindImportSpecifier:
+			if local := child.Name(); local != nil && local.Text() == name {
+				found = local
+				return true
+			}
+		}
+		found = findImportedName(child, name)
+		return found != nil
+	})
+	return found
+}
+
+// declaredVariableName returns the variable a statement declares, for a `@typical-assert`
+// directive that doesn't name a target: `const body = await req.json()` declares body.
+// Returns "" unless the statement declares exactly one variable by name.
+func declaredVariableName(stmt *ast.Node) string {
+	if stmt.Kind != ast.KindVariableStatement {
+		return ""
+	}
+	decls := stmt.AsVariableStatement().DeclarationList.AsVariableDeclarationList().Declarations.Nodes
+	if len(decls) != 1 {
+		return ""
+	}
+	name := decls[0].Name()
+	if name == nil || name.Kind != ast.KindIdentifier {
+		return ""
+	}
+	return name.AsIdentifier().Text
+}
+
+// statementSeparator returns the text to start a statement inserted at pos with, so it
+// doesn't run on from a previous statement that relies on automatic semicolon insertion.
+// After a closing brace it could be ending an object literal (const a = {}), so that
+// gets a semicolon too.
+func statementSeparator(text string, pos int) string {
+	if before := strings.TrimRight(text[:pos], " \t\r\n"); before != "" && !strings.HasSuffix(before, ";") && !strings.HasSuffix(before, "{") {
+		return "; "
+	}
+	return " "
+}
@@ -1,9 +1,13 @@
 package transform
 
 import (
+	"fmt"
+	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/elliots/typical/packages/compiler/internal/analyse"
+	"github.com/elliots/typical/packages/compiler/internal/codegen"
 )
 
 // Config specifies which validations to apply during transformation.
@@ -16,9 +20,31 @@ type Config struct {
 	// For sync functions returning Promise<T>, validation is added via .then()
 	ValidateReturns bool
 
+	// ValidateExportedOnly restricts parameter and return validation to exported functions
+	// (and public methods of exported classes) - the module's public boundary. Internal
+	// helpers are only called with values that already passed through that boundary, so
+	// skipping them greatly reduces generated code for libraries.
+	// Default: false
+	ValidateExportedOnly bool
+
+	// ValidateDefaults also validates parameters with a default value (x: Foo = makeDefault())
+	// when the caller omits them, so a default can't produce an invalid value unnoticed.
+	// Otherwise they're only validated when passed (if (x !== undefined) ...). Validation runs
+	// at the start of the body, after JavaScript has applied defaults and destructured the
+	// parameters: a destructured parameter ({ a, b }: Opts = {}) has each binding validated,
+	// with or without this option, whether its value came from the caller or a default.
+	// Default: false
+	ValidateDefaults bool
+
 	// ValidateCasts wraps type assertions with validators.
 	ValidateCasts bool
 
+	// ValidateNonNull validates non-null assertions (value!) against the asserted type when
+	// the operand may be null or undefined and hasn't already been validated, so an
+	// assertion on untrusted data fails where it's made rather than later on use.
+	// Default: false
+	ValidateNonNull bool
+
 	// TransformJSONParse transforms JSON.parse<T>() calls to validate and filter
 	// the parsed result to only include properties defined in type T.
 	TransformJSONParse bool
@@ -34,10 +60,31 @@ type Config struct {
 	// Default: 50
 	MaxGeneratedFunctions int
 
+	// DegradeOnComplexity turns exceeding MaxGeneratedFunctions from a file-level error into
+	// a partial validator: objects beyond the limit are only checked to be non-null objects
+	// (marked /* depth-limited */ in the output), while everything before it is validated
+	// in full. Keeps some safety for huge types without having to ignore them entirely.
+	// Default: false
+	DegradeOnComplexity bool
+
 	// IgnoreTypes is a list of compiled regex patterns for types to skip validation.
 	// Types matching any pattern will not have validators generated.
 	IgnoreTypes []*regexp.Regexp
 
+	// ErrorFormat is a template for validation error messages. The placeholders %path
+	// (e.g. "user.address.street"), %expected and %got are filled in at runtime, so
+	// "invalid %path" produces short messages like "invalid user.address.street".
+	// Default: "Expected %path to be %expected, got %got"
+	ErrorFormat string
+
+	// WarnTypes is a list of compiled regex patterns for types whose validation failures are
+	// logged with console.warn instead of thrown, e.g. while deprecating a type. Matched
+	// against type names like IgnoreTypes; a property or element of a warn type only warns,
+	// and the rest of the value is still checked. JSON.parse filtering still throws, since it
+	// can't produce a filtered result for invalid input.
+	// Default: none
+	WarnTypes []*regexp.Regexp
+
 	// PureFunctions is a list of function names (or patterns) that are considered "pure"
 	// or "readonly" for their arguments. Passing a validated object to these functions
 	// will NOT mark it as dirty (re-validation needed).
@@ -49,6 +96,193 @@ type Config struct {
 	// Example: "db.loadUser" -> const user: User = db.loadUser(id) -> user is valid
 	TrustedFunctions []*regexp.Regexp
 
+	// RejectEmptyObjects requires the keys of required properties to be present even when
+	// their type accepts undefined (any, unknown, T | undefined). Without it `{}` passes for
+	// `{ data: unknown }`, which is correct TypeScript but rarely what untrusted input means.
+	// Default: false
+	RejectEmptyObjects bool
+
+	// ValidationBrand is a sentinel value stamped (as a non-enumerable __validated property)
+	// onto objects that pass validation. Check functions return early for values carrying
+	// it, so data passed through many validated layers is only fully checked once. The
+	// brand isn't per type, and later mutation of a branded object isn't detected.
+	// Default: "" (disabled)
+	ValidationBrand string
+
+	// ObjectAcceptsFunctions lets parameters typed as the broad `object` type accept
+	// functions, as TypeScript does. By default `object` only accepts non-null objects,
+	// since it usually means a plain data object. `{}` always accepts any non-nullish value.
+	// Default: false
+	ObjectAcceptsFunctions bool
+
+	// SampleRate validates only a fraction of calls, for hot paths where always validating
+	// costs too much: each validator invocation is guarded by Math.random() < SampleRate and
+	// passes the value through unchanged when skipped. Values a skipped call let through are
+	// still treated as validated afterwards. JSON.parse and JSON.stringify filtering always
+	// runs. 0 or 1 validates every call.
+	// Default: 1
+	SampleRate float64
+
+	// GuardEnvVar names an environment variable that switches validation off in production:
+	// each validator invocation is guarded by typeof process !== "undefined" &&
+	// process.env.<GuardEnvVar> !== "production" and passes the value through unchanged
+	// otherwise. JSON.parse and JSON.stringify filtering isn't guarded. With a bundler that
+	// defines the variable, as most do for NODE_ENV, production builds can drop the validators
+	// as dead code.
+	// Example: "NODE_ENV"
+	// Default: "" (always validate)
+	GuardEnvVar string
+
+	// CrossRealmBuiltins checks built-in class instances (Date, Map, Set, typed arrays,
+	// errors, ...) with Object.prototype.toString rather than instanceof, which rejects
+	// values created in another realm such as an iframe or a vm context. Arrays are always
+	// checked with Array.isArray, and other classes still use instanceof.
+	// Default: false
+	CrossRealmBuiltins bool
+
+	// RejectDroppedFunctions makes JSON filtering reject function-valued properties the type
+	// doesn't declare. Filtering drops undeclared properties, so JSON.stringify(x as T) would
+	// otherwise silently lose them. Types with an index signature are unaffected.
+	// Default: false
+	RejectDroppedFunctions bool
+
+	// CycleSafeValidation makes validation terminate on data that is cyclic at runtime, such
+	// as a graph whose nodes refer back to each other. Check functions for recursive types
+	// record the objects they've checked against each type and skip them when a cycle comes
+	// back round, rather than recursing until the stack overflows.
+	// Default: false
+	CycleSafeValidation bool
+
+	// ExportValidators exports the hoisted check functions under stable public names, such as
+	// __typical_check_User, so tests in the project can import them and call them directly
+	// with (value, name) - they return an error message or null. Only types validated more
+	// than once in a file are hoisted, except exported types declared in the file, which are
+	// also given exported isUser(value) type guards and assertUser(value, name?) assertion
+	// functions. Exporting makes a script file a module.
+	// Default: false
+	ExportValidators bool
+
+	// FunctionDeclarations emits hoisted check and filter functions as function declarations,
+	// `function _check_User(...) {...}`, rather than arrow functions assigned to consts. Function
+	// declarations are hoisted, so they can be called from anywhere in the file whatever order
+	// they're emitted in, and CommonJS transforms handle them more simply.
+	// Default: false
+	FunctionDeclarations bool
+
+	// StrictNumbers makes the number type reject NaN, Infinity and -Infinity, which typeof
+	// reports as numbers. The check becomes "number" === typeof x && Number.isFinite(x), and
+	// the error shows the value ("got NaN"). Number literal types are checked by value as before.
+	// Default: false
+	StrictNumbers bool
+
+	// IntegerBrand is the brand marker that declares a branded number an integer, so
+	// number & { __int: true } is checked with Number.isInteger(x) rather than as any number.
+	// Set it to match an existing Integer type's brand property.
+	// Default: "__int"
+	IntegerBrand string
+
+	// ValidateDateValues makes Date-typed values also have to be valid dates. An instanceof
+	// check accepts new Date("garbage"), whose time is NaN; with this it fails with
+	// "Expected x to be a valid Date, got Invalid Date".
+	// Default: false
+	ValidateDateValues bool
+
+	// ErrorMode is ErrorModeFailFast to stop validating a value at its first failure, or
+	// ErrorModeCollect to carry on past failing properties and elements and report them all
+	// at once, joined with "; ", e.g. "Expected user.age to be number, got string; Expected
+	// user.roles[2] to be string, got number". Filters for JSON.parse and JSON.stringify
+	// always stop at the first failure.
+	// Default: "fail-fast"
+	ErrorMode string
+
+	// StructuredErrors throws a TypicalError, a TypeError subclass with the same message that
+	// also carries the failure's path, expected type, what was received and the value, so
+	// callers can handle particular fields. The class is declared in each file that uses it.
+	// Check functions return TypicalErrors rather than messages; filters for JSON.parse and
+	// JSON.stringify still fail with plain TypeErrors.
+	// Default: false
+	StructuredErrors bool
+
+	// ErrorConstructor is the error class validation failures throw, such as ValidationError
+	// or an HTTP 400 error class. It's constructed with the message and must be a simple
+	// identifier in scope wherever validation is added; a comment is added to files that
+	// neither declare nor import it. With StructuredErrors, TypicalError extends it.
+	// Default: "TypeError"
+	ErrorConstructor string
+
+	// ValidateFunctionArity makes function-typed values also take at least as many parameters
+	// as the type declares, so for (a: string, b: number) => void a one-parameter callback
+	// fails. Types with optional or rest parameters, or several overloads, only check typeof.
+	// Default: false
+	ValidateFunctionArity bool
+
+	// SidecarValidators moves the functions hoisted into a transformed file into a companion
+	// module next to it (user.ts gets user.typical.ts), which the file imports them from and
+	// re-exports the exported ones of. If they use anything the file doesn't export, they stay
+	// inline. Only TransformFileWithSidecar writes sidecars; other entry points ignore this.
+	// Default: false
+	SidecarValidators bool
+
+	// EmitSummaryHeader inserts a comment at the top of transformed files summarising what
+	// was added, e.g. `/* typical: 3 params, 2 returns, 1 cast validated; 4 helpers hoisted */`.
+	// It goes after any shebang or "use strict" directive.
+	// Default: false
+	EmitSummaryHeader bool
+
+	// SerializableBoundaries is a list of functions whose first argument crosses a structured
+	// clone boundary, such as "postMessage" or "structuredClone". Arguments get a check that
+	// fails early, naming the field, if anything typed as a function or symbol would reach
+	// the boundary. Names match the full callee ("worker.postMessage") or its method name.
+	// Default: none
+	SerializableBoundaries []string
+
+	// ExcludePatterns is a list of glob patterns for files that are passed through unchanged,
+	// e.g. "**/*.test.ts" or "test/**" for tests that build partial mocks on purpose. Excluded
+	// files are still part of project analysis, so cross-file information is unaffected.
+	// Default: none
+	ExcludePatterns []string
+
+	// StrictOptionalPresence changes how optional properties (prop?: T) are detected.
+	// By default an optional property is skipped when its value is undefined, so
+	// { prop: undefined } and {} are treated the same. When enabled, the property is
+	// validated whenever it is an own property of the object (hasOwnProperty), so an
+	// explicit { prop: undefined } is rejected unless T itself includes undefined.
+	// Useful for serialisers where an absent key and an undefined value differ.
+	// Default: false
+	StrictOptionalPresence bool
+
+	// Coerce enables lenient primitive coercion in JSON.parse filter functions.
+	// Values are coerced before validation and the coerced value is what the
+	// filtered result contains, e.g. {"age": "42"} parsed as { age: number } yields { age: 42 }.
+	// Plain parameter/return/cast validation never coerces.
+	// Default: no coercion
+	Coerce codegen.Coercion
+
+	// IncludeSourcesContent embeds the original source text in generated source maps
+	// (sourcesContent), so tools can apply the map without access to the original files.
+	// Disable to keep maps small when the sources are always available.
+	// Default: true
+	IncludeSourcesContent bool
+
+	// AllowedSkips is a list of compiled patterns for types that check-only mode
+	// (typical --check-only) accepts as unvalidated. Any other parameter, return or
+	// cast skipped because its type is generic or too complex fails the check.
+	// Patterns are matched against the type as written by the checker, e.g. "T" or "Foo<*>".
+	// Default: none
+	AllowedSkips []*regexp.Regexp
+
+	// NoAnyBoundaries makes check-only mode also fail on parameters and return types of
+	// exported functions typed any. Validation skips them, as any accepts every value, so a
+	// public API typed any has a hole no runtime check covers; this makes it get narrowed.
+	// Default: false
+	NoAnyBoundaries bool
+
+	// AnalysisConcurrency is how many files project analysis works on at once. 0 uses one
+	// worker per CPU; 1 analyses the files one after another, e.g. to keep a build's memory
+	// down on a machine shared with other jobs.
+	// Default: 0
+	AnalysisConcurrency int
+
 	// ProjectAnalysis contains cross-file analysis results for validation optimisation.
 	// When set, the transformer can skip redundant validation based on call graph analysis.
 	ProjectAnalysis *analyse.ProjectAnalysis
@@ -57,6 +291,38 @@ type Config struct {
 // DefaultMaxGeneratedFunctions is the default limit for generated helper functions.
 const DefaultMaxGeneratedFunctions = 50
 
+// ComplexityError is returned when a file's validators need more helper functions than
+// Config.MaxGeneratedFunctions allows.
+type ComplexityError struct {
+	Message  string // the generator's report, with the type path that exceeded the limit
+	FileName string
+}
+
+func (e *ComplexityError) Error() string {
+	return fmt.Sprintf("%s in file %s", e.Message, e.FileName)
+}
+
+// Values of Config.ErrorMode.
+const (
+	ErrorModeFailFast = "fail-fast"
+	ErrorModeCollect  = "collect"
+)
+
+// builtinErrorConstructors are the error classes every JavaScript runtime defines.
+var builtinErrorConstructors = map[string]bool{
+	"Error": true, "TypeError": true, "RangeError": true, "SyntaxError": true,
+	"ReferenceError": true, "EvalError": true, "URIError": true,
+}
+
+// checkErrorConstructor returns an error if ErrorConstructor isn't a simple identifier, which
+// would make the generated throws invalid.
+func (c *Config) checkErrorConstructor() error {
+	if c.ErrorConstructor != "" && !isSimpleIdentifier(c.ErrorConstructor) {
+		return fmt.Errorf("errorConstructor %q must be a simple identifier", c.ErrorConstructor)
+	}
+	return nil
+}
+
 // DefaultConfig returns the default configuration with all validations enabled.
 func DefaultConfig() Config {
 	return Config{
@@ -66,10 +332,14 @@ func DefaultConfig() Config {
 		TransformJSONParse:     true,
 		TransformJSONStringify: true,
 		MaxGeneratedFunctions:  DefaultMaxGeneratedFunctions,
-		PureFunctions:          CompileIgnorePatterns([]string{"console.*", "JSON.stringify"}),
+		IncludeSourcesContent:  true,
+		PureFunctions:          CompileIgnorePatterns(defaultPureFunctions),
 	}
 }
 
+// defaultPureFunctions are DefaultConfig's PureFunctions patterns.
+var defaultPureFunctions = []string{"console.*", "JSON.stringify"}
+
 // CompileIgnorePattern converts a glob-style pattern to a regexp.
 // Supports wildcards: "React.*" -> /^React\..*$/
 func CompileIgnorePattern(pattern string) (*regexp.Regexp, error) {
@@ -103,6 +373,57 @@ func CompileIgnorePatterns(patterns []string) []*regexp.Regexp {
 	return result
 }
 
+// IsExcludedFile checks if a file name matches any ExcludePatterns glob. Patterns match
+// against the end of the path, so "test/**" matches "/project/test/user.ts".
+func (c *Config) IsExcludedFile(fileName string) bool {
+	fileName = filepath.ToSlash(fileName)
+	for _, pattern := range c.ExcludePatterns {
+		re, err := compileFilePattern(pattern)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(fileName) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileFilePattern converts a file glob to a regexp. "**" matches across directories,
+// "*" and "?" match within a single path segment.
+func compileFilePattern(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("(^|/)")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			sb.WriteString("[^/]*")
+		case pattern[i] == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// IsAllowedSkip checks if a skipped type string matches any allowed skip pattern.
+func (c *Config) IsAllowedSkip(typeString string) bool {
+	for _, re := range c.AllowedSkips {
+		if re.MatchString(typeString) {
+			return true
+		}
+	}
+	return false
+}
+
 // ShouldIgnoreType checks if a type name matches any ignore pattern.
 func (c *Config) ShouldIgnoreType(typeName string) bool {
 	for _, re := range c.IgnoreTypes {
@@ -0,0 +1,183 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/elliots/typical/packages/compiler/internal/codegen"
+)
+
+// ConfigFileName is the config file the compiler server looks for in its working directory.
+const ConfigFileName = "typical.config.json"
+
+// fileConfig is the JSON form of Config in a config file. Keys match the TypeScript options
+// of the same name, and pattern lists take the same glob patterns, e.g. "React.*".
+type fileConfig struct {
+	ValidateParameters     bool     `json:"validateParameters"`
+	ValidateReturns        bool     `json:"validateReturns"`
+	ValidateExportedOnly   bool     `json:"validateExportedOnly"`
+	ValidateDefaults       bool     `json:"validateDefaults"`
+	ValidateCasts          bool     `json:"validateCasts"`
+	ValidateNonNull        bool     `json:"validateNonNull"`
+	TransformJSONParse     bool     `json:"transformJSONParse"`
+	TransformJSONStringify bool     `json:"transformJSONStringify"`
+	MaxGeneratedFunctions  int      `json:"maxGeneratedFunctions"`
+	DegradeOnComplexity    bool     `json:"degradeOnComplexity"`
+	IgnoreTypes            []string `json:"ignoreTypes"`
+	ErrorFormat            string   `json:"errorFormat"`
+	WarnTypes              []string `json:"warnTypes"`
+	PureFunctions          []string `json:"pureFunctions"`
+	TrustedFunctions       []string `json:"trustedFunctions"`
+	RejectEmptyObjects     bool     `json:"rejectEmptyObjects"`
+	ValidationBrand        string   `json:"validationBrand"`
+	ObjectAcceptsFunctions bool     `json:"objectAcceptsFunctions"`
+	SampleRate             float64  `json:"sampleRate"`
+	GuardEnvVar            string   `json:"guardEnvVar"`
+	CrossRealmBuiltins     bool     `json:"crossRealmBuiltins"`
+	RejectDroppedFunctions bool     `json:"rejectDroppedFunctions"`
+	CycleSafeValidation    bool     `json:"cycleSafeValidation"`
+	ExportValidators       bool     `json:"exportValidators"`
+	FunctionDeclarations   bool     `json:"functionDeclarations"`
+	StrictNumbers          bool     `json:"strictNumbers"`
+	IntegerBrand           string   `json:"integerBrand"`
+	ValidateDateValues     bool     `json:"validateDateValues"`
+	ErrorMode              string   `json:"errorMode"`
+	StructuredErrors       bool     `json:"structuredErrors"`
+	ErrorConstructor       string   `json:"errorConstructor"`
+	ValidateFunctionArity  bool     `json:"validateFunctionArity"`
+	SidecarValidators      bool     `json:"sidecarValidators"`
+	EmitSummaryHeader      bool     `json:"emitSummaryHeader"`
+	SerializableBoundaries []string `json:"serializableBoundaries"`
+	ExcludePatterns        []string `json:"excludePatterns"`
+	StrictOptionalPresence bool     `json:"strictOptionalPresence"`
+	IncludeSourcesContent  bool     `json:"includeSourcesContent"`
+	AllowedSkips           []string `json:"allowedSkips"`
+	NoAnyBoundaries        bool     `json:"noAnyBoundaries"`
+	AnalysisConcurrency    int      `json:"analysisConcurrency"`
+	Coerce                 struct {
+		StringToNumber  bool `json:"stringToNumber"`
+		StringToBoolean bool `json:"stringToBoolean"`
+		StringToDate    bool `json:"stringToDate"`
+		NumericKeys     bool `json:"numericKeys"`
+	} `json:"coerce"`
+}
+
+// FindConfigFile returns the path of the config file in dir, or "" if there isn't one.
+func FindConfigFile(dir string) string {
+	path := filepath.Join(dir, ConfigFileName)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// LoadConfigFile reads a config file, such as typical.config.json. Options it leaves out
+// keep their DefaultConfig values, and unknown options or invalid patterns are errors.
+func LoadConfigFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Decode over the defaults, so only the options given change
+	defaults := DefaultConfig()
+	fc := fileConfig{
+		ValidateParameters:     defaults.ValidateParameters,
+		ValidateReturns:        defaults.ValidateReturns,
+		ValidateCasts:          defaults.ValidateCasts,
+		TransformJSONParse:     defaults.TransformJSONParse,
+		TransformJSONStringify: defaults.TransformJSONStringify,
+		MaxGeneratedFunctions:  defaults.MaxGeneratedFunctions,
+		IncludeSourcesContent:  defaults.IncludeSourcesContent,
+		PureFunctions:          defaultPureFunctions,
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fc); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	config, err := fc.config()
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// config converts the file's options to a Config.
+func (fc *fileConfig) config() (Config, error) {
+	config := Config{
+		ValidateParameters:     fc.ValidateParameters,
+		ValidateReturns:        fc.ValidateReturns,
+		ValidateExportedOnly:   fc.ValidateExportedOnly,
+		ValidateDefaults:       fc.ValidateDefaults,
+		ValidateCasts:          fc.ValidateCasts,
+		ValidateNonNull:        fc.ValidateNonNull,
+		TransformJSONParse:     fc.TransformJSONParse,
+		TransformJSONStringify: fc.TransformJSONStringify,
+		MaxGeneratedFunctions:  fc.MaxGeneratedFunctions,
+		DegradeOnComplexity:    fc.DegradeOnComplexity,
+		ErrorFormat:            fc.ErrorFormat,
+		RejectEmptyObjects:     fc.RejectEmptyObjects,
+		ValidationBrand:        fc.ValidationBrand,
+		ObjectAcceptsFunctions: fc.ObjectAcceptsFunctions,
+		SampleRate:             fc.SampleRate,
+		GuardEnvVar:            fc.GuardEnvVar,
+		CrossRealmBuiltins:     fc.CrossRealmBuiltins,
+		RejectDroppedFunctions: fc.RejectDroppedFunctions,
+		CycleSafeValidation:    fc.CycleSafeValidation,
+		ExportValidators:       fc.ExportValidators,
+		FunctionDeclarations:   fc.FunctionDeclarations,
+		StrictNumbers:          fc.StrictNumbers,
+		IntegerBrand:           fc.IntegerBrand,
+		ValidateDateValues:     fc.ValidateDateValues,
+		ErrorMode:              fc.ErrorMode,
+		StructuredErrors:       fc.StructuredErrors,
+		ErrorConstructor:       fc.ErrorConstructor,
+		ValidateFunctionArity:  fc.ValidateFunctionArity,
+		SidecarValidators:      fc.SidecarValidators,
+		EmitSummaryHeader:      fc.EmitSummaryHeader,
+		SerializableBoundaries: fc.SerializableBoundaries,
+		ExcludePatterns:        fc.ExcludePatterns,
+		StrictOptionalPresence: fc.StrictOptionalPresence,
+		IncludeSourcesContent:  fc.IncludeSourcesContent,
+		NoAnyBoundaries:        fc.NoAnyBoundaries,
+		AnalysisConcurrency:    fc.AnalysisConcurrency,
+		Coerce: codegen.Coercion{
+			StringToNumber:  fc.Coerce.StringToNumber,
+			StringToBoolean: fc.Coerce.StringToBoolean,
+			StringToDate:    fc.Coerce.StringToDate,
+			NumericKeys:     fc.Coerce.NumericKeys,
+		},
+	}
+
+	patterns := []struct {
+		option   string
+		patterns []string
+		target   *[]*regexp.Regexp
+	}{
+		{"ignoreTypes", fc.IgnoreTypes, &config.IgnoreTypes},
+		{"warnTypes", fc.WarnTypes, &config.WarnTypes},
+		{"pureFunctions", fc.PureFunctions, &config.PureFunctions},
+		{"trustedFunctions", fc.TrustedFunctions, &config.TrustedFunctions},
+		{"allowedSkips", fc.AllowedSkips, &config.AllowedSkips},
+	}
+	for _, p := range patterns {
+		for _, pattern := range p.patterns {
+			re, err := CompileIgnorePattern(pattern)
+			if err != nil {
+				return Config{}, fmt.Errorf("%s: invalid pattern %q: %w", p.option, pattern, err)
+			}
+			*p.target = append(*p.target, re)
+		}
+	}
+
+	if err := config.checkErrorConstructor(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
@@ -0,0 +1,195 @@
+package transform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/elliots/typical/packages/compiler/internal/codegen"
+	"github.com/microsoft/typescript-go/shim/ast"
+	"github.com/microsoft/typescript-go/shim/checker"
+	"github.com/microsoft/typescript-go/shim/compiler"
+)
+
+// Version is the compiler version recorded in the hash of emitted validators, so modules
+// generated by a different version show up as stale. Release builds set it from the version
+// being published (see scripts/build-binaries.sh) with
+// -ldflags "-X github.com/elliots/typical/packages/compiler/internal/transform.Version=x.y.z".
+var Version = "dev"
+
+// EmitValidators generates a standalone module containing validators for the exported types
+// of a source file, instead of transforming the file itself. Each exported interface, type
+// alias, class and enum gets an exported check function (_check_User), filter function
+// (_filter_User) and type guard (isUser), and the types are imported from the source module.
+// Generic types are skipped, since their type arguments are only known where they're used.
+// The module starts with a typical-hash header (see ValidatorsHash) for detecting stale copies.
+func EmitValidators(sourceFile *ast.SourceFile, c *checker.Checker, program *compiler.Program, config Config) (string, error) {
+	module, err := emitValidatorsModule(sourceFile, c, program, config)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("// Validators generated by typical from %s - regenerate rather than editing.\n", filepath.Base(sourceFile.FileName())))
+	sb.WriteString(fmt.Sprintf("// typical-hash: %s\n", validatorsHash(module)))
+	sb.WriteString(module)
+	return sb.String(), nil
+}
+
+// ValidatorsHash returns the hash EmitValidators records in the typical-hash header of the
+// module it would emit for sourceFile, so CI can check committed validators are up to date.
+// It covers the compiler version and the generated validators, which follow both the
+// structure of the types and the config, so changes that don't affect them (comments,
+// formatting, unexported types) don't make committed validators stale.
+func ValidatorsHash(sourceFile *ast.SourceFile, c *checker.Checker, program *compiler.Program, config Config) (string, error) {
+	module, err := emitValidatorsModule(sourceFile, c, program, config)
+	if err != nil {
+		return "", err
+	}
+	return validatorsHash(module), nil
+}
+
+// emitValidatorsModule generates the imports and validators of an EmitValidators module,
+// without its header.
+func emitValidatorsModule(sourceFile *ast.SourceFile, c *checker.Checker, program *compiler.Program, config Config) (string, error) {
+	fileName := sourceFile.FileName()
+	if err := config.checkErrorConstructor(); err != nil {
+		return "", err
+	}
+	gen := newGenerator(c, program, config)
+
+	var imports []string
+	var body strings.Builder
+	for _, stmt := range sourceFile.Statements.Nodes {
+		if !isExportedTypeDeclaration(stmt) {
+			continue
+		}
+		name := stmt.Name().Text()
+		t := checker.Checker_GetTypeAtLocation(c, stmt.Name())
+		if t == nil || shouldSkipType(t) {
+			continue
+		}
+
+		check := gen.GenerateCheckFunction(t, name)
+		if check.Ignored || check.Code == "" {
+			debugf("[DEBUG] EmitValidators skipping %s: %s\n", name, check.IgnoredReason)
+			continue
+		}
+		filter := gen.GenerateFilterFunction(t, name)
+		if errMsg := gen.GetComplexityError(); errMsg != "" {
+			return "", &ComplexityError{Message: errMsg, FileName: fileName}
+		}
+		if errMsg := gen.GetDirectiveError(); errMsg != "" {
+			return "", fmt.Errorf("%s in file %s", errMsg, fileName)
+		}
+
+		// Classes are needed at runtime for instanceof checks, everything else is type-only
+		if stmt.Kind == ast.KindClassDeclaration || stmt.Kind == ast.KindEnumDeclaration {
+			imports = append(imports, name)
+		} else {
+			imports = append(imports, "type "+name)
+		}
+
+		body.WriteString("export " + check.Code + ";\n")
+		if !filter.Ignored && filter.Code != "" {
+			body.WriteString("export " + filter.Code + ";\n")
+		}
+		body.WriteString(fmt.Sprintf("export function is%s(value: unknown): value is %s { return %s(value, \"value\") === null; }\n",
+			name, name, check.Name))
+	}
+
+	baseName := filepath.Base(fileName)
+	modulePath := "./" + strings.TrimSuffix(baseName, filepath.Ext(baseName))
+
+	var sb strings.Builder
+	if len(imports) > 0 {
+		sb.WriteString(fmt.Sprintf("import { %s } from %q;\n", strings.Join(imports, ", "), modulePath))
+	}
+	if config.StructuredErrors && strings.Contains(body.String(), "TypicalError") {
+		sb.WriteString("export " + gen.TypicalErrorClass() + "\n")
+	}
+	sb.WriteString(body.String())
+	return sb.String(), nil
+}
+
+// validatorsHash hashes the compiler version and an emitted module without its header.
+func validatorsHash(module string) string {
+	sum := sha256.Sum256([]byte(Version + "\n" + module))
+	return hex.EncodeToString(sum[:8])
+}
+
+// isExportedTypeDeclaration reports whether a top-level statement exports a non-generic
+// interface, type alias, class or enum.
+func isExportedTypeDeclaration(stmt *ast.Node) bool {
+	if ast.GetCombinedModifierFlags(stmt)&ast.ModifierFlagsExport == 0 || stmt.Name() == nil {
+		return false
+	}
+	switch stmt.Kind {
+	case ast.KindInterfaceDeclaration:
+		return stmt.AsInterfaceDeclaration().TypeParameters == nil
+	case ast.KindTypeAliasDeclaration:
+		return stmt.AsTypeAliasDeclaration().TypeParameters == nil
+	case ast.KindClassDeclaration:
+		return stmt.AsClassDeclaration().TypeParameters == nil
+	case ast.KindEnumDeclaration:
+		return true
+	}
+	return false
+}
+
+// topLevelValueNames returns the names of the functions, classes and variables declared at the
+// top level of a file, and the names it imports, so generated declarations can avoid
+// clashing with them.
+func topLevelValueNames(sourceFile *ast.SourceFile) map[string]bool {
+	names := make(map[string]bool)
+	for _, stmt := range sourceFile.Statements.Nodes {
+		switch stmt.Kind {
+		case ast.KindFunctionDeclaration, ast.KindClassDeclaration:
+			if stmt.Name() != nil {
+				names[stmt.Name().Text()] = true
+			}
+		case ast.KindVariableStatement:
+			for _, decl := range stmt.AsVariableStatement().DeclarationList.AsVariableDeclarationList().Declarations.Nodes {
+				if name := decl.Name(); name != nil && name.Kind == ast.KindIdentifier {
+					names[name.Text()] = true
+				}
+			}
+		case ast.KindImportDeclaration:
+			clause := stmt.AsImportDeclaration().ImportClause
+			if clause == nil {
+				continue
+			}
+			if name := clause.Name(); name != nil {
+				names[name.Text()] = true
+			}
+			bindings := clause.AsImportClause().NamedBindings
+			switch {
+			case bindings == nil:
+			case bindings.Kind == ast.KindNamespaceImport:
+				names[bindings.Name().Text()] = true
+			case bindings.Kind == ast.KindNamedImports:
+				for _, spec := range bindings.AsNamedImports().Elements.Nodes {
+					names[spec.Name().Text()] = true
+				}
+			}
+		}
+	}
+	return names
+}
+
+// typeGuards returns exported isX and assertX functions for a type, calling its check function.
+// Either is left out when the file already declares something by that name.
+func typeGuards(gen *codegen.Generator, typeName, checkName string, declared map[string]bool) string {
+	var sb strings.Builder
+	if !declared["is"+typeName] {
+		sb.WriteString(fmt.Sprintf("export function is%s(value: unknown): value is %s { return %s(value, \"value\") === null; }\n",
+			typeName, typeName, checkName))
+	}
+	if !declared["assert"+typeName] {
+		sb.WriteString(fmt.Sprintf("export function assert%s(value: unknown, name = \"value\"): asserts value is %s { const _r = %s(value, name); if (_r !== null) %s; }\n",
+			typeName, typeName, checkName, gen.ThrowError("_r")))
+	}
+	return sb.String()
+}
@@ -0,0 +1,160 @@
+package transform
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/microsoft/typescript-go/shim/ast"
+)
+
+// Sidecar is a companion module holding the validators hoisted out of a transformed file,
+// for Config.SidecarValidators. The transformed file imports them from it, and re-exports
+// the ones it would otherwise have exported itself: type guards and, with ExportValidators,
+// check functions.
+type Sidecar struct {
+	FileName string // Next to the source file, see SidecarFileName
+	Code     string
+}
+
+// SidecarFileName returns where the sidecar module of a source file goes: user.ts and
+// user.tsx get user.typical.ts, user.mts gets user.typical.mts and user.cts gets
+// user.typical.cts. The name differs from --emit-validators-only's user.validators.ts so
+// the two can't overwrite each other.
+func SidecarFileName(fileName string) string {
+	ext := filepath.Ext(fileName)
+	base := strings.TrimSuffix(fileName, ext)
+	if ext == ".tsx" || ext == "" {
+		ext = ".ts"
+	}
+	return base + ".typical" + ext
+}
+
+// hoistedFunction is a check, filter, guard or sampling function hoisted to the top of a
+// file, and whether the file exports it.
+type hoistedFunction struct {
+	code     string // Including its trailing ";\n"
+	exported bool
+}
+
+// text returns the function's code, with an export keyword if export is set.
+func (f hoistedFunction) text(export bool) string {
+	if export && !strings.HasPrefix(f.code, "export ") {
+		return "export " + f.code
+	}
+	return f.code
+}
+
+var (
+	// sidecarExportRegex matches the names a sidecar module exports.
+	sidecarExportRegex = regexp.MustCompile(`(?m)^export (?:function|const|class) ([A-Za-z_$][\w$]*)`)
+	// identifierRegex matches anything that could be an identifier, including inside strings,
+	// which errs on the side of treating a name as used.
+	identifierRegex = regexp.MustCompile(`[A-Za-z_$][\w$]*`)
+)
+
+// buildSidecar moves a file's hoisted functions into a sidecar module. shared declares the
+// error variables they share, which both modules keep their own copy of. typicalError is the
+// TypicalError class when anything uses it, which the sidecar exports and the file imports
+// if mainUsesTypicalError, and guardTypes are the types the type guards assert. It returns
+// the module and the header that replaces the functions in the file, importing them all and
+// re-exporting the ones the file exported.
+//
+// ok is false, and the functions have to stay inline, when they use something the file
+// imports or declares without exporting, since the sidecar can't reach it, or when
+// TypicalError extends an error class from the file, since the sidecar would have to
+// evaluate before the file it imports that class from.
+func buildSidecar(sourceFile *ast.SourceFile, shared string, hoisted []hoistedFunction, typicalError string, mainUsesTypicalError bool, guardTypes []string) (sidecar *Sidecar, header string, ok bool) {
+	var body strings.Builder
+	body.WriteString(shared)
+	if typicalError != "" {
+		body.WriteString("export " + typicalError + ";\n")
+	}
+	for _, f := range hoisted {
+		body.WriteString(f.text(true))
+	}
+
+	declared := topLevelValueNames(sourceFile)
+	exported := exportedValueNames(sourceFile)
+	var valueImports []string
+	for _, id := range identifierRegex.FindAllString(body.String(), -1) {
+		if !declared[id] && !exported[id] || slices.Contains(valueImports, id) {
+			continue
+		}
+		if !exported[id] || typicalError != "" && strings.Contains(typicalError, "extends "+id+" ") {
+			debugf("[DEBUG] Keeping validators inline in %s: they use %s\n", sourceFile.FileName(), id)
+			return nil, "", false
+		}
+		valueImports = append(valueImports, id)
+	}
+	var typeImports []string
+	for _, name := range guardTypes {
+		if !slices.Contains(valueImports, name) && !slices.Contains(typeImports, name) {
+			typeImports = append(typeImports, name)
+		}
+	}
+
+	fileName := sourceFile.FileName()
+	sourceModule := "./" + strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	sidecarFileName := SidecarFileName(fileName)
+	sidecarModule := "./" + strings.TrimSuffix(filepath.Base(sidecarFileName), filepath.Ext(sidecarFileName))
+
+	var code strings.Builder
+	code.WriteString(fmt.Sprintf("// Validators hoisted by typical from %s - regenerate rather than editing.\n", filepath.Base(fileName)))
+	if len(valueImports) > 0 {
+		slices.Sort(valueImports)
+		code.WriteString(fmt.Sprintf("import { %s } from %q;\n", strings.Join(valueImports, ", "), sourceModule))
+	}
+	if len(typeImports) > 0 {
+		slices.Sort(typeImports)
+		code.WriteString(fmt.Sprintf("import type { %s } from %q;\n", strings.Join(typeImports, ", "), sourceModule))
+	}
+	code.WriteString(body.String())
+
+	// The file imports everything, and re-exports what it used to export itself
+	var imports, reexports []string
+	for _, f := range hoisted {
+		for _, m := range sidecarExportRegex.FindAllStringSubmatch(f.text(true), -1) {
+			imports = append(imports, m[1])
+			if f.exported {
+				reexports = append(reexports, m[1])
+			}
+		}
+	}
+	if typicalError != "" && mainUsesTypicalError {
+		imports = append(imports, "TypicalError")
+	}
+	var hb strings.Builder
+	hb.WriteString(fmt.Sprintf("import { %s } from %q;\n", strings.Join(imports, ", "), sidecarModule))
+	if len(reexports) > 0 {
+		hb.WriteString(fmt.Sprintf("export { %s };\n", strings.Join(reexports, ", ")))
+	}
+
+	return &Sidecar{FileName: sidecarFileName, Code: code.String()}, hb.String(), true
+}
+
+// exportedValueNames returns the names of the functions, classes, enums and variables a
+// file declares with an export modifier.
+func exportedValueNames(sourceFile *ast.SourceFile) map[string]bool {
+	names := make(map[string]bool)
+	for _, stmt := range sourceFile.Statements.Nodes {
+		if ast.GetCombinedModifierFlags(stmt)&ast.ModifierFlagsExport == 0 {
+			continue
+		}
+		switch stmt.Kind {
+		case ast.KindFunctionDeclaration, ast.KindClassDeclaration, ast.KindEnumDeclaration:
+			if stmt.Name() != nil {
+				names[stmt.Name().Text()] = true
+			}
+		case ast.KindVariableStatement:
+			for _, decl := range stmt.AsVariableStatement().DeclarationList.AsVariableDeclarationList().Declarations.Nodes {
+				if name := decl.Name(); name != nil && name.Kind == ast.KindIdentifier {
+					names[name.Text()] = true
+				}
+			}
+		}
+	}
+	return names
+}
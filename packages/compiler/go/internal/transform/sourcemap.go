@@ -114,14 +114,16 @@ func (b *sourceMapBuilder) String() string {
 	return b.mappings.String()
 }
 
-// buildSourceMap generates a source map from the original text and insertions
-func buildSourceMap(fileName, originalText string, insertions []insertion) (string, *RawSourceMap) {
+// buildSourceMap generates a source map from the original text and insertions.
+// The original text is embedded as sourcesContent when includeContent is set.
+func buildSourceMap(fileName, originalText string, insertions []insertion, includeContent bool) (string, *RawSourceMap) {
 	lineStarts := computeLineStarts(originalText)
 
-	// Sort insertions ascending by position for forward processing
+	// Sort insertions ascending by position for forward processing, keeping the order
+	// insertions at the same position were added in
 	sorted := make([]insertion, len(insertions))
 	copy(sorted, insertions)
-	sort.Slice(sorted, func(i, j int) bool {
+	sort.SliceStable(sorted, func(i, j int) bool {
 		return sorted[i].pos < sorted[j].pos
 	})
 
@@ -203,15 +205,17 @@ func buildSourceMap(fileName, originalText string, insertions []insertion) (stri
 	// Build the source map
 	// File: the generated file this map is for (will be set by the build tool)
 	// Sources: the original source file(s)
-	content := originalText
 	baseName := filepath.Base(fileName)
 	sourceMap := &RawSourceMap{
-		Version:        3,
-		File:           baseName, // Generated output filename (same as source for in-place transform)
-		Sources:        []string{baseName},
-		SourcesContent: []*string{&content},
-		Names:          []string{},
-		Mappings:       builder.String(),
+		Version:  3,
+		File:     baseName, // Generated output filename (same as source for in-place transform)
+		Sources:  []string{baseName},
+		Names:    []string{},
+		Mappings: builder.String(),
+	}
+	if includeContent {
+		content := originalText
+		sourceMap.SourcesContent = []*string{&content}
 	}
 
 	return result.String(), sourceMap
@@ -0,0 +1,63 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elliots/typical/packages/compiler/internal/analyse"
+	"github.com/microsoft/typescript-go/shim/ast"
+)
+
+// summaryHeader returns the Config.EmitSummaryHeader comment, counting the validated items
+// from the analysis pass, e.g. `/* typical: 3 params, 2 returns, 1 cast validated; 4 helpers hoisted */`.
+func summaryHeader(items []analyse.ValidationItem, hoisted int) string {
+	counts := make(map[string]int)
+	for _, item := range items {
+		if item.Status == "validated" {
+			counts[item.Kind]++
+		}
+	}
+
+	parts := []string{
+		plural(counts["parameter"], "param"),
+		plural(counts["return"], "return"),
+		plural(counts["cast"], "cast"),
+	}
+	if n := counts["json-parse"] + counts["json-stringify"]; n > 0 {
+		parts = append(parts, plural(n, "JSON call"))
+	}
+	return fmt.Sprintf("/* typical: %s validated; %s hoisted */\n", strings.Join(parts, ", "), plural(hoisted, "helper"))
+}
+
+// plural formats a count with a noun, adding an "s" unless the count is one.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// prologueEnd returns the start of the line after a file's shebang and directive prologue
+// ("use strict"), which have to stay at the top of the file.
+func prologueEnd(sourceFile *ast.SourceFile) int {
+	text := sourceFile.Text()
+	end := 0
+	if strings.HasPrefix(text, "#!") {
+		end = len(text)
+		if nl := strings.IndexByte(text, '\n'); nl >= 0 {
+			end = nl + 1
+		}
+	}
+	for _, stmt := range sourceFile.Statements.Nodes {
+		if stmt.Kind != ast.KindExpressionStatement || stmt.AsExpressionStatement().Expression.Kind != ast.KindStringLiteral {
+			break
+		}
+		end = stmt.End()
+		if nl := strings.IndexByte(text[end:], '\n'); nl >= 0 {
+			end += nl + 1
+		} else {
+			end = len(text)
+		}
+	}
+	return end
+}
@@ -2,8 +2,10 @@ package transform
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/elliots/typical/packages/compiler/internal/analyse"
@@ -15,7 +17,14 @@ import (
 
 var debug = os.Getenv("DEBUG") == "1"
 
-var ignoreCommentRegex = regexp.MustCompile(`(//.*@typical-ignore)|(/\*[\s\S]*?@typical-ignore)`)
+// ignoreCommentRegex matches a bare @typical-ignore, not the suffixed per-function directives.
+var ignoreCommentRegex = regexp.MustCompile(`(//.*@typical-ignore(?:[^-\w]|$))|(/\*[\s\S]*?@typical-ignore(?:[^-\w]|$))`)
+
+// ignoreFileRegex matches a `// @typical-ignore-file` directive comment.
+var ignoreFileRegex = regexp.MustCompile(`(?m)^\s*(?://|/\*+|\*)\s*@typical-ignore-file(?:[^-\w]|$)`)
+
+// ignoreFileLines is how many lines at the top of a file are searched for @typical-ignore-file.
+const ignoreFileLines = 10
 
 func debugf(format string, args ...interface{}) {
 	if debug {
@@ -51,11 +60,38 @@ func TransformFileWithSourceMap(sourceFile *ast.SourceFile, c *checker.Checker,
 
 // TransformFileWithSourceMapAndError transforms a TypeScript source file and returns code, source map, and any error.
 // Returns error if a type exceeds the complexity limit (e.g., complex DOM types).
+// Validators are always hoisted inline; Config.SidecarValidators needs TransformFileWithSidecar.
 func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.Checker, program *compiler.Program, config Config) (string, *RawSourceMap, error) {
+	config.SidecarValidators = false
+	code, sourceMap, _, err := transformFile(sourceFile, c, program, config)
+	return code, sourceMap, err
+}
+
+// TransformFileWithSidecar is TransformFileWithSourceMapAndError, also returning the sidecar
+// module the hoisted validators moved to when Config.SidecarValidators is set. The caller
+// writes it to Sidecar.FileName, which the transformed code imports. It's nil when nothing
+// was hoisted or the validators had to stay inline, and the file then imports nothing.
+func TransformFileWithSidecar(sourceFile *ast.SourceFile, c *checker.Checker, program *compiler.Program, config Config) (string, *RawSourceMap, *Sidecar, error) {
+	return transformFile(sourceFile, c, program, config)
+}
+
+// transformFile implements TransformFileWithSourceMapAndError and TransformFileWithSidecar.
+func transformFile(sourceFile *ast.SourceFile, c *checker.Checker, program *compiler.Program, config Config) (string, *RawSourceMap, *Sidecar, error) {
 	text := sourceFile.Text()
 	fileName := sourceFile.FileName()
 	debugf("[DEBUG] Starting transform for %s\n", fileName)
 
+	if err := config.checkErrorConstructor(); err != nil {
+		return "", nil, nil, err
+	}
+
+	// Files opting out with @typical-ignore-file come back as they are
+	if hasIgnoreFileDirective(text) {
+		debugf("[DEBUG] %s has @typical-ignore-file, skipping\n", fileName)
+		code, sourceMap := buildSourceMap(fileName, text, nil, config.IncludeSourcesContent)
+		return code, sourceMap, nil, nil
+	}
+
 	// Compute line starts for position-to-line conversion
 	lineStarts := computeLineStarts(text)
 
@@ -85,12 +121,10 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 		return fmt.Sprintf("%d:%d", line+1, col) // 1-based line, 0-based col
 	}
 
-	// Create generator with config's max functions limit and ignore patterns
-	maxFuncs := config.MaxGeneratedFunctions
-	if maxFuncs == 0 {
-		maxFuncs = DefaultMaxGeneratedFunctions
-	}
-	gen := codegen.NewGeneratorWithIgnoreTypes(c, program, maxFuncs, config.IgnoreTypes)
+	gen := newGenerator(c, program, config)
+
+	// Under noUncheckedIndexedAccess, arr[i] and record[key] may be undefined
+	uncheckedIndexedAccess := program != nil && program.Options().NoUncheckedIndexedAccess.IsTrue()
 
 	// Collect all insertions (position -> text to insert)
 	var insertions []insertion
@@ -176,13 +210,39 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 	}
 	debugf("[DEBUG] First pass complete: %d check types, %d filter types\n", len(checkTypeUsage), len(filterTypeUsage))
 
+	// Exported check functions get public names that tests in the project can import
+	checkPrefix := "_check_"
+	if config.ExportValidators {
+		checkPrefix = "__typical_check_"
+	}
+
+	// With Config.ExportValidators, exported non-generic types declared in this file get isX and
+	// assertX guards, so their check functions are hoisted even when validated only once
+	guardTypes := make(map[string]bool)
+	if config.ExportValidators {
+		for _, stmt := range sourceFile.Statements.Nodes {
+			if !isExportedTypeDeclaration(stmt) {
+				continue
+			}
+			name := stmt.Name().Text()
+			if t := checker.Checker_GetTypeAtLocation(c, stmt.Name()); t != nil && getTypeKey(t, nil) == name {
+				guardTypes[name] = true
+			}
+		}
+	}
+	hoistCheck := func(typeKey string) bool {
+		count := checkTypeUsage[typeKey]
+		return count > 1 || (count > 0 && guardTypes[typeKey])
+	}
+
 	// Pre-allocate function names for types that will be hoisted (usage > 1)
-	// This enables composable validators - nested types can call parent's check function
-	for typeKey, count := range checkTypeUsage {
-		if count > 1 {
+	// This enables composable validators - nested types can call parent's check function.
+	// Keys are sorted so numbered names for complex types are the same from build to build
+	for _, typeKey := range slices.Sorted(maps.Keys(checkTypeUsage)) {
+		if hoistCheck(typeKey) {
 			// Generate a unique function name based on the type key
 			// Uses smart naming: simple types get full name, complex types get shortened name with number
-			finalName := generateFunctionName("_check_", typeKey, checkNameCounter, usedCheckNames)
+			finalName := generateFunctionName(checkPrefix, typeKey, checkNameCounter, usedCheckNames)
 			checkFunctionNames[typeKey] = finalName
 		}
 	}
@@ -194,8 +254,8 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 	// This must happen BEFORE the main visitor so that when we generate
 	// a check function for NestedUser that calls _check_Address,
 	// the _check_Address code already exists
-	for typeKey, count := range checkTypeUsage {
-		if count > 1 {
+	for typeKey := range checkTypeUsage {
+		if hoistCheck(typeKey) {
 			if info, exists := checkTypeObjects[typeKey]; exists {
 				typeName := info.typeName
 				if typeName == "" {
@@ -211,7 +271,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 				if !result.Ignored && result.Code != "" {
 					finalName := checkFunctionNames[typeKey]
 					if result.Name != finalName {
-						result.Code = strings.Replace(result.Code, result.Name+" ", finalName+" ", 1)
+						result.Code = renameFunction(result.Code, result.Name, finalName)
 					}
 					checkFunctions[typeKey] = result.Code
 				}
@@ -221,10 +281,9 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 	debugf("[DEBUG] Pre-generated %d check functions\n", len(checkFunctions))
 
 	// shouldUseReusable returns true if we should use a reusable function for this type
-	// Hoist only if used more than once
+	// Hoist only if used more than once, or if the type gets exported guards
 	shouldUseReusableCheck := func(t *checker.Type, typeNode *ast.Node) bool {
-		key := getTypeKey(t, typeNode)
-		return checkTypeUsage[key] > 1
+		return hoistCheck(getTypeKey(t, typeNode))
 	}
 
 	shouldUseReusableFilter := func(t *checker.Type, typeNode *ast.Node) bool {
@@ -232,6 +291,30 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 		return filterTypeUsage[key] > 1
 	}
 
+	// sampled returns the name to call a hoisted check function by from an insertion site. With
+	// Config.SampleRate or Config.GuardEnvVar that's a hoisted wrapper that only calls it for
+	// sampled calls, returning skipped otherwise. Check functions calling each other use the
+	// unwrapped names, so a sampled validation always runs in full. Filter functions are never
+	// wrapped: their result is the filtered value, which has to be produced every time.
+	sampledFunctions := make(map[string]string)
+	sampled := func(funcName, returnType, skipped string) string {
+		cond := gen.SampleCondition()
+		if cond == "" || funcName == "" {
+			return funcName
+		}
+		wrapperName := "_sampled" + funcName
+		if _, exists := sampledFunctions[wrapperName]; !exists {
+			if config.FunctionDeclarations {
+				sampledFunctions[wrapperName] = fmt.Sprintf("function %s(_v: any, _n: string): %s { return %s ? %s(_v, _n) : %s; }",
+					wrapperName, returnType, cond, funcName, skipped)
+			} else {
+				sampledFunctions[wrapperName] = fmt.Sprintf("const %s = (_v: any, _n: string): %s => %s ? %s(_v, _n) : %s",
+					wrapperName, returnType, cond, funcName, skipped)
+			}
+		}
+		return wrapperName
+	}
+
 	// getOrCreateCheckFunction returns the check function name for a type,
 	// generating it if needed. Returns empty string if generation fails or is ignored.
 	getOrCreateCheckFunction := func(t *checker.Type, typeNode *ast.Node, typeName string) string {
@@ -240,21 +323,35 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 		// Check if we already have the code generated
 		if _, codeExists := checkFunctions[key]; codeExists {
 			// Code already generated, return the name
-			return checkFunctionNames[key]
+			return sampled(checkFunctionNames[key], gen.ErrorType()+" | null", "null")
 		}
 
 		// Check if we have a pre-allocated name (from first pass in auto mode)
 		preAllocatedName, hasPreAllocatedName := checkFunctionNames[key]
 
-		// Generate the check function code
+		// Generate the check function code, unless another file of the project already has
 		var result codegen.CheckFunctionResult
-		if typeNode != nil {
-			result = gen.GenerateCheckFunctionFromNode(t, typeNode, typeName)
-		} else {
-			result = gen.GenerateCheckFunction(t, typeName)
+		var cache *analyse.CheckFunctionCache
+		if config.ProjectAnalysis != nil {
+			cache = config.ProjectAnalysis.CheckFunctions
 		}
-		if result.Ignored || result.Code == "" {
-			return ""
+		cacheKey := analyse.CheckFunctionKey(c, t, typeNode)
+		if cached, ok := cache.Get(program, cacheKey); ok {
+			result = codegen.CheckFunctionResult{Name: cached.Name, Code: cached.Code}
+		} else {
+			if typeNode != nil {
+				result = gen.GenerateCheckFunctionFromNode(t, typeNode, typeName)
+			} else {
+				result = gen.GenerateCheckFunction(t, typeName)
+			}
+			if result.Ignored || result.Code == "" {
+				return ""
+			}
+			// Functions calling this file's other check functions can't be shared
+			if !strings.Contains(strings.ReplaceAll(result.Code, result.Name, ""), checkPrefix) &&
+				gen.GetComplexityError() == "" && gen.GetDirectiveError() == "" {
+				cache.Put(program, cacheKey, analyse.CachedCheckFunction{Name: result.Name, Code: result.Code})
+			}
 		}
 
 		var finalName string
@@ -262,21 +359,21 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 			// Use the pre-allocated name, but replace the generated name in the code
 			finalName = preAllocatedName
 			if result.Name != finalName {
-				result.Code = strings.Replace(result.Code, result.Name+" ", finalName+" ", 1)
+				result.Code = renameFunction(result.Code, result.Name, finalName)
 			}
 		} else {
 			// Generate a smart function name based on the type key
 			// This ensures short, unique names for complex types
-			finalName = generateFunctionName("_check_", key, checkNameCounter, usedCheckNames)
+			finalName = generateFunctionName(checkPrefix, key, checkNameCounter, usedCheckNames)
 			// Replace the function name in the generated code
 			if result.Name != finalName {
-				result.Code = strings.Replace(result.Code, result.Name+" ", finalName+" ", 1)
+				result.Code = renameFunction(result.Code, result.Name, finalName)
 			}
 			checkFunctionNames[key] = finalName
 		}
 
 		checkFunctions[key] = result.Code
-		return finalName
+		return sampled(finalName, gen.ErrorType()+" | null", "null")
 	}
 
 	// getOrCreateFilterFunction returns the filter function name for a type,
@@ -309,7 +406,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 			// Use the pre-allocated name, but replace the generated name in the code
 			finalName = preAllocatedName
 			if result.Name != finalName {
-				result.Code = strings.Replace(result.Code, result.Name+" ", finalName+" ", 1)
+				result.Code = renameFunction(result.Code, result.Name, finalName)
 			}
 		} else {
 			// Generate a smart function name based on the type key
@@ -317,7 +414,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 			finalName = generateFunctionName("_filter_", key, filterNameCounter, usedFilterNames)
 			// Replace the function name in the generated code
 			if result.Name != finalName {
-				result.Code = strings.Replace(result.Code, result.Name+" ", finalName+" ", 1)
+				result.Code = renameFunction(result.Code, result.Name, finalName)
 			}
 			filterFunctionNames[key] = finalName
 		}
@@ -326,25 +423,140 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 		return finalName
 	}
 
+	// failOnCheck returns the statement run when a reusable check function reports an error in _e:
+	// a throw, or console.warn for types matching WarnTypes so execution continues.
+	failOnCheck := func(t *checker.Type) string {
+		if gen.ShouldWarnType(t) {
+			return "console.warn(" + gen.ErrorMessage("_e") + ")"
+		}
+		return gen.ThrowError("_e")
+	}
+
+	// failOnCheckExpr is the expression form of failOnCheck. When warning it evaluates to value.
+	failOnCheckExpr := func(t *checker.Type, value string) string {
+		if gen.ShouldWarnType(t) {
+			return "(console.warn(" + gen.ErrorMessage("_e") + "), " + value + ")"
+		}
+		return "(() => { " + gen.ThrowError("_e") + "; })()"
+	}
+
 	// generateCheckAndThrow generates the compact check-and-throw pattern for reusable validators
 	// Pattern: if ((_e = _check_Type(value, "name")) !== null) throw new TypeError(_e);
-	generateCheckAndThrow := func(checkFuncName, valueExpr, nameStr string) string {
-		return fmt.Sprintf(`if ((_e = %s(%s, "%s")) !== null) throw new TypeError(_e); `,
-			checkFuncName, valueExpr, nameStr)
+	generateCheckAndThrow := func(t *checker.Type, checkFuncName, valueExpr, nameStr string) string {
+		return fmt.Sprintf(`if ((_e = %s(%s, "%s")) !== null) %s; `,
+			checkFuncName, valueExpr, nameStr, failOnCheck(t))
+	}
+
+	// dirtyArgValidation returns the replacement text validating an argument the analyse pass
+	// marked as dirty when passed to an external function, or "" if it needs no validation.
+	dirtyArgValidation := func(currentFuncKey string, callPos, argIdx int, arg *ast.Node, argText string) string {
+		// Use argument position as part of the key to ensure uniqueness
+		// This handles chained calls where multiple calls share the same start position
+		key := fmt.Sprintf("%d:%d:%d", callPos, argIdx, arg.Pos())
+		dirtyArg, needsValidation := dirtyExternalArgs[key]
+		if !needsValidation {
+			return ""
+		}
+
+		// Skip if project analysis knows this variable is validated
+		// (e.g., assigned from a function that validates its return)
+		if currentFuncKey != "" && isValidatedVariable(config, c, currentFuncKey, arg, arg.Pos(), dirtyArg.Type) {
+			return ""
+		}
+
+		// Get type info for the validator
+		argType := dirtyArg.Type
+		if argType == nil {
+			return ""
+		}
+
+		// Get type name for the check function
+		typeName := getTypeNameWithChecker(argType, c)
+		if typeName == "" {
+			typeName = dirtyArg.VarName
+		}
+
+		// Check if we should use a reusable check function
+		typeKey := c.TypeToString(argType)
+		if checkTypeUsage[typeKey] > 1 {
+			// Use reusable check function
+			checkFuncName := getOrCreateCheckFunction(argType, nil, typeName)
+			if checkFuncName != "" {
+				// Wrap the argument: ((_e = _check_X(arg)) !== null ? (() => { throw ... })() : arg)
+				escapedName := escapeString(argText)
+				return fmt.Sprintf(`((_e = %s(%s, "%s")) !== null ? %s : %s)`, checkFuncName, argText, escapedName, failOnCheckExpr(argType, argText), argText)
+			}
+		}
+
+		// Use inline validation
+		result := gen.GenerateValidator(argType, "")
+		if result.Code != "" && !result.Ignored {
+			// Wrap: validator(arg, "argName")
+			return result.Code + "(" + argText + `, "` + escapeString(argText) + `")`
+		}
+		return ""
 	}
 
 	// Track which function we're currently in for return statement handling
 	type funcContext struct {
-		returnType *ast.Node
-		isAsync    bool
-		bodyStart  int                        // Position after opening brace
-		validated  map[string][]*checker.Type // varName -> list of validated types
-		bodyNode   *ast.Node                  // Function body for dirty detection
-		funcKey    string                     // Unique key for cross-file analysis
+		returnType  *ast.Node
+		isAsync     bool
+		isGenerator bool                       // function* - returnType describes the iterator, yields are validated instead
+		bodyStart   int                        // Position after opening brace
+		validated   map[string][]*checker.Type // varName -> list of validated types
+		bodyNode    *ast.Node                  // Function body for dirty detection
+		funcKey     string                     // Unique key for cross-file analysis
+		internal    bool                       // Not exported - params/returns skipped with ValidateExportedOnly
+
+		// Per-function @typical-ignore-params / -returns / -casts directives
+		ignoreParams  bool
+		ignoreReturns bool
+		ignoreCasts   bool // Inherited by nested functions, as they're part of the body
 	}
 	var funcStack []*funcContext
 	nodeCount := 0
 
+	// assertInsertion validates the target of a `// @typical-assert TypeName [target]` comment
+	// on stmt against the named type, using a check function so the error can name the type.
+	// A target is checked before the statement; without one, the variable the statement
+	// declares is checked after it.
+	assertInsertion := func(stmt *ast.Node, typeName, target string) insertion {
+		directivePos := skipTrivia(stmt.Pos())
+		pos := stmt.Pos()
+		if target == "" {
+			target = declaredVariableName(stmt)
+			pos = stmt.End()
+		}
+		assertType := resolveTypeName(c, sourceFile, typeName)
+		switch {
+		case target == "":
+			return insertion{pos: pos, text: fmt.Sprintf("/* @typical-assert %s: no variable declared to check */", typeName), sourcePos: -1}
+		case assertType == nil:
+			return insertion{pos: pos, text: fmt.Sprintf("/* @typical-assert: type %s not found */", typeName), sourcePos: -1}
+		case shouldSkipType(assertType) || shouldSkipComplexType(assertType, c):
+			return insertion{pos: pos, text: fmt.Sprintf("/* @typical-assert: %s can't be validated */", typeName), sourcePos: -1}
+		}
+
+		gen.SetContext(fmt.Sprintf("@typical-assert %s at line %d", typeName, getLineNumber(directivePos)))
+		checkFuncName := getOrCreateCheckFunction(assertType, nil, typeName)
+		if checkFuncName == "" {
+			return insertion{pos: pos, text: fmt.Sprintf("/* @typical-assert: %s can't be validated */", typeName), sourcePos: -1}
+		}
+		message := fmt.Sprintf(`"@typical-assert %s: " + %s`, typeName, gen.ErrorMessage("_e"))
+		fail := gen.ThrowMessage(message)
+		if config.StructuredErrors {
+			fail = fmt.Sprintf(`throw new TypicalError(%s, _e.path, _e.expected, _e.received, _e.value, _e.errors)`, message)
+		}
+		if gen.ShouldWarnType(assertType) {
+			fail = fmt.Sprintf(`console.warn(%s)`, message)
+		}
+		return insertion{
+			pos:       pos,
+			text:      statementSeparator(text, pos) + fmt.Sprintf(`if ((_e = %s(%s, "%s")) !== null) %s;`, checkFuncName, target, target, fail),
+			sourcePos: directivePos,
+		}
+	}
+
 	// Recursive visitor
 	var visit ast.Visitor
 	visit = func(node *ast.Node) bool {
@@ -354,6 +566,13 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 		}
 
 		nodeCount++
+
+		// Statements preceded by a `// @typical-assert` comment
+		if node.Parent != nil && (node.Parent.Kind == ast.KindBlock || node.Parent.Kind == ast.KindSourceFile) {
+			if typeName, target := codegen.AssertDirective(text, node.Pos()); typeName != "" {
+				insertions = append(insertions, assertInsertion(node, typeName, target))
+			}
+		}
 		switch node.Kind {
 		case ast.KindFunctionDeclaration,
 			ast.KindFunctionExpression,
@@ -364,10 +583,18 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 			if fn := getFunctionLike(node); fn != nil {
 				// Push function context
 				ctx := &funcContext{
-					returnType: fn.Type(),
-					isAsync:    fn.IsAsync(),
-					validated:  make(map[string][]*checker.Type),
-					funcKey:    getFunctionKey(sourceFile, fn),
+					returnType:  fn.Type(),
+					isAsync:     fn.IsAsync(),
+					isGenerator: fn.IsGenerator(),
+					validated:   make(map[string][]*checker.Type),
+					funcKey:     getFunctionKey(sourceFile, fn),
+				}
+				if config.ValidateExportedOnly {
+					ctx.internal = !isExportedFunction(config, ctx.funcKey, node)
+				}
+				ctx.ignoreParams, ctx.ignoreReturns, ctx.ignoreCasts = analyse.IgnoreDirectives(node, text)
+				if len(funcStack) > 0 && funcStack[len(funcStack)-1].ignoreCasts {
+					ctx.ignoreCasts = true
 				}
 
 				// Get body start position for inserting parameter validations
@@ -395,12 +622,61 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 				}()
 
 				// Add validators for parameters at the start of function body
-				if config.ValidateParameters && ctx.bodyStart > 0 {
+				if config.ValidateParameters && ctx.bodyStart > 0 && !ctx.internal && !ctx.ignoreParams {
 					// Reset the function index counter for this function scope
 					// This ensures _io0, _io1, etc. start fresh for each function
 					gen.ResetFuncIdx()
 					isFirstParam := true
 
+					// validateBindingPattern validates the variables a destructured parameter binds,
+					// recursing into nested patterns such as { a: { b } } and [first, [x, y]]
+					var validateBindingPattern func(pattern *ast.Node)
+					validateBindingPattern = func(pattern *ast.Node) {
+						bindingPattern := pattern.AsBindingPattern()
+						if bindingPattern == nil || bindingPattern.Elements == nil {
+							return
+						}
+						for _, element := range bindingPattern.Elements.Nodes {
+							// Array patterns can have holes ([, second]), which aren't binding elements
+							if element.Kind != ast.KindBindingElement {
+								continue
+							}
+							elemName := element.AsBindingElement().Name()
+							if elemName == nil {
+								continue
+							}
+							if ast.IsBindingPattern(elemName) {
+								validateBindingPattern(elemName)
+								continue
+							}
+							if elemName.Kind != ast.KindIdentifier {
+								continue
+							}
+							elemNameStr := elemName.AsIdentifier().Text
+							// Get the type of this binding element (including ...rest remainders)
+							elemType := bindingElementType(c, element)
+							if elemType == nil || shouldSkipType(elemType) || shouldSkipComplexType(elemType, c) {
+								continue
+							}
+							// Use continued validation after first param to avoid duplicate _io names
+							var validation string
+							if isFirstParam {
+								validation = gen.GenerateInlineValidation(elemType, elemNameStr)
+								isFirstParam = false
+							} else {
+								validation = gen.GenerateInlineValidationContinued(elemType, nil, elemNameStr)
+							}
+							if validation != "" {
+								insertions = append(insertions, insertion{
+									pos:       ctx.bodyStart,
+									text:      " " + validation,
+									sourcePos: elemName.Pos(),
+								})
+							}
+							ctx.validated[elemNameStr] = append(ctx.validated[elemNameStr], elemType)
+						}
+					}
+
 					params := fn.Parameters()
 					for paramIdx, param := range params {
 						// Check if cross-file analysis determined we can skip this parameter
@@ -426,52 +702,16 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 						// This helps explain why validation is required in internal functions
 						validationReason := getParamValidationReason(config, ctx.funcKey, paramIdx)
 
-						if param.Type != nil {
-							paramType := checker.Checker_getTypeFromTypeNode(c, param.Type)
-							if paramType != nil && !shouldSkipType(paramType) && !shouldSkipComplexType(paramType, c) {
+						// Unannotated parameters fall back to their contextual type, e.g. (req) in
+						// const handler: Handler = (req) => {...}; paramTypeNode is nil then
+						if paramType, paramTypeNode := analyse.GetParamType(c, node, param); paramType != nil {
+							if !shouldSkipType(paramType) && !shouldSkipComplexType(paramType, c) {
 								paramName := getParamName(param)
 								// Handle destructuring patterns - validate each binding element
 								if paramName == "" {
 									// Check for ObjectBindingPattern or ArrayBindingPattern
-									nameNode := param.Name()
-									if nameNode != nil && ast.IsBindingPattern(nameNode) {
-										bindingPattern := nameNode.AsBindingPattern()
-										if bindingPattern != nil && bindingPattern.Elements != nil {
-											for _, element := range bindingPattern.Elements.Nodes {
-												if element.Kind == ast.KindBindingElement {
-													bindingElement := element.AsBindingElement()
-													if bindingElement != nil {
-														elemName := bindingElement.Name()
-														if elemName != nil && elemName.Kind == ast.KindIdentifier {
-															elemNameStr := elemName.AsIdentifier().Text
-															// Get the type of this binding element from its symbol
-															elemSym := element.Symbol()
-															if elemSym != nil {
-																elemType := checker.Checker_getTypeOfSymbol(c, elemSym)
-																if elemType != nil && !shouldSkipType(elemType) && !shouldSkipComplexType(elemType, c) {
-																	// Use continued validation after first param to avoid duplicate _io names
-																	var validation string
-																	if isFirstParam {
-																		validation = gen.GenerateInlineValidation(elemType, elemNameStr)
-																		isFirstParam = false
-																	} else {
-																		validation = gen.GenerateInlineValidationContinued(elemType, nil, elemNameStr)
-																	}
-																	if validation != "" {
-																		insertions = append(insertions, insertion{
-																			pos:       ctx.bodyStart,
-																			text:      " " + validation,
-																			sourcePos: elemName.Pos(),
-																		})
-																	}
-																	ctx.validated[elemNameStr] = append(ctx.validated[elemNameStr], elemType)
-																}
-															}
-														}
-													}
-												}
-											}
-										}
+									if nameNode := param.Name(); nameNode != nil && ast.IsBindingPattern(nameNode) {
+										validateBindingPattern(nameNode)
 									}
 									continue
 								}
@@ -488,25 +728,34 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 								}
 
 								var validation string
-								if shouldUseReusableCheck(paramType, param.Type) {
+								if param.DotDotDotToken != nil {
+									// ...ids: number[] - check each argument against the element type
+									validation = gen.GenerateRestValidationContinued(paramType, paramTypeNode, paramName)
+								}
+								if validation != "" {
+									isFirstParam = false
+								} else if shouldUseReusableCheck(paramType, paramTypeNode) {
 									// Use reusable check function (type is used more than once)
-									checkFuncName := getOrCreateCheckFunction(paramType, param.Type, typeName)
+									checkFuncName := getOrCreateCheckFunction(paramType, paramTypeNode, typeName)
 									if checkFuncName != "" {
-										validation = generateCheckAndThrow(checkFuncName, paramName, paramName)
+										validation = generateCheckAndThrow(paramType, checkFuncName, paramName, paramName)
 									}
 								} else {
 									// Generate inline validation without IIFE wrapper
 									// Use continued validation after first param to avoid duplicate _io names
 									if isFirstParam {
-										validation = gen.GenerateInlineValidationFromNode(paramType, param.Type, paramName)
+										validation = gen.GenerateInlineValidationFromNode(paramType, paramTypeNode, paramName)
 										isFirstParam = false
 									} else {
-										validation = gen.GenerateInlineValidationContinued(paramType, param.Type, paramName)
+										validation = gen.GenerateInlineValidationContinued(paramType, paramTypeNode, paramName)
 									}
 								}
+								// Then any range tags such as @min 1 in the parameter's JSDoc
+								validation += gen.GenerateParameterConstraints(param.AsNode(), paramType, paramName)
 								if validation != "" {
-									// Check if parameter is optional (has ? token or default value)
-									isOptional := param.QuestionToken != nil || param.Initializer != nil
+									// Check if parameter is optional (has ? token or default value). With
+									// ValidateDefaults the value a default produced is validated too
+									isOptional := param.QuestionToken != nil || (param.Initializer != nil && !config.ValidateDefaults)
 
 									var validationText string
 									if isOptional {
@@ -541,6 +790,56 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 						}
 					}
 				}
+
+				// Concise arrow bodies (() => expr) have no return statement, so the body
+				// expression is validated against the return type here
+				if body := ctx.bodyNode; body != nil && body.Kind != ast.KindBlock && config.ValidateReturns && !ctx.internal && !ctx.ignoreReturns && !ctx.isGenerator && ctx.returnType != nil && !analyse.HasTrustComment(body, text) {
+					returnType := checker.Checker_getTypeFromTypeNode(c, ctx.returnType)
+					if returnType != nil && !shouldSkipType(returnType) && !shouldSkipComplexType(returnType, c) {
+						actualType, actualTypeNode := unwrapReturnType(returnType, ctx.returnType, ctx.isAsync, c)
+						if !shouldSkipType(actualType) && !shouldSkipComplexType(actualType, c) &&
+							!isValidatedVariable(config, c, ctx.funcKey, body, body.Pos(), actualType) {
+							lineNum := getLineNumber(body.Pos())
+							gen.SetContext(fmt.Sprintf("return at line %d", lineNum))
+
+							result := gen.GenerateValidatorFromNode(actualType, actualTypeNode, "")
+							returnTypePos := ctx.returnType.Pos()
+							bodyType := checker.Checker_GetTypeAtLocation(c, body)
+							if result.Ignored {
+								insertions = append(insertions, insertion{
+									pos:       body.Pos(),
+									text:      "/* validation skipped: " + result.IgnoredReason + " */",
+									sourcePos: -1,
+								})
+							} else if result.Code != "" && (isPromiseType(returnType, c) && !ctx.isAsync || ctx.isAsync && bodyType != nil && isPromiseType(bodyType, c)) {
+								// The body is a promise, so validate what it resolves to
+								// () => p -> () => (p).then(_v => validator(_v, "return value"))
+								insertions = append(insertions, insertion{
+									pos:       body.Pos(),
+									text:      "(",
+									sourcePos: returnTypePos,
+								})
+								insertions = append(insertions, insertion{
+									pos:       body.End(),
+									text:      ").then(_v => " + result.Code + `(_v, "return value"))`,
+									sourcePos: returnTypePos,
+								})
+							} else if result.Code != "" {
+								// () => expr -> () => validator(expr, "return value")
+								insertions = append(insertions, insertion{
+									pos:       body.Pos(),
+									text:      result.Code + "(",
+									sourcePos: returnTypePos,
+								})
+								insertions = append(insertions, insertion{
+									pos:       body.End(),
+									text:      `, "return value")`,
+									sourcePos: returnTypePos,
+								})
+							}
+						}
+					}
+				}
 			}
 
 		case ast.KindReturnStatement:
@@ -548,7 +847,76 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 			if len(funcStack) > 0 {
 				ctx := funcStack[len(funcStack)-1]
 				returnStmt := node.AsReturnStatement()
-				if returnStmt != nil && returnStmt.Expression != nil && ctx.returnType != nil {
+
+				// return /* @typical-trust */ x: the caller vouches for x, so nothing is checked
+				if returnStmt != nil && returnStmt.Expression != nil && analyse.HasTrustComment(returnStmt.Expression, text) {
+					break
+				}
+
+				// return x satisfies T: validate x against T instead of the function's return type.
+				// Unlike a cast, satisfies doesn't change the type of x, so it stays in the output and
+				// the check is a ternary that evaluates to x itself - the inferred return type is kept.
+				if returnStmt != nil && returnStmt.Expression != nil && returnStmt.Expression.Kind == ast.KindSatisfiesExpression {
+					satisfiesExpr := returnStmt.Expression.AsSatisfiesExpression()
+					if !config.ValidateReturns || ctx.ignoreReturns || satisfiesExpr == nil || satisfiesExpr.Type == nil {
+						break
+					}
+					targetType := checker.Checker_getTypeFromTypeNode(c, satisfiesExpr.Type)
+					targetTypeNode := satisfiesExpr.Type
+					isPromise := targetType != nil && isPromiseType(targetType, c)
+					if isPromise {
+						// x satisfies Promise<T>: validate the resolved value
+						targetType, targetTypeNode = unwrapPromiseType(targetType, targetTypeNode, c)
+					}
+					if targetType == nil || shouldSkipType(targetType) || shouldSkipComplexType(targetType, c) {
+						break
+					}
+
+					lineNum := getLineNumber(returnStmt.Pos())
+					gen.SetContext(fmt.Sprintf("return at line %d", lineNum))
+
+					typeName := getTypeNameWithChecker(targetType, c)
+					if typeName == "" {
+						typeName = "value"
+					}
+					checkFuncName := getOrCreateCheckFunction(targetType, targetTypeNode, typeName)
+					if checkFuncName == "" {
+						break
+					}
+
+					exprStart := satisfiesExpr.Expression.Pos()
+					exprEnd := satisfiesExpr.Expression.End()
+					typePos := satisfiesExpr.Type.Pos()
+					if isPromise {
+						// return p satisfies Promise<T>; -> return (p).then(_v => check ? fail : _v) satisfies Promise<T>;
+						insertions = append(insertions, insertion{
+							pos:       exprStart,
+							text:      "(",
+							sourcePos: typePos,
+						})
+						insertions = append(insertions, insertion{
+							pos:       exprEnd,
+							text:      fmt.Sprintf(`).then(_v => ((_e = %s(_v, "return value")) !== null ? %s : _v))`, checkFuncName, failOnCheckExpr(targetType, "_v")),
+							sourcePos: typePos,
+						})
+					} else {
+						// return x satisfies T; -> return ((_e = _check_T(x, "return value")) !== null ? fail : x) satisfies T;
+						insertions = append(insertions, insertion{
+							pos:       exprStart,
+							text:      fmt.Sprintf(`((_e = %s(`, checkFuncName),
+							sourcePos: typePos,
+						})
+						insertions = append(insertions, insertion{
+							pos:       exprEnd,
+							text:      `, "return value")) !== null ? ` + failOnCheckExpr(targetType, text[exprStart:exprEnd]) + ` : ` + text[exprStart:exprEnd] + `)`,
+							sourcePos: typePos,
+						})
+					}
+					break
+				}
+
+				// A generator's return type describes the iterator, not the returned value
+				if returnStmt != nil && returnStmt.Expression != nil && ctx.returnType != nil && !ctx.isGenerator {
 					returnType := checker.Checker_getTypeFromTypeNode(c, ctx.returnType)
 
 					// Check if return expression is an "as" cast (but NOT "as const")
@@ -590,7 +958,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 												// Generate: ((_f = _filter_X(JSON.parse(arg)))[0] !== null ? (() => { throw ... })() : _f[1])
 												insertions = append(insertions, insertion{
 													pos:       returnStmt.Expression.Pos(),
-													text:      fmt.Sprintf(`((_f = %s(JSON.parse(%s), "JSON.parse"))[0] !== null ? (() => { throw new TypeError(_f[0]); })() : _f[1])`, filterFuncName, argText),
+													text:      fmt.Sprintf(`((_f = %s(JSON.parse(%s), "JSON.parse"))[0] !== null ? (() => { %s; })() : _f[1])`, filterFuncName, argText, gen.ThrowMessage("_f[0]")),
 													sourcePos: ctx.returnType.Pos(),
 													skipTo:    returnStmt.Expression.End(),
 												})
@@ -615,7 +983,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 
 					// Regular return statement validation
 					debugf("[DEBUG] Checking return type validation...\n")
-					if config.ValidateReturns && returnType != nil && !shouldSkipType(returnType) && !shouldSkipComplexType(returnType, c) {
+					if config.ValidateReturns && !ctx.internal && !ctx.ignoreReturns && returnType != nil && !shouldSkipType(returnType) && !shouldSkipComplexType(returnType, c) {
 						debugf("[DEBUG] Return type not skipped, unwrapping...\n")
 						// Get the actual return type (unwrap Promise for async functions)
 						actualType, actualTypeNode := unwrapReturnType(returnType, ctx.returnType, ctx.isAsync, c)
@@ -631,7 +999,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 							}
 
 							// Check project analysis: is return expression a validated variable?
-							if !skipValidation && isValidatedVariable(config, ctx.funcKey, returnStmt.Expression, returnStmt.Expression.Pos()) {
+							if !skipValidation && isValidatedVariable(config, c, ctx.funcKey, returnStmt.Expression, returnStmt.Expression.Pos(), actualType) {
 								skipValidation = true
 								debugf("[DEBUG] Skipping validation: validated variable (project analysis)\n")
 							}
@@ -683,7 +1051,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 											})
 											insertions = append(insertions, insertion{
 												pos:       exprEnd,
-												text:      `, "return value")) !== null ? (() => { throw new TypeError(_e); })() : ` + text[exprStart:exprEnd] + `)`,
+												text:      `, "return value")) !== null ? ` + failOnCheckExpr(actualType, text[exprStart:exprEnd]) + ` : ` + text[exprStart:exprEnd] + `)`,
 												sourcePos: returnTypePos,
 											})
 										} else if isPromiseType(returnType, c) {
@@ -695,7 +1063,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 											})
 											insertions = append(insertions, insertion{
 												pos:       exprEnd,
-												text:      fmt.Sprintf(`).then(_v => ((_e = %s(_v, "return value")) !== null ? (() => { throw new TypeError(_e); })() : _v))`, checkFuncName),
+												text:      fmt.Sprintf(`).then(_v => ((_e = %s(_v, "return value")) !== null ? %s : _v))`, checkFuncName, failOnCheckExpr(actualType, "_v")),
 												sourcePos: returnTypePos,
 											})
 										} else {
@@ -707,7 +1075,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 											})
 											insertions = append(insertions, insertion{
 												pos:       exprEnd,
-												text:      `, "return value")) !== null ? (() => { throw new TypeError(_e); })() : ` + text[exprStart:exprEnd] + `)`,
+												text:      `, "return value")) !== null ? ` + failOnCheckExpr(actualType, text[exprStart:exprEnd]) + ` : ` + text[exprStart:exprEnd] + `)`,
 												sourcePos: returnTypePos,
 											})
 										}
@@ -772,10 +1140,67 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 				}
 			}
 
+		case ast.KindYieldExpression:
+			// yield x in a generator: validate x against the generator's yield type, then yield it.
+			// yield* delegates to another iterator, whose values aren't checked here.
+			if !config.ValidateReturns || len(funcStack) == 0 {
+				break
+			}
+			ctx := funcStack[len(funcStack)-1]
+			yieldExpr := node.AsYieldExpression()
+			if !ctx.isGenerator || ctx.internal || ctx.ignoreReturns || ctx.returnType == nil || yieldExpr == nil || yieldExpr.AsteriskToken != nil || yieldExpr.Expression == nil {
+				break
+			}
+
+			generatorType := checker.Checker_getTypeFromTypeNode(c, ctx.returnType)
+			yieldType, yieldTypeNode := unwrapGeneratorType(generatorType, ctx.returnType, c)
+			if yieldType == nil || shouldSkipType(yieldType) || shouldSkipComplexType(yieldType, c) {
+				break
+			}
+
+			lineNum := getLineNumber(node.Pos())
+			gen.SetContext(fmt.Sprintf("yield at line %d", lineNum))
+
+			result := gen.GenerateValidatorFromNode(yieldType, yieldTypeNode, "")
+			if result.Ignored || result.Code == "" {
+				break
+			}
+
+			exprStart := yieldExpr.Expression.Pos()
+			exprEnd := yieldExpr.Expression.End()
+			operandType := checker.Checker_GetTypeAtLocation(c, yieldExpr.Expression)
+			if ctx.isAsync && operandType != nil && isPromiseType(operandType, c) {
+				// Async generators await a yielded promise, so validate what it resolves to
+				// yield p; -> yield (p).then(_v => validator(_v, "yield value"));
+				insertions = append(insertions, insertion{
+					pos:       exprStart,
+					text:      "(",
+					sourcePos: ctx.returnType.Pos(),
+				})
+				insertions = append(insertions, insertion{
+					pos:       exprEnd,
+					text:      ").then(_v => " + result.Code + `(_v, "yield value"))`,
+					sourcePos: ctx.returnType.Pos(),
+				})
+			} else {
+				// yield x; -> yield validator(x, "yield value");
+				insertions = append(insertions, insertion{
+					pos:       exprStart,
+					text:      result.Code + "(",
+					sourcePos: ctx.returnType.Pos(),
+				})
+				insertions = append(insertions, insertion{
+					pos:       exprEnd,
+					text:      `, "yield value")`,
+					sourcePos: ctx.returnType.Pos(),
+				})
+			}
+
 		case ast.KindAsExpression:
 			// Handle type cast validation: expr as Type
 			// Also handle JSON.parse(x) as T and JSON.stringify(x) as T patterns
 			asExpr := node.AsAsExpression()
+			validateCasts := config.ValidateCasts && (len(funcStack) == 0 || !funcStack[len(funcStack)-1].ignoreCasts)
 			if asExpr != nil && asExpr.Type != nil {
 				// Skip "as const" assertions - they're compile-time only
 				// Check by looking at the source text since the AST node type varies
@@ -795,7 +1220,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 						}
 					}
 				}
-				castType := checker.Checker_getTypeFromTypeNode(c, asExpr.Type)
+				castType := analyse.ResolveIndexedAccessType(c, checker.Checker_getTypeFromTypeNode(c, asExpr.Type), asExpr.Type)
 				skipType := castType == nil || shouldSkipType(castType)
 				if !skipType {
 					skipType = shouldSkipComplexType(castType, c)
@@ -803,9 +1228,19 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 				if !skipType {
 					castTypePos := asExpr.Type.Pos()
 
+					// See through Object.freeze(...) / Object.seal(...): validate the wrapped
+					// value and re-apply the wrapper to the result
+					castExpr, wrapper := unwrapObjectWrapperCall(asExpr.Expression)
+					wrap := func(code string) string {
+						if wrapper == "" {
+							return code
+						}
+						return wrapper + "(" + code + ")"
+					}
+
 					// Check if inner expression is JSON.parse() or JSON.stringify()
-					if asExpr.Expression.Kind == ast.KindCallExpression {
-						innerCall := asExpr.Expression.AsCallExpression()
+					if castExpr.Kind == ast.KindCallExpression {
+						innerCall := castExpr.AsCallExpression()
 						if innerCall != nil {
 							methodName, isJSON := getJSONMethodName(innerCall)
 							if isJSON {
@@ -826,7 +1261,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 												// Generate: ((_f = _filter_X(JSON.parse(arg)))[0] !== null ? (() => { throw ... })() : _f[1])
 												insertions = append(insertions, insertion{
 													pos:       node.Pos(),
-													text:      fmt.Sprintf(`((_f = %s(JSON.parse(%s), "JSON.parse"))[0] !== null ? (() => { throw new TypeError(_f[0]); })() : _f[1])`, filterFuncName, argText),
+													text:      wrap(fmt.Sprintf(`((_f = %s(JSON.parse(%s), "JSON.parse"))[0] !== null ? (() => { %s; })() : _f[1])`, filterFuncName, argText, gen.ThrowMessage("_f[0]"))),
 													sourcePos: castTypePos,
 													skipTo:    node.End(),
 												})
@@ -837,7 +1272,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 										filteringValidator := gen.GenerateFilteringValidator(castType, "")
 										insertions = append(insertions, insertion{
 											pos:       node.Pos(),
-											text:      filteringValidator + "(JSON.parse(" + argText + `), "JSON.parse")`,
+											text:      wrap(filteringValidator + "(JSON.parse(" + argText + `), "JSON.parse")`),
 											sourcePos: castTypePos,
 											skipTo:    node.End(),
 										})
@@ -862,7 +1297,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 												// Generate: ((_f = _filter_X(arg))[0] !== null ? (() => { throw ... })() : JSON.stringify(_f[1]))
 												insertions = append(insertions, insertion{
 													pos:       node.Pos(),
-													text:      fmt.Sprintf(`((_f = %s(%s, "JSON.stringify"))[0] !== null ? (() => { throw new TypeError(_f[0]); })() : JSON.stringify(_f[1]))`, filterFuncName, argText),
+													text:      wrap(fmt.Sprintf(`((_f = %s(%s, "JSON.stringify"))[0] !== null ? (() => { %s; })() : JSON.stringify(_f[1]))`, filterFuncName, argText, gen.ThrowMessage("_f[0]"))),
 													sourcePos: castTypePos,
 													skipTo:    node.End(),
 												})
@@ -873,7 +1308,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 										stringifier := gen.GenerateStringifier(castType, "")
 										insertions = append(insertions, insertion{
 											pos:       node.Pos(),
-											text:      stringifier + "(" + argText + `, "JSON.stringify")`,
+											text:      wrap(stringifier + "(" + argText + `, "JSON.stringify")`),
 											sourcePos: castTypePos,
 											skipTo:    node.End(),
 										})
@@ -884,16 +1319,31 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 						}
 					}
 
+					// Re-casting a variable that's already validated against a type at least as strict
+					// doesn't need validating again. A broader target still validates, e.g.
+					// const a = raw as Partial<User>; const b = a as User; re-checks the required fields.
+					if validateCasts && asExpr.Expression.Kind == ast.KindIdentifier && len(funcStack) > 0 {
+						ctx := funcStack[len(funcStack)-1]
+						if isValidatedVariable(config, c, ctx.funcKey, asExpr.Expression, node.Pos(), castType) {
+							insertions = append(insertions, insertion{
+								pos:       node.Pos(),
+								text:      "/* already valid */",
+								sourcePos: -1,
+							})
+							return true
+						}
+					}
+
 					// Regular cast validation (not JSON)
-					if config.ValidateCasts {
+					if validateCasts {
 						// Set context for error messages
 						castPos := node.Pos()
 						lineNum := getLineNumber(castPos)
 						gen.SetContext(fmt.Sprintf("cast at line %d", lineNum))
 
 						// Get the expression text for error messages
-						exprStart := asExpr.Expression.Pos()
-						exprEnd := asExpr.Expression.End()
+						exprStart := castExpr.Pos()
+						exprEnd := castExpr.End()
 						exprText := strings.TrimSpace(text[exprStart:exprEnd])
 
 						// Get type name for the check function
@@ -916,7 +1366,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 								escapedName := escapeString(exprText)
 								insertions = append(insertions, insertion{
 									pos:       node.Pos(),
-									text:      fmt.Sprintf(`((_e = %s(%s, "%s")) !== null ? (() => { throw new TypeError(_e); })() : %s as %s)`, checkFuncName, exprText, escapedName, exprText, typeText),
+									text:      wrap(fmt.Sprintf(`((_e = %s(%s, "%s")) !== null ? %s : %s as %s)`, checkFuncName, exprText, escapedName, failOnCheckExpr(castType, exprText), exprText, typeText)),
 									sourcePos: castTypePos,
 									skipTo:    node.End(),
 								})
@@ -940,7 +1390,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 								// Use skipTo to skip the entire "as Type" part
 								insertions = append(insertions, insertion{
 									pos:       node.Pos(),
-									text:      result.Code + "(" + exprText + `, "` + escapeString(exprText) + `")`,
+									text:      wrap(result.Code + "(" + exprText + `, "` + escapeString(exprText) + `")`),
 									sourcePos: castTypePos,
 									skipTo:    node.End(),
 								})
@@ -950,6 +1400,72 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 				}
 			}
 
+		case ast.KindNonNullExpression:
+			// Handle non-null assertions: value! claims value isn't null or undefined,
+			// which untrusted data can contradict at runtime
+			if config.ValidateNonNull {
+				nonNull := node.AsNonNullExpression()
+				if nonNull == nil || nonNull.Expression == nil {
+					break
+				}
+
+				// x! = v, x! += 1 and x!++ write to the operand, which a validator call can't be
+				if isWriteTarget(node) {
+					break
+				}
+
+				// Only assertions that narrow something away need checking
+				operandType := checker.Checker_GetTypeAtLocation(c, nonNull.Expression)
+				if operandType == nil || !includesNullOrUndefined(operandType) {
+					break
+				}
+
+				assertedType := checker.Checker_GetTypeAtLocation(c, node)
+				if assertedType == nil || shouldSkipType(assertedType) || shouldSkipComplexType(assertedType, c) {
+					break
+				}
+
+				// Trusted if already validated against a type that excludes null
+				if len(funcStack) > 0 && isValidatedVariable(config, c, funcStack[len(funcStack)-1].funcKey, nonNull.Expression, node.Pos(), assertedType) {
+					break
+				}
+
+				lineNum := getLineNumber(node.Pos())
+				gen.SetContext(fmt.Sprintf("non-null assertion at line %d", lineNum))
+
+				exprText := strings.TrimSpace(text[nonNull.Expression.Pos():nonNull.Expression.End()])
+				result := gen.GenerateValidator(assertedType, "")
+				if result.Ignored {
+					insertions = append(insertions, insertion{
+						pos:       node.Pos(),
+						text:      "/* validation skipped: " + result.IgnoredReason + " */",
+						sourcePos: -1,
+					})
+				} else if call := methodCallOf(node); call != nil && result.Code != "" {
+					// obj.method!() -> (validator(obj.method, "obj.method"), obj.method!()), which
+					// reads obj.method twice but keeps obj as the call's this
+					insertions = append(insertions, insertion{
+						pos:       node.Pos(),
+						text:      "(" + result.Code + "(" + exprText + `, "` + escapeString(exprText) + `"), `,
+						sourcePos: node.Pos(),
+					})
+					insertions = append(insertions, insertion{
+						pos:       call.End(),
+						text:      ")",
+						sourcePos: -1,
+					})
+				} else if result.Code != "" {
+					// (value!) -> validator(value, "value")
+					insertions = append(insertions, insertion{
+						pos:       node.Pos(),
+						text:      result.Code + "(" + exprText + `, "` + escapeString(exprText) + `")`,
+						sourcePos: node.Pos(),
+						skipTo:    node.End(),
+					})
+					return false
+				}
+			}
+
 		case ast.KindCallExpression:
 			// Handle JSON.parse and JSON.stringify transformations
 			callExpr := node.AsCallExpression()
@@ -1020,7 +1536,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 										// Generate: ((_f = _filter_X(JSON.parse(arg)))[0] !== null ? (() => { throw ... })() : _f[1])
 										insertions = append(insertions, insertion{
 											pos:       node.Pos(),
-											text:      fmt.Sprintf(`((_f = %s(JSON.parse(%s), "JSON.parse"))[0] !== null ? (() => { throw new TypeError(_f[0]); })() : _f[1])`, filterFuncName, argText),
+											text:      fmt.Sprintf(`((_f = %s(JSON.parse(%s), "JSON.parse"))[0] !== null ? (() => { %s; })() : _f[1])`, filterFuncName, argText, gen.ThrowMessage("_f[0]")),
 											sourcePos: sourcePos,
 											skipTo:    node.End(),
 										})
@@ -1060,7 +1576,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 										// Generate: ((_f = _filter_X(arg))[0] !== null ? (() => { throw ... })() : JSON.stringify(_f[1]))
 										insertions = append(insertions, insertion{
 											pos:       node.Pos(),
-											text:      fmt.Sprintf(`((_f = %s(%s, "JSON.stringify"))[0] !== null ? (() => { throw new TypeError(_f[0]); })() : JSON.stringify(_f[1]))`, filterFuncName, argText),
+											text:      fmt.Sprintf(`((_f = %s(%s, "JSON.stringify"))[0] !== null ? (() => { %s; })() : JSON.stringify(_f[1]))`, filterFuncName, argText, gen.ThrowMessage("_f[0]")),
 											sourcePos: sourcePos,
 											skipTo:    node.End(),
 										})
@@ -1091,64 +1607,37 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 					currentFuncKey = funcStack[len(funcStack)-1].funcKey
 				}
 
+				// Values passed to structured clone boundaries (postMessage, structuredClone)
+				// are also checked for fields the runtime can't serialise
+				boundary := isSerializableBoundary(config, callExpr)
+
 				// Use the position of the opening paren (node.End() is after the closing paren)
 				// For chained calls like Object.keys(x).map(y), node.Pos() returns the same
 				// position for both calls, but the argument positions are unique
 				callPos := node.Pos()
 				for argIdx, arg := range callExpr.Arguments.Nodes {
-					// Use argument position as part of the key to ensure uniqueness
-					// This handles chained calls where multiple calls share the same start position
-					key := fmt.Sprintf("%d:%d:%d", callPos, argIdx, arg.Pos())
-					dirtyArg, needsValidation := dirtyExternalArgs[key]
-					if !needsValidation {
+					if analyse.HasTrustComment(arg, text) {
 						continue
 					}
-
-					// Skip if project analysis knows this variable is validated
-					// (e.g., assigned from a function that validates its return)
-					if currentFuncKey != "" && isValidatedVariable(config, currentFuncKey, arg, arg.Pos()) {
-						continue
-					}
-
-					// Get type info for the validator
-					argType := dirtyArg.Type
-					if argType == nil {
-						continue
-					}
-
-					// Get type name for the check function
-					typeName := getTypeNameWithChecker(argType, c)
-					if typeName == "" {
-						typeName = dirtyArg.VarName
-					}
-
 					argText := text[arg.Pos():arg.End()]
-
-					// Check if we should use a reusable check function
-					typeKey := c.TypeToString(argType)
-					if checkTypeUsage[typeKey] > 1 {
-						// Use reusable check function
-						checkFuncName := getOrCreateCheckFunction(argType, nil, typeName)
-						if checkFuncName != "" {
-							// Wrap the argument: ((_e = _check_X(arg)) !== null ? (() => { throw ... })() : arg)
-							escapedName := escapeString(argText)
-							insertions = append(insertions, insertion{
-								pos:       arg.Pos(),
-								text:      fmt.Sprintf(`((_e = %s(%s, "%s")) !== null ? (() => { throw new TypeError(_e); })() : %s)`, checkFuncName, argText, escapedName, argText),
-								sourcePos: arg.Pos(),
-								skipTo:    arg.End(),
-							})
-							continue
+					argCode := dirtyArgValidation(currentFuncKey, callPos, argIdx, arg, argText)
+
+					if boundary && argIdx == 0 {
+						argType := checker.Checker_GetTypeAtLocation(c, arg)
+						if argType != nil && !shouldSkipType(argType) {
+							if check := gen.GenerateSerializableCheck(argType); check != "" {
+								if argCode == "" {
+									argCode = argText
+								}
+								argCode = check + "(" + argCode + `, "` + escapeString(strings.TrimSpace(argText)) + `")`
+							}
 						}
 					}
 
-					// Use inline validation
-					result := gen.GenerateValidator(argType, "")
-					if result.Code != "" && !result.Ignored {
-						// Wrap: validator(arg, "argName")
+					if argCode != "" {
 						insertions = append(insertions, insertion{
 							pos:       arg.Pos(),
-							text:      result.Code + "(" + argText + `, "` + escapeString(argText) + `")`,
+							text:      argCode,
 							sourcePos: arg.Pos(),
 							skipTo:    arg.End(),
 						})
@@ -1190,7 +1679,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 												// Generate: ((_f = _filter_X(JSON.parse(arg)))[0] !== null ? (() => { throw ... })() : _f[1])
 												insertions = append(insertions, insertion{
 													pos:       varDecl.Initializer.Pos(),
-													text:      fmt.Sprintf(`((_f = %s(JSON.parse(%s), "JSON.parse"))[0] !== null ? (() => { throw new TypeError(_f[0]); })() : _f[1])`, filterFuncName, argText),
+													text:      fmt.Sprintf(`((_f = %s(JSON.parse(%s), "JSON.parse"))[0] !== null ? (() => { %s; })() : _f[1])`, filterFuncName, argText, gen.ThrowMessage("_f[0]")),
 													sourcePos: varDecl.Type.Pos(),
 													skipTo:    varDecl.Initializer.End(),
 												})
@@ -1226,10 +1715,156 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 					}
 				}
 
-				// Handle unvalidated call results: const x = externalFunc()
+				// Handle: const xs: T[] = [...untrusted]
+				// Spreading an any[] satisfies any element type, so validate the spread source
+				if varDecl.Type != nil && varDecl.Initializer != nil && varDecl.Initializer.Kind == ast.KindArrayLiteralExpression {
+					declaredType := checker.Checker_getTypeFromTypeNode(c, varDecl.Type)
+					if declaredType != nil && checker.Checker_isArrayType(c, declaredType) && !shouldSkipType(declaredType) && !shouldSkipComplexType(declaredType, c) {
+						for _, elem := range varDecl.Initializer.AsArrayLiteralExpression().Elements.Nodes {
+							if elem.Kind != ast.KindSpreadElement {
+								continue
+							}
+							source := elem.AsSpreadElement().Expression
+							if source.Kind == ast.KindCallExpression {
+								// JSON.parse results are filtered by their own handler
+								if _, isJSON := getJSONMethodName(source.AsCallExpression()); isJSON {
+									continue
+								}
+							}
+							// An any[] variable is never validated as anything narrower, so there's
+							// no validated-variable check to skip on
+							if !isUntrustedArraySource(c, checker.Checker_GetTypeAtLocation(c, source)) {
+								continue
+							}
+							lineNum := getLineNumber(elem.Pos())
+							gen.SetContext(fmt.Sprintf("spread at line %d", lineNum))
+
+							sourceText := strings.TrimSpace(text[source.Pos():source.End()])
+							result := gen.GenerateValidator(declaredType, "")
+							if result.Ignored {
+								insertions = append(insertions, insertion{
+									pos:       source.Pos(),
+									text:      "/* validation skipped: " + result.IgnoredReason + " */",
+									sourcePos: -1,
+								})
+							} else if result.Code != "" {
+								// [...source] -> [...validator(source, "source")]
+								insertions = append(insertions, insertion{
+									pos:       source.Pos(),
+									text:      result.Code + "(" + sourceText + `, "` + escapeString(sourceText) + `")`,
+									sourcePos: source.Pos(),
+									skipTo:    source.End(),
+								})
+							}
+						}
+					}
+				}
+
+				// Handle: const x: A | B = { ...untrusted, id: anyValue }
+				// A typed literal can only be wrong through any-typed values, so only those are validated
+				if varDecl.Type != nil && varDecl.Initializer != nil && varDecl.Initializer.Kind == ast.KindObjectLiteralExpression && varDecl.Name().Kind == ast.KindIdentifier {
+					declaredType := checker.Checker_getTypeFromTypeNode(c, varDecl.Type)
+					if declaredType != nil && !shouldSkipType(declaredType) && !shouldSkipComplexType(declaredType, c) && hasUntrustedLiteralValues(c, varDecl.Initializer) {
+						varName := varDecl.Name().AsIdentifier().Text
+						lineNum := getLineNumber(varDecl.Initializer.Pos())
+						gen.SetContext(fmt.Sprintf("object literal at line %d", lineNum))
+
+						result := gen.GenerateValidatorFromNode(declaredType, varDecl.Type, "")
+						if result.Ignored {
+							insertions = append(insertions, insertion{
+								pos:       varDecl.Initializer.Pos(),
+								text:      "/* validation skipped: " + result.IgnoredReason + " */",
+								sourcePos: -1,
+							})
+						} else if result.Code != "" {
+							// = { ... } -> = validator({ ... }, "x")
+							// Wrapped rather than replaced, so values inside the literal are still transformed
+							insertions = append(insertions, insertion{
+								pos:       varDecl.Initializer.Pos(),
+								text:      result.Code + "(",
+								sourcePos: varDecl.Type.Pos(),
+							})
+							insertions = append(insertions, insertion{
+								pos:       varDecl.Initializer.End(),
+								text:      `, "` + varName + `")`,
+								sourcePos: varDecl.Type.Pos(),
+							})
+						}
+					}
+				}
+
+				// Handle: const x: T = arr[i] under noUncheckedIndexedAccess
+				// The element may be missing, so check it's really a T rather than undefined
+				if uncheckedIndexedAccess && varDecl.Type != nil && varDecl.Initializer != nil && varDecl.Initializer.Kind == ast.KindElementAccessExpression {
+					declaredType := checker.Checker_getTypeFromTypeNode(c, varDecl.Type)
+					elemType := checker.Checker_GetTypeAtLocation(c, varDecl.Initializer)
+					if declaredType != nil && elemType != nil && includesNullOrUndefined(elemType) && !includesNullOrUndefined(declaredType) &&
+						!shouldSkipType(declaredType) && !shouldSkipComplexType(declaredType, c) {
+						lineNum := getLineNumber(varDecl.Initializer.Pos())
+						gen.SetContext(fmt.Sprintf("indexed access at line %d", lineNum))
+
+						exprText := strings.TrimSpace(text[varDecl.Initializer.Pos():varDecl.Initializer.End()])
+						result := gen.GenerateValidatorFromNode(declaredType, varDecl.Type, "")
+						if result.Ignored {
+							insertions = append(insertions, insertion{
+								pos:       varDecl.Initializer.Pos(),
+								text:      "/* validation skipped: " + result.IgnoredReason + " */",
+								sourcePos: -1,
+							})
+						} else if result.Code != "" {
+							// = arr[i] -> = validator(arr[i], "arr[i]")
+							insertions = append(insertions, insertion{
+								pos:       varDecl.Initializer.Pos(),
+								text:      result.Code + "(",
+								sourcePos: varDecl.Type.Pos(),
+							})
+							insertions = append(insertions, insertion{
+								pos:       varDecl.Initializer.End(),
+								text:      `, "` + escapeString(exprText) + `")`,
+								sourcePos: varDecl.Type.Pos(),
+							})
+						}
+					}
+				}
+
+				// Handle: const x: T = obj.method?.()
+				// The call is skipped when the method is absent, giving undefined instead of a T
+				if varDecl.Type != nil && varDecl.Initializer != nil && isOptionalChainCall(varDecl.Initializer) {
+					declaredType := checker.Checker_getTypeFromTypeNode(c, varDecl.Type)
+					if declaredType != nil && !includesNullOrUndefined(declaredType) &&
+						!shouldSkipType(declaredType) && !shouldSkipComplexType(declaredType, c) {
+						lineNum := getLineNumber(varDecl.Initializer.Pos())
+						gen.SetContext(fmt.Sprintf("optional call at line %d", lineNum))
+
+						exprText := strings.TrimSpace(text[varDecl.Initializer.Pos():varDecl.Initializer.End()])
+						result := gen.GenerateValidatorFromNode(declaredType, varDecl.Type, "")
+						if result.Ignored {
+							insertions = append(insertions, insertion{
+								pos:       varDecl.Initializer.Pos(),
+								text:      "/* validation skipped: " + result.IgnoredReason + " */",
+								sourcePos: -1,
+							})
+						} else if result.Code != "" {
+							// = obj.method?.() -> = validator(obj.method?.(), "obj.method?.()")
+							insertions = append(insertions, insertion{
+								pos:       varDecl.Initializer.Pos(),
+								text:      result.Code + "(",
+								sourcePos: varDecl.Type.Pos(),
+							})
+							insertions = append(insertions, insertion{
+								pos:       varDecl.Initializer.End(),
+								text:      `, "` + escapeString(exprText) + `")`,
+								sourcePos: varDecl.Type.Pos(),
+							})
+						}
+					}
+				}
+
+				// Handle unvalidated call results: const x = externalFunc(), or const x = list.find(...)!
 				// These are calls to functions that don't validate their returns
 				// Adds validation after the assignment: const x = externalFunc(); if ((_e = _check_X(x)) !== null) throw ...
-				if config.ProjectAnalysis != nil && varDecl.Initializer != nil && varDecl.Initializer.Kind == ast.KindCallExpression {
+				isCall := varDecl.Initializer != nil && (varDecl.Initializer.Kind == ast.KindCallExpression || analyse.ArrayFindAssertion(c, varDecl.Initializer) != nil)
+				if config.ProjectAnalysis != nil && isCall {
 					callPos := varDecl.Initializer.Pos()
 					if unvalidatedCall, exists := config.ProjectAnalysis.UnvalidatedCallResults[callPos]; exists {
 						// Get type info
@@ -1258,7 +1893,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 
 								insertions = append(insertions, insertion{
 									pos:       insertPos,
-									text:      fmt.Sprintf(`; if ((_e = %s(%s, "%s")) !== null) throw new TypeError(_e)`, checkFuncName, varName, varName),
+									text:      fmt.Sprintf(`; if ((_e = %s(%s, "%s")) !== null) %s`, checkFuncName, varName, varName, failOnCheck(targetType)),
 									sourcePos: callStart,
 								})
 
@@ -1310,7 +1945,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 								}
 							}
 						}
-					} else if varDecl.Initializer.Kind == ast.KindAsExpression && config.ValidateCasts {
+					} else if varDecl.Initializer.Kind == ast.KindAsExpression && config.ValidateCasts && !ctx.ignoreCasts {
 						// 3. Cast expression: const x = data as T
 						asExpr := varDecl.Initializer.AsAsExpression()
 						if asExpr != nil && asExpr.Type != nil {
@@ -1354,7 +1989,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 										// Generate: ((_f = _filter_X(JSON.parse(arg)))[0] !== null ? (() => { throw ... })() : _f[1])
 										insertions = append(insertions, insertion{
 											pos:       bin.Right.Pos(),
-											text:      fmt.Sprintf(`((_f = %s(JSON.parse(%s), "JSON.parse"))[0] !== null ? (() => { throw new TypeError(_f[0]); })() : _f[1])`, filterFuncName, argText),
+											text:      fmt.Sprintf(`((_f = %s(JSON.parse(%s), "JSON.parse"))[0] !== null ? (() => { %s; })() : _f[1])`, filterFuncName, argText, gen.ThrowMessage("_f[0]")),
 											sourcePos: bin.Left.Pos(),
 											skipTo:    bin.Right.End(),
 										})
@@ -1405,7 +2040,7 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 
 							insertions = append(insertions, insertion{
 								pos:       insertPos,
-								text:      fmt.Sprintf(`; if ((_e = %s(%s, "%s")) !== null) throw new TypeError(_e)`, checkFuncName, varName, varName),
+								text:      fmt.Sprintf(`; if ((_e = %s(%s, "%s")) !== null) %s`, checkFuncName, varName, varName, failOnCheck(targetType)),
 								sourcePos: callStart,
 							})
 
@@ -1433,35 +2068,80 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 
 	// Check for complexity errors from the generator
 	if errMsg := gen.GetComplexityError(); errMsg != "" {
-		return "", nil, fmt.Errorf("%s in file %s", errMsg, fileName)
+		return "", nil, nil, &ComplexityError{Message: errMsg, FileName: fileName}
+	}
+	if errMsg := gen.GetDirectiveError(); errMsg != "" {
+		return "", nil, nil, fmt.Errorf("%s in file %s", errMsg, fileName)
 	}
 
 	debugf("[DEBUG] Visitor complete for %s, building source map with %d insertions...\n", fileName, len(insertions))
 
 	// If reusable validators were generated, prepend them at the start of the file
-	// Note: checkFunctions and filterFunctions only contain functions for types used more than once
-	// (due to shouldUseReusableCheck/shouldUseReusableFilter checks)
+	// Note: checkFunctions and filterFunctions only contain functions for types used more than once,
+	// or with exported guards (due to shouldUseReusableCheck/shouldUseReusableFilter checks)
+	var sidecar *Sidecar
 	if len(checkFunctions) > 0 || len(filterFunctions) > 0 {
 		var hoistedCode strings.Builder
 
 		// Add the shared error variables
 		if len(checkFunctions) > 0 {
-			hoistedCode.WriteString("let _e: string | null;\n")
+			hoistedCode.WriteString("let _e: " + gen.ErrorType() + " | null;\n")
 		}
 		if len(filterFunctions) > 0 {
 			hoistedCode.WriteString("let _f: [string | null, any];\n")
 		}
+		shared := hoistedCode.String()
 
-		// Add check functions
-		for _, code := range checkFunctions {
-			hoistedCode.WriteString(code)
-			hoistedCode.WriteString(";\n")
+		// Check functions, in a stable order (Config.ExportValidators exports them), then
+		// type guards, filter functions and sampling wrappers (Config.SampleRate)
+		var hoisted []hoistedFunction
+		for _, key := range slices.Sorted(maps.Keys(checkFunctions)) {
+			hoisted = append(hoisted, hoistedFunction{code: checkFunctions[key] + ";\n", exported: config.ExportValidators})
+		}
+		var guarded []string
+		if len(guardTypes) > 0 {
+			declared := topLevelValueNames(sourceFile)
+			for _, key := range slices.Sorted(maps.Keys(checkFunctions)) {
+				if !guardTypes[key] {
+					continue
+				}
+				if code := typeGuards(gen, key, checkFunctionNames[key], declared); code != "" {
+					hoisted = append(hoisted, hoistedFunction{code: code, exported: true})
+					guarded = append(guarded, key)
+				}
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(filterFunctions)) {
+			hoisted = append(hoisted, hoistedFunction{code: filterFunctions[key] + ";\n"})
+		}
+		for _, name := range slices.Sorted(maps.Keys(sampledFunctions)) {
+			hoisted = append(hoisted, hoistedFunction{code: sampledFunctions[name] + ";\n"})
 		}
 
-		// Add filter functions
-		for _, code := range filterFunctions {
-			hoistedCode.WriteString(code)
-			hoistedCode.WriteString(";\n")
+		// With Config.SidecarValidators the functions move to a companion module, and the
+		// file imports them instead, unless they need something only the file can see
+		inline := true
+		if config.SidecarValidators {
+			usesTypicalError := func(text string) bool {
+				return config.StructuredErrors && strings.Contains(text, "TypicalError")
+			}
+			mainUsesTypicalError := slices.ContainsFunc(insertions, func(ins insertion) bool {
+				return usesTypicalError(ins.text)
+			})
+			typicalError := ""
+			if mainUsesTypicalError || slices.ContainsFunc(hoisted, func(f hoistedFunction) bool { return usesTypicalError(f.code) }) {
+				typicalError = gen.TypicalErrorClass()
+			}
+			if built, header, ok := buildSidecar(sourceFile, shared, hoisted, typicalError, mainUsesTypicalError, guarded); ok {
+				sidecar = built
+				hoistedCode.WriteString(header)
+				inline = false
+			}
+		}
+		if inline {
+			for _, f := range hoisted {
+				hoistedCode.WriteString(f.text(f.exported))
+			}
 		}
 
 		// Insert at position 0 (start of file)
@@ -1475,9 +2155,82 @@ func TransformFileWithSourceMapAndError(sourceFile *ast.SourceFile, c *checker.C
 			len(checkFunctions), len(filterFunctions))
 	}
 
+	// Structured errors need the TypicalError class, declared once ahead of anything using it,
+	// unless it's been moved to the sidecar
+	if config.StructuredErrors && sidecar == nil && slices.ContainsFunc(insertions, func(ins insertion) bool {
+		return strings.Contains(ins.text, "TypicalError")
+	}) {
+		insertions = append([]insertion{{
+			pos:       0,
+			text:      gen.TypicalErrorClass() + ";\n",
+			sourcePos: -1,
+		}}, insertions...)
+	}
+
+	// A custom error constructor has to be in scope. Globals can't be seen from here, so files
+	// that throw it without declaring or importing it get a comment pointing it out
+	if ctor := config.ErrorConstructor; ctor != "" && !builtinErrorConstructors[ctor] && !topLevelValueNames(sourceFile)[ctor] {
+		throws := slices.ContainsFunc(insertions, func(ins insertion) bool {
+			return strings.Contains(ins.text, "new "+ctor+"(") || strings.Contains(ins.text, "extends "+ctor+" ")
+		})
+		if throws {
+			insertions = append([]insertion{{
+				pos:       prologueEnd(sourceFile),
+				text:      fmt.Sprintf("/* typical: errorConstructor %s isn't declared or imported in this file */\n", ctor),
+				sourcePos: -1,
+			}}, insertions...)
+		}
+	}
+
+	// Summarise what was added, after any shebang or "use strict" so they stay first
+	if config.EmitSummaryHeader {
+		hoisted := len(checkFunctions) + len(filterFunctions) + len(sampledFunctions)
+		insertions = append([]insertion{{
+			pos:       prologueEnd(sourceFile),
+			text:      summaryHeader(analyseResult.Items, hoisted),
+			sourcePos: -1,
+		}}, insertions...)
+	}
+
 	// Build result with source map
-	code, sourceMap := buildSourceMap(fileName, text, insertions)
-	return code, sourceMap, nil
+	code, sourceMap := buildSourceMap(fileName, text, insertions, config.IncludeSourcesContent)
+	return code, sourceMap, sidecar, nil
+}
+
+// newGenerator creates a code generator with the config's max functions limit,
+// ignore patterns and generation options.
+func newGenerator(c *checker.Checker, program *compiler.Program, config Config) *codegen.Generator {
+	maxFuncs := config.MaxGeneratedFunctions
+	if maxFuncs == 0 {
+		maxFuncs = DefaultMaxGeneratedFunctions
+	}
+	gen := codegen.NewGeneratorWithIgnoreTypes(c, program, maxFuncs, config.IgnoreTypes)
+	gen.SetStrictOptionalPresence(config.StrictOptionalPresence)
+	gen.SetCoercion(config.Coerce)
+	gen.SetWarnTypes(config.WarnTypes)
+	gen.SetErrorFormat(config.ErrorFormat)
+	gen.SetRejectEmptyObjects(config.RejectEmptyObjects)
+	gen.SetValidationBrand(config.ValidationBrand)
+	gen.SetObjectAcceptsFunctions(config.ObjectAcceptsFunctions)
+	gen.SetDegradeOnComplexity(config.DegradeOnComplexity)
+	gen.SetSampleRate(config.SampleRate)
+	gen.SetGuardEnvVar(config.GuardEnvVar)
+	gen.SetCrossRealmBuiltins(config.CrossRealmBuiltins)
+	gen.SetRejectDroppedFunctions(config.RejectDroppedFunctions)
+	gen.SetCycleSafeValidation(config.CycleSafeValidation)
+	gen.SetFunctionDeclarations(config.FunctionDeclarations)
+	gen.SetStrictNumbers(config.StrictNumbers)
+	if config.IntegerBrand != "" {
+		gen.SetIntegerBrand(config.IntegerBrand)
+	}
+	gen.SetValidateDateValues(config.ValidateDateValues)
+	gen.SetValidateFunctionArity(config.ValidateFunctionArity)
+	gen.SetCollectErrors(config.ErrorMode == ErrorModeCollect)
+	gen.SetStructuredErrors(config.StructuredErrors)
+	if config.ErrorConstructor != "" {
+		gen.SetErrorConstructor(config.ErrorConstructor)
+	}
+	return gen
 }
 
 // MaxTypeComplexity is the maximum number of properties/constituents a type can have
@@ -1558,6 +2311,30 @@ func unwrapPromiseType(t *checker.Type, typeNode *ast.Node, c *checker.Checker)
 	return t, typeNode
 }
 
+// generatorTypeNames are the types a generator function can be declared to return.
+// The first type argument of each is the type of the values it yields.
+var generatorTypeNames = map[string]bool{
+	"Generator":             true,
+	"AsyncGenerator":        true,
+	"Iterator":              true,
+	"AsyncIterator":         true,
+	"IterableIterator":      true,
+	"AsyncIterableIterator": true,
+	"Iterable":              true,
+	"AsyncIterable":         true,
+}
+
+// unwrapGeneratorType extracts Y from Generator<Y, R, N>, AsyncGenerator<Y, R, N> and the
+// iterator types a generator can be declared with, or returns nil for any other type.
+func unwrapGeneratorType(t *checker.Type, typeNode *ast.Node, c *checker.Checker) (*checker.Type, *ast.Node) {
+	sym := checker.Type_symbol(t)
+	if sym == nil || !generatorTypeNames[sym.Name] || len(checker.Checker_getTypeArguments(c, t)) == 0 {
+		return nil, nil
+	}
+	// Same shape as Promise<T> - the yield type is the first type argument
+	return unwrapPromiseType(t, typeNode, c)
+}
+
 // getParamName delegates to the exported analyse.GetParamName.
 func getParamName(param *ast.ParameterDeclaration) string {
 	return analyse.GetParamName(param)
@@ -1604,6 +2381,13 @@ func (f *functionLike) IsAsync() bool {
 	return f.inner.IsAsync()
 }
 
+func (f *functionLike) IsGenerator() bool {
+	if f == nil || f.inner == nil {
+		return false
+	}
+	return f.inner.IsGenerator()
+}
+
 // escapeString escapes a string for use in a JavaScript string literal.
 func escapeString(s string) string {
 	s = strings.ReplaceAll(s, "\\", "\\\\")
@@ -1668,6 +2452,17 @@ func generateFunctionName(prefix string, typeKey string, counter map[string]int,
 	return numberedName
 }
 
+// renameFunction renames a generated check or filter function where it's declared, as either
+// `const name = (...) => {...}` or, with Config.FunctionDeclarations, `function name(...) {...}`.
+func renameFunction(code, name, newName string) string {
+	for _, keyword := range []string{"const ", "function "} {
+		if strings.HasPrefix(code, keyword+name) {
+			return keyword + newName + code[len(keyword)+len(name):]
+		}
+	}
+	return code
+}
+
 // extractBaseTypeName extracts the primary type name from a type string.
 // For example:
 //   - "ArrayItem" -> "ArrayItem"
@@ -1744,6 +2539,134 @@ func isSimpleIdentifier(s string) bool {
 	return true
 }
 
+// isOptionalChainCall reports whether node is a call in an optional chain, such as
+// obj.method?.() or obj?.method(), which evaluates to undefined when the chain short-circuits.
+func isOptionalChainCall(node *ast.Node) bool {
+	return node.Kind == ast.KindCallExpression && node.Flags&ast.NodeFlagsOptionalChain != 0
+}
+
+// isWriteTarget reports whether node is assigned to or incremented, as in node = v,
+// node += 1 or node++, looking through parentheses.
+func isWriteTarget(node *ast.Node) bool {
+	child, parent := node, node.Parent
+	for parent != nil && parent.Kind == ast.KindParenthesizedExpression {
+		child, parent = parent, parent.Parent
+	}
+	if parent == nil {
+		return false
+	}
+	switch parent.Kind {
+	case ast.KindBinaryExpression:
+		bin := parent.AsBinaryExpression()
+		return bin.Left == child && analyse.IsAssignmentOperator(bin.OperatorToken.Kind)
+	case ast.KindPrefixUnaryExpression:
+		op := parent.AsPrefixUnaryExpression().Operator
+		return op == ast.KindPlusPlusToken || op == ast.KindMinusMinusToken
+	case ast.KindPostfixUnaryExpression:
+		op := parent.AsPostfixUnaryExpression().Operator
+		return op == ast.KindPlusPlusToken || op == ast.KindMinusMinusToken
+	}
+	return false
+}
+
+// methodCallOf returns the call expression a non-null assertion on a property or element
+// access is the callee of, as in obj.method!(), or nil. Replacing such a callee with a
+// validator call would lose obj as the call's this.
+func methodCallOf(node *ast.Node) *ast.Node {
+	parent := node.Parent
+	if parent == nil || parent.Kind != ast.KindCallExpression || parent.AsCallExpression().Expression != node {
+		return nil
+	}
+	switch node.AsNonNullExpression().Expression.Kind {
+	case ast.KindPropertyAccessExpression, ast.KindElementAccessExpression:
+		return parent
+	}
+	return nil
+}
+
+// includesNullOrUndefined reports whether t is, or is a union containing, null or undefined.
+func includesNullOrUndefined(t *checker.Type) bool {
+	nullish := checker.TypeFlagsNull | checker.TypeFlagsUndefined | checker.TypeFlagsVoid
+	if checker.Type_flags(t)&nullish != 0 {
+		return true
+	}
+	if checker.Type_flags(t)&checker.TypeFlagsUnion != 0 {
+		for _, member := range t.Types() {
+			if checker.Type_flags(member)&nullish != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isUntrustedArraySource checks whether t is an array whose elements the type system can't
+// vouch for (any[] or unknown[]), so spreading it into a typed array needs validating.
+func isUntrustedArraySource(c *checker.Checker, t *checker.Type) bool {
+	if t == nil || !checker.Checker_isArrayType(c, t) {
+		return false
+	}
+	typeArgs := checker.Checker_getTypeArguments(c, t)
+	if len(typeArgs) == 0 {
+		return false
+	}
+	return checker.Type_flags(typeArgs[0])&(checker.TypeFlagsAny|checker.TypeFlagsUnknown) != 0
+}
+
+// hasUntrustedLiteralValues checks whether an object or array literal contains an any-typed
+// value or spread, directly or in a nested literal. These are the only parts of a literal
+// that can disagree with the type it's assigned to.
+func hasUntrustedLiteralValues(c *checker.Checker, literal *ast.Node) bool {
+	isAny := func(node *ast.Node) bool {
+		t := checker.Checker_GetTypeAtLocation(c, node)
+		return t != nil && checker.Type_flags(t)&checker.TypeFlagsAny != 0
+	}
+	isUntrustedValue := func(node *ast.Node) bool {
+		switch node.Kind {
+		case ast.KindObjectLiteralExpression, ast.KindArrayLiteralExpression:
+			return hasUntrustedLiteralValues(c, node)
+		case ast.KindCallExpression:
+			// JSON.parse results are filtered by their own handler
+			if _, isJSON := getJSONMethodName(node.AsCallExpression()); isJSON {
+				return false
+			}
+		}
+		return isAny(node)
+	}
+
+	switch literal.Kind {
+	case ast.KindObjectLiteralExpression:
+		for _, prop := range literal.AsObjectLiteralExpression().Properties.Nodes {
+			switch prop.Kind {
+			case ast.KindPropertyAssignment:
+				if isUntrustedValue(prop.AsPropertyAssignment().Initializer) {
+					return true
+				}
+			case ast.KindShorthandPropertyAssignment:
+				if isAny(prop.Name()) {
+					return true
+				}
+			case ast.KindSpreadAssignment:
+				if isAny(prop.AsSpreadAssignment().Expression) {
+					return true
+				}
+			}
+		}
+	case ast.KindArrayLiteralExpression:
+		for _, elem := range literal.AsArrayLiteralExpression().Elements.Nodes {
+			if elem.Kind == ast.KindSpreadElement {
+				source := elem.AsSpreadElement().Expression
+				if isAny(source) || isUntrustedArraySource(c, checker.Checker_GetTypeAtLocation(c, source)) {
+					return true
+				}
+			} else if isUntrustedValue(elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // getTypeName returns a stable name for a type, suitable for naming check functions.
 // For primitives, returns the primitive name (e.g., "string", "number").
 // For named types, returns the symbol name.
@@ -1866,16 +2789,88 @@ func getTypeNameWithChecker(t *checker.Type, c *checker.Checker) string {
 	return getTypeName(t)
 }
 
+// isSerializableBoundary reports whether a call passes its first argument across a structured
+// clone boundary configured in SerializableBoundaries. Names match the whole callee
+// ("worker.postMessage") or its last segment ("postMessage").
+func isSerializableBoundary(config Config, callExpr *ast.CallExpression) bool {
+	if len(config.SerializableBoundaries) == 0 {
+		return false
+	}
+	name := analyse.GetEntityName(callExpr.Expression)
+	if name == "" {
+		return false
+	}
+	method := name[strings.LastIndex(name, ".")+1:]
+	for _, boundary := range config.SerializableBoundaries {
+		if boundary == name || boundary == method {
+			return true
+		}
+	}
+	return false
+}
+
 // getJSONMethodName delegates to the exported analyse.GetJSONMethodName.
 func getJSONMethodName(callExpr *ast.CallExpression) (string, bool) {
 	return analyse.GetJSONMethodName(callExpr)
 }
 
+// isExportedFunction delegates to the exported analyse.IsExportedFunction.
+func isExportedFunction(config Config, funcKey string, node *ast.Node) bool {
+	return analyse.IsExportedFunction(config.ProjectAnalysis, funcKey, node)
+}
+
+// bindingElementType returns the type of a destructured binding element. A ...rest element
+// captures the remaining properties, so its type is the computed remainder (e.g. Omit<T, "a">)
+// rather than a declared property type - ask the checker for the type at the binding name.
+func bindingElementType(c *checker.Checker, element *ast.Node) *checker.Type {
+	bindingElement := element.AsBindingElement()
+	if bindingElement.DotDotDotToken != nil {
+		return checker.Checker_GetTypeAtLocation(c, bindingElement.Name())
+	}
+	if sym := element.Symbol(); sym != nil {
+		return checker.Checker_getTypeOfSymbol(c, sym)
+	}
+	return nil
+}
+
+// unwrapObjectWrapperCall sees through Object.freeze(x) and Object.seal(x), which return
+// their argument unchanged. Returns the wrapped expression and the wrapper name, or the
+// expression itself and "" if it isn't a wrapper call.
+func unwrapObjectWrapperCall(expr *ast.Node) (*ast.Node, string) {
+	if expr.Kind != ast.KindCallExpression {
+		return expr, ""
+	}
+	call := expr.AsCallExpression()
+	if call == nil || call.Arguments == nil || len(call.Arguments.Nodes) != 1 {
+		return expr, ""
+	}
+	switch name := getEntityName(call.Expression); name {
+	case "Object.freeze", "Object.seal":
+		return call.Arguments.Nodes[0], name
+	}
+	return expr, ""
+}
+
 // getEntityName delegates to the exported analyse.GetEntityName.
 func getEntityName(node *ast.Node) string {
 	return analyse.GetEntityName(node)
 }
 
+// hasIgnoreFileDirective reports whether a `// @typical-ignore-file` comment is in the first
+// ignoreFileLines lines of text.
+func hasIgnoreFileDirective(text string) bool {
+	end := 0
+	for range ignoreFileLines {
+		i := strings.IndexByte(text[end:], '\n')
+		if i < 0 {
+			end = len(text)
+			break
+		}
+		end += i + 1
+	}
+	return ignoreFileRegex.MatchString(text[:end])
+}
+
 func hasIgnoreComment(node *ast.Node, text string) bool {
 	pos := node.Pos()
 	limit := pos + 500
@@ -2009,7 +3004,9 @@ func isReturnFromValidatedFunction(config Config, c *checker.Checker, node *ast.
 
 // isValidatedVariable checks if an expression is a variable that's been validated in the current function.
 // This uses project analysis's ValidatedVariables and checks dirty tracking.
-func isValidatedVariable(config Config, funcKey string, node *ast.Node, nodePos int) bool {
+// When the expression is the variable itself, its recorded validation must also be at least
+// as strict as targetType (nil accepts any validation).
+func isValidatedVariable(config Config, c *checker.Checker, funcKey string, node *ast.Node, nodePos int, targetType *checker.Type) bool {
 	if config.ProjectAnalysis == nil || node == nil {
 		return false
 	}
@@ -2024,7 +3021,11 @@ func isValidatedVariable(config Config, funcKey string, node *ast.Node, nodePos
 	analyseConfig := analyse.Config{
 		PureFunctions: config.PureFunctions,
 	}
-	result := analyse.IsVariableValidAtPosition(config.ProjectAnalysis, funcKey, varName, nodePos, analyseConfig)
+	// Property chains are recorded against the root variable, whose type isn't the expression's type
+	if node.Kind != ast.KindIdentifier {
+		targetType = nil
+	}
+	result := analyse.IsVariableValidAsType(config.ProjectAnalysis, c, funcKey, varName, nodePos, node, targetType, analyseConfig)
 	debugf("[DEBUG] isValidatedVariable: funcKey=%s varName=%s pos=%d result=%v\n", funcKey, varName, nodePos, result)
 	return result
 }
@@ -2,12 +2,19 @@ package transform
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/elliots/typical/packages/compiler/internal/analyse"
+	"github.com/elliots/typical/packages/compiler/internal/codegen"
+	"github.com/microsoft/typescript-go/shim/ast"
 	"github.com/microsoft/typescript-go/shim/bundled"
+	"github.com/microsoft/typescript-go/shim/checker"
+	"github.com/microsoft/typescript-go/shim/compiler"
 	"github.com/microsoft/typescript-go/shim/project"
 	"github.com/microsoft/typescript-go/shim/vfs/osvfs"
 )
@@ -164,6 +171,1435 @@ function greet(user: User): void {
 				`Expected nums[" + _i0 + "] to be number`, // Error message with array index expression
 			},
 		},
+		{
+			name: "optional property - skipped when undefined by default",
+			input: `interface Profile { nick?: string; }
+function save(p: Profile): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`if (p.nick !== undefined) {`,
+			},
+			unexpectedParts: []string{
+				`hasOwnProperty`,
+			},
+		},
+		{
+			name: "optional property - strict presence rejects explicit undefined",
+			input: `interface Profile { nick?: string; }
+function save(p: Profile): void {}`,
+			config: Config{ValidateParameters: true, StrictOptionalPresence: true},
+			expectedParts: []string{
+				`if (Object.prototype.hasOwnProperty.call(p, "nick")) {`,
+				`if (!(p.nick !== undefined)) throw new TypeError("Expected p.nick to be string, got "`,
+			},
+		},
+		{
+			name: "optional property - strict presence allows declared undefined",
+			input: `interface Profile { nick?: string | undefined; }
+function save(p: Profile): void {}`,
+			config: Config{ValidateParameters: true, StrictOptionalPresence: true},
+			expectedParts: []string{
+				`if (p.nick !== undefined) {`,
+			},
+			unexpectedParts: []string{
+				`hasOwnProperty`,
+			},
+		},
+		{
+			name: "non-null assertion on untrusted value",
+			input: `declare function lookup(id: string): string | null;
+function name(id: string): number {
+	const value = lookup(id);
+	return value!.length;
+}`,
+			config: Config{ValidateNonNull: true},
+			expectedParts: []string{
+				`"string" === typeof _v`,
+				`(value, "value")`,
+			},
+			unexpectedParts: []string{
+				`value!`,
+			},
+		},
+		{
+			name: "non-null assertion written to not validated",
+			input: `declare let cache: { hits: number } | null;
+declare let count: number | undefined;
+function record(): void {
+	cache! = { hits: 0 };
+	count! += 1;
+	count!++;
+}`,
+			config: Config{ValidateNonNull: true},
+			expectedParts: []string{
+				`cache! = { hits: 0 }`,
+				`count! += 1`,
+				`count!++`,
+			},
+			unexpectedParts: []string{
+				`(cache, "cache")`,
+				`(count, "count")`,
+			},
+		},
+		{
+			name: "non-null assertion on a method keeps this",
+			input: `interface Service { run?: () => void; }
+declare const service: Service;
+function start(): void {
+	service.run!();
+}`,
+			config: Config{ValidateNonNull: true},
+			expectedParts: []string{
+				`"function" === typeof _v`,
+				`(service.run, "service.run"), service.run!())`,
+			},
+			unexpectedParts: []string{
+				`(service.run, "service.run")()`,
+			},
+		},
+		{
+			name: "non-null assertion on non-nullable value not validated",
+			input: `function name(value: string): number {
+	return value!.length;
+}`,
+			config: Config{ValidateNonNull: true},
+			expectedParts: []string{
+				`value!.length`,
+			},
+		},
+		{
+			name: "degrade on complexity validates partially instead of failing",
+			input: `interface Order {
+	customer: { name: string } | null;
+	address: { street: string } | null;
+}
+function save(order: Order): void {}`,
+			config: Config{ValidateParameters: true, MaxGeneratedFunctions: 1, DegradeOnComplexity: true},
+			expectedParts: []string{
+				`const _io0 = (input: any) =>`,
+				`/* depth-limited */ "object" === typeof order.address`,
+			},
+			unexpectedParts: []string{
+				`const _io1 =`,
+			},
+		},
+		{
+			name: "cast to indexed access type validates the property type",
+			input: `interface Config { database: { host: string; port: number }; }
+function getDatabase(data: unknown) {
+	return data as Config["database"];
+}
+function getDatabaseFrom<T extends Config>(data: unknown) {
+	return data as T["database"];
+}`,
+			config: Config{ValidateCasts: true},
+			expectedParts: []string{
+				`"string" === typeof _v.host`,
+				`"number" === typeof _v.port`,
+				`(data, "data")`,
+			},
+			unexpectedParts: []string{
+				`as Config["database"]`,
+				`as T["database"]`,
+			},
+		},
+		{
+			name: "identical nested shapes share one _io helper",
+			input: `interface Line {
+	start: { x: number; y: number } | null;
+	end: { x: number; y: number } | null;
+}
+function draw(line: Line): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`const _io0 = (input: any) =>`,
+				`_io0(line.start)`,
+				`_io0(line.end)`,
+			},
+			unexpectedParts: []string{
+				`const _io1 =`,
+			},
+		},
+		{
+			name: "return satisfies validates against the satisfies type",
+			input: `interface Config { port: number; }
+declare function load(): any;
+function getConfig() {
+	const raw = load();
+	return raw satisfies Config;
+}`,
+			config: Config{ValidateReturns: true},
+			expectedParts: []string{
+				`((_e = _check_Config(`,
+				`raw, "return value")) !== null ?`,
+				`raw) satisfies Config;`,
+				`"number" === typeof _v.port`,
+			},
+			unexpectedParts: []string{
+				` as Config`,
+			},
+		},
+		{
+			name: "async return satisfies validates the resolved value",
+			input: `interface Config { port: number; }
+declare function fetchConfig(): Promise<any>;
+async function getConfig() {
+	const raw = await fetchConfig();
+	return raw satisfies Config;
+}
+function getConfigLater() {
+	return fetchConfig() satisfies Promise<Config>;
+}`,
+			config: Config{ValidateReturns: true},
+			expectedParts: []string{
+				`raw) satisfies Config;`,
+				`fetchConfig()).then(_v => ((_e = _check_Config(_v, "return value")) !== null ?`,
+				`satisfies Promise<Config>;`,
+			},
+		},
+		{
+			name: "object parameter accepts non-null objects",
+			input: `function save(data: object): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`typeof data === "object" && data !== null`,
+				`"Expected data to be object, got "`,
+			},
+			unexpectedParts: []string{
+				`typeof data === "function"`,
+			},
+		},
+		{
+			name: "object parameter accepts functions when configured",
+			input: `function save(data: object): void {}`,
+			config: Config{ValidateParameters: true, ObjectAcceptsFunctions: true},
+			expectedParts: []string{
+				`(typeof data === "object" && data !== null) || typeof data === "function"`,
+			},
+		},
+		{
+			name: "empty object type accepts any non-nullish value",
+			input: `function save(data: {}): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`data !== null && data !== undefined`,
+				`"Expected data to be {}, got "`,
+			},
+			unexpectedParts: []string{
+				`typeof data === "object"`,
+			},
+		},
+		{
+			name: "Parameters<typeof f> validates each parameter",
+			input: `interface User { name: string; }
+function handler(user: User, id: number, note?: string, ...tags: string[]): void {}
+export function replay(raw: string): void {
+	const args: Parameters<typeof handler> = JSON.parse(raw);
+	handler(...args);
+}`,
+			config: Config{TransformJSONParse: true},
+			expectedParts: []string{
+				`.length < 2)`,
+				`"number" === typeof`,
+				`.length > 2) {`,
+				`for (let _i`,
+				`"string" === typeof _e`,
+			},
+			unexpectedParts: []string{
+				`.length < 4)`,
+			},
+		},
+		{
+			name: "Parameters<typeof f> cast validates as a tuple",
+			input: `function handler(id: number, name: string): void {}
+export function call(raw: unknown): void {
+	const args = raw as Parameters<typeof handler>;
+	handler(...args);
+}`,
+			config: Config{ValidateCasts: true},
+			expectedParts: []string{
+				`Array.isArray(_v)`,
+				`_v.length === 2`,
+				`"number" === typeof _v[0]`,
+				`"string" === typeof _v[1]`,
+				`(raw, "raw")`,
+			},
+		},
+		{
+			name: "cast to type from global augmentation",
+			input: `declare global {
+	interface App { name: string; version: number; }
+	interface Window { app: App; }
+}
+export function getApp(): App {
+	return window.app as App;
+}`,
+			config: Config{ValidateCasts: true},
+			expectedParts: []string{
+				`"string" === typeof`,
+				`"number" === typeof`,
+				`(window.app, "window.app")`,
+			},
+			unexpectedParts: []string{
+				`validation skipped`,
+				`instanceof App`,
+			},
+		},
+		{
+			name: "parameter typed with global augmentation interface",
+			input: `declare global {
+	interface App { name: string; version: number; }
+}
+export function start(app: App): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`"string" === typeof app.name`,
+				`"number" === typeof app.version`,
+			},
+		},
+		{
+			name: "object literal with any values assigned to union",
+			input: `interface Cat { kind: "cat"; lives: number; }
+interface Dog { kind: "dog"; name: string; }
+function build(raw: any, extra: any): Cat | Dog {
+	const pet: Cat | Dog = { kind: "cat", lives: raw.lives, ...extra };
+	return pet;
+}`,
+			config: Config{},
+			expectedParts: []string{
+				`"cat" === `,
+				`"dog" === `,
+				`, "pet")`,
+				`{ kind: "cat", lives: raw.lives, ...extra }, "pet")`,
+			},
+		},
+		{
+			name: "object literal with typed values not validated",
+			input: `interface Cat { kind: "cat"; lives: number; }
+interface Dog { kind: "dog"; name: string; }
+function build(lives: number): Cat | Dog {
+	const pet: Cat | Dog = { kind: "cat", lives };
+	return pet;
+}`,
+			config: Config{},
+			expectedParts: []string{
+				`const pet: Cat | Dog = { kind: "cat", lives };`,
+			},
+		},
+		{
+			name: "spread of any[] into typed array",
+			input: `interface Item { id: number; }
+function collect(source: any[], extra: Item): Item[] {
+	const xs: Item[] = [extra, ...source];
+	return xs;
+}`,
+			config: Config{},
+			expectedParts: []string{
+				`Array.isArray(_v)`,
+				`"number" === typeof`,
+				`...((_v: any, _n: string) =>`,
+				`(source, "source")]`,
+			},
+		},
+		{
+			name: "spread of typed array not validated",
+			input: `interface Item { id: number; }
+function collect(source: Item[]): Item[] {
+	const xs: Item[] = [...source];
+	return xs;
+}`,
+			config: Config{},
+			expectedParts: []string{
+				`[...source]`,
+			},
+		},
+		{
+			name: "Readonly<T> validates the same properties as T",
+			input: `interface User { name: string; age?: number; tags: string[]; }
+function save(user: Readonly<User>): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`"string" === typeof user.name`,
+				`user.age !== undefined`,
+				`"number" === typeof user.age`,
+				`Array.isArray(user.tags)`,
+			},
+		},
+		{
+			name: "Readonly<T> keeps strict optional presence",
+			input: `interface User { name: string; age?: number; }
+function save(user: Readonly<User>): void {}`,
+			config: Config{ValidateParameters: true, StrictOptionalPresence: true},
+			expectedParts: []string{
+				`Object.prototype.hasOwnProperty.call(user, "age")`,
+			},
+		},
+		{
+			name: "DeepReadonly<T> validates nested properties",
+			input: `type DeepReadonly<T> = { readonly [K in keyof T]: T[K] extends object ? DeepReadonly<T[K]> : T[K] };
+interface Address { street: string; postcode?: string; }
+interface User { name: string; address: Address; }
+function save(user: DeepReadonly<User>): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`"string" === typeof user.name`,
+				`"string" === typeof user.address.street`,
+				`user.address.postcode !== undefined`,
+				`Expected user.address.street to be string`,
+			},
+		},
+		{
+			name: "readonly modifiers keep validation and optionality",
+			input: `interface Limits { readonly id: string; readonly values?: readonly number[]; }
+function apply(limits: Limits): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`"string" === typeof limits.id`,
+				`limits.values !== undefined`,
+				`Array.isArray(limits.values)`,
+				`"number" === typeof`,
+			},
+		},
+		{
+			name: "reject empty objects requires unknown-typed keys",
+			input: `interface Envelope { data: unknown; note: string | undefined; id?: string; }
+function handle(e: Envelope): void {}`,
+			config: Config{ValidateParameters: true, RejectEmptyObjects: true},
+			expectedParts: []string{
+				`"data" in e`,
+				`"note" in e`,
+				`"Expected e.data to be present, got "`,
+			},
+			unexpectedParts: []string{
+				`"id" in e`,
+			},
+		},
+		{
+			name: "empty objects accepted for unknown-typed keys by default",
+			input: `interface Envelope { data: unknown; }
+function handle(e: Envelope): void {}`,
+			config: Config{ValidateParameters: true},
+			unexpectedParts: []string{
+				`"data" in e`,
+			},
+		},
+		{
+			name: "validation brand skips branded values and stamps validated ones",
+			input: `interface User { name: string; }
+function greet(user: User): void {}
+function farewell(user: User): void {}`,
+			config: Config{ValidateParameters: true, ValidationBrand: "app"},
+			expectedParts: []string{
+				`const _check_User = (_v: any, _n: string): string | null => { if (_v && _v.__validated === "app") return null; `,
+				`Object.defineProperty(_v, "__validated", { value: "app" }); return null; }`,
+			},
+		},
+		{
+			name: "serializable boundary checks function fields",
+			input: `interface Job { id: number; tags: string[]; onDone: () => void; }
+declare const port: { postMessage(message: unknown): void };
+function send(job: Job) { port.postMessage(job); }`,
+			config: Config{SerializableBoundaries: []string{"postMessage"}},
+			expectedParts: []string{
+				`typeof _v.onDone !== "function"`,
+				`".onDone to be serialisable, got "`,
+				`, "job")`,
+			},
+			unexpectedParts: []string{
+				`_v.id`,
+				`_v.tags`,
+			},
+		},
+		{
+			name: "serializable boundary checks interface methods but not class methods",
+			input: `interface Task { id: number; run(): void; }
+class Timer { id = 0; tick(): void {} }
+declare const port: { postMessage(message: unknown): void };
+function send(task: Task, timer: Timer) { port.postMessage(task); port.postMessage(timer); }`,
+			config: Config{SerializableBoundaries: []string{"postMessage"}},
+			expectedParts: []string{
+				`typeof _v.run !== "function"`,
+				`".run to be serialisable, got "`,
+			},
+			unexpectedParts: []string{
+				`_v.tick`,
+			},
+		},
+		{
+			name: "custom error format - nested path",
+			input: `interface Address { street: string; }
+interface User { address: Address; }
+function save(user: User): void {}`,
+			config: Config{ValidateParameters: true, ErrorFormat: "invalid %path"},
+			expectedParts: []string{
+				`"invalid user.address.street"`,
+			},
+			unexpectedParts: []string{
+				`Expected user`,
+			},
+		},
+		{
+			name: "custom error format - expected and got",
+			input: `interface Address { street: string; }
+interface User { address: Address; }
+function save(user: User): void {}`,
+			config: Config{ValidateParameters: true, ErrorFormat: "%path: want %expected (%got)"},
+			expectedParts: []string{
+				`"user.address.street: want string ("+`,
+			},
+		},
+		{
+			name: "destructured parameter with object rest",
+			input: `interface WithRest { a: string; b: number; c: boolean; }
+function f({ a, ...rest }: WithRest): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`"string" === typeof a`,
+				`"number" === typeof rest.b`, // rest is validated against the remainder
+				`"boolean" === typeof rest.c`,
+			},
+			unexpectedParts: []string{
+				`rest.a`, // a isn't part of the remainder
+			},
+		},
+		{
+			name: "nested destructured parameter validates each leaf",
+			input: `interface Order { customer: { name: string; address: { postcode: number } }; id: string; }
+function ship({ customer: { name, address: { postcode } }, id }: Order): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`"string" === typeof name`,
+				`"number" === typeof postcode`,
+				`"string" === typeof id`,
+			},
+		},
+		{
+			name:   "array destructured parameter with nested pattern and rest element",
+			input:  `function sum([first, [x, y], , ...others]: [number, [string, boolean], unknown, ...number[]]): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`"number" === typeof first`,
+				`"string" === typeof x`,
+				`"boolean" === typeof y`,
+				`Array.isArray(others)`,
+			},
+		},
+		{
+			name: "warn types log instead of throwing",
+			input: `interface LegacyUser { name: string; }
+interface User { id: number; }
+function migrate(old: LegacyUser, user: User): void {}`,
+			config: Config{ValidateParameters: true, WarnTypes: CompileIgnorePatterns([]string{"Legacy*"})},
+			expectedParts: []string{
+				`try { `,
+				`} catch (_w: any) { console.warn(_w.message); }`,
+				`"number" === typeof user.id`,
+			},
+		},
+		{
+			name: "warn types with reusable check function",
+			input: `interface LegacyUser { name: string; }
+function a(u: LegacyUser): void {}
+function b(u: LegacyUser): void {}`,
+			config: Config{ValidateParameters: true, WarnTypes: CompileIgnorePatterns([]string{"LegacyUser"})},
+			expectedParts: []string{
+				`!== null) console.warn(_e);`,
+			},
+			unexpectedParts: []string{
+				`throw new TypeError(_e)`,
+			},
+		},
+		{
+			name: "warn types nested in properties and elements log instead of throwing",
+			input: `interface LegacyAddress { street: string; }
+interface User { id: number; address: LegacyAddress; tags: LegacyAddress[]; }
+function save(user: User): void {}`,
+			config: Config{ValidateParameters: true, WarnTypes: CompileIgnorePatterns([]string{"Legacy*"})},
+			expectedParts: []string{
+				`try { `,
+				`} catch (_w: any) { console.warn(_w.message); }`,
+				`"number" === typeof user.id`,
+			},
+		},
+		{
+			name: "warn types nested in a reusable check function",
+			input: `interface LegacyAddress { street: string; }
+interface User { id: number; address: LegacyAddress; }
+function a(u: User): void {}
+function b(u: User): void {}`,
+			config: Config{ValidateParameters: true, WarnTypes: CompileIgnorePatterns([]string{"Legacy*"})},
+			expectedParts: []string{
+				`{ const _w = ((): any => { `,
+				`return null; })(); if (_w !== null) console.warn(_w); }`,
+				`throw new TypeError(_e)`, // User itself still throws
+			},
+		},
+		{
+			name: "array of discriminated union switches on tag",
+			input: `type Shape = { kind: "circle"; radius: number } | { kind: "square"; side: number };
+function area(shapes: Shape[]): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`for (let _i`,
+				`switch (_e`,
+				`case "circle": {`,
+				`case "square": {`,
+				`default: throw new TypeError(`,
+			},
+		},
+		{
+			name: "nullable discriminated union switches on tag and checks dispatch on it",
+			input: `type Shape = { kind: "circle"; radius: number } | { kind: "square"; side: number };
+function draw(shape: Shape | null, shapes: Shape[] | string): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`if (null === shape) { } else {`, // Null is accepted before the switch
+				`switch (shape.kind) {`,
+				`elem.kind === "circle" ? `, // Checks only check the member matching the tag
+				`: elem.kind === "square" ? `,
+			},
+		},
+		{
+			name: "accessor with divergent get/set types validates read type",
+			input: `interface Box {
+	get value(): string;
+	set value(v: string | number);
+}
+function useBox(box: Box): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`"string" === typeof box.value`,
+			},
+			unexpectedParts: []string{
+				`"number" === typeof box.value`,
+			},
+		},
+		{
+			name: "exported only - internal function skipped",
+			input: `function helper(count: number): number {
+	return count;
+}
+export function publicApi(name: string): string {
+	return name;
+}
+export const arrow = (flag: boolean) => flag;
+export class Service {
+	run(id: string) {}
+	private secret(key: string) {}
+}`,
+			config: Config{ValidateParameters: true, ValidateReturns: true, ValidateExportedOnly: true},
+			expectedParts: []string{
+				`"string" === typeof name`,
+				`"boolean" === typeof flag`,
+				`"string" === typeof id`,
+			},
+			unexpectedParts: []string{
+				`"number" === typeof count`,
+				`"string" === typeof key`,
+			},
+		},
+		{
+			name: "cast validation - Object.freeze wrapped literal",
+			input: `interface Config { port: number; }
+const c = Object.freeze({ port: 8080 }) as Config;`,
+			config: Config{ValidateCasts: true},
+			expectedParts: []string{
+				`Object.freeze((`,     // Wrapper re-applied around the validated value
+				`"{ port: 8080 }")`,   // Inner literal is what gets validated
+				`"number" === typeof`, // Validated against Config
+			},
+		},
+		{
+			name: "cast validation - Object.seal wrapped JSON.parse",
+			input: `interface Config { port: number; }
+declare const str: string;
+const c = Object.seal(JSON.parse(str)) as Config;`,
+			config: Config{ValidateCasts: true, TransformJSONParse: true},
+			expectedParts: []string{
+				`Object.seal((`,
+				`"JSON.parse")`, // JSON.parse is filtered, not just checked
+			},
+		},
+		{
+			name: "custom error message - interface property and primitive alias",
+			input: `// @typical-error "Age must be a positive integer"
+type Age = number;
+interface Person {
+	name: string;
+	// @typical-error "Email is required"
+	email: string;
+	age: Age;
+}
+function register(person: Person): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`TypeError("Email is required")`,
+				`TypeError("Age must be a positive integer")`,
+				`to be string, got`, // name keeps the default message
+			},
+			unexpectedParts: []string{
+				`.email to be string`,
+				`.age to be number`,
+			},
+		},
+		{
+			name: "custom error message - named type alias",
+			input: `// @typical-error "Role must be admin or user"
+type Role = "admin" | "user";
+function setRole(role: Role): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`TypeError("Role must be admin or user")`,
+			},
+			unexpectedParts: []string{
+				`to be 'admin' | 'user'`,
+			},
+		},
+		{
+			name: "custom error message - JSON.parse filtering",
+			input: `interface Person {
+	/** @typical-error "Email is required" */
+	email: string;
+	nickname?: string; // @typical-error "Only a trailing comment"
+	age: number;
+}
+declare const str: string;
+const p = JSON.parse(str) as Person;`,
+			config: Config{ValidateCasts: true, TransformJSONParse: true},
+			expectedParts: []string{
+				`TypeError("Email is required")`,
+			},
+			unexpectedParts: []string{
+				`TypeError("Only a trailing comment")`,
+			},
+		},
+		{
+			name: "generator yield validation",
+			input: `function* ids(raw: any[]): Generator<number, string> {
+	for (const r of raw) {
+		yield r;
+	}
+	return "done";
+}`,
+			config: Config{ValidateReturns: true},
+			expectedParts: []string{
+				`"number" === typeof`,
+				` r, "yield value")`, // Validated, then yielded
+			},
+			unexpectedParts: []string{
+				`"return value"`, // The return type describes the iterator, not "done"
+			},
+		},
+		{
+			name: "async generator yield validation",
+			input: `interface Item { id: number; }
+declare function fetchItem(id: number): Promise<Item>;
+declare function parseItem(raw: string): any;
+declare const more: AsyncIterable<Item>;
+async function* items(ids: number[], raw: string): AsyncGenerator<Item, void, unknown> {
+	for (const id of ids) {
+		yield fetchItem(id);
+	}
+	yield parseItem(raw);
+	yield* more;
+}`,
+			config: Config{ValidateReturns: true},
+			expectedParts: []string{
+				` fetchItem(id)).then(_v => `, // Yielded promises are awaited, so check the resolved value
+				`(_v, "yield value"))`,
+				` parseItem(raw), "yield value")`,
+				`yield* more;`, // Delegation is left alone
+			},
+		},
+		{
+			name: "sample rate - inline validation",
+			input: `interface Config { port: number; }
+declare const str: string;
+function greet(name: string): void {}
+const c = JSON.parse(str) as Config;`,
+			config: Config{ValidateParameters: true, ValidateCasts: true, TransformJSONParse: true, SampleRate: 0.25},
+			expectedParts: []string{
+				`if (Math.random() < 0.25) { `, // Parameter checks only run for sampled calls
+			},
+			unexpectedParts: []string{
+				`return _v; `, // JSON.parse is always filtered
+			},
+		},
+		{
+			name: "sample rate - filter functions always run",
+			input: `interface User { name: string; }
+declare const str: string;
+const a = JSON.parse<User>(str);
+const b = JSON.parse<User>(str);
+const s = JSON.stringify<User>(a);`,
+			config: Config{TransformJSONParse: true, TransformJSONStringify: true, SampleRate: 0.25},
+			unexpectedParts: []string{
+				`_sampled_filter_`,
+				`Math.random()`,
+			},
+		},
+		{
+			name: "sample rate - hoisted check functions",
+			input: `interface User { name: string; }
+function a(user: User): void {}
+function b(user: User): void {}`,
+			config: Config{ValidateParameters: true, SampleRate: 0.25},
+			expectedParts: []string{
+				`const _sampled_check_User = (_v: any, _n: string): string | null => Math.random() < 0.25 ? _check_User(_v, _n) : null`,
+				`_sampled_check_User(user, "user")`,
+			},
+		},
+		{
+			name:   "sample rate - 1 validates every call",
+			input:  `function greet(name: string): void {}`,
+			config: Config{ValidateParameters: true, SampleRate: 1},
+			unexpectedParts: []string{
+				`Math.random()`,
+			},
+		},
+		{
+			name: "guard env var - validators pass values through in production",
+			input: `interface Config { port: number; }
+declare const str: string;
+function greet(name: string): void {}
+const c = JSON.parse(str) as Config;
+const s = JSON.stringify<Config>(c);`,
+			config: Config{ValidateParameters: true, ValidateCasts: true, TransformJSONParse: true, TransformJSONStringify: true, GuardEnvVar: "NODE_ENV"},
+			expectedParts: []string{
+				`if (typeof process !== "undefined" && process.env.NODE_ENV !== "production") { `, // Parameter checks are skipped in production
+			},
+			unexpectedParts: []string{
+				`return _v; `, // JSON.parse is filtered in production too
+				`_sampled_`,   // and so is JSON.stringify
+			},
+		},
+		{
+			name: "guard env var - combined with sample rate",
+			input: `interface User { name: string; }
+function a(user: User): void {}
+function b(user: User): void {}`,
+			config: Config{ValidateParameters: true, SampleRate: 0.25, GuardEnvVar: "NODE_ENV"},
+			expectedParts: []string{
+				`=> typeof process !== "undefined" && process.env.NODE_ENV !== "production" && Math.random() < 0.25 ? _check_User(_v, _n) : null`,
+			},
+		},
+		{
+			name: "nested function-typed properties are only checked to be functions",
+			input: `interface Widget { id: string; secret: number; }
+interface Registry {
+	plugins: {
+		ui: {
+			makeWidget: (id: string) => Widget;
+			makeFactory: () => () => Widget;
+		};
+	};
+}
+function register(registry: Registry): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`"function" === typeof registry.plugins.ui.makeWidget`,
+				`"function" === typeof registry.plugins.ui.makeFactory`,
+			},
+			unexpectedParts: []string{
+				`.secret`,      // Return types can't be checked without calling the function
+				`makeWidget(`,  // Never called
+				`makeFactory(`, // Never called
+			},
+		},
+		{
+			name:   "set members are validated against a literal union with includes",
+			input:  `function tag(tags: Set<'a' | 'b'>, ids: Set<number>): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`tags instanceof Set`,
+				`["a", "b"].includes(_e`,
+				`Expected tags member to be 'a' | 'b', got`,
+				`ids instanceof Set`,
+				`"number" === typeof _e`,
+			},
+			unexpectedParts: []string{
+				`"a" === _e`, // No if/else chain for literal unions
+			},
+		},
+		{
+			name:   "set literal union members outside the BMP are valid JS strings",
+			input:  "function react(reactions: Set<'😀' | '\\u{E007F}'>): void {}",
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				"[\"😀\", \"\U000E007F\"].includes(_e",
+			},
+			unexpectedParts: []string{
+				`\U000e007f`, // Go's escape for non-printable runes isn't valid JS
+			},
+		},
+		{
+			name: "summary header counts validators after shebang and use strict",
+			input: `#!/usr/bin/env node
+"use strict";
+function greet(name: string, age: number): void {}`,
+			config: Config{ValidateParameters: true, EmitSummaryHeader: true},
+			expectedParts: []string{
+				"#!/usr/bin/env node\n\"use strict\";\n/* typical: 2 params, 0 returns, 0 casts validated; 0 helpers hoisted */\nfunction greet",
+			},
+		},
+		{
+			name: "optional call assigned to a required type is validated",
+			input: `function load(api: any): void {
+	const data: string = api.getData?.();
+	const name: string | undefined = api.getName?.();
+}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`api.getData?.(), "api.getData?.()")`,
+			},
+			unexpectedParts: []string{
+				`api.getName?.(), "api.getName?.()")`, // undefined is allowed
+			},
+		},
+		{
+			name: "expression-bodied arrow validates its return value",
+			input: `const double = (n: any): number => n * 2;
+const label = async (id: any): Promise<string> => id;
+const fetchName = async (load: () => Promise<any>): Promise<string> => load();`,
+			config: Config{ValidateReturns: true},
+			expectedParts: []string{
+				` n * 2, "return value")`, // Sync arrow: body wrapped in a validator
+				` id, "return value")`,    // Async arrow with a plain value
+				`( load()).then(_v => `,   // Async arrow returning a promise
+				`"string" === typeof _v`,
+			},
+		},
+		{
+			name: "symbol index signature values are validated separately from string keys",
+			input: `interface Meta { [key: symbol]: number; [key: string]: string; }
+function tag(meta: Meta): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`of Object.getOwnPropertySymbols(meta)) { const _v`, // Symbol keys, skipped by for...in
+				`meta[" + String(_s`, // Symbols converted for the error path
+				`for (const _k`,      // String keys still use for...in
+			},
+		},
+		{
+			name: "cast in a map callback is validated per element with a hoisted check",
+			input: `interface Item { id: number; }
+function load(raw: unknown[]): Item[] {
+	return raw.map(x => x as Item);
+}`,
+			config: Config{ValidateCasts: true},
+			expectedParts: []string{
+				`const _check_Item = (_v: any, _n: string): string | null`, // Hoisted, as it runs once per element
+				`((_e = _check_Item(x, "x")) !== null ?`,
+				`: x as Item)`,
+			},
+		},
+		{
+			name: "unannotated parameters take their type from a typed variable",
+			input: `interface Request { body: string; }
+type Handler = (req: Request) => void;
+const handler: Handler = (req) => {
+	console.log(req.body);
+};
+[1, 2].forEach((n) => {
+	console.log(n);
+});`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`typeof req === "object"`,
+				`"string" === typeof req.body`,
+			},
+			unexpectedParts: []string{
+				`typeof n`, // Callbacks get their types from already-typed values
+			},
+		},
+		{
+			name: "stringify rejects undeclared function properties it would drop",
+			input: `interface Payload { id: number; }
+const data = { id: 1, save() {} };
+const json = JSON.stringify(data as Payload);`,
+			config: Config{TransformJSONStringify: true, RejectDroppedFunctions: true},
+			expectedParts: []string{
+				`] === "function" && _k`, // Only properties Payload doesn't declare
+				`!== "id") throw new TypeError(`,
+				` to be a declared property (functions are dropped), got `,
+				`return JSON.stringify(_r)`,
+			},
+		},
+		{
+			name: "typical-assert validates a declared variable or named target",
+			input: `interface User { name: string; }
+declare function load(): unknown;
+// @typical-assert User
+const user = load();
+function handle(req: { body: unknown }) {
+	// @typical-assert User req.body
+	console.log(req.body);
+	// @typical-assert Missing req
+	console.log(req);
+}`,
+			config: Config{},
+			expectedParts: []string{
+				`const _check_User = (_v: any, _n: string): string | null`,
+				`const user = load(); if ((_e = _check_User(user, "user")) !== null) throw new TypeError("@typical-assert User: " + _e);`, // Checked once declared
+				`{ if ((_e = _check_User(req.body, "req.body")) !== null) throw new TypeError("@typical-assert User: " + _e);`,            // Checked before the statement
+				`/* @typical-assert: type Missing not found */`,
+			},
+		},
+		{
+			name: "array literal return is validated per tuple position",
+			input: `interface A { id: string; }
+declare function getA(): any;
+declare function getB(): any;
+function pair(): [A, number] {
+	return [getA(), getB()];
+}`,
+			config: Config{ValidateReturns: true},
+			expectedParts: []string{
+				`Array.isArray(_v)`,
+				`_v.length === 2`,
+				`"string" === typeof _v[0].id`, // Each position against its own element type
+				`"number" === typeof _v[1]`,
+				`[getA(), getB()], "return value")`,
+			},
+		},
+		{
+			name: "cycle safe validation tracks visited objects in check functions",
+			input: `interface Node { value: number; next?: Node; }
+function first(node: Node): number {
+	return node.value;
+}
+function last(node: Node): number {
+	return node.value;
+}`,
+			config: Config{ValidateParameters: true, CycleSafeValidation: true},
+			expectedParts: []string{
+				`const _check_Node = (_v: any, _n: string, _seen?: WeakMap<object, Set<string>>): string | null`,
+				`if (_c.has("Node")) return null; _c.add("Node"); } try {`, // A cycle back to a node being checked ends there
+				`} finally { _c?.delete("Node"); }`,                        // The node is only skipped while its check is in progress
+				`, _seen); if (_t !== null) return _t;`,                    // The nested call shares the visited objects
+			},
+		},
+		{
+			name: "export validators exports hoisted check functions under public names",
+			input: `interface User { name: string; }
+interface Team { lead: User; }
+function a(user: User, team: Team): void {}
+function b(user: User, team: Team): void {}`,
+			config: Config{ValidateParameters: true, ExportValidators: true},
+			expectedParts: []string{
+				`export const __typical_check_Team = (_v: any, _n: string): string | null`,
+				`export const __typical_check_User = (_v: any, _n: string): string | null`,
+				`__typical_check_User(_v.lead, _n + ".lead")`, // Check functions call each other by the public names
+				`__typical_check_User(user, "user")`,
+			},
+			unexpectedParts: []string{
+				`const _check_`,
+			},
+		},
+		{
+			name: "export validators adds guards for exported types validated once",
+			input: `export interface User { name: string; }
+interface Draft { title: string; }
+export function isUser(value: unknown): boolean { return true; }
+function save(user: User, draft: Draft): void {}`,
+			config: Config{ValidateParameters: true, ExportValidators: true},
+			expectedParts: []string{
+				`export const __typical_check_User = (_v: any, _n: string): string | null`,
+				`export function assertUser(value: unknown, name = "value"): asserts value is User { const _r = __typical_check_User(value, name); if (_r !== null) throw new TypeError(_r); }`,
+				`__typical_check_User(user, "user")`,
+			},
+			unexpectedParts: []string{
+				`export function isUser(value: unknown): value is User`, // The file's own isUser is kept
+				`__typical_check_Draft`,                                 // Types that aren't exported aren't hoisted for one use
+			},
+		},
+		{
+			name: "collect error mode reports every failing property",
+			input: `interface User { name: string; age: number; }
+function save(user: User): void {}`,
+			config: Config{ValidateParameters: true, ErrorMode: ErrorModeCollect},
+			expectedParts: []string{
+				`const _es: string[] = [];`,
+				`_es.push("Expected user.name to be string, got "+`,
+				`_es.push("Expected user.age to be number, got "+`,
+				`if (_es.length > 0) throw new TypeError(_es.join("; "));`,
+			},
+		},
+		{
+			name: "structured errors throw TypicalError with the failure details",
+			input: `interface User { age: number; }
+function save(user: User): void {}`,
+			config: Config{ValidateParameters: true, StructuredErrors: true},
+			expectedParts: []string{
+				`class TypicalError extends TypeError {`,
+				`throw new TypicalError("Expected user.age to be number, got "+`,
+				`, "user.age", "number", `,
+			},
+			unexpectedParts: []string{
+				`throw new TypeError(`,
+			},
+		},
+		{
+			name: "error constructor replaces TypeError in throws",
+			input: `class ValidationError extends Error {}
+interface User { name: string; }
+function save(user: User): void {}
+function parse(text: string): User { return JSON.parse(text) as User; }`,
+			config: Config{ValidateParameters: true, ValidateCasts: true, TransformJSONParse: true, ErrorConstructor: "ValidationError"},
+			expectedParts: []string{
+				`throw new ValidationError("Expected user.name to be string, got "+`,
+			},
+			unexpectedParts: []string{
+				`new TypeError(`,
+				`isn't declared or imported`,
+			},
+		},
+		{
+			name:   "error constructor that isn't declared or imported gets a comment",
+			input:  `function save(name: string): void {}`,
+			config: Config{ValidateParameters: true, ErrorConstructor: "HttpError"},
+			expectedParts: []string{
+				`/* typical: errorConstructor HttpError isn't declared or imported in this file */`,
+				`throw new HttpError(`,
+			},
+		},
+		{
+			name: "range tags bound properties and parameters",
+			input: `interface Page {
+  /** @minLength 1 */
+  title: string;
+}
+function resize(page: Page, /** @min 0 @max 100 */ percent: number): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`.title.length >= 1`,
+				`"number" !== typeof percent || percent >= 0`,
+				`"Expected percent to be <= 100, got "+`,
+			},
+		},
+		{
+			name: "function arity checks length unless parameters are optional",
+			input: `function run(cb: (a: string, b: number) => void): void {}
+function finish(done: (err?: Error) => void): void {}`,
+			config: Config{ValidateParameters: true, ValidateFunctionArity: true},
+			expectedParts: []string{
+				`"function" === typeof cb && cb.length >= 2`,
+				`"Expected cb to be function taking at least 2 parameters, got "+`,
+			},
+			unexpectedParts: []string{
+				`done.length`,
+			},
+		},
+		{
+			name: "optional property treats only undefined as missing",
+			input: `interface Point { x?: number; }
+function plot(point: Point): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`.x !== undefined) {`, // An explicit null is validated, and rejected, as a number
+			},
+			unexpectedParts: []string{
+				`.x != null`,
+			},
+		},
+		{
+			name:   "bigint literal parameter is compared by value",
+			input:  `function scale(factor: 5n | -1n, base: 10n): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`10n === base`,
+				`"Expected base to be 10n, got "+`,
+				`-1n`,
+			},
+		},
+		{
+			name:   "readonly arrays are validated as arrays",
+			input:  `function join(tags: readonly string[], counts: ReadonlyArray<number>): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`Array.isArray(tags)`,
+				`Array.isArray(counts)`,
+				`Expected tags[" + _i`,
+				`Expected counts[" + _i`,
+			},
+			unexpectedParts: []string{
+				`to be ReadonlyArray`,
+			},
+		},
+		{
+			name: "@typical-ignore-params skips only parameter validation",
+			input: `// @typical-ignore-params
+function greet(name: string): string {
+	return name;
+}`,
+			config: Config{ValidateParameters: true, ValidateReturns: true},
+			expectedParts: []string{
+				`"return value"`,
+			},
+			unexpectedParts: []string{
+				`(name, "name")`,
+				`"Expected name to be string`,
+			},
+		},
+		{
+			name: "@typical-ignore-returns skips only return validation",
+			input: `/** @typical-ignore-returns */
+export const greet = (name: string): string => {
+	return name;
+};`,
+			config: Config{ValidateParameters: true, ValidateReturns: true},
+			expectedParts: []string{
+				`"Expected name to be string`,
+			},
+			unexpectedParts: []string{
+				`"return value"`,
+			},
+		},
+		{
+			name: "@typical-ignore-casts skips casts in the function and its nested functions",
+			input: `interface User { name: string; }
+// @typical-ignore-casts
+function load(raw: unknown): User[] {
+	const first = raw as User;
+	return [first].map((u) => u as User);
+}
+function parse(raw: unknown): User {
+	return raw as User;
+}`,
+			config: Config{ValidateCasts: true},
+			expectedParts: []string{
+				`const first = raw as User;`,
+				`(raw, "raw")`,
+			},
+			unexpectedParts: []string{
+				`(u, "u")`,
+			},
+		},
+		{
+			name: "@typical-trust skips validation of the marked return expression",
+			input: `function getName(): string {
+	return /* @typical-trust */ "hello";
+}
+const getAge = (): number => /* @typical-trust */ 42;
+function getId(): string {
+	return "id";
+}`,
+			config: Config{ValidateReturns: true},
+			expectedParts: []string{
+				`return /* @typical-trust */ "hello";`,
+				`=> /* @typical-trust */ 42;`,
+				`"string" === typeof _v`,
+			},
+			unexpectedParts: []string{
+				`"number" === typeof _v`,
+			},
+		},
+		{
+			name: "@typical-trust skips validation of the marked argument",
+			input: `interface Job { id: number; onDone: () => void; }
+declare const port: { postMessage(message: unknown): void };
+function send(job: Job) { port.postMessage(/* @typical-trust */ job); }`,
+			config: Config{SerializableBoundaries: []string{"postMessage"}},
+			expectedParts: []string{
+				`port.postMessage(/* @typical-trust */ job);`,
+			},
+			unexpectedParts: []string{
+				`".onDone to be serialisable, got "`,
+			},
+		},
+		{
+			name: "rest parameter is validated element by element",
+			input: `interface Tag { name: string; }
+function tag(label: string, ...tags: Tag[]): void {}
+function pair(...args: [string, number]): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`_i0 < tags.length; _i0++) { const _e0: any = tags[_i0];`,
+				`Expected tags[" + _i0 + "].name to be string`,
+				`args.length === 2`, // A tuple rest parameter is still checked as a tuple
+			},
+			unexpectedParts: []string{
+				`Array.isArray(tags)`,
+			},
+		},
+		{
+			name: "default-valued parameter is only validated when passed",
+			input: `declare function makeLimit(): number;
+function page(limit: number = makeLimit()): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`if (limit !== undefined) {`,
+			},
+		},
+		{
+			name: "ValidateDefaults validates the value a default produced",
+			input: `declare function makeLimit(): number;
+interface Opts { size: number; }
+function page(limit: number = makeLimit(), { size }: Opts = { size: 10 }): void {}`,
+			config: Config{ValidateParameters: true, ValidateDefaults: true},
+			expectedParts: []string{
+				`"number" === typeof limit`,
+				`"number" === typeof size`, // Destructured bindings are validated after the default applies
+			},
+			unexpectedParts: []string{
+				`if (limit !== undefined)`,
+			},
+		},
+		{
+			name: "numeric property names use bracket access and symbol keys are skipped",
+			input: `declare const tag: unique symbol;
+interface Row { 0: string; "1": number; [tag]: string; label: string; }
+function render(row: Row): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`"string" === typeof row["0"]`,
+				`"number" === typeof row["1"]`,
+				`"string" === typeof row.label`,
+			},
+			unexpectedParts: []string{
+				`row.0`,
+				`@tag`,
+			},
+		},
+		{
+			name: "unique symbol brand is compile-time only",
+			input: `declare const brand: unique symbol;
+type Email = string & { [brand]: true };
+function send(to: Email): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`"string" === typeof to`,
+			},
+			unexpectedParts: []string{
+				`"object" === typeof to`,
+			},
+		},
+		{
+			name: "enum imported with import type is validated by its member values",
+			input: `export enum Status { Active = "active", Inactive = "inactive" }
+export enum Level { Low = 1, High = 2 }
+import type { Status as ImportedStatus, Level as ImportedLevel } from "./test";
+function update(status: ImportedStatus, level: ImportedLevel): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`"active" === status`,
+				`"inactive" === status`,
+				`1 === level`,
+				`2 === level`,
+			},
+			unexpectedParts: []string{
+				`ImportedStatus.`, // The enum object doesn't exist at runtime
+				`ImportedLevel.`,
+				`Object.values(`,
+			},
+		},
+		{
+			name: "enum errors name the enum and its members",
+			input: `enum Color { Red, Green, Blue }
+enum Status { Active = "active", Inactive = "inactive" }
+function paint(color: Color, status: Status): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`"Expected color to be Color (Red | Green | Blue), got "`,
+				`"Expected status to be Status (Active | Inactive), got "`,
+			},
+			unexpectedParts: []string{
+				`to be 0 | 1 | 2`,
+			},
+		},
+		{
+			name: "function declarations for hoisted check functions",
+			input: `interface User { name: string; }
+interface Team { lead: User; }
+function a(user: User, team: Team): void {}
+function b(user: User, team: Team): void {}`,
+			config: Config{ValidateParameters: true, FunctionDeclarations: true, SampleRate: 0.5},
+			expectedParts: []string{
+				`function _check_User(_v: any, _n: string): string | null { `,
+				`function _check_Team(_v: any, _n: string): string | null { `,
+				`_check_User(_v.lead, _n + ".lead")`,
+				`function _sampled_check_User(_v: any, _n: string): string | null { return Math.random() < 0.5 ? _check_User(_v, _n) : null; }`,
+			},
+			unexpectedParts: []string{
+				`const _check_`,
+				`const _sampled_check_`,
+			},
+		},
+		{
+			name:   "tuples with optional and rest elements",
+			input:  `function configure(opt: [string, number?], rest: [string, number?, ...boolean[]], both: [string, ...number[], boolean] | string): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`opt.length >= 1`,                            // Only the required element must be there
+				`opt.length <= 2`,                            // But no more than every element
+				`if (opt.length > 1) {`,                      // Optional elements are validated when present
+				`rest.length >= 1`,                           // Optional elements don't count towards the minimum
+				`for (let _i`,                                // Rest elements are validated in a loop
+				`both.length >= 2`,                           // Leading and trailing elements are required
+				`both.slice(1, -1).every((_e`,                // Checks cover the rest elements
+				`"boolean" === typeof both[both.length - 1]`, // And trailing elements from the end
+			},
+			unexpectedParts: []string{
+				`rest.length >= 2`,
+			},
+		},
+		{
+			name: "readonly set members are validated, including inside unions",
+			input: `interface User { name: string; }
+function members(users: ReadonlySet<User>, any: Set<unknown>, ids: Set<number> | string): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`users instanceof Set`, // ReadonlySet values are Sets at runtime
+				`for (const _e`,
+				`"string" === typeof _e`, // Each member is validated as a User
+				`any instanceof Set`,
+				`Array.from(ids).every((_e`, // Checks inside unions cover members too
+			},
+			unexpectedParts: []string{
+				`of any)`, // Nothing to check for Set<unknown> members
+			},
+		},
+		{
+			name: "map entries are validated against the key and value types",
+			input: `interface User { name: string; }
+function index(users: ReadonlyMap<string, User>, cache: Map<any, any>, scores: Map<string, number> | string): void {}`,
+			config: Config{ValidateParameters: true},
+			expectedParts: []string{
+				`users instanceof Map`, // ReadonlyMap values are Maps at runtime
+				`for (const [_mk`,
+				`Expected users key to be string, got`,
+				`"string" === typeof _mv`,
+				`cache instanceof Map`,
+				`Array.from(scores).every(([_mk`, // Checks inside unions cover entries too
+			},
+			unexpectedParts: []string{
+				`of cache)`, // Nothing to check for Map<any, any> entries
+			},
+		},
+		{
+			name:   "strict numbers reject NaN and the infinities",
+			input:  `function measure(n: number, exact: 5, either: number | string): void {}`,
+			config: Config{ValidateParameters: true, StrictNumbers: true},
+			expectedParts: []string{
+				`"number" === typeof n && Number.isFinite(n)`,
+				`"Expected n to be a finite number, got "+("number" === typeof n ? String(n) :`, // Shows NaN by value
+				`5 === exact`, // Literal types keep their exact-value check
+				`Number.isFinite(either)`,
+			},
+			unexpectedParts: []string{
+				`Number.isFinite(exact)`,
+			},
+		},
+		{
+			name:   "date values must be valid dates",
+			input:  `function schedule(at: Date, when: Date | string): void {}`,
+			config: Config{ValidateParameters: true, ValidateDateValues: true},
+			expectedParts: []string{
+				`at instanceof Date`,
+				`if (!(!isNaN(at.getTime()))) throw new TypeError("Expected at to be a valid Date, got "+"Invalid Date")`,
+				`(when instanceof Date && !isNaN(when.getTime()))`, // Checks inside unions too
+			},
+		},
 		{
 			name: "error message includes variable name",
 			input: `function greet(name: string): void {
@@ -176,44 +1612,680 @@ function greet(user: User): void {
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := transformTestCode(t, tt.input, tt.config)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := transformTestCode(t, tt.input, tt.config)
+
+			// Check expected parts
+			for _, part := range tt.expectedParts {
+				if !strings.Contains(result, part) {
+					t.Errorf("Expected output to contain %q\nGot:\n%s", part, result)
+				}
+			}
+
+			// Check unexpected parts
+			for _, part := range tt.unexpectedParts {
+				if strings.Contains(result, part) {
+					t.Errorf("Expected output NOT to contain %q\nGot:\n%s", part, result)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckErrorConstructor(t *testing.T) {
+	for _, name := range []string{"", "TypeError", "ValidationError", "_HttpError"} {
+		config := Config{ErrorConstructor: name}
+		if err := config.checkErrorConstructor(); err != nil {
+			t.Errorf("checkErrorConstructor(%q) = %v, want nil", name, err)
+		}
+	}
+	for _, name := range []string{"errors.Http", "new Foo", "Foo()", "1Error"} {
+		config := Config{ErrorConstructor: name}
+		if err := config.checkErrorConstructor(); err == nil {
+			t.Errorf("checkErrorConstructor(%q) = nil, want an error", name)
+		}
+	}
+}
+
+func TestDefaultConfig(t *testing.T) {
+	config := DefaultConfig()
+
+	if !config.ValidateParameters {
+		t.Error("Default config should have ValidateParameters = true")
+	}
+	if !config.ValidateReturns {
+		t.Error("Default config should have ValidateReturns = true")
+	}
+	if !config.ValidateCasts {
+		t.Error("Default config should have ValidateCasts = true")
+	}
+	if !config.TransformJSONParse {
+		t.Error("Default config should have TransformJSONParse = true")
+	}
+	if !config.TransformJSONStringify {
+		t.Error("Default config should have TransformJSONStringify = true")
+	}
+	if !config.IncludeSourcesContent {
+		t.Error("Default config should have IncludeSourcesContent = true")
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, ConfigFileName)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		return path
+	}
+
+	if FindConfigFile(dir) != "" {
+		t.Fatal("Expected no config file before one is written")
+	}
+	path := write(`{"validateReturns": false, "ignoreTypes": ["React.*"], "maxGeneratedFunctions": 10, "analysisConcurrency": 1, "coerce": {"stringToNumber": true}}`)
+	if FindConfigFile(dir) != path {
+		t.Fatalf("Expected to find %s", path)
+	}
+
+	config, err := LoadConfigFile(path)
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	if config.ValidateReturns || config.MaxGeneratedFunctions != 10 || config.AnalysisConcurrency != 1 || !config.Coerce.StringToNumber {
+		t.Errorf("Expected the file's options, got %+v", config)
+	}
+	if !config.ShouldIgnoreType("React.FC") || config.ShouldIgnoreType("User") {
+		t.Errorf("Expected ignoreTypes to match React.FC only, got %v", config.IgnoreTypes)
+	}
+	// Options left out keep their defaults
+	if !config.ValidateParameters || !config.IncludeSourcesContent || len(config.PureFunctions) != 2 {
+		t.Errorf("Expected defaults for options not given, got %+v", config)
+	}
+
+	for content, expected := range map[string]string{
+		`{"validateParams": true}`:          "unknown field",
+		`{"errorConstructor": "new Error"}`: "simple identifier",
+		`{"validateParameters": "yes"}`:     "validateParameters",
+	} {
+		if _, err := LoadConfigFile(write(content)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %s to fail with %q, got %v", content, expected, err)
+		}
+	}
+}
+
+func TestSourceMapSourcesContent(t *testing.T) {
+	input := `function greet(name: string): void {}`
+
+	insertions := []insertion{{pos: 36, text: " /* validated */", sourcePos: -1}}
+
+	for _, include := range []bool{true, false} {
+		_, sourceMap := buildSourceMap("/project/greet.ts", input, insertions, include)
+
+		if !include {
+			if sourceMap.SourcesContent != nil {
+				t.Errorf("expected no sourcesContent, got %d entries", len(sourceMap.SourcesContent))
+			}
+			continue
+		}
+		if len(sourceMap.SourcesContent) != 1 || sourceMap.SourcesContent[0] == nil {
+			t.Fatalf("expected one sourcesContent entry, got %v", sourceMap.SourcesContent)
+		}
+		if *sourceMap.SourcesContent[0] != input {
+			t.Errorf("expected sourcesContent to be the original source, got %q", *sourceMap.SourcesContent[0])
+		}
+	}
+}
+
+func TestEmitValidators(t *testing.T) {
+	input := `export interface User { name: string; age?: number; }
+export type Role = "admin" | "user";
+export class Account { id: string = ""; }
+export interface Page<T> { items: T[]; }
+interface Internal { secret: string; }
+export function greet(user: User): string { return user.name; }`
+
+	sourceFile, c, program, cleanup := setupTestProgram(t, input)
+	defer cleanup()
+
+	result, err := EmitValidators(sourceFile, c, program, DefaultConfig())
+	if err != nil {
+		t.Fatalf("EmitValidators failed: %v", err)
+	}
+
+	expectedParts := []string{
+		`import { type User, type Role, Account } from "./test";`,
+		`export const _check_User = (_v: any, _n: string): string | null =>`,
+		`export const _filter_User = `,
+		`export function isUser(value: unknown): value is User { return _check_User(value, "value") === null; }`,
+		`export const _check_Role = `,
+		`export function isRole(value: unknown): value is Role`,
+		`instanceof Account`,
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(result, part) {
+			t.Errorf("Expected output to contain %q\nGot:\n%s", part, result)
+		}
+	}
+
+	// Generic, unexported and non-type declarations are left out, as is the source itself
+	unexpectedParts := []string{"Page", "Internal", "greet", "return user.name"}
+	for _, part := range unexpectedParts {
+		if strings.Contains(result, part) {
+			t.Errorf("Expected output NOT to contain %q\nGot:\n%s", part, result)
+		}
+	}
+}
+
+func TestValidatorsHash(t *testing.T) {
+	hashOf := func(input string) string {
+		sourceFile, c, program, cleanup := setupTestProgram(t, input)
+		defer cleanup()
+		hash, err := ValidatorsHash(sourceFile, c, program, DefaultConfig())
+		if err != nil {
+			t.Fatalf("ValidatorsHash failed: %v", err)
+		}
+		return hash
+	}
+
+	input := `export interface User { name: string; age?: number; }`
+	sourceFile, c, program, cleanup := setupTestProgram(t, input)
+	defer cleanup()
+	result, err := EmitValidators(sourceFile, c, program, DefaultConfig())
+	if err != nil {
+		t.Fatalf("EmitValidators failed: %v", err)
+	}
+
+	hash := hashOf(input)
+	if !strings.Contains(result, "\n// typical-hash: "+hash+"\n") {
+		t.Errorf("Expected output to contain hash header %q\nGot:\n%s", hash, result)
+	}
+
+	// Comments don't change the validators, the structure of the types does
+	if got := hashOf("// Users of the app\n" + input); got != hash {
+		t.Errorf("Expected comment-only change to keep hash %s, got %s", hash, got)
+	}
+	if got := hashOf(`export interface User { name: string; age: number; }`); got == hash {
+		t.Errorf("Expected structural change to change hash %s", hash)
+	}
+}
+
+func TestSidecarFileName(t *testing.T) {
+	tests := map[string]string{
+		"/src/user.ts":  "/src/user.typical.ts",
+		"/src/user.tsx": "/src/user.typical.ts",
+		"/src/user.mts": "/src/user.typical.mts",
+		"/src/user.cts": "/src/user.typical.cts",
+	}
+	for fileName, expected := range tests {
+		if got := SidecarFileName(fileName); got != expected {
+			t.Errorf("SidecarFileName(%q) = %q, expected %q", fileName, got, expected)
+		}
+	}
+}
+
+func TestTransformFileWithSidecar(t *testing.T) {
+	transformWithSidecar := func(input string, config Config) (string, *Sidecar) {
+		sourceFile, c, program, cleanup := setupTestProgram(t, input)
+		defer cleanup()
+		code, _, sidecar, err := TransformFileWithSidecar(sourceFile, c, program, config)
+		if err != nil {
+			t.Fatalf("TransformFileWithSidecar failed: %v", err)
+		}
+		return code, sidecar
+	}
+
+	config := DefaultConfig()
+	config.ExportValidators = true
+	config.SidecarValidators = true
+
+	input := `export interface User { name: string; }
+export function greet(user: User): string { return user.name; }`
+	code, sidecar := transformWithSidecar(input, config)
+	if sidecar == nil {
+		t.Fatalf("Expected a sidecar\nGot:\n%s", code)
+	}
+	if !strings.HasSuffix(sidecar.FileName, "test.typical.ts") {
+		t.Errorf("Expected sidecar next to test.ts, got %s", sidecar.FileName)
+	}
+	for _, part := range []string{
+		`import type { User } from "./test";`,
+		`export const __typical_check_User = `,
+		`export function isUser(value: unknown): value is User`,
+	} {
+		if !strings.Contains(sidecar.Code, part) {
+			t.Errorf("Expected sidecar to contain %q\nGot:\n%s", part, sidecar.Code)
+		}
+	}
+	for _, part := range []string{`from "./test.typical";`, `export { __typical_check_User, isUser, assertUser };`} {
+		if !strings.Contains(code, part) {
+			t.Errorf("Expected output to contain %q\nGot:\n%s", part, code)
+		}
+	}
+	if strings.Contains(code, "const __typical_check_User = ") {
+		t.Errorf("Expected check function to move to the sidecar\nGot:\n%s", code)
+	}
+
+	// Validators using something the file doesn't export stay inline
+	code, sidecar = transformWithSidecar(`class Secret { key = ""; }
+export interface Vault { secret: Secret; }
+export function open(vault: Vault): string { return vault.secret.key; }`, config)
+	if sidecar != nil {
+		t.Errorf("Expected no sidecar, got:\n%s", sidecar.Code)
+	}
+	if !strings.Contains(code, "const __typical_check_Vault = ") {
+		t.Errorf("Expected check function to stay inline\nGot:\n%s", code)
+	}
+
+	// The other entry points always hoist inline
+	sourceFile, c, program, cleanup := setupTestProgram(t, input)
+	defer cleanup()
+	code, _, err := TransformFileWithSourceMapAndError(sourceFile, c, program, config)
+	if err != nil {
+		t.Fatalf("TransformFileWithSourceMapAndError failed: %v", err)
+	}
+	if strings.Contains(code, "test.typical") {
+		t.Errorf("Expected no sidecar import\nGot:\n%s", code)
+	}
+}
+
+func TestIgnoreFileDirective(t *testing.T) {
+	input := `// Legacy module, not migrated yet
+// @typical-ignore-file
+interface User { name: string; }
+export function greet(user: User): string {
+	return JSON.parse(user.name) as string;
+}`
+	sourceFile, c, program, cleanup := setupTestProgram(t, input)
+	defer cleanup()
+	code := TransformFileWithConfig(sourceFile, c, program, Config{ValidateParameters: true, ValidateReturns: true, ValidateCasts: true, TransformJSONParse: true})
+	if code != input {
+		t.Errorf("expected the file unchanged, got:\n%s", code)
+	}
+
+	tests := map[string]bool{
+		"/* @typical-ignore-file */\nconst a = 1;":                       true,
+		"/**\n * @typical-ignore-file\n */\nconst a = 1;":                true,
+		"const note = \"@typical-ignore-file\";":                         false,
+		"// @typical-ignore-file-later\nconst a = 1;":                    false,
+		strings.Repeat("const a = 1;\n", 10) + "// @typical-ignore-file": false,
+	}
+	for text, expected := range tests {
+		if got := hasIgnoreFileDirective(text); got != expected {
+			t.Errorf("hasIgnoreFileDirective(%q) = %v, expected %v", text, got, expected)
+		}
+	}
+}
+
+func TestSampledWrapperOrder(t *testing.T) {
+	input := `interface Zebra { stripes: number; }
+interface Apple { seeds: number; }
+interface Mango { ripe: boolean; }
+function a(z: Zebra, x: Apple, m: Mango): void {}
+function b(z: Zebra, x: Apple, m: Mango): void {}`
+	config := Config{ValidateParameters: true, SampleRate: 0.5}
 
-			// Check expected parts
-			for _, part := range tt.expectedParts {
-				if !strings.Contains(result, part) {
-					t.Errorf("Expected output to contain %q\nGot:\n%s", part, result)
-				}
+	// The hoisted wrappers come from a map, but the output mustn't change between builds
+	first := transformTestCode(t, input, config)
+	for range 5 {
+		if code := transformTestCode(t, input, config); code != first {
+			t.Fatalf("Expected the same output on every build\nFirst:\n%s\nLater:\n%s", first, code)
+		}
+	}
+	apple := strings.Index(first, "const _sampled_check_Apple =")
+	mango := strings.Index(first, "const _sampled_check_Mango =")
+	zebra := strings.Index(first, "const _sampled_check_Zebra =")
+	if apple < 0 || !(apple < mango && mango < zebra) {
+		t.Errorf("Expected the sampling wrappers in name order\nGot:\n%s", first)
+	}
+}
+
+// checkFunctionCacheProject is a project where every file validates the same imported type.
+func checkFunctionCacheProject(files int) map[string]string {
+	project := map[string]string{
+		"user.ts": `export interface Address { street: string; city: string; postcode: string; }
+export interface User { id: number; name: string; email: string; tags: string[]; address: Address; roles: ("admin" | "user")[]; }`,
+	}
+	for i := range files {
+		project[fmt.Sprintf("file%d.ts", i)] = `import type { User } from "./user";
+export function save(user: User): void {}
+export function update(user: User): void {}`
+	}
+	return project
+}
+
+// transformProject transforms every fileN.ts with one project analysis, as the server does,
+// and returns the output by file name.
+func transformProject(tb testing.TB, c *checker.Checker, program *compiler.Program, cache bool) (map[string]string, *analyse.ProjectAnalysis) {
+	config := DefaultConfig()
+	config.ProjectAnalysis = analyse.AnalyseProject(program, c, analyse.Config{
+		ValidateParameters: config.ValidateParameters,
+		ValidateReturns:    config.ValidateReturns,
+		ValidateCasts:      config.ValidateCasts,
+	})
+	if !cache {
+		config.ProjectAnalysis.CheckFunctions = nil
+	}
+	outputs := make(map[string]string)
+	for _, sourceFile := range program.GetSourceFiles() {
+		name := filepath.Base(sourceFile.FileName())
+		if !strings.HasPrefix(name, "file") {
+			continue
+		}
+		code, _, err := TransformFileWithSourceMapAndError(sourceFile, c, program, config)
+		if err != nil {
+			tb.Fatalf("Transform of %s failed: %v", name, err)
+		}
+		outputs[name] = code
+	}
+	return outputs, config.ProjectAnalysis
+}
+
+func TestCheckFunctionCache(t *testing.T) {
+	c, program, cleanup := setupTestProject(t, checkFunctionCacheProject(3))
+	defer cleanup()
+
+	cached, analysis := transformProject(t, c, program, true)
+	uncached, _ := transformProject(t, c, program, false)
+	if len(cached) != 3 {
+		t.Fatalf("Expected 3 transformed files, got %d", len(cached))
+	}
+	for name, code := range cached {
+		if code != uncached[name] {
+			t.Errorf("Expected cached output of %s to match uncached\nCached:\n%s\nUncached:\n%s", name, code, uncached[name])
+		}
+	}
+	if hits, _ := analysis.CheckFunctions.Stats(); hits < 2 {
+		t.Errorf("Expected the second and third files to reuse User's check function, got %d hits", hits)
+	}
+
+	// A different program starts with an empty cache
+	analysis.CheckFunctions.Put(program, "User", analyse.CachedCheckFunction{Name: "_check_User"})
+	if _, ok := analysis.CheckFunctions.Get(nil, "User"); ok {
+		t.Error("Expected nothing cached for another program")
+	}
+}
+
+func TestCheckFunctionCacheSameNamedTypes(t *testing.T) {
+	c, program, cleanup := setupTestProject(t, map[string]string{
+		"file0.ts": `interface User { name: string; }
+export function save(user: User | null): void {}
+export function update(user: User | null): void {}`,
+		"file1.ts": `interface User { id: number; }
+export function save(user: User | null): void {}
+export function update(user: User | null): void {}`,
+	})
+	defer cleanup()
+
+	cached, _ := transformProject(t, c, program, true)
+	if !strings.Contains(cached["file0.ts"], ".name") {
+		t.Errorf("Expected file0.ts to check User.name\nGot:\n%s", cached["file0.ts"])
+	}
+	if !strings.Contains(cached["file1.ts"], ".id") || strings.Contains(cached["file1.ts"], ".name") {
+		t.Errorf("Expected file1.ts to check its own User's id, not file0.ts's name\nGot:\n%s", cached["file1.ts"])
+	}
+}
+
+// BenchmarkCheckFunctionCache transforms a project of 50 files all validating the same
+// imported User type, with and without the project's check function cache.
+func BenchmarkCheckFunctionCache(b *testing.B) {
+	c, program, cleanup := setupTestProject(b, checkFunctionCacheProject(50))
+	defer cleanup()
+
+	for _, cache := range []bool{false, true} {
+		b.Run(fmt.Sprintf("cache=%t", cache), func(b *testing.B) {
+			for b.Loop() {
+				transformProject(b, c, program, cache)
 			}
+		})
+	}
+}
 
-			// Check unexpected parts
-			for _, part := range tt.unexpectedParts {
-				if strings.Contains(result, part) {
-					t.Errorf("Expected output NOT to contain %q\nGot:\n%s", part, result)
-				}
+// analyseProjectFiles is a synthetic project of n files, each with an internal and an
+// exported function, the exported one calling into the previous file.
+func analyseProjectFiles(n int) map[string]string {
+	files := map[string]string{
+		"user.ts": `export interface User { id: number; name: string; tags: string[]; }`,
+	}
+	for i := range n {
+		imports, call := "", ""
+		if i > 0 {
+			imports = fmt.Sprintf("import { handle%d } from \"./file%d\";\n", i-1, i-1)
+			call = fmt.Sprintf("handle%d(user); ", i-1)
+		}
+		files[fmt.Sprintf("file%d.ts", i)] = fmt.Sprintf(`import type { User } from "./user";
+%sfunction normalise%[3]d(user: User): User { const copy = JSON.parse(JSON.stringify(user)) as User; return copy; }
+export function handle%[3]d(user: User): string { %[2]sconst clean = normalise%[3]d(user); return clean.name; }
+`, imports, call, i)
+	}
+	return files
+}
+
+func TestAnalyseProjectConcurrency(t *testing.T) {
+	c, program, cleanup := setupTestProject(t, analyseProjectFiles(20))
+	defer cleanup()
+
+	analyseWith := func(concurrency int) *analyse.ProjectAnalysis {
+		return analyse.AnalyseProject(program, c, analyse.Config{
+			ValidateParameters: true,
+			ValidateReturns:    true,
+			ValidateCasts:      true,
+			Concurrency:        concurrency,
+		})
+	}
+	sequential := analyseWith(1)
+	concurrent := analyseWith(8)
+
+	if len(sequential.CallGraph) != 40 {
+		t.Fatalf("Expected 40 functions, got %d", len(sequential.CallGraph))
+	}
+	if len(concurrent.CallGraph) != len(sequential.CallGraph) {
+		t.Fatalf("Expected %d functions analysed concurrently, got %d", len(sequential.CallGraph), len(concurrent.CallGraph))
+	}
+	for key, want := range sequential.CallGraph {
+		got := concurrent.CallGraph[key]
+		if got == nil {
+			t.Errorf("Expected %s to be analysed concurrently", key)
+			continue
+		}
+		if got.IsExported != want.IsExported || len(got.CallSites) != len(want.CallSites) {
+			t.Errorf("Expected %s to match: exported %v/%v, call sites %d/%d", key, got.IsExported, want.IsExported, len(got.CallSites), len(want.CallSites))
+		}
+		for name, v := range want.ValidatedVariables {
+			if g := got.ValidatedVariables[name]; g == nil || g.Source != v.Source || g.Position != v.Position {
+				t.Errorf("Expected %s in %s to be validated by %s, got %+v", name, key, v.Source, g)
+			}
+		}
+		if len(got.ValidatedVariables) != len(want.ValidatedVariables) {
+			t.Errorf("Expected %d validated variables in %s, got %d", len(want.ValidatedVariables), key, len(got.ValidatedVariables))
+		}
+	}
+}
+
+func TestAnalyseProjectIncremental(t *testing.T) {
+	c, program, cleanup := setupTestProject(t, analyseProjectFiles(10))
+	defer cleanup()
+
+	config := analyse.Config{ValidateParameters: true, ValidateReturns: true, ValidateCasts: true}
+	full := analyse.AnalyseProject(program, c, config)
+
+	var changed string
+	for fileName := range full.Files {
+		if strings.HasSuffix(fileName, "/file5.ts") {
+			changed = fileName
+		}
+	}
+	incremental := analyse.AnalyseProjectIncremental(program, c, config, full, []string{changed})
+
+	// file5 and the files importing from it, directly or not, are re-collected
+	for fileName, fileAnalysis := range incremental.Files {
+		var n int
+		if _, err := fmt.Sscanf(filepath.Base(fileName), "file%d.ts", &n); err != nil {
+			continue
+		}
+		expected := int32(0)
+		if n >= 5 {
+			expected = 1
+		}
+		if fileAnalysis.Version != expected {
+			t.Errorf("Expected %s to be at version %d, got %d", filepath.Base(fileName), expected, fileAnalysis.Version)
+		}
+	}
+
+	// Reused or not, every function is analysed the same as from scratch
+	if len(incremental.CallGraph) != len(full.CallGraph) {
+		t.Fatalf("Expected %d functions, got %d", len(full.CallGraph), len(incremental.CallGraph))
+	}
+	for key, want := range full.CallGraph {
+		got := incremental.CallGraph[key]
+		if got == nil {
+			t.Errorf("Expected %s to be analysed incrementally", key)
+			continue
+		}
+		if got == want {
+			t.Errorf("Expected %s to be analysed again, not shared with the previous analysis", key)
+		}
+		if !slices.Equal(got.MutatesParams, want.MutatesParams) || !slices.Equal(got.CanSkipParamValidation, want.CanSkipParamValidation) || len(got.CallSites) != len(want.CallSites) {
+			t.Errorf("Expected %s to match the full analysis", key)
+		}
+	}
+}
+
+// BenchmarkAnalyseProject analyses a synthetic 500-file project sequentially and with one
+// worker per CPU.
+func BenchmarkAnalyseProject(b *testing.B) {
+	c, program, cleanup := setupTestProject(b, analyseProjectFiles(500))
+	defer cleanup()
+
+	for _, concurrency := range []int{1, 0} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			for b.Loop() {
+				analyse.AnalyseProject(program, c, analyse.Config{
+					ValidateParameters: true,
+					ValidateReturns:    true,
+					ValidateCasts:      true,
+					Concurrency:        concurrency,
+				})
 			}
 		})
 	}
 }
 
-func TestDefaultConfig(t *testing.T) {
-	config := DefaultConfig()
+func TestNoUncheckedIndexedAccess(t *testing.T) {
+	input := `interface Item { id: number; }
+export function first(items: Item[], i: number): number {
+	const item: Item = items[i];
+	const maybe: Item | undefined = items[i + 1];
+	return item.id + (maybe?.id ?? 0);
+}`
 
-	if !config.ValidateParameters {
-		t.Error("Default config should have ValidateParameters = true")
+	transformWith := func(extraOptions string) string {
+		sourceFile, c, program, cleanup := setupTestProgramWithOptions(t, input, extraOptions)
+		defer cleanup()
+		return TransformFileWithConfig(sourceFile, c, program, Config{})
 	}
-	if !config.ValidateReturns {
-		t.Error("Default config should have ValidateReturns = true")
+
+	result := transformWith(`"noUncheckedIndexedAccess": true`)
+	expectedParts := []string{
+		`"number" === typeof`,
+		`items[i], "items[i]")`,
+		`const maybe: Item | undefined = items[i + 1];`,
 	}
-	if !config.ValidateCasts {
-		t.Error("Default config should have ValidateCasts = true")
+	for _, part := range expectedParts {
+		if !strings.Contains(result, part) {
+			t.Errorf("Expected output to contain %q\nGot:\n%s", part, result)
+		}
 	}
-	if !config.TransformJSONParse {
-		t.Error("Default config should have TransformJSONParse = true")
+
+	// Without the option the element type is just Item, so there's nothing to check
+	result = transformWith("")
+	if !strings.Contains(result, "const item: Item = items[i];") {
+		t.Errorf("Expected indexed access to be left alone without noUncheckedIndexedAccess\nGot:\n%s", result)
 	}
-	if !config.TransformJSONStringify {
-		t.Error("Default config should have TransformJSONStringify = true")
+}
+
+func TestAnalyseParameterReassignment(t *testing.T) {
+	input := `interface User { name: string; }
+declare function save(user: User): void;
+export function update(user: User, body: any, other: User): void {
+	user = body;
+	save(user);
+	other = { name: "x" };
+	save(other);
+}`
+
+	sourceFile, c, program, cleanup := setupTestProgram(t, input)
+	defer cleanup()
+
+	result := analyse.AnalyseFile(sourceFile, c, program, analyse.Config{ValidateParameters: true})
+
+	var reassigned []analyse.ValidationItem
+	for _, item := range result.Items {
+		if item.Kind == "parameter-reassignment" {
+			reassigned = append(reassigned, item)
+		}
+	}
+
+	// Only the reassignment from an any-typed value is flagged
+	if len(reassigned) != 1 {
+		t.Fatalf("expected 1 parameter-reassignment item, got %d: %+v", len(reassigned), reassigned)
+	}
+	item := reassigned[0]
+	if item.Name != "user" || item.Status != "skipped" || item.TypeString != "User" || item.StartLine != 4 {
+		t.Errorf("unexpected item: %+v", item)
+	}
+	if item.SkipReason != analyse.SkipReasonParameterReassigned {
+		t.Errorf("expected skip reason %q, got %q", analyse.SkipReasonParameterReassigned, item.SkipReason)
+	}
+}
+
+func TestAnalyseAnyBoundaries(t *testing.T) {
+	input := `export function handle(body: any): any {
+	return internal(body);
+}
+function internal(x: any): any {
+	return x;
+}`
+
+	sourceFile, c, program, cleanup := setupTestProgram(t, input)
+	defer cleanup()
+
+	result := analyse.AnalyseFile(sourceFile, c, program, analyse.Config{ValidateParameters: true, ValidateReturns: true})
+
+	boundaries := map[string]bool{}
+	for _, item := range result.Items {
+		if item.IsAnyBoundary() {
+			boundaries[item.Kind+":"+item.Name] = true
+		}
+	}
+
+	// Only the exported function's any-typed parameter and return type are boundaries
+	if len(boundaries) != 2 || !boundaries["parameter:body"] || !boundaries["return-type:return type"] {
+		t.Errorf("expected body parameter and handle return type as any boundaries, got %v", boundaries)
+	}
+}
+
+func TestIsExcludedFile(t *testing.T) {
+	config := Config{ExcludePatterns: []string{"**/*.test.ts", "test/**", "fixtures/mock?.ts"}}
+
+	tests := []struct {
+		fileName string
+		excluded bool
+	}{
+		{"/project/src/user.test.ts", true},
+		{"/project/test/helpers.ts", true},
+		{"/project/test/deep/nested/helpers.ts", true},
+		{"/project/fixtures/mock1.ts", true},
+		{"/project/src/user.ts", false},
+		{"/project/src/contest/user.ts", false},
+		{"/project/fixtures/mock12.ts", false},
+	}
+
+	for _, tt := range tests {
+		if got := config.IsExcludedFile(tt.fileName); got != tt.excluded {
+			t.Errorf("IsExcludedFile(%q) = %v, want %v", tt.fileName, got, tt.excluded)
+		}
 	}
 }
 
@@ -228,6 +2300,7 @@ func TestSkipRedundantValidation(t *testing.T) {
 	tests := []struct {
 		name            string
 		input           string
+		projectAnalysis bool // Run project analysis first, as the server does
 		expectedParts   []string
 		unexpectedParts []string
 	}{
@@ -257,6 +2330,23 @@ func TestSkipRedundantValidation(t *testing.T) {
 				`"return value"`,
 			},
 		},
+		{
+			name: "skip return - narrowed by in operator",
+			input: `interface Cat { meow: string; }
+interface Dog { bark: string; }
+function pickCat(pet: Cat | Dog): Cat {
+	if ("meow" in pet) {
+		return pet;
+	}
+	throw new Error("not a cat");
+}`,
+			expectedParts: []string{
+				`/* already valid */ pet`, // Skip - pet is a validated Cat | Dog narrowed to Cat
+			},
+			unexpectedParts: []string{
+				`"return value"`,
+			},
+		},
 		{
 			name: "must validate - supertype to subtype",
 			input: `function toSubtype(x: string | null): string {
@@ -410,11 +2500,88 @@ function ignored(x: string): string {
 				`"return value"`,
 			},
 		},
+		{
+			name: "unannotated inline callback result validated at call site",
+			input: `interface User { name: string; }
+declare function fetchUser(): User;
+function withRetry<T>(fn: () => T): T { return fn(); }
+function load(): string {
+	const user = withRetry(() => fetchUser());
+	return user.name;
+}`,
+			projectAnalysis: true,
+			expectedParts: []string{
+				`(user, "user")`,
+			},
+		},
+		{
+			name: "annotated inline callback validates its own return",
+			input: `interface User { name: string; }
+declare function fetchUser(): User;
+function withRetry<T>(fn: () => T): T { return fn(); }
+function load(): string {
+	const user = withRetry((): User => fetchUser());
+	return user.name;
+}`,
+			projectAnalysis: true,
+			unexpectedParts: []string{
+				`(user, "user")`,
+			},
+		},
+		{
+			name: "cast to a broader type re-validates",
+			input: `interface User { name: string; age: number; }
+function load(raw: unknown): User {
+	const a = raw as Partial<User>;
+	const b = a as User;
+	return b;
+}`,
+			projectAnalysis: true,
+			expectedParts: []string{
+				`(raw, "raw")`, // First cast validates raw against Partial<User>
+				`(a, "a")`,     // Second cast validates a against User
+			},
+			unexpectedParts: []string{
+				`/* already valid */ a as User`,
+			},
+		},
+		{
+			name: "cast to the same type skipped",
+			input: `interface User { name: string; age: number; }
+function load(raw: unknown): User {
+	const a = raw as User;
+	const b = a as User;
+	return b;
+}`,
+			projectAnalysis: true,
+			expectedParts: []string{
+				`(raw, "raw")`,
+				`/* already valid */ a as User`,
+			},
+			unexpectedParts: []string{
+				`(a, "a")`, // No second check
+			},
+		},
+		{
+			name: "return cast of partially validated variable validates",
+			input: `interface User { name: string; age: number; }
+function load(raw: unknown): User {
+	const a = raw as Partial<User>;
+	return a as User;
+}`,
+			projectAnalysis: true,
+			expectedParts: []string{
+				`(a, "a")`,
+			},
+			unexpectedParts: []string{
+				`/* already valid */ a as User`,
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := transformTestCode(t, tt.input, config)
+			result := transformTestCodeWithAnalysis(t, tt.input, config, tt.projectAnalysis)
 
 			for _, part := range tt.expectedParts {
 				if !strings.Contains(result, part) {
@@ -431,6 +2598,67 @@ function ignored(x: string): string {
 	}
 }
 
+// TestOverloadedCallResults checks that results of calls to overloaded external functions are
+// validated against the return type of the overload the arguments select.
+func TestOverloadedCallResults(t *testing.T) {
+	config := Config{
+		ValidateParameters: true,
+		ValidateReturns:    true,
+	}
+
+	input := `interface User { name: string; }
+interface Team { members: User[]; }
+declare function fetchEntity(kind: "user", id: string): User;
+declare function fetchEntity(kind: "team", id: string): Team;
+declare function fetchEntity(kind: string, id: string): unknown;
+function load(): string {
+	const user = fetchEntity("user", "1");
+	const team = fetchEntity("team", "2");
+	return user.name + team.members.length;
+}`
+
+	result := transformTestCodeWithAnalysis(t, input, config, true)
+
+	for _, part := range []string{
+		`_check_User(user, "user")`,
+		`_check_Team(team, "team")`,
+	} {
+		if !strings.Contains(result, part) {
+			t.Errorf("Expected output to contain %q\nGot:\n%s", part, result)
+		}
+	}
+	for _, part := range []string{
+		`_check_Team(user, "user")`,
+		`_check_User(team, "team")`,
+	} {
+		if strings.Contains(result, part) {
+			t.Errorf("Expected output NOT to contain %q\nGot:\n%s", part, result)
+		}
+	}
+}
+
+// TestArrayFindAssertion checks that `list.find(...)!` on an untrusted array is validated
+// against the element type the assertion narrows it to.
+func TestArrayFindAssertion(t *testing.T) {
+	config := Config{
+		ValidateParameters: true,
+		ValidateReturns:    true,
+	}
+
+	input := `interface User { id: string; name: string; }
+declare const cache: { users: User[] };
+function nameOf(id: string): string {
+	const user = cache.users.find((u) => u.id === id)!;
+	return user.name;
+}`
+
+	result := transformTestCodeWithAnalysis(t, input, config, true)
+
+	if part := `)!; if ((_e = _check_User(user, "user")) !== null)`; !strings.Contains(result, part) {
+		t.Errorf("Expected output to contain %q\nGot:\n%s", part, result)
+	}
+}
+
 func TestJSONTransformations(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -483,6 +2711,70 @@ const user = JSON.parse<User>(jsonStr);`,
 				`const _r`, // No filtering
 			},
 		},
+		{
+			name: "JSON.parse with coercion",
+			input: `interface Query { name: string; page: number; draft?: boolean; }
+const q = JSON.parse<Query>(jsonStr);`,
+			config: Config{TransformJSONParse: true, Coerce: codegen.Coercion{StringToNumber: true, StringToBoolean: true}},
+			expectedParts: []string{
+				`_r.name = _v.name`,                      // Strings are never coerced
+				`!isNaN(+_v.page) ? +_v.page : _v.page)`, // Numeric strings become numbers
+				`"true" === _v.draft ? true : "false" === _v.draft ? false : _v.draft`,
+				`_r.page = _c`, // Filtered result holds the coerced value
+			},
+			unexpectedParts: []string{
+				`_r.page = _v.page`,
+			},
+		},
+		{
+			name: "JSON.parse into Record filters each value",
+			input: `interface User { name: string; }
+const users = JSON.parse<Record<string, User>>(jsonStr);`,
+			config: Config{TransformJSONParse: true},
+			expectedParts: []string{
+				`for (const _k0 in _v) {`,   // Iterates dynamic keys
+				`const _v0: any = _v[_k0];`, // Reads each value
+				`const _t1: any = {};`,      // Rebuilds each User
+				`_t1.name = _v0.name;`,      // Only User's properties are kept
+				`_r[_k0] = _t1;`,            // Filtered value is stored under the same key
+			},
+		},
+		{
+			name: "JSON.parse into Record<number, T> requires numeric keys",
+			input: `interface Item { name: string; }
+const items = JSON.parse<Record<number, Item>>(jsonStr);`,
+			config: Config{TransformJSONParse: true},
+			expectedParts: []string{
+				`for (const _k0 in _v) {`,
+				`if (String(+_k0) !== _k0) throw new TypeError(`, // JSON keys are always strings
+				`] to be numeric key, got "+"'" + _k0 + "'"`,
+				`_t1.name = _v0.name;`,
+				`_r[_k0] = _t1;`,
+			},
+		},
+		{
+			name: "JSON.parse into Record<number, T> with numeric key coercion",
+			input: `interface Item { name: string; }
+const items = JSON.parse<Record<number, Item>>(jsonStr);`,
+			config: Config{TransformJSONParse: true, Coerce: codegen.Coercion{NumericKeys: true}},
+			expectedParts: []string{
+				`const _nk0 = _k0.trim() !== "" && !isNaN(+_k0) ? String(+_k0) : _k0;`, // "01" -> "1"
+				`if (String(+_nk0) !== _nk0) throw`,
+				`_r[_nk0] = _t1;`, // Stored under the normalised key
+			},
+		},
+		{
+			name: "JSON.parse without coercion keeps raw values",
+			input: `interface Query { page: number; }
+const q = JSON.parse<Query>(jsonStr);`,
+			config: Config{TransformJSONParse: true},
+			expectedParts: []string{
+				`_r.page = _v.page`,
+			},
+			unexpectedParts: []string{
+				`isNaN(`,
+			},
+		},
 		{
 			name: "JSON.stringify disabled",
 			input: `interface User { name: string; }
@@ -800,31 +3092,66 @@ function logCompany2(company: Company): void {}`,
 // transformTestCode is a helper that sets up a TypeScript project and transforms the code
 func transformTestCode(t *testing.T, input string, config Config) string {
 	t.Helper()
+	return transformTestCodeWithAnalysis(t, input, config, false)
+}
+
+// transformTestCodeWithAnalysis is transformTestCode, optionally running project analysis
+// first as the server does, so cross-statement validation tracking is exercised.
+func transformTestCodeWithAnalysis(t *testing.T, input string, config Config, withProjectAnalysis bool) string {
+	t.Helper()
+
+	sourceFile, c, program, cleanup := setupTestProgram(t, input)
+	defer cleanup()
+
+	if withProjectAnalysis {
+		config.ProjectAnalysis = analyse.AnalyseProject(program, c, analyse.Config{
+			ValidateParameters:     config.ValidateParameters,
+			ValidateReturns:        config.ValidateReturns,
+			ValidateCasts:          config.ValidateCasts,
+			TransformJSONParse:     config.TransformJSONParse,
+			TransformJSONStringify: config.TransformJSONStringify,
+			PureFunctions:          config.PureFunctions,
+		})
+	}
+
+	// Transform the file
+	return TransformFileWithConfig(sourceFile, c, program, config)
+}
+
+// setupTestProgram writes input to test.ts in a temporary project and returns its source file,
+// type checker and program. cleanup releases the checker and removes the project.
+func setupTestProgram(t *testing.T, input string) (*ast.SourceFile, *checker.Checker, *compiler.Program, func()) {
+	t.Helper()
+	return setupTestProgramWithOptions(t, input, "")
+}
+
+// setupTestProgramWithOptions is setupTestProgram with extra compilerOptions entries,
+// e.g. `"noUncheckedIndexedAccess": true`.
+func setupTestProgramWithOptions(t *testing.T, input string, extraOptions string) (*ast.SourceFile, *checker.Checker, *compiler.Program, func()) {
+	t.Helper()
 
 	// Create a temporary directory for test files
 	tmpDir, err := os.MkdirTemp("", "transform-test-*")
 	if err != nil {
 		t.Fatalf("Failed to create temp dir: %v", err)
 	}
-	defer os.RemoveAll(tmpDir)
 
 	// Write the test file
 	testFile := filepath.Join(tmpDir, "test.ts")
 	if err := os.WriteFile(testFile, []byte(input), 0644); err != nil {
+		os.RemoveAll(tmpDir)
 		t.Fatalf("Failed to write test file: %v", err)
 	}
 
 	// Write tsconfig.json
-	tsconfig := `{
-		"compilerOptions": {
-			"target": "ES2020",
-			"module": "ESNext",
-			"strict": true
-		},
-		"include": ["test.ts"]
-	}`
+	compilerOptions := `"target": "ES2020", "module": "ESNext", "strict": true`
+	if extraOptions != "" {
+		compilerOptions += ", " + extraOptions
+	}
+	tsconfig := `{"compilerOptions": {` + compilerOptions + `}, "include": ["test.ts"]}`
 	tsconfigFile := filepath.Join(tmpDir, "tsconfig.json")
 	if err := os.WriteFile(tsconfigFile, []byte(tsconfig), 0644); err != nil {
+		os.RemoveAll(tmpDir)
 		t.Fatalf("Failed to write tsconfig: %v", err)
 	}
 
@@ -841,6 +3168,7 @@ func transformTestCode(t *testing.T, input string, config Config) string {
 	})
 	proj, _, releaseSnap, err := session.APIOpenProject(ctx, tsconfigFile, project.FileChangeSummary{})
 	if err != nil {
+		os.RemoveAll(tmpDir)
 		t.Fatalf("Failed to open project: %v", err)
 	}
 	releaseSnap()
@@ -848,13 +3176,60 @@ func transformTestCode(t *testing.T, input string, config Config) string {
 	program := proj.GetProgram()
 	sourceFile := program.GetSourceFile(testFile)
 	if sourceFile == nil {
+		os.RemoveAll(tmpDir)
 		t.Fatal("Could not find test.ts source file")
 	}
 
 	// Get type checker
 	c, release := program.GetTypeChecker(ctx)
-	defer release()
+	return sourceFile, c, program, func() {
+		release()
+		os.RemoveAll(tmpDir)
+	}
+}
 
-	// Transform the file
-	return TransformFileWithConfig(sourceFile, c, program, config)
+// setupTestProject writes files (name -> source) to a temporary project and returns its
+// type checker and program. cleanup releases the checker and removes the project.
+func setupTestProject(tb testing.TB, files map[string]string) (*checker.Checker, *compiler.Program, func()) {
+	tb.Helper()
+
+	tmpDir, err := os.MkdirTemp("", "transform-project-*")
+	if err != nil {
+		tb.Fatalf("Failed to create temp dir: %v", err)
+	}
+	for name, source := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(source), 0644); err != nil {
+			os.RemoveAll(tmpDir)
+			tb.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	tsconfig := `{"compilerOptions": {"target": "ES2020", "module": "ESNext", "strict": true}, "include": ["*.ts"]}`
+	tsconfigFile := filepath.Join(tmpDir, "tsconfig.json")
+	if err := os.WriteFile(tsconfigFile, []byte(tsconfig), 0644); err != nil {
+		os.RemoveAll(tmpDir)
+		tb.Fatalf("Failed to write tsconfig: %v", err)
+	}
+
+	ctx := context.Background()
+	session := project.NewSession(&project.SessionInit{
+		BackgroundCtx: ctx,
+		FS:            bundled.WrapFS(osvfs.FS()),
+		Options: &project.SessionOptions{
+			CurrentDirectory:   tmpDir,
+			DefaultLibraryPath: bundled.LibPath(),
+		},
+	})
+	proj, _, releaseSnap, err := session.APIOpenProject(ctx, tsconfigFile, project.FileChangeSummary{})
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		tb.Fatalf("Failed to open project: %v", err)
+	}
+	releaseSnap()
+
+	program := proj.GetProgram()
+	c, release := program.GetTypeChecker(ctx)
+	return c, program, func() {
+		release()
+		os.RemoveAll(tmpDir)
+	}
 }
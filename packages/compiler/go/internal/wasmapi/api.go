@@ -187,6 +187,7 @@ func (a *API) TransformSource(fileName, source string, options *TransformOptions
 		TransformJSONStringify: config.TransformJSONStringify,
 		IgnoreTypes:            config.IgnoreTypes,
 		PureFunctions:          config.PureFunctions,
+		Concurrency:            config.AnalysisConcurrency,
 	}
 	projectAnalysis := analyse.AnalyseProject(program, checker, analyseConfig)
 	config.ProjectAnalysis = projectAnalysis
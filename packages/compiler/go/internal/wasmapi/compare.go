@@ -0,0 +1,56 @@
+//go:build js && wasm
+
+package wasmapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elliots/typical/packages/compiler/internal/codegen"
+)
+
+// CompareResult is the outcome of comparing an old and new version of a type.
+type CompareResult struct {
+	Compatible bool                 `json:"compatible"`
+	Changes    []codegen.TypeChange `json:"changes"`
+}
+
+// CompareTypes compiles an old and new version of a TypeScript source string and reports
+// whether every value valid under the old version of typeName is still valid under the new
+// one, listing the breaking changes (added required properties, narrowed unions, ...) if not.
+func (a *API) CompareTypes(fileName, oldSource, newSource, typeName string) (*CompareResult, error) {
+	debugf("[WASM DEBUG] CompareTypes called: fileName=%s typeName=%s\n", fileName, typeName)
+
+	oldProgram, oldFile, oldCleanup, err := openSourceProgram(fileName, oldSource)
+	if err != nil {
+		return nil, err
+	}
+	defer oldCleanup()
+	newProgram, newFile, newCleanup, err := openSourceProgram(fileName, newSource)
+	if err != nil {
+		return nil, err
+	}
+	defer newCleanup()
+
+	oldChecker, oldRelease := oldProgram.GetTypeChecker(context.Background())
+	defer oldRelease()
+	newChecker, newRelease := newProgram.GetTypeChecker(context.Background())
+	defer newRelease()
+
+	oldType := findDeclaredType(oldChecker, oldFile, typeName)
+	if oldType == nil {
+		return nil, fmt.Errorf("type not found in old source: %s", typeName)
+	}
+	newType := findDeclaredType(newChecker, newFile, typeName)
+	if newType == nil {
+		return nil, fmt.Errorf("type not found in new source: %s", typeName)
+	}
+
+	oldGen := codegen.NewGenerator(oldChecker, oldProgram)
+	newGen := codegen.NewGenerator(newChecker, newProgram)
+	changes := oldGen.CompareTypes(oldType, newGen, newType, typeName)
+	if changes == nil {
+		changes = []codegen.TypeChange{}
+	}
+	return &CompareResult{Compatible: len(changes) == 0, Changes: changes}, nil
+}
@@ -0,0 +1,52 @@
+//go:build js && wasm
+
+package wasmapi
+
+import (
+	"context"
+
+	"github.com/microsoft/typescript-go/shim/ast"
+	"github.com/microsoft/typescript-go/shim/checker"
+	"github.com/microsoft/typescript-go/shim/compiler"
+
+	"github.com/elliots/typical/packages/compiler/internal/transform"
+)
+
+// EmitValidators compiles a standalone TypeScript source string and returns a module of
+// validators for its exported types, without the transformed source.
+func (a *API) EmitValidators(fileName, source string, options *TransformOptions) (string, error) {
+	debugf("[WASM DEBUG] EmitValidators called: fileName=%s sourceLen=%d\n", fileName, len(source))
+	return emitForSource(fileName, source, options, transform.EmitValidators)
+}
+
+// ValidatorsHash compiles a standalone TypeScript source string and returns the typical-hash
+// EmitValidators would record for it, for checking committed validators are up to date.
+func (a *API) ValidatorsHash(fileName, source string, options *TransformOptions) (string, error) {
+	debugf("[WASM DEBUG] ValidatorsHash called: fileName=%s sourceLen=%d\n", fileName, len(source))
+	return emitForSource(fileName, source, options, transform.ValidatorsHash)
+}
+
+// emitForSource runs emit against a standalone source string with the given options.
+func emitForSource(fileName, source string, options *TransformOptions,
+	emit func(*ast.SourceFile, *checker.Checker, *compiler.Program, transform.Config) (string, error)) (string, error) {
+	if options == nil {
+		options = &TransformOptions{}
+	}
+
+	program, sourceFile, cleanup, err := openSourceProgram(fileName, source)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	c, release := program.GetTypeChecker(context.Background())
+	defer release()
+
+	config := transform.DefaultConfig()
+	config.IgnoreTypes = transform.CompileIgnorePatterns(options.IgnoreTypes)
+	if options.MaxGeneratedFunctions > 0 {
+		config.MaxGeneratedFunctions = options.MaxGeneratedFunctions
+	}
+
+	return emit(sourceFile, c, program, config)
+}
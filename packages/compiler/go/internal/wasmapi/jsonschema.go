@@ -0,0 +1,33 @@
+//go:build js && wasm
+
+package wasmapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elliots/typical/packages/compiler/internal/codegen"
+)
+
+// TypeToJSONSchema compiles a standalone TypeScript source string and describes the type,
+// interface, class or enum declared in it as typeName as a JSON Schema (draft 2020-12)
+// document.
+func (a *API) TypeToJSONSchema(fileName, source, typeName string) (string, error) {
+	debugf("[WASM DEBUG] TypeToJSONSchema called: fileName=%s typeName=%s\n", fileName, typeName)
+
+	program, sourceFile, cleanup, err := openSourceProgram(fileName, source)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	c, release := program.GetTypeChecker(context.Background())
+	defer release()
+
+	t := findDeclaredType(c, sourceFile, typeName)
+	if t == nil {
+		return "", fmt.Errorf("type not found: %s", typeName)
+	}
+
+	return codegen.NewGenerator(c, program).GenerateJSONSchema(t)
+}
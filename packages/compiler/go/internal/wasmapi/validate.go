@@ -0,0 +1,123 @@
+//go:build js && wasm
+
+package wasmapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/microsoft/typescript-go/shim/ast"
+	"github.com/microsoft/typescript-go/shim/bundled"
+	"github.com/microsoft/typescript-go/shim/checker"
+	"github.com/microsoft/typescript-go/shim/compiler"
+	"github.com/microsoft/typescript-go/shim/lsp/lsproto"
+	"github.com/microsoft/typescript-go/shim/project"
+
+	"github.com/elliots/typical/packages/compiler/internal/codegen"
+)
+
+// ValidateResult is the outcome of checking a value against a named type.
+type ValidateResult struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// ValidateValue compiles a standalone TypeScript source string and checks a JSON value
+// against the type, interface, class or enum declared in it as typeName.
+// The check interprets the type in Go rather than running a generated validator, so the
+// caller doesn't need to evaluate any code - an error is a problem with the inputs, while
+// a value that fails validation gives Valid=false with the validator's message.
+func (a *API) ValidateValue(fileName, source, typeName, jsonValue string) (*ValidateResult, error) {
+	debugf("[WASM DEBUG] ValidateValue called: fileName=%s typeName=%s\n", fileName, typeName)
+
+	var value any
+	if err := json.Unmarshal([]byte(jsonValue), &value); err != nil {
+		return nil, fmt.Errorf("failed to parse value: %w", err)
+	}
+
+	program, sourceFile, cleanup, err := openSourceProgram(fileName, source)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	c, release := program.GetTypeChecker(context.Background())
+	defer release()
+
+	t := findDeclaredType(c, sourceFile, typeName)
+	if t == nil {
+		return nil, fmt.Errorf("type not found: %s", typeName)
+	}
+
+	gen := codegen.NewGenerator(c, program)
+	if msg := gen.CheckValue(t, value, "value"); msg != "" {
+		return &ValidateResult{Valid: false, Error: msg}, nil
+	}
+	return &ValidateResult{Valid: true}, nil
+}
+
+// findDeclaredType returns the type declared at the top level of sourceFile as name,
+// or nil if there is no such declaration.
+func findDeclaredType(c *checker.Checker, sourceFile *ast.SourceFile, name string) *checker.Type {
+	for _, stmt := range sourceFile.Statements.Nodes {
+		switch stmt.Kind {
+		case ast.KindTypeAliasDeclaration, ast.KindInterfaceDeclaration, ast.KindClassDeclaration, ast.KindEnumDeclaration:
+			if declName := stmt.Name(); declName != nil && declName.Text() == name {
+				return checker.Checker_GetTypeAtLocation(c, declName)
+			}
+		}
+	}
+	return nil
+}
+
+// openSourceProgram writes a standalone source string to a temporary project and returns
+// its program and source file. cleanup removes the temporary project.
+func openSourceProgram(fileName, source string) (*compiler.Program, *ast.SourceFile, func(), error) {
+	tmpDir, err := os.MkdirTemp("", "typical-wasm-*")
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(tmpDir) }
+
+	tsconfigPath := filepath.Join(tmpDir, "tsconfig.json")
+	tsconfigContent := `{"compilerOptions":{"strict":true,"target":"ES2020","module":"ESNext"},"include":["*.ts","*.tsx"]}`
+	if err := os.WriteFile(tsconfigPath, []byte(tsconfigContent), 0644); err != nil {
+		cleanup()
+		return nil, nil, nil, fmt.Errorf("failed to write tsconfig: %w", err)
+	}
+
+	sourcePath := filepath.Join(tmpDir, fileName)
+	if err := os.WriteFile(sourcePath, []byte(source), 0644); err != nil {
+		cleanup()
+		return nil, nil, nil, fmt.Errorf("failed to write source file: %w", err)
+	}
+
+	ctx := context.Background()
+	tmpSession := project.NewSession(&project.SessionInit{
+		BackgroundCtx: ctx,
+		FS:            bundled.WrapFS(WasmFS()),
+		Options: &project.SessionOptions{
+			CurrentDirectory:   tmpDir,
+			DefaultLibraryPath: bundled.LibPath(),
+			PositionEncoding:   lsproto.PositionEncodingKindUTF8,
+		},
+	})
+
+	proj, _, release, err := tmpSession.APIOpenProject(ctx, tsconfigPath, project.FileChangeSummary{})
+	if err != nil {
+		cleanup()
+		return nil, nil, nil, fmt.Errorf("failed to create project: %w", err)
+	}
+	release()
+
+	program := proj.GetProgram()
+	sourceFile := program.GetSourceFile(sourcePath)
+	if sourceFile == nil {
+		cleanup()
+		return nil, nil, nil, fmt.Errorf("source file not found: %s", sourcePath)
+	}
+	return program, sourceFile, cleanup, nil
+}
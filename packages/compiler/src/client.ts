@@ -113,6 +113,8 @@ export class TypicalCompiler {
     fileName: string,
     ignoreTypes?: string[],
     maxGeneratedFunctions?: number,
+    excludePatterns?: string[],
+    sidecarValidators?: boolean,
   ): Promise<TransformResult> {
     const projectId = typeof project === "string" ? project : project.id;
     return this.request<TransformResult>("transformFile", {
@@ -120,6 +122,8 @@ export class TypicalCompiler {
       fileName,
       ignoreTypes,
       maxGeneratedFunctions,
+      excludePatterns,
+      sidecarValidators,
     });
   }
 
@@ -17,6 +17,8 @@ export interface RawSourceMap {
 export interface TransformResult {
   code: string;
   sourceMap?: RawSourceMap;
+  /** The companion module the validators were written to, with sidecarValidators */
+  sidecarFileName?: string;
 }
 
 /** Represents a single validation point in the source code */
@@ -29,8 +31,14 @@ export interface ValidationItem {
   endLine: number;
   /** 0-based column */
   endColumn: number;
-  /** Type of validation: "parameter", "return", "cast", "json-parse", "json-stringify" */
-  kind: "parameter" | "return" | "cast" | "json-parse" | "json-stringify";
+  /** Type of validation: "parameter", "return", "cast", "json-parse", "json-stringify", "parameter-reassignment" */
+  kind:
+    | "parameter"
+    | "return"
+    | "cast"
+    | "json-parse"
+    | "json-stringify"
+    | "parameter-reassignment";
   /** Name of the item being validated (param name, "return value", or expression text) */
   name: string;
   /** Whether the item will be validated or skipped */
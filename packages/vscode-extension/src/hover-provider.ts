@@ -74,6 +74,8 @@ export class HoverProvider implements vscode.HoverProvider {
         return "JSON.parse Validation";
       case "json-stringify":
         return "JSON.stringify Validation";
+      case "parameter-reassignment":
+        return "Parameter Reassignment";
       default:
         return "Validation";
     }
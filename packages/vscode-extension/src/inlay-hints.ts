@@ -90,6 +90,8 @@ export class InlayHintsProvider implements vscode.InlayHintsProvider {
         return "JSON.parse result";
       case "json-stringify":
         return "JSON.stringify input";
+      case "parameter-reassignment":
+        return "Reassigned parameter";
       default:
         return "Value";
     }
@@ -8,8 +8,15 @@ export interface ValidationItem {
   endLine: number;
   /** 0-based column */
   endColumn: number;
-  /** Type of validation: "parameter", "return-type", "return", "cast", "json-parse", "json-stringify" */
-  kind: "parameter" | "return-type" | "return" | "cast" | "json-parse" | "json-stringify";
+  /** Type of validation: "parameter", "return-type", "return", "cast", "json-parse", "json-stringify", "parameter-reassignment" */
+  kind:
+    | "parameter"
+    | "return-type"
+    | "return"
+    | "cast"
+    | "json-parse"
+    | "json-stringify"
+    | "parameter-reassignment";
   /** Name of the item being validated (param name, "return value", or expression text) */
   name: string;
   /** Whether the item will be validated or skipped */
@@ -14,6 +14,12 @@ echo "==> Syncing Go dependencies..."
 
 cd "$GO_DIR"
 
+# Version recorded in the typical-hash of emitted validators: the one being published, or
+# the current package version
+VERSION="${TYPICAL_VERSION:-$(node -p "require('$ROOT_DIR/package.json').version")}"
+LDFLAGS="-X github.com/elliots/typical/packages/compiler/internal/transform.Version=$VERSION"
+echo "==> Version: $VERSION"
+
 # Build function
 build_platform() {
   local goos=$1
@@ -31,7 +37,7 @@ build_platform() {
   echo "==> Building for $goos/$goarch -> compiler-$npm_platform..."
 
   mkdir -p "$output_dir"
-  CGO_ENABLED=0 GOOS="$goos" GOARCH="$goarch" go build -o "$output_file" ./cmd/typical
+  CGO_ENABLED=0 GOOS="$goos" GOARCH="$goarch" go build -ldflags "$LDFLAGS" -o "$output_file" ./cmd/typical
 
   echo "    Created: $output_file"
 }
@@ -40,7 +46,7 @@ build_platform() {
 if [ -z "$WASM_ONLY" ]; then
 
   # Build dev binary
-  go build -o "$GO_DIR/bin/typical" ./cmd/typical
+  go build -ldflags "$LDFLAGS" -o "$GO_DIR/bin/typical" ./cmd/typical
 
   # Build all platforms
   build_platform darwin arm64 darwin-arm64
@@ -54,7 +60,7 @@ fi
 # Build WASM (js/wasm for browser compatibility)
 echo "==> Building for js/wasm -> compiler-wasm..."
 mkdir -p "$PACKAGES_DIR/compiler-wasm/bin"
-GOOS=js GOARCH=wasm go build -o "$PACKAGES_DIR/compiler-wasm/bin/typical.wasm" ./cmd/typical-wasm
+GOOS=js GOARCH=wasm go build -ldflags "$LDFLAGS" -o "$PACKAGES_DIR/compiler-wasm/bin/typical.wasm" ./cmd/typical-wasm
 echo "    Created: $PACKAGES_DIR/compiler-wasm/bin/typical.wasm"
 
 echo ""
@@ -245,7 +245,8 @@ async function main() {
 
   // Build everything (Go binaries for all platforms + TypeScript packages)
   console.log("\n==> Building all packages...");
-  exec("pnpm run build", { cwd: rootDir });
+  // The binaries record the new version in emitted validators' typical-hash
+  exec("pnpm run build", { cwd: rootDir, env: { ...process.env, TYPICAL_VERSION: newVersion } });
 
   // Verify all binaries exist
   console.log("\n==> Verifying binaries...");
@@ -20,9 +20,21 @@ export interface TypicalSourceMapConfig {
   inline?: boolean;
 }
 
+/**
+ * Options for the loaders and bundler plugins. The compiler's other options, such as
+ * `validateNonNull` or `sampleRate`, are only read from `typical.config.json`.
+ */
 export interface TypicalConfig {
   include?: string[];
   exclude?: string[];
+  /**
+   * Glob patterns for files the compiler passes through unchanged, e.g. test files
+   * that build partial mocks. Unlike `exclude`, matching files are still analysed
+   * for cross-file validation tracking.
+   * Example: ["**\/*.test.ts", "test/**"]
+   * Default: []
+   */
+  excludePatterns?: string[];
   validateCasts?: boolean;
   hoistRegex?: boolean;
   debug?: TypicalDebugConfig;
@@ -32,6 +44,14 @@ export interface TypicalConfig {
    * Example: ["React.*", "Express.Request", "*.Event"]
    */
   ignoreTypes?: string[];
+  /**
+   * Move the validators hoisted into each transformed file into a companion module next to
+   * it (`user.ts` gets `user.typical.ts`), which the file imports them from and re-exports
+   * the exported ones of. Files whose validators use something the file doesn't export keep
+   * them inline. The companion module is only rewritten when its content changes.
+   * Default: false
+   */
+  sidecarValidators?: boolean;
   /**
    * Validate function parameters and return types at runtime.
    * When enabled, typed function parameters get runtime validation calls injected.
@@ -58,12 +58,14 @@ export class TypicalTransformer {
     await this.ensureInitialized();
 
     const resolvedPath = resolve(fileName);
-    // Pass config options to the Go compiler
+    // Pass config options to the Go compiler, which reads the rest from typical.config.json
     const result = await this.compiler.transformFile(
       this.projectHandle!,
       resolvedPath,
       this.config.ignoreTypes,
       this.config.maxGeneratedFunctions,
+      this.config.excludePatterns,
+      this.config.sidecarValidators,
     );
 
     return {
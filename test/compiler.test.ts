@@ -3,6 +3,9 @@ import assert from "node:assert";
 import ts from "typescript";
 import { TypicalCompiler } from "@elliots/typical-compiler";
 import { createRequire } from "node:module";
+import { mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
 
 const require = createRequire(import.meta.url);
 
@@ -35,11 +38,16 @@ after(async () => {
 
 /**
  * Registers a test case that transforms source, checks patterns, and runs execution cases.
+ * getCompiler picks another compiler, e.g. one started with a typical.config.json.
  */
-function registerTestCase(testCase: TestCase) {
+function registerTestCase(testCase: TestCase, getCompiler: () => TypicalCompiler = () => compiler) {
   it(testCase.name, async () => {
     // 1. Transform
-    const transformed = await compiler.transformSource("test.ts", testCase.source, testCase.config);
+    const transformed = await getCompiler().transformSource(
+      "test.ts",
+      testCase.source,
+      testCase.config,
+    );
 
     // 2. Check strings
     if (testCase.expectStrings) {
@@ -184,6 +192,17 @@ void describe("Primitive Types", () => {
       { input: 10, error: "to be bigint" },
     ],
   });
+
+  registerTestCase({
+    name: "bigint literal parameter validation",
+    source: `export function run(input: 5n): bigint { return input * 2n }`,
+    expectStrings: ["5n === input"],
+    cases: [
+      { input: 5n, result: 10n },
+      { input: 4n, error: "to be 5n, got bigint (4)" },
+      { input: 5, error: "to be 5n, got number (5)" },
+    ],
+  });
 });
 
 // =============================================================================
@@ -233,6 +252,33 @@ void describe("Object Types", () => {
       { input: { host: "localhost", port: "8080" }, error: "to be undefined | number" },
     ],
   });
+
+  registerTestCase({
+    name: "optional property rejects an explicit null",
+    source: `
+      interface Point { x?: number }
+      export function run(input: Point): number { return input.x ?? 0 }
+    `,
+    notExpectStrings: ["input.x != null", "null != input.x"],
+    cases: [
+      { input: {}, result: 0 },
+      { input: { x: undefined }, result: 0 },
+      { input: { x: 1 }, result: 1 },
+      { input: { x: null }, error: "to be undefined | number, got null" },
+    ],
+  });
+
+  registerTestCase({
+    name: "optional nullable property accepts an explicit null",
+    source: `
+      interface Point { x?: number | null }
+      export function run(input: Point): number { return input.x ?? 0 }
+    `,
+    cases: [
+      { input: { x: null }, result: 0 },
+      { input: { x: "1" }, error: "got string" },
+    ],
+  });
 });
 
 // =============================================================================
@@ -251,6 +297,17 @@ void describe("Array and Tuple Types", () => {
     ],
   });
 
+  registerTestCase({
+    name: "readonly array of primitives",
+    source: `export function run(input: readonly string[]): string { return input.join(",") }`,
+    expectStrings: ["Array.isArray"],
+    cases: [
+      { input: ["a", "b"], result: "a,b" },
+      { input: ["a", 1], error: "to be string" },
+      { input: "a", error: "to be array" },
+    ],
+  });
+
   registerTestCase({
     name: "array of objects",
     source: `
@@ -618,6 +675,21 @@ void describe("Optional Parameters", () => {
       { input: ["A", 123], error: "to be undefined | string" }, // Validated in run's tuple check
     ],
   });
+
+  registerTestCase({
+    name: "rest parameter checks each argument",
+    source: `
+      export function run(...ids: number[]): number {
+        return ids.length;
+      }
+    `,
+    expectStrings: ["ids.length; _i0++", '"ids[" + _i0 + "]"'],
+    notExpectStrings: ["Array.isArray(ids)"],
+    cases: [
+      { input: 7, result: 1 },
+      { input: "7", error: "Expected ids[0] to be number" },
+    ],
+  });
 });
 
 // =============================================================================
@@ -1466,3 +1538,63 @@ void describe("Chained Method Calls", () => {
     cases: [{ input: null, result: ["one", "two"] }],
   });
 });
+
+describe("Cycle-safe validation", () => {
+  let cycleCompiler: TypicalCompiler;
+  let cwd: string;
+
+  before(async () => {
+    cwd = mkdtempSync(join(tmpdir(), "typical-cycles-"));
+    writeFileSync(join(cwd, "typical.config.json"), JSON.stringify({ cycleSafeValidation: true }));
+    cycleCompiler = new TypicalCompiler({ cwd });
+    await cycleCompiler.start();
+  });
+
+  after(async () => {
+    await cycleCompiler.close();
+    rmSync(cwd, { recursive: true, force: true });
+  });
+
+  registerTestCase(
+    {
+      name: "cyclic data terminates",
+      source: `
+      interface Node { value: number; next?: Node }
+      function first(node: Node): number { return node.value; }
+      function second(node: Node): number { return node.value; }
+      export function run(input: unknown): number {
+        const a: any = { value: input };
+        a.next = { value: 2, next: a };
+        return first(a) + second(a.next);
+      }
+    `,
+      cases: [
+        { input: 1, result: 3 },
+        { input: "1", error: "Expected node.value to be number" },
+      ],
+    },
+    () => cycleCompiler,
+  );
+
+  registerTestCase(
+    {
+      name: "object failing one union member is still checked elsewhere",
+      source: `
+      interface Node { value: number; next?: Node }
+      interface Other { label: string }
+      interface Pair { a: Node | Other; b: Node }
+      function check(pair: Pair): string { return "ok"; }
+      function checkAgain(pair: Pair): string { return "ok"; }
+      export function run(input: unknown): string {
+        const shared: any = input;
+        return check({ a: shared, b: shared }) + checkAgain({ a: shared, b: shared });
+      }
+    `,
+      cases: [
+        { input: { value: 1 }, result: "okok" },
+        { input: { label: "x" }, error: "Expected pair.b.value to be number" },
+      ],
+    },
+    () => cycleCompiler,
+  );
+});